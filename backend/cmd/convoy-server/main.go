@@ -3,8 +3,12 @@ package main
 import (
 	"context"
 	"convoy-app/backend/src/api"
+	"convoy-app/backend/src/config"
+	"convoy-app/backend/src/cors"
+	"convoy-app/backend/src/ierr"
 	"convoy-app/backend/src/storage"
 	"convoy-app/backend/src/ws"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -13,24 +17,29 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/joho/godotenv"
 )
 
+// allowedOrigins is the shared origin policy for CORS and WebSocket upgrades,
+// extended from the configuration in main
+var allowedOrigins = cors.DefaultPolicy()
+
 // corsMiddleware adds CORS headers to the response with dynamic origin detection.
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		origin := r.Header.Get("Origin")
 
 		// Allow requests from development origins (localhost and local network IPs on port 3000)
-		if isAllowedOrigin(origin) {
+		if allowedOrigins.Allows(origin) {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
 			log.Printf("CORS: Allowed origin %s for %s %s", origin, r.Method, r.URL.Path)
 		} else if origin != "" {
 			log.Printf("CORS: Blocked origin %s for %s %s", origin, r.Method, r.URL.Path)
 		}
 
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Idempotency-Key")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 
 		// Handle preflight requests
@@ -43,125 +52,6 @@ func corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// isAllowedOrigin checks if the origin is allowed for CORS requests
-func isAllowedOrigin(origin string) bool {
-	if origin == "" {
-		return false
-	}
-
-	// Allow localhost development (both HTTP and HTTPS)
-	if origin == "http://localhost:3000" || origin == "http://127.0.0.1:3000" ||
-		origin == "https://localhost:3000" || origin == "https://127.0.0.1:3000" ||
-		origin == "http://localhost:8000" || origin == "http://127.0.0.1:8000" ||
-		origin == "https://localhost:8000" || origin == "https://127.0.0.1:8000" {
-		return true
-	}
-
-	// Allow Caddy HTTPS proxy (192.168.1.18:443)
-	if origin == "https://192.168.1.18" || origin == "https://192.168.1.18:443" {
-		return true
-	}
-
-	// Allow ngrok domains (for internet access)
-	// Check for ngrok-free.app, ngrok-free.dev, ngrok.app, or ngrok.io domains
-	if len(origin) > 8 && origin[:8] == "https://" {
-		hostname := origin[8:]
-		// Remove port if present
-		if colonIdx := len(hostname) - 1; colonIdx > 0 {
-			for i := len(hostname) - 1; i >= 0; i-- {
-				if hostname[i] == ':' {
-					hostname = hostname[:i]
-					break
-				}
-				if hostname[i] == '/' {
-					break
-				}
-			}
-		}
-
-		// Allow *.ngrok-free.dev (current free tier domain) - 15 characters
-		if len(hostname) > 15 && hostname[len(hostname)-15:] == ".ngrok-free.dev" {
-			log.Printf("CORS: Allowing ngrok free domain (.ngrok-free.dev): %s", origin)
-			return true
-		}
-		// Allow *.ngrok-free.app (older free tier domain) - 15 characters
-		if len(hostname) > 15 && hostname[len(hostname)-15:] == ".ngrok-free.app" {
-			log.Printf("CORS: Allowing ngrok free domain (.ngrok-free.app): %s", origin)
-			return true
-		}
-		// Allow *.ngrok.app (paid tier domain) - 10 characters
-		if len(hostname) > 10 && hostname[len(hostname)-10:] == ".ngrok.app" {
-			log.Printf("CORS: Allowing ngrok paid domain (.ngrok.app): %s", origin)
-			return true
-		}
-		// Allow *.ngrok.io (legacy domain) - 9 characters
-		if len(hostname) > 9 && hostname[len(hostname)-9:] == ".ngrok.io" {
-			log.Printf("CORS: Allowing ngrok legacy domain (.ngrok.io): %s", origin)
-			return true
-		}
-	}
-
-	// Allow custom origin from environment variable (for production or custom setups)
-	if customOrigin := os.Getenv("ALLOWED_ORIGIN"); customOrigin != "" {
-		if origin == customOrigin {
-			log.Printf("CORS: Allowing custom origin from env: %s", origin)
-			return true
-		}
-	}
-
-	// Allow local network IPs on port 3000 (for mobile testing)
-	// This matches patterns like http://192.168.1.18:3000, http://10.0.0.5:3000, etc.
-	if len(origin) > 7 && origin[:7] == "http://" {
-		// Extract the part after "http://"
-		hostPort := origin[7:]
-
-		// Check if it ends with ":3000"
-		if len(hostPort) > 5 && hostPort[len(hostPort)-5:] == ":3000" {
-			// Extract the IP part
-			ip := hostPort[:len(hostPort)-5]
-
-			// Allow private IP ranges commonly used in local networks
-			return isPrivateIP(ip)
-		}
-	}
-
-	return false
-}
-
-// isPrivateIP checks if an IP address is in a private range
-func isPrivateIP(ip string) bool {
-	// Common private IP ranges:
-	// 192.168.x.x (most common home networks)
-	// 10.x.x.x (corporate networks)
-	// 172.16.x.x - 172.31.x.x (less common)
-	// 127.x.x.x (localhost)
-
-	if len(ip) >= 7 {
-		// Check 192.168.x.x
-		if len(ip) >= 8 && ip[:8] == "192.168." {
-			return true
-		}
-
-		// Check 10.x.x.x
-		if len(ip) >= 3 && ip[:3] == "10." {
-			return true
-		}
-
-		// Check 127.x.x.x (localhost)
-		if len(ip) >= 4 && ip[:4] == "127." {
-			return true
-		}
-
-		// Check 172.16.x.x - 172.31.x.x
-		if len(ip) >= 7 && ip[:4] == "172." {
-			// This is a simplified check - in production you'd want more precise validation
-			return true
-		}
-	}
-
-	return false
-}
-
 func main() {
 	// 0. Load environment variables from .env file
 	if err := godotenv.Load(); err != nil {
@@ -171,20 +61,45 @@ func main() {
 		log.Println("Environment variables loaded from .env file")
 	}
 
+	// 0.1. Load runtime configuration (ports, limits, monitoring thresholds)
+	cfg := config.Load()
+	allowedOrigins = cors.NewPolicy(cfg.AllowedOrigins, cfg.AllowedOriginSuffixes)
+
 	// 1. Initialize the storage layer.
 	memStorage := storage.NewMemoryStorage()
+	memStorage.SetOutlierFilter(cfg.MaxMemberSpeedKmh, cfg.LocationOutlierWindow)
+	memStorage.SetMaxMembers(cfg.MaxMembersPerConvoy)
+	memStorage.SetMaxConvoys(cfg.MaxConvoys)
+	memStorage.SetLowAccuracyThreshold(cfg.LowAccuracyMeters)
+	memStorage.SetVerificationAudit(cfg.VerificationAudit)
+	if cfg.DevDeterministicIDs {
+		log.Println("WARNING: DEV_DETERMINISTIC_IDS is on; convoy IDs are sequential and guessable. Never use this in production.")
+		memStorage.SetIDGenerator(&storage.SequentialIDs{})
+	}
 	log.Println("In-memory storage initialized.")
 
 	// 2. Initialize the WebSocket hub.
 	wsHub := ws.NewHub()
+	wsHub.SetOriginPolicy(allowedOrigins)
+	wsHub.SetCompression(cfg.WSCompressionEnabled, cfg.WSCompressionThreshold)
+	wsHub.SetConnectionLimits(cfg.MaxConnectionsPerConvoy, cfg.MaxTotalConnections)
+	wsHub.SetSpectatorLimit(cfg.MaxSpectatorsPerConvoy)
+	wsHub.SetLoadShedding(cfg.LoadShedHighWater, cfg.LoadShedLowWater)
+	wsHub.SetRequireConnectToken(cfg.WSRequireConnectToken)
+	wsHub.SetSingleSession(cfg.WSSingleSession)
+	wsHub.SetAckRetry(cfg.WSAckMaxRetries, cfg.WSAckRetryDelay)
+	wsHub.SetReplayRetention(cfg.WSReplayRetention)
+	wsHub.SetSequenceNumbers(cfg.WSSequenceNumbers)
+	wsHub.SetBroadcastRetryBackoff(cfg.WSBroadcastRetryBackoff)
 	log.Println("WebSocket hub initialized.")
 
 	// 3. Wire the WebSocket hub to the storage layer for connection status checking
 	memStorage.SetWebSocketHub(wsHub)
+	wsHub.SetConvoyProvider(memStorage)
 	log.Println("WebSocket hub connected to storage layer.")
 
 	// 4. Initialize the API layer, injecting the storage and wsHub dependencies.
-	apiServer := api.New(memStorage, wsHub)
+	apiServer := api.New(memStorage, wsHub, cfg)
 	log.Println("API layer initialized.")
 
 	// 5. Start the convoy monitoring service.
@@ -201,6 +116,11 @@ func main() {
 			} else {
 				log.Println("Expired verifications cleaned up successfully")
 			}
+			// Reset broadcast stats of convoys the cleanup removed
+			wsHub.PruneConvoys(func(convoyID string) bool {
+				_, err := memStorage.GetConvoy(context.Background(), convoyID)
+				return !errors.Is(err, ierr.ErrNotFound)
+			})
 		}
 	}()
 	log.Println("Verification cleanup service started.")
@@ -213,39 +133,85 @@ func main() {
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprintf(w, `{"status":"healthy","websocket_connections":%d}`, totalConnections)
 	})
+	mux.HandleFunc("GET /livez", apiServer.HandleLivez)
+	mux.HandleFunc("GET /readyz", apiServer.HandleReadyz)
 
 	// Convoy endpoints
 	mux.HandleFunc("POST /api/convoys", apiServer.HandleCreateConvoy)
 	mux.HandleFunc("POST /api/convoys/create-with-verification", apiServer.HandleCreateConvoyWithVerification)
 	mux.HandleFunc("GET /api/convoys/verify/{token}", apiServer.HandleVerifyConvoy)
 	mux.HandleFunc("POST /api/convoys/{convoyId}/resend-verification", apiServer.HandleResendVerification)
+	mux.HandleFunc("GET /api/convoys/{convoyId}/verification", apiServer.HandleGetVerificationStatus)
+	mux.HandleFunc("POST /api/convoys/create-with-sms", apiServer.HandleCreateConvoyWithSMS)
+	mux.HandleFunc("POST /api/convoys/{convoyId}/verify-sms", apiServer.HandleVerifySMS)
+	mux.HandleFunc("GET /api/convoys/status", apiServer.HandleGetConvoysStatus)
 	mux.HandleFunc("GET /api/convoys/{convoyId}", apiServer.HandleGetConvoy)
+	mux.HandleFunc("GET /api/convoys/{convoyId}/bounds", apiServer.HandleGetConvoyBounds)
+	mux.HandleFunc("GET /api/convoys/{convoyId}/events", apiServer.HandleGetConvoyEvents)
+	mux.HandleFunc("GET /api/convoys/{convoyId}/events/stream", wsHub.SSEHandler)
+	mux.HandleFunc("GET /api/convoys/{convoyId}/summary", apiServer.HandleGetConvoySummary)
+	mux.HandleFunc("GET /api/convoys/{convoyId}/export", apiServer.HandleExportConvoy)
+	mux.HandleFunc("GET /api/convoys/{convoyId}/members", apiServer.HandleListMembers)
 	mux.HandleFunc("POST /api/convoys/{convoyId}/members", apiServer.HandleAddMember)
+	mux.HandleFunc("POST /api/convoys/{convoyId}/members/bulk", apiServer.HandleAddMembersBulk)
+	mux.HandleFunc("POST /api/convoys/{convoyId}/invites", apiServer.HandleCreateInvite)
+	mux.HandleFunc("POST /api/convoys/{convoyId}/join", apiServer.HandleJoinWithInvite)
+	mux.HandleFunc("POST /api/convoys/{convoyId}/members/{memberId}/rejoin", apiServer.HandleRejoinMember)
+	mux.HandleFunc("GET /api/convoys/{convoyId}/members/{memberId}/nearest", apiServer.HandleGetNearestMember)
+	mux.HandleFunc("GET /api/convoys/{convoyId}/members/{memberId}/address", apiServer.HandleGetMemberAddress)
+	mux.HandleFunc("POST /api/convoys/{convoyId}/members/{memberId}/alert", apiServer.HandleRaiseAttention)
+	mux.HandleFunc("GET /api/convoys/{convoyId}/members/{memberId}/track.gpx", apiServer.HandleExportMemberTrackGPX)
 	mux.HandleFunc("POST /api/convoys/{convoyId}/destination", apiServer.HandleSetConvoyDestination)
+	mux.HandleFunc("PATCH /api/convoys/{convoyId}/destination", apiServer.HandlePatchConvoyDestination)
+	mux.HandleFunc("POST /api/convoys/{convoyId}/destination/search", apiServer.HandleSearchConvoyDestination)
+	mux.HandleFunc("PUT /api/convoys/{convoyId}/name", apiServer.HandleSetConvoyName)
+	mux.HandleFunc("PUT /api/convoys/{convoyId}/metadata", apiServer.HandleSetConvoyMetadata)
+	mux.HandleFunc("POST /api/convoys/{convoyId}/pause", apiServer.HandlePauseConvoy)
+	mux.HandleFunc("POST /api/convoys/{convoyId}/start", apiServer.HandleStartConvoy)
+	mux.HandleFunc("POST /api/convoys/{convoyId}/resume", apiServer.HandleResumeConvoy)
 	mux.HandleFunc("PUT /api/convoys/{convoyId}/members/{memberId}/location", apiServer.HandleUpdateMemberLocation)
+	mux.HandleFunc("GET /api/convoys/{convoyId}/members/{memberId}", apiServer.HandleGetMember)
+	mux.HandleFunc("PATCH /api/convoys/{convoyId}/members/{memberId}", apiServer.HandleUpdateMember)
 	mux.HandleFunc("DELETE /api/convoys/{convoyId}/members/{memberId}", apiServer.HandleLeaveConvoy)
 	mux.HandleFunc("OPTIONS /api/convoys/{convoyId}/members/{memberId}", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
 
+	// Operator endpoints (require ADMIN_TOKEN)
+	mux.HandleFunc("GET /api/admin/convoys", apiServer.HandleAdminListConvoys)
+	mux.HandleFunc("GET /api/admin/verification-attempts", apiServer.HandleAdminListVerificationAttempts)
+	mux.HandleFunc("POST /api/admin/convoys/{convoyId}/announce", apiServer.HandleAdminAnnounce)
+	mux.HandleFunc("GET /metrics", apiServer.HandleMetrics)
+
 	// WebSocket endpoint
 	mux.HandleFunc("GET /ws/convoys/{convoyId}", wsHub.Handler)
 
 	// 6. Configure and start the HTTP server with graceful shutdown.
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
+	port := cfg.Port
 
+	// WebSocket upgrades skip the per-request timeout and compression, and
+	// gorilla clears the server's read/write deadlines once the connection is hijacked
+	handler := api.TimeoutMiddleware(cfg.RequestTimeout)(mux)
+	handler = api.GzipMiddleware(cfg.HTTPGzipEnabled, cfg.HTTPGzipThreshold)(handler)
 	server := &http.Server{
-		Addr:    ":" + port,
-		Handler: corsMiddleware(mux), // Wrap the mux with CORS middleware
+		Addr:         ":" + port,
+		Handler:      corsMiddleware(handler),
+		ReadTimeout:  cfg.HTTPReadTimeout,
+		WriteTimeout: cfg.RequestTimeout + 5*time.Second, // leave room to write the timeout response
+		IdleTimeout:  cfg.HTTPIdleTimeout,
 	}
 
 	// Run server in a goroutine so that it doesn't block.
 	go func() {
-		log.Printf("Starting Convoy backend server on port %s", port)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if cfg.TLSEnabled() {
+			log.Printf("Starting Convoy backend server on port %s (HTTPS, cert %s)", port, cfg.TLSCertFile)
+			err = server.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+		} else {
+			log.Printf("Starting Convoy backend server on port %s (plain HTTP; set TLS_CERT_FILE and TLS_KEY_FILE for HTTPS)", port)
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Could not start server: %s\n", err)
 		}
 	}()
@@ -260,6 +226,10 @@ func main() {
 	apiServer.StopMonitoring()
 	log.Println("Convoy monitoring service stopped.")
 
+	// Tell clients this is a restart so they reconnect quietly instead of
+	// treating it as an abnormal closure
+	wsHub.CloseAll(websocket.CloseServiceRestart, "server restarting")
+
 	// The context is used to inform the server it has 5 seconds to finish
 	// the requests it is currently handling.
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -268,5 +238,8 @@ func main() {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	// Only now are no handlers left to raise alerts
+	apiServer.CloseWebhooks()
+
 	log.Println("Server exiting")
 }
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"convoy-app/backend/src/domain"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// MemberAddressResponse is the human-readable place of a member's current
+// location. Address is empty when reverse geocoding is not configured.
+type MemberAddressResponse struct {
+	Address  string        `json:"address"`
+	Location domain.LatLng `json:"location"`
+}
+
+// HandleGetMemberAddress reverse-geocodes a member's current location
+func (a *API) HandleGetMemberAddress(w http.ResponseWriter, r *http.Request) {
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
+		return
+	}
+	memberID, err := strconv.ParseInt(r.PathValue("memberId"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid member ID"))
+		return
+	}
+
+	convoy, err := a.storage.GetConvoy(r.Context(), convoyID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, errors.New("convoy not found"))
+		return
+	}
+
+	var member *domain.Member
+	for _, m := range convoy.Members {
+		if m.ID == memberID {
+			member = m
+			break
+		}
+	}
+	if member == nil {
+		writeError(w, http.StatusNotFound, errors.New("member not found"))
+		return
+	}
+
+	response := MemberAddressResponse{Location: member.Location}
+	if member.Location == (domain.LatLng{}) {
+		writeJSON(w, http.StatusOK, response) // no location reported yet
+		return
+	}
+
+	address, err := a.geocoder.Address(r.Context(), member.Location)
+	if err != nil {
+		log.Printf("ERROR: failed to reverse-geocode member %d in convoy %s: %v", memberID, convoyID, err)
+		writeErrorWithCode(w, http.StatusBadGateway, "address lookup failed", "GEOCODE_FAILED")
+		return
+	}
+	response.Address = address
+	writeJSON(w, http.StatusOK, response)
+}
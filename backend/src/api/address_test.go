@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/geocode"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// stubGeocoder returns a fixed address for every location and a fixed place
+// (or error) for every search
+type stubGeocoder struct {
+	address   string
+	place     *geocode.Place
+	searchErr error
+}
+
+func (s stubGeocoder) Reverse(ctx context.Context, location domain.LatLng) (string, error) {
+	return s.address, nil
+}
+
+func (s stubGeocoder) Search(ctx context.Context, query string) (*geocode.Place, error) {
+	if s.searchErr != nil {
+		return nil, s.searchErr
+	}
+	if s.place == nil {
+		return nil, geocode.ErrNoResults
+	}
+	return s.place, nil
+}
+
+func TestGetMemberAddress(t *testing.T) {
+	apiServer, memStorage, mux := newTestAPI(t)
+
+	convoy, err := memStorage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	member := &domain.Member{ID: 1, Name: "TestMember1", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}}
+	if err := memStorage.AddMember(context.Background(), convoy.ID, member); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+	path := "/api/convoys/" + convoy.ID + "/members/1/address"
+
+	// No provider configured: an empty address, not an error
+	rec := doRequest(mux, http.MethodGet, path, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 without a provider, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var response MemberAddressResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Address != "" || response.Location != member.Location {
+		t.Errorf("Expected an empty address at the member's location, got %+v", response)
+	}
+
+	apiServer.geocoder = geocode.NewService(stubGeocoder{address: "Exit 23, I-95"})
+	rec = doRequest(mux, http.MethodGet, path, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Address != "Exit 23, I-95" {
+		t.Errorf("Expected the geocoded address, got %q", response.Address)
+	}
+
+	if rec := doRequest(mux, http.MethodGet, "/api/convoys/"+convoy.ID+"/members/99/address", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown member, got %d", rec.Code)
+	}
+}
+
+func TestSearchConvoyDestination(t *testing.T) {
+	apiServer, memStorage, mux := newTestAPI(t)
+
+	convoy, err := memStorage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	path := "/api/convoys/" + convoy.ID + "/destination/search"
+	body := `{"query": "Yosemite Valley"}`
+
+	// No provider configured
+	rec := doRequest(mux, http.MethodPost, path, body)
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "GEOCODING_UNAVAILABLE") {
+		t.Errorf("Expected 503 GEOCODING_UNAVAILABLE without a provider, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// No match
+	apiServer.geocoder = geocode.NewService(stubGeocoder{})
+	rec = doRequest(mux, http.MethodPost, path, body)
+	if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), "PLACE_NOT_FOUND") {
+		t.Errorf("Expected 422 PLACE_NOT_FOUND, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Provider failure
+	apiServer.geocoder = geocode.NewService(stubGeocoder{searchErr: errors.New("rate limited")})
+	if rec := doRequest(mux, http.MethodPost, path, body); rec.Code != http.StatusBadGateway {
+		t.Errorf("Expected 502 on provider failure, got %d", rec.Code)
+	}
+
+	apiServer.geocoder = geocode.NewService(stubGeocoder{place: &geocode.Place{
+		Name:        "Yosemite Valley",
+		DisplayName: "Yosemite Valley, Mariposa County, California",
+		Location:    domain.LatLng{Lat: 37.7456, Lng: -119.5936},
+	}})
+	if rec := doRequest(mux, http.MethodPost, path, `{"query": "  "}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an empty query, got %d", rec.Code)
+	}
+	if rec := doRequest(mux, http.MethodPost, "/api/convoys/"+missingConvoyID+"/destination/search", body); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown convoy, got %d", rec.Code)
+	}
+
+	rec = doRequest(mux, http.MethodPost, path, body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resolved domain.Destination
+	if err := json.Unmarshal(rec.Body.Bytes(), &resolved); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resolved.Name != "Yosemite Valley" || resolved.Lat != 37.7456 || resolved.Lng != -119.5936 {
+		t.Errorf("Expected the resolved destination, got %+v", resolved)
+	}
+
+	stored, err := memStorage.GetConvoy(context.Background(), convoy.ID)
+	if err != nil {
+		t.Fatalf("Failed to get convoy: %v", err)
+	}
+	if stored.Destination == nil || stored.Destination.Name != "Yosemite Valley" {
+		t.Errorf("Expected the destination to be stored, got %+v", stored.Destination)
+	}
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/ierr"
+	"convoy-app/backend/src/storage"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultVerificationAttemptsLimit is how many attempts the audit endpoint returns without ?limit=
+const DefaultVerificationAttemptsLimit = 100
+
+// AdminConvoySummary is the operator view of a single convoy
+type AdminConvoySummary struct {
+	ID              string    `json:"id"`
+	Name            string    `json:"name"`
+	MemberCount     int       `json:"memberCount"`
+	IsVerified      bool      `json:"isVerified"`
+	CreatedByPhone  string    `json:"createdByPhone,omitempty"` // kept out of the public convoy JSON
+	Paused          bool      `json:"paused"`
+	CreatedAt       time.Time `json:"createdAt"`
+	ConnectionCount int       `json:"connectionCount"`
+	MessageCount    int64     `json:"messageCount"`   // WebSocket broadcasts since the convoy was created
+	BytesBroadcast  int64     `json:"bytesBroadcast"` // summed over every connection that received them
+}
+
+// isAdmin checks the request's bearer token against ADMIN_TOKEN.
+// Admin endpoints are disabled entirely when no token is configured.
+func (a *API) isAdmin(r *http.Request) bool {
+	if a.adminToken == "" {
+		return false
+	}
+	token := bearerToken(r)
+	if token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(a.adminToken)) == 1
+}
+
+// HandleAdminListConvoys returns a summary of every convoy, newest first
+func (a *API) HandleAdminListConvoys(w http.ResponseWriter, r *http.Request) {
+	if !a.isAdmin(r) {
+		log.Printf("WARNING: Rejected admin request from %s", getClientIP(r))
+		writeErrorWithCode(w, http.StatusUnauthorized, "Admin token required", "UNAUTHORIZED")
+		return
+	}
+
+	convoys, err := a.storage.GetAllConvoys(r.Context())
+	if err != nil {
+		log.Printf("ERROR: failed to list convoys for admin: %v", err)
+		writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		return
+	}
+
+	summaries := make([]AdminConvoySummary, 0, len(convoys))
+	for _, convoy := range convoys {
+		stats := a.wsHub.GetConvoyStats(convoy.ID)
+		summaries = append(summaries, AdminConvoySummary{
+			ID:              convoy.ID,
+			Name:            convoy.Name,
+			MemberCount:     len(convoy.Members),
+			IsVerified:      convoy.IsVerified,
+			CreatedByPhone:  convoy.CreatedByPhone,
+			Paused:          convoy.Paused,
+			CreatedAt:       convoy.CreatedAt,
+			ConnectionCount: a.wsHub.GetConnectionCount(convoy.ID),
+			MessageCount:    stats.Messages,
+			BytesBroadcast:  stats.Bytes,
+		})
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].CreatedAt.After(summaries[j].CreatedAt)
+	})
+
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+// VerificationAttemptsResponse lists recent verification attempts. Counts
+// covers every retained attempt, not just the ones listed.
+type VerificationAttemptsResponse struct {
+	Counts   map[string]int               `json:"counts"` // outcome -> attempts
+	Attempts []domain.VerificationAttempt `json:"attempts"`
+}
+
+// HandleAdminListVerificationAttempts returns the most recent verification
+// attempts, newest first, to help spot token guessing. It is empty unless
+// VERIFICATION_AUDIT is enabled.
+func (a *API) HandleAdminListVerificationAttempts(w http.ResponseWriter, r *http.Request) {
+	if !a.isAdmin(r) {
+		log.Printf("WARNING: Rejected admin request from %s", getClientIP(r))
+		writeErrorWithCode(w, http.StatusUnauthorized, "Admin token required", "UNAUTHORIZED")
+		return
+	}
+
+	limit := DefaultVerificationAttemptsLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > storage.MaxVerificationAttempts {
+			writeError(w, http.StatusBadRequest, errors.New("limit must be between 1 and "+strconv.Itoa(storage.MaxVerificationAttempts)))
+			return
+		}
+		limit = parsed
+	}
+
+	attempts, err := a.storage.GetVerificationAttempts(r.Context(), 0)
+	if err != nil {
+		log.Printf("ERROR: failed to list verification attempts for admin: %v", err)
+		writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		return
+	}
+
+	response := VerificationAttemptsResponse{Counts: make(map[string]int)}
+	for _, attempt := range attempts {
+		response.Counts[attempt.Outcome]++
+	}
+	response.Attempts = attempts[:min(limit, len(attempts))]
+	writeJSON(w, http.StatusOK, response)
+}
+
+// HandleAdminAnnounce lets an operator push a message, such as a road closure,
+// to every client of a convoy. It broadcasts OPERATOR_ANNOUNCEMENT and records
+// it in the convoy's event log so clients that reconnect can catch up.
+func (a *API) HandleAdminAnnounce(w http.ResponseWriter, r *http.Request) {
+	if !a.isAdmin(r) {
+		log.Printf("WARNING: Rejected admin request from %s", getClientIP(r))
+		writeErrorWithCode(w, http.StatusUnauthorized, "Admin token required", "UNAUTHORIZED")
+		return
+	}
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req AnnouncementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	if _, err := a.storage.GetConvoy(r.Context(), convoyID); err != nil {
+		if errors.Is(err, ierr.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("convoy not found"))
+		} else {
+			log.Printf("ERROR: failed to get convoy %s: %v", convoyID, err)
+			writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		}
+		return
+	}
+
+	alert := &domain.ConvoyAlert{
+		EventType: domain.EventOperatorAnnouncement,
+		ConvoyID:  convoyID,
+		Message:   strings.TrimSpace(req.Message),
+		Timestamp: time.Now(),
+	}
+	if err := a.storage.AppendConvoyEvent(r.Context(), alert); err != nil {
+		log.Printf("WARNING: failed to record announcement for convoy %s: %v", convoyID, err)
+	}
+
+	log.Printf("INFO: Operator announcement to convoy %s from %s: %q", convoyID, getClientIP(r), alert.Message)
+	a.wsHub.Broadcast(convoyID, alert)
+	writeJSON(w, http.StatusOK, alert)
+}
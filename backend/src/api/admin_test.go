@@ -0,0 +1,249 @@
+package api
+
+import (
+	"context"
+	"convoy-app/backend/src/config"
+	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/storage"
+	"convoy-app/backend/src/ws"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandleAdminListConvoys(t *testing.T) {
+	t.Setenv("ADMIN_TOKEN", "secret")
+	memStorage := storage.NewMemoryStorage()
+	apiServer := New(memStorage, ws.NewHub(), config.Load())
+
+	ctx := context.Background()
+	empty, _ := memStorage.CreateConvoy(ctx)
+	active, _ := memStorage.CreateConvoy(ctx)
+	if err := memStorage.AddMember(ctx, active.ID, &domain.Member{ID: 1, Name: "TestMember1"}); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		authorization  string
+		expectedStatus int
+	}{
+		{"missing token", "", http.StatusUnauthorized},
+		{"wrong token", "Bearer nope", http.StatusUnauthorized},
+		{"wrong scheme", "secret", http.StatusUnauthorized},
+		{"valid token", "Bearer secret", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/convoys", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			apiServer.HandleAdminListConvoys(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, rec.Code)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+
+			var summaries []AdminConvoySummary
+			if err := json.NewDecoder(rec.Body).Decode(&summaries); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			counts := make(map[string]int)
+			for _, summary := range summaries {
+				counts[summary.ID] = summary.MemberCount
+			}
+			if len(summaries) != 2 || counts[empty.ID] != 0 || counts[active.ID] != 1 {
+				t.Errorf("Expected both convoys with member counts, got %+v", summaries)
+			}
+		})
+	}
+}
+
+func TestCreatorPhoneOnlyShownToAdmins(t *testing.T) {
+	t.Setenv("ADMIN_TOKEN", "secret")
+	apiServer, memStorage, mux := newTestAPI(t)
+
+	convoy, err := memStorage.CreateConvoyWithSMSVerification(context.Background(), "+15550100", "Leader", "123456", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+
+	rec := doRequest(mux, http.MethodGet, "/api/convoys/"+convoy.ID, "")
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "+15550100") {
+		t.Errorf("Expected the public convoy without the creator's phone, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/convoys", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	apiServer.HandleAdminListConvoys(rec, req)
+	var summaries []AdminConvoySummary
+	if err := json.NewDecoder(rec.Body).Decode(&summaries); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(summaries) != 1 || summaries[0].CreatedByPhone != "+15550100" {
+		t.Errorf("Expected the admin listing to carry the creator's phone, got %+v", summaries)
+	}
+}
+
+// failingStorage is in-memory storage whose admin listings fail with errors
+// carrying internal detail that must not reach clients
+type failingStorage struct {
+	*storage.MemoryStorage
+}
+
+func (failingStorage) GetAllConvoys(ctx context.Context) ([]*domain.Convoy, error) {
+	return nil, errors.New("dial tcp 10.0.0.5:6379: connection refused")
+}
+
+func (failingStorage) GetVerificationAttempts(ctx context.Context, limit int) ([]domain.VerificationAttempt, error) {
+	return nil, errors.New("dial tcp 10.0.0.5:6379: connection refused")
+}
+
+func TestAdminListingsHideInternalErrors(t *testing.T) {
+	t.Setenv("ADMIN_TOKEN", "secret")
+	apiServer := New(failingStorage{storage.NewMemoryStorage()}, ws.NewHub(), config.Load())
+
+	for path, handler := range map[string]http.HandlerFunc{
+		"/api/admin/convoys":               apiServer.HandleAdminListConvoys,
+		"/api/admin/verification-attempts": apiServer.HandleAdminListVerificationAttempts,
+	} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("%s: expected status 500, got %d", path, rec.Code)
+		}
+		if strings.Contains(rec.Body.String(), "10.0.0.5") {
+			t.Errorf("%s: expected a generic error, got %s", path, rec.Body.String())
+		}
+	}
+}
+
+func TestHandleAdminListVerificationAttempts(t *testing.T) {
+	t.Setenv("ADMIN_TOKEN", "secret")
+	memStorage := storage.NewMemoryStorage()
+	memStorage.SetVerificationAudit(true)
+	apiServer := New(memStorage, ws.NewHub(), config.Load())
+
+	ctx := context.Background()
+	for _, token := range []string{"guess-1", "guess-2"} {
+		memStorage.VerifyConvoy(ctx, token)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/verification-attempts?limit=1", nil)
+	rec := httptest.NewRecorder()
+	apiServer.HandleAdminListVerificationAttempts(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected 401 without the admin token, got %d", rec.Code)
+	}
+
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	apiServer.HandleAdminListVerificationAttempts(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var response VerificationAttemptsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Counts[domain.VerificationInvalid] != 2 {
+		t.Errorf("Expected 2 invalid attempts counted, got %v", response.Counts)
+	}
+	if len(response.Attempts) != 1 || response.Attempts[0].TokenPrefix != "guess-" {
+		t.Errorf("Expected only the newest attempt, got %+v", response.Attempts)
+	}
+}
+
+func TestHandleMetricsReportsBroadcastStats(t *testing.T) {
+	t.Setenv("ADMIN_TOKEN", "secret")
+	hub := ws.NewHub()
+	apiServer := New(storage.NewMemoryStorage(), hub, config.Load())
+
+	hub.Broadcast("convoy-1", map[string]string{"type": "test"})
+	hub.Broadcast("convoy-1", map[string]string{"type": "test"})
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rec := httptest.NewRecorder()
+	apiServer.HandleMetrics(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without the admin token, got %d", rec.Code)
+	}
+
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	apiServer.HandleMetrics(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, `convoy_broadcast_messages_total{convoy="convoy-1"} 2`) {
+		t.Errorf("Expected the convoy's message count, got:\n%s", body)
+	}
+}
+
+func TestHandleAdminAnnounce(t *testing.T) {
+	t.Setenv("ADMIN_TOKEN", "secret")
+	apiServer, memStorage, mux := newTestAPI(t)
+	convoy, _ := memStorage.CreateConvoy(context.Background())
+	path := "/api/admin/convoys/" + convoy.ID + "/announce"
+
+	announce := func(path, authorization, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		authorization  string
+		body           string
+		expectedStatus int
+	}{
+		{"missing token", path, "", `{"message":"Route closure ahead"}`, http.StatusUnauthorized},
+		{"wrong token", path, "Bearer nope", `{"message":"Route closure ahead"}`, http.StatusUnauthorized},
+		{"empty message", path, "Bearer secret", `{"message":"  "}`, http.StatusBadRequest},
+		{"message too long", path, "Bearer secret", `{"message":"` + strings.Repeat("x", maxAnnouncementLength+1) + `"}`, http.StatusBadRequest},
+		{"unknown convoy", "/api/admin/convoys/" + missingConvoyID + "/announce", "Bearer secret", `{"message":"Hi"}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := announce(tt.path, tt.authorization, tt.body); rec.Code != tt.expectedStatus {
+				t.Errorf("Expected %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+	if stats := apiServer.wsHub.GetConvoyStats(convoy.ID); stats.Messages != 0 {
+		t.Fatalf("Expected rejected announcements not to be broadcast, got %d messages", stats.Messages)
+	}
+
+	rec := announce(path, "Bearer secret", `{"message":" Route closure ahead on Main St "}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if stats := apiServer.wsHub.GetConvoyStats(convoy.ID); stats.Messages != 1 {
+		t.Errorf("Expected the announcement to be broadcast once, got %d messages", stats.Messages)
+	}
+	events, _ := memStorage.GetConvoyEvents(context.Background(), convoy.ID, time.Time{})
+	if len(events) != 1 || events[0].EventType != domain.EventOperatorAnnouncement || events[0].Message != "Route closure ahead on Main St" {
+		t.Errorf("Expected the announcement in the event log, got %+v", events)
+	}
+}
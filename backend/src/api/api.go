@@ -2,17 +2,24 @@ package api
 
 import (
 	"context"
+	"convoy-app/backend/src/config"
 	"convoy-app/backend/src/domain"
 	"convoy-app/backend/src/email"
+	"convoy-app/backend/src/geo"
+	"convoy-app/backend/src/geocode"
 	"convoy-app/backend/src/ierr"
 	"convoy-app/backend/src/monitoring"
 	"convoy-app/backend/src/ratelimit"
+	"convoy-app/backend/src/sms"
 	"convoy-app/backend/src/storage"
+	"convoy-app/backend/src/webhook"
 	"convoy-app/backend/src/ws"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
+	"math"
 	"net"
 	"net/http"
 	"strconv"
@@ -25,15 +32,34 @@ import (
 type BroadcastThrottler struct {
 	mu            sync.RWMutex
 	lastBroadcast map[string]time.Time
+	pending       map[string]bool // convoys with a trailing broadcast scheduled
 	minInterval   time.Duration
+	forcedEvents  map[string]bool // event types whose updates bypass throttling
 }
 
-// NewBroadcastThrottler creates a new broadcast throttler
-func NewBroadcastThrottler(minInterval time.Duration) *BroadcastThrottler {
-	return &BroadcastThrottler{
+// NewBroadcastThrottler creates a new broadcast throttler. Updates accompanying
+// any of forcedEvents are never throttled.
+func NewBroadcastThrottler(minInterval time.Duration, forcedEvents ...string) *BroadcastThrottler {
+	bt := &BroadcastThrottler{
 		lastBroadcast: make(map[string]time.Time),
+		pending:       make(map[string]bool),
 		minInterval:   minInterval,
+		forcedEvents:  make(map[string]bool, len(forcedEvents)),
 	}
+	for _, eventType := range forcedEvents {
+		bt.forcedEvents[eventType] = true
+	}
+	return bt
+}
+
+// IsForced reports whether any of the event types must bypass throttling
+func (bt *BroadcastThrottler) IsForced(eventTypes ...string) bool {
+	for _, eventType := range eventTypes {
+		if bt.forcedEvents[eventType] {
+			return true
+		}
+	}
+	return false
 }
 
 // ShouldBroadcast checks if enough time has passed since the last broadcast for a convoy
@@ -56,188 +82,966 @@ func (bt *BroadcastThrottler) RecordBroadcast(convoyID string) {
 	bt.lastBroadcast[convoyID] = time.Now()
 }
 
+// ScheduleTrailing runs send once the convoy's interval has passed, unless a
+// trailing broadcast is already scheduled. Updates throttled in the meantime
+// share that one broadcast, which reads the convoy state when it runs.
+func (bt *BroadcastThrottler) ScheduleTrailing(convoyID string, send func()) {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+
+	if bt.pending[convoyID] {
+		return
+	}
+	bt.pending[convoyID] = true
+	time.AfterFunc(bt.minInterval-time.Since(bt.lastBroadcast[convoyID]), func() {
+		bt.mu.Lock()
+		delete(bt.pending, convoyID)
+		bt.mu.Unlock()
+		send()
+	})
+}
+
 // API provides the handlers for our REST endpoints.
 type API struct {
 	storage            storage.Storage
 	wsHub              *ws.Hub
 	monitor            *monitoring.ConvoyMonitor
+	webhookNotifier    *webhook.WebhookNotifier
 	broadcastThrottler *BroadcastThrottler
+	updateBudget       *LocationUpdateBudget
+	locationLimiter    *MemberRateLimiter
+	attentionLimiter   *MemberRateLimiter
 	emailService       *email.Service
+	geocoder           *geocode.Service
+	smsService         *sms.Service
 	rateLimiter        *ratelimit.Limiter
+	adminToken         string
+	trustProxy         bool    // build links from X-Forwarded-* headers
+	broadcastMode      string  // config.BroadcastModeSnapshot or config.BroadcastModeDelta
+	arrivalRadius      float64 // meters; for destinations set without their own radius
 }
 
 // New creates a new API instance.
-func New(storage storage.Storage, wsHub *ws.Hub) *API {
-	monitor := monitoring.NewConvoyMonitor(storage, wsHub)
-	// Set up broadcast throttling with 1-second minimum interval
-	throttler := NewBroadcastThrottler(1 * time.Second)
+func New(storage storage.Storage, wsHub *ws.Hub, cfg *config.Config) *API {
+	monitor := monitoring.NewConvoyMonitor(storage, wsHub, cfg)
+	// Members whose socket closes are held as reconnecting before counting as disconnected
+	wsHub.SetMemberListener(monitor)
+
+	// Optionally forward monitor alerts to an external webhook
+	var notifier *webhook.WebhookNotifier
+	if cfg.AlertWebhookURL != "" {
+		notifier = webhook.NewWebhookNotifier(webhook.Config{
+			URL:        cfg.AlertWebhookURL,
+			EventTypes: cfg.AlertWebhookEvents,
+		})
+		monitor.SetNotifier(notifier)
+	}
+
+	// Throttle routine convoy updates; safety-critical alerts are always sent
+	throttler := NewBroadcastThrottler(cfg.BroadcastThrottleInterval, cfg.BroadcastForcedEvents...)
 
 	// Initialize email service
 	emailService := email.NewServiceFromEnv()
+	if cfg.EmailVerifyOnStartup {
+		if err := emailService.Verify(); err != nil {
+			log.Printf("ERROR: Email self-check failed, verification emails will not be delivered: %v", err)
+		} else {
+			log.Println("Email self-check passed")
+		}
+	}
+
+	// Initialize SMS service
+	smsService := sms.NewServiceFromEnv()
 
 	// Initialize rate limiter
 	rateLimiter := ratelimit.NewLimiter(ratelimit.DefaultConfig())
 
-	return &API{
+	// Reverse geocoding is optional; without a provider addresses are empty
+	var geocodeProvider geocode.Provider
+	switch cfg.GeocodeProvider {
+	case "":
+	case "nominatim":
+		geocodeProvider = geocode.NewNominatimProvider(cfg.GeocodeURL, cfg.GeocodeUserAgent)
+	default:
+		log.Printf("WARNING: Unknown GEOCODE_PROVIDER %q, reverse geocoding disabled", cfg.GeocodeProvider)
+	}
+
+	a := &API{
 		storage:            storage,
 		wsHub:              wsHub,
 		monitor:            monitor,
+		webhookNotifier:    notifier,
 		broadcastThrottler: throttler,
+		updateBudget:       NewLocationUpdateBudget(cfg.LocationUpdateBudget, cfg.LocationUpdateBudgetWindow),
+		locationLimiter:    NewMemberRateLimiter(cfg.LocationMinInterval),
+		attentionLimiter:   NewMemberRateLimiter(cfg.AttentionMinInterval),
 		emailService:       emailService,
+		geocoder:           geocode.NewService(geocodeProvider),
+		smsService:         smsService,
 		rateLimiter:        rateLimiter,
+		adminToken:         cfg.AdminToken,
+		trustProxy:         cfg.TrustProxy,
+		arrivalRadius:      cfg.ArrivalRadiusMeters,
+		broadcastMode:      cfg.BroadcastMode,
+	}
+	// Route the monitor's convoy updates through the throttler
+	monitor.SetConvoyBroadcaster(a)
+	return a
+}
+
+// StartMonitoring starts the convoy monitoring service
+func (a *API) StartMonitoring() {
+	a.monitor.Start()
+}
+
+// StopMonitoring stops the convoy monitoring service. Handlers may still
+// raise alerts afterwards; CloseWebhooks stops their delivery once the
+// server has shut down.
+func (a *API) StopMonitoring() {
+	a.monitor.Stop()
+}
+
+// CloseWebhooks delivers the queued webhook alerts and drops any raised later
+func (a *API) CloseWebhooks() {
+	if a.webhookNotifier != nil {
+		a.webhookNotifier.Close()
+	}
+}
+
+// HandleCreateConvoy creates a new convoy.
+func (a *API) HandleCreateConvoy(w http.ResponseWriter, r *http.Request) {
+	if a.rejectIfShedding(w) {
+		return
+	}
+
+	// The body is optional; it only carries the convoy name and metadata
+	var req ConvoyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+	var errs ValidationErrors
+	validateOptionalConvoyName(&errs, req.Name)
+	validateConvoyMetadata(&errs, req.Metadata)
+	if err := errs.Err(); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	key, err := idempotencyKey(r, "create")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	replay, ok := a.reserveIdempotentConvoy(w, r, key, requestFingerprint(req))
+	if !ok {
+		return
+	}
+	if replay != nil {
+		w.Header().Set("Idempotent-Replayed", "true")
+		writeJSON(w, http.StatusCreated, replay)
+		return
+	}
+	defer a.releaseIdempotentConvoy(r.Context(), key)
+
+	convoy, err := a.storage.CreateConvoy(r.Context())
+	if err != nil {
+		writeCreateConvoyError(w, "", err)
+		return
+	}
+	if !a.setUpNewConvoy(w, r, convoy.ID, req.Name, req.Metadata) {
+		return
+	}
+	a.rememberIdempotentConvoy(r.Context(), key, convoy.ID)
+
+	log.Printf("SUCCESS: Convoy created with ID %s", convoy.ID)
+	writeJSON(w, http.StatusCreated, convoy)
+}
+
+// HandleGetConvoy retrieves a convoy by its ID.
+func (a *API) HandleGetConvoy(w http.ResponseWriter, r *http.Request) {
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
+		return
+	}
+	convoy, err := a.storage.GetConvoy(r.Context(), convoyID)
+	if errors.Is(err, context.DeadlineExceeded) {
+		writeError(w, http.StatusGatewayTimeout, errors.New("request timed out"))
+		return
+	}
+	if errors.Is(err, ierr.ErrNotFound) {
+		writeError(w, http.StatusNotFound, errors.New("convoy not found"))
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: failed to get convoy %s: %v", convoyID, err)
+		writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		return
+	}
+
+	w.Header().Set("Vary", "Accept")
+	if acceptsCSV(r) {
+		writeConvoyCSV(w, convoy)
+		return
+	}
+	writeJSON(w, http.StatusOK, a.convoyFor(r, convoy))
+}
+
+// HandleAddMember adds a member to a convoy.
+func (a *API) HandleAddMember(w http.ResponseWriter, r *http.Request) {
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req MemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	a.addMember(w, r, convoyID, req, "")
+}
+
+// addMember adds a member built from a validated request and writes the
+// response, including the member's rejoin and connect tokens. With an invite
+// token, the member joins on that invite and is only added if it is valid.
+func (a *API) addMember(w http.ResponseWriter, r *http.Request, convoyID string, req MemberRequest, inviteToken string) {
+	memberID := domain.NewMemberID()
+
+	member := &domain.Member{
+		ID:   memberID,
+		Name: req.Name,
+	}
+	if req.Location != nil {
+		member.Location = *req.Location
+	}
+
+	created, err := withMemberTokens(member)
+	if err != nil {
+		log.Printf("ERROR: failed to generate member tokens: %v", err)
+		writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		return
+	}
+
+	if inviteToken != "" {
+		_, err = a.storage.RedeemInvite(r.Context(), convoyID, inviteToken, member)
+	} else {
+		err = a.storage.AddMember(r.Context(), convoyID, member)
+	}
+	if err != nil {
+		if errors.Is(err, ierr.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("convoy not found"))
+		} else if errors.Is(err, ierr.ErrInvalidToken) {
+			writeErrorWithCode(w, http.StatusNotFound, "Invalid invite", "INVALID_INVITE")
+		} else if errors.Is(err, ierr.ErrExpired) {
+			writeErrorWithCode(w, http.StatusGone, "Invite has expired", "INVITE_EXPIRED")
+		} else if errors.Is(err, ierr.ErrUsedUp) {
+			writeErrorWithCode(w, http.StatusConflict, "Invite has already been used", "INVITE_USED")
+		} else if errors.Is(err, ierr.ErrConvoyFull) {
+			writeErrorWithCode(w, http.StatusConflict, "Convoy has reached its member limit", "CONVOY_FULL")
+		} else if errors.Is(err, ierr.ErrConflict) {
+			writeError(w, http.StatusConflict, errors.New("member ID already in use"))
+		} else {
+			log.Printf("ERROR: failed to add member to convoy %s: %v", convoyID, err)
+			writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		}
+		return
+	}
+
+	log.Printf("SUCCESS: Member %s (ID: %d) joined convoy %s", req.Name, memberID, convoyID)
+	a.broadcastMembershipEvent(convoyID, domain.EventMemberJoined, member.ID, member.Name)
+	a.broadcastUpdate(r.Context(), convoyID)
+
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// HandleAddMembersBulk adds a roster of members in one request, e.g. a tour
+// group known in advance. The batch is all or nothing: one invalid entry or
+// too little room under the member cap rejects every member. The response
+// lists the new members, with their tokens, in request order.
+func (a *API) HandleAddMembersBulk(w http.ResponseWriter, r *http.Request) {
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req BulkMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	members := make([]*domain.Member, len(req.Members))
+	created := make([]*newMember, len(req.Members))
+	for i, memberReq := range req.Members {
+		members[i] = &domain.Member{
+			ID:   domain.NewMemberID(),
+			Name: memberReq.Name,
+		}
+		if memberReq.Location != nil {
+			members[i].Location = *memberReq.Location
+		}
+		var err error
+		if created[i], err = withMemberTokens(members[i]); err != nil {
+			log.Printf("ERROR: failed to generate member tokens: %v", err)
+			writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+			return
+		}
+	}
+
+	if err := a.storage.AddMembers(r.Context(), convoyID, members); err != nil {
+		if errors.Is(err, ierr.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("convoy not found"))
+		} else if errors.Is(err, ierr.ErrConvoyFull) {
+			writeErrorWithCode(w, http.StatusConflict, "Convoy has no room for all of these members", "CONVOY_FULL")
+		} else if errors.Is(err, ierr.ErrConflict) {
+			writeError(w, http.StatusConflict, errors.New("member ID already in use"))
+		} else {
+			log.Printf("ERROR: failed to add members to convoy %s: %v", convoyID, err)
+			writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		}
+		return
+	}
+
+	log.Printf("SUCCESS: %d members imported into convoy %s", len(members), convoyID)
+	for _, member := range members {
+		a.broadcastMembershipEvent(convoyID, domain.EventMemberJoined, member.ID, member.Name)
+	}
+	a.broadcastUpdate(r.Context(), convoyID)
+
+	writeJSON(w, http.StatusCreated, map[string][]*newMember{"members": created})
+}
+
+// newMember is a member as returned on creation. The plain tokens are only
+// ever returned then; storage keeps the hashes.
+type newMember struct {
+	*domain.Member
+	RejoinToken  string `json:"rejoinToken"`
+	ConnectToken string `json:"connectToken"`
+}
+
+// withMemberTokens issues a new member's rejoin and connect tokens
+func withMemberTokens(member *domain.Member) (*newMember, error) {
+	// A rejoin token lets the client reclaim this identity after a network drop
+	rejoinToken, err := email.GenerateVerificationToken()
+	if err != nil {
+		return nil, fmt.Errorf("rejoin token: %w", err)
+	}
+	member.SetRejoinToken(rejoinToken)
+
+	// The connect token authorizes this member's WebSocket (see WS_REQUIRE_CONNECT_TOKEN)
+	connectToken, err := email.GenerateVerificationToken()
+	if err != nil {
+		return nil, fmt.Errorf("connect token: %w", err)
+	}
+	member.SetConnectToken(connectToken)
+
+	return &newMember{Member: member, RejoinToken: rejoinToken, ConnectToken: connectToken}, nil
+}
+
+// HandleRejoinMember restores an existing member identity using its rejoin token.
+func (a *API) HandleRejoinMember(w http.ResponseWriter, r *http.Request) {
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
+		return
+	}
+	memberID, err := strconv.ParseInt(r.PathValue("memberId"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid member ID"))
+		return
+	}
+
+	var req struct {
+		RejoinToken string `json:"rejoinToken"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+
+	member, err := a.storage.RejoinMember(r.Context(), convoyID, memberID, req.RejoinToken)
+	if err != nil {
+		if errors.Is(err, ierr.ErrInvalidToken) {
+			writeErrorWithCode(w, http.StatusUnauthorized, "Invalid rejoin token", "INVALID_TOKEN")
+		} else if errors.Is(err, ierr.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("convoy or member not found"))
+		} else {
+			log.Printf("ERROR: failed to rejoin member %d in convoy %s: %v", memberID, convoyID, err)
+			writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		}
+		return
+	}
+
+	log.Printf("SUCCESS: Member %s (ID: %d) rejoined convoy %s", member.Name, memberID, convoyID)
+	a.broadcastUpdate(r.Context(), convoyID)
+	writeJSON(w, http.StatusOK, member.WithoutNotes())
+}
+
+// HandleUpdateMember applies a partial update to a member's name and profile.
+// Server-managed fields such as id and status are rejected.
+func (a *API) HandleUpdateMember(w http.ResponseWriter, r *http.Request) {
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
+		return
+	}
+	memberID, err := strconv.ParseInt(r.PathValue("memberId"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid member ID"))
+		return
+	}
+
+	var req UpdateMemberRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %v", err))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	// Notes are the leader's own reminders about members, so only the
+	// leader's connect token may change them
+	if req.Notes != nil {
+		token := bearerToken(r)
+		if token == "" {
+			writeErrorWithCode(w, http.StatusUnauthorized, "The leader's connect token is required to edit notes", "UNAUTHORIZED")
+			return
+		}
+		leader, err := a.isLeader(r.Context(), convoyID, token)
+		if err != nil {
+			if errors.Is(err, ierr.ErrNotFound) {
+				writeError(w, http.StatusNotFound, errors.New("convoy or member not found"))
+			} else {
+				log.Printf("ERROR: failed to check the leader of convoy %s: %v", convoyID, err)
+				writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+			}
+			return
+		}
+		if !leader {
+			writeErrorWithCode(w, http.StatusForbidden, "Only the convoy leader can edit notes", "LEADER_ONLY")
+			return
+		}
+	}
+
+	member, err := a.storage.UpdateMember(r.Context(), convoyID, memberID, req.ToDomain())
+	if err != nil {
+		if errors.Is(err, ierr.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("convoy or member not found"))
+		} else {
+			log.Printf("ERROR: failed to update member %d in convoy %s: %v", memberID, convoyID, err)
+			writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		}
+		return
+	}
+
+	log.Printf("INFO: Member %d in convoy %s updated profile", memberID, convoyID)
+	a.broadcastUpdateForced(r.Context(), convoyID)
+	writeJSON(w, http.StatusOK, a.memberFor(r, convoyID, member))
+}
+
+// NearestMemberResponse describes the member closest to the requesting member
+type NearestMemberResponse struct {
+	MemberID   int64   `json:"memberId"`
+	Name       string  `json:"name"`
+	DistanceKm float64 `json:"distanceKm"`
+}
+
+// HandleGetMember returns a single member's current record
+func (a *API) HandleGetMember(w http.ResponseWriter, r *http.Request) {
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
+		return
+	}
+	memberID, err := strconv.ParseInt(r.PathValue("memberId"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid member ID"))
+		return
+	}
+
+	member, err := a.storage.GetMember(r.Context(), convoyID, memberID)
+	if err != nil {
+		if errors.Is(err, ierr.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("convoy or member not found"))
+		} else {
+			log.Printf("ERROR: failed to get member %d in convoy %s: %v", memberID, convoyID, err)
+			writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, a.memberFor(r, convoyID, member))
+}
+
+// HandleGetNearestMember returns the closest other active member, or null if
+// the member is alone in the convoy.
+func (a *API) HandleGetNearestMember(w http.ResponseWriter, r *http.Request) {
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
+		return
+	}
+	memberID, err := strconv.ParseInt(r.PathValue("memberId"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid member ID"))
+		return
+	}
+
+	convoy, err := a.storage.GetConvoy(r.Context(), convoyID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, errors.New("convoy not found"))
+		return
+	}
+
+	var member *domain.Member
+	for _, m := range convoy.Members {
+		if m.ID == memberID {
+			member = m
+			break
+		}
+	}
+	if member == nil {
+		writeError(w, http.StatusNotFound, errors.New("member not found"))
+		return
+	}
+
+	nearest, distance := a.monitor.FindNearestMember(convoy, member)
+	if nearest == nil {
+		writeJSON(w, http.StatusOK, nil)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, NearestMemberResponse{
+		MemberID:   nearest.ID,
+		Name:       nearest.Name,
+		DistanceKm: distance,
+	})
+}
+
+// ConvoyBoundsResponse lets map clients fit the viewport to the convoy
+type ConvoyBoundsResponse struct {
+	Bounds      geo.Bounds    `json:"bounds"`
+	Center      domain.LatLng `json:"center"`
+	MemberCount int           `json:"memberCount"` // members included in the bounds
+}
+
+// HandleGetConvoyBounds returns the bounding box of members that aren't
+// disconnected (or of all members if every one is) plus the convoy center.
+func (a *API) HandleGetConvoyBounds(w http.ResponseWriter, r *http.Request) {
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	convoy, err := a.storage.GetConvoy(r.Context(), convoyID)
+	if errors.Is(err, ierr.ErrNotFound) {
+		writeError(w, http.StatusNotFound, errors.New("convoy not found"))
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: failed to get convoy %s: %v", convoyID, err)
+		writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		return
+	}
+
+	// Bound the same members the center is averaged from
+	points := geo.CenterPoints(convoy.Members)
+	bounds, ok := geo.BoundsOf(points)
+	if !ok {
+		writeErrorWithCode(w, http.StatusNotFound, "Convoy has no members", "NO_MEMBERS")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ConvoyBoundsResponse{
+		Bounds:      bounds,
+		Center:      geo.Center(convoy.Members),
+		MemberCount: len(points),
+	})
+}
+
+// HandleUpdateMemberLocation updates a member's location.
+func (a *API) HandleUpdateMemberLocation(w http.ResponseWriter, r *http.Request) {
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
+		return
+	}
+	memberIDStr := r.PathValue("memberId")
+
+	memberID, err := strconv.ParseInt(memberIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid member ID: %v", err))
+		return
+	}
+
+	var req LocationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %v", err))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	if ok, retryAfter := a.locationLimiter.Allow(convoyID, memberID); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		writeErrorWithCode(w, http.StatusTooManyRequests, "Location updates are too frequent", "LOCATION_UPDATE_TOO_FREQUENT")
+		return
+	}
+
+	location := domain.LatLng{Lat: req.Lat, Lng: req.Lng}
+
+	if err := a.storage.UpdateMemberLocationWithAccuracy(r.Context(), convoyID, memberID, location, req.Accuracy); err != nil {
+		if errors.Is(err, ierr.ErrMemberLeft) {
+			writeErrorWithCode(w, http.StatusGone, "member has left the convoy", "MEMBER_LEFT")
+		} else if errors.Is(err, ierr.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("convoy or member not found"))
+		} else {
+			log.Printf("ERROR: failed to update member location: %v", err)
+			writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		}
+		return
+	}
+
+	// Log location update for testing
+	log.Printf("LOCATION_UPDATE: Member %d in convoy %s updated location to [%.6f, %.6f]",
+		memberID, convoyID, req.Lat, req.Lng)
+
+	// Broadcast the updated convoy data, unless this convoy has used up its
+	// update budget; the location is stored either way
+	if a.updateBudget.Allow(convoyID, memberID) {
+		if a.broadcastMode == config.BroadcastModeDelta {
+			a.broadcastMemberMoved(r.Context(), convoyID, memberID)
+		} else {
+			a.broadcastUpdate(r.Context(), convoyID)
+		}
+	} else {
+		log.Printf("DEBUG: Location update budget exhausted for convoy %s, not broadcasting update from member %d", convoyID, memberID)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "location updated"})
+}
+
+// HandleSetConvoyDestination sets the destination for a convoy.
+func (a *API) HandleSetConvoyDestination(w http.ResponseWriter, r *http.Request) {
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req DestinationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+
+	// Validate the destination request
+	if err := req.Validate(); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	// Convert to domain object
+	destination := req.ToDomain(a.arrivalRadius)
+
+	if err := a.storage.SetConvoyDestination(r.Context(), convoyID, destination); err != nil {
+		if errors.Is(err, ierr.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("convoy not found"))
+		} else {
+			log.Printf("ERROR: failed to set destination for convoy %s: %v", convoyID, err)
+			writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		}
+		return
 	}
-}
 
-// StartMonitoring starts the convoy monitoring service
-func (a *API) StartMonitoring() {
-	a.monitor.Start()
-}
+	log.Printf("INFO: Destination set for convoy %s: %s at [%.6f, %.6f], arrival within %.0fm",
+		convoyID, destination.Name, destination.Lat, destination.Lng, destination.ArrivalRadiusMeters)
 
-// StopMonitoring stops the convoy monitoring service
-func (a *API) StopMonitoring() {
-	a.monitor.Stop()
+	// Every member's distance to the destination changed; don't let the
+	// throttle hold back the new values until the next location update
+	a.broadcastUpdateForced(r.Context(), convoyID)
+	writeJSON(w, http.StatusOK, map[string]string{"message": "destination set"})
 }
 
-// HandleCreateConvoy creates a new convoy.
-func (a *API) HandleCreateConvoy(w http.ResponseWriter, r *http.Request) {
-	convoy, err := a.storage.CreateConvoy(r.Context())
-	if err != nil {
-		log.Printf("ERROR: failed to create convoy: %v", err)
-		writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+// HandlePatchConvoyDestination changes part of the convoy's destination, such
+// as a note on where to park, without resending the rest. A convoy without a
+// destination gets 409; one must be set with POST first.
+func (a *API) HandlePatchConvoyDestination(w http.ResponseWriter, r *http.Request) {
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
 		return
 	}
 
-	log.Printf("SUCCESS: Convoy created with ID %s", convoy.ID)
-	writeJSON(w, http.StatusCreated, convoy)
-}
+	var req DestinationPatchRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %v", err))
+		return
+	}
 
-// HandleGetConvoy retrieves a convoy by its ID.
-func (a *API) HandleGetConvoy(w http.ResponseWriter, r *http.Request) {
-	convoyID := r.PathValue("convoyId")
-	convoy, err := a.storage.GetConvoy(r.Context(), convoyID)
+	if err := req.Validate(); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	destination, err := a.storage.UpdateConvoyDestination(r.Context(), convoyID, req.ToDomain())
 	if err != nil {
-		writeError(w, http.StatusNotFound, errors.New("convoy not found"))
+		switch {
+		case errors.Is(err, ierr.ErrNotFound):
+			writeError(w, http.StatusNotFound, errors.New("convoy not found"))
+		case errors.Is(err, ierr.ErrNoDestination):
+			writeErrorWithCode(w, http.StatusConflict, "Convoy has no destination yet; set one with POST first", "NO_DESTINATION")
+		default:
+			log.Printf("ERROR: failed to update destination for convoy %s: %v", convoyID, err)
+			writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		}
 		return
 	}
-	writeJSON(w, http.StatusOK, convoy)
+
+	log.Printf("INFO: Destination updated for convoy %s: %s at [%.6f, %.6f]",
+		convoyID, destination.Name, destination.Lat, destination.Lng)
+
+	a.broadcastUpdateForced(r.Context(), convoyID)
+	writeJSON(w, http.StatusOK, destination)
 }
 
-// HandleAddMember adds a member to a convoy.
-func (a *API) HandleAddMember(w http.ResponseWriter, r *http.Request) {
-	convoyID := r.PathValue("convoyId")
+// HandlePauseConvoy pauses monitoring alerts for a convoy (e.g. during a meal stop).
+func (a *API) HandlePauseConvoy(w http.ResponseWriter, r *http.Request) {
+	a.setConvoyPaused(w, r, true)
+}
 
-	var req struct {
-		Name     string        `json:"name"`
-		Location domain.LatLng `json:"location"` // Added Location field
+// HandleResumeConvoy resumes monitoring alerts for a paused convoy.
+func (a *API) HandleResumeConvoy(w http.ResponseWriter, r *http.Request) {
+	a.setConvoyPaused(w, r, false)
+}
+
+// setConvoyPaused updates the paused state and notifies connected clients.
+func (a *API) setConvoyPaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
+		return
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, errors.New("invalid request body"))
+
+	if err := a.storage.SetConvoyPaused(r.Context(), convoyID, paused); err != nil {
+		if errors.Is(err, ierr.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("convoy not found"))
+		} else {
+			log.Printf("ERROR: failed to update paused state for convoy %s: %v", convoyID, err)
+			writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		}
 		return
 	}
 
-	// Use a shorter, more reliable ID generation
-	memberID := time.Now().Unix()*1000 + int64(time.Now().Nanosecond()/1000000)
+	eventType := domain.EventConvoyPaused
+	message := "convoy paused"
+	if !paused {
+		eventType = domain.EventConvoyResumed
+		message = "convoy resumed"
+		// Re-evaluate member statuses right away rather than on the next tick
+		a.monitor.CheckConvoy(convoyID)
+	}
 
-	member := &domain.Member{
-		ID:       memberID,
-		Name:     req.Name,
-		Location: req.Location, // Assigned Location from request
+	log.Printf("INFO: Convoy %s %s", convoyID, message)
+
+	a.wsHub.Broadcast(convoyID, &domain.ConvoyAlert{
+		EventType: eventType,
+		ConvoyID:  convoyID,
+		Timestamp: time.Now(),
+	})
+	a.broadcastUpdateForced(r.Context(), convoyID)
+	writeJSON(w, http.StatusOK, map[string]string{"message": message})
+}
+
+// HandleStartConvoy records the convoy's departure and tells connected
+// clients. Trip durations are measured from it and, with MONITOR_REQUIRE_START,
+// monitoring alerts only begin once the convoy has started. A convoy starts
+// only once; starting it again is a 409.
+func (a *API) HandleStartConvoy(w http.ResponseWriter, r *http.Request) {
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
+		return
 	}
 
-	if err := a.storage.AddMember(r.Context(), convoyID, member); err != nil {
+	startedAt := time.Now()
+	if err := a.storage.SetConvoyStarted(r.Context(), convoyID, startedAt); err != nil {
 		if errors.Is(err, ierr.ErrNotFound) {
 			writeError(w, http.StatusNotFound, errors.New("convoy not found"))
+		} else if errors.Is(err, ierr.ErrConflict) {
+			writeErrorWithCode(w, http.StatusConflict, "convoy has already started", "ALREADY_STARTED")
 		} else {
-			log.Printf("ERROR: failed to add member to convoy %s: %v", convoyID, err)
+			log.Printf("ERROR: failed to start convoy %s: %v", convoyID, err)
 			writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
 		}
 		return
 	}
 
-	log.Printf("SUCCESS: Member %s (ID: %d) joined convoy %s", req.Name, memberID, convoyID)
-	a.broadcastUpdate(r.Context(), convoyID)
-	writeJSON(w, http.StatusCreated, member)
+	log.Printf("INFO: Convoy %s started", convoyID)
+
+	a.wsHub.Broadcast(convoyID, &domain.ConvoyAlert{
+		EventType: domain.EventConvoyStarted,
+		ConvoyID:  convoyID,
+		Timestamp: startedAt,
+	})
+	// Evaluate member statuses right away rather than on the next tick
+	a.monitor.CheckConvoy(convoyID)
+	a.broadcastUpdateForced(r.Context(), convoyID)
+	writeJSON(w, http.StatusOK, map[string]time.Time{"startedAt": startedAt})
 }
 
-// HandleUpdateMemberLocation updates a member's location.
-func (a *API) HandleUpdateMemberLocation(w http.ResponseWriter, r *http.Request) {
-	convoyID := r.PathValue("convoyId")
-	memberIDStr := r.PathValue("memberId")
+// rejectIfShedding answers 503 while the hub is shedding load, keeping its
+// remaining capacity for convoys that already exist. It reports whether the
+// request was rejected.
+func (a *API) rejectIfShedding(w http.ResponseWriter) bool {
+	if !a.wsHub.Shedding() {
+		return false
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(a.wsHub.ReconnectAfter().Seconds()))))
+	writeErrorWithCode(w, http.StatusServiceUnavailable, "Server is under heavy load, try again later", "SERVER_OVERLOADED")
+	return true
+}
 
-	memberID, err := strconv.ParseInt(memberIDStr, 10, 64)
-	if err != nil {
-		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid member ID: %v", err))
+// writeCreateConvoyError reports a failed convoy creation: 503 when the server
+// holds as many convoys as it allows, 500 otherwise
+func writeCreateConvoyError(w http.ResponseWriter, kind string, err error) {
+	if errors.Is(err, ierr.ErrCapacity) {
+		log.Printf("WARNING: Refused to create convoy%s: %v", kind, err)
+		writeErrorWithCode(w, http.StatusServiceUnavailable, "Server is at capacity, try again later", "SERVER_AT_CAPACITY")
 		return
 	}
+	log.Printf("ERROR: failed to create convoy%s: %v", kind, err)
+	writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+}
 
-	var req LocationRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %v", err))
+// setUpNewConvoy applies the optional name and metadata given at creation.
+// If either fails it deletes the convoy, so no half set up convoy is left
+// behind with a live verification token, writes an error response and
+// returns false.
+func (a *API) setUpNewConvoy(w http.ResponseWriter, r *http.Request, convoyID, name string, metadata map[string]string) bool {
+	if a.nameNewConvoy(w, r, convoyID, name) && a.tagNewConvoy(w, r, convoyID, metadata) {
+		return true
+	}
+	if err := a.storage.DeleteConvoy(r.Context(), convoyID); err != nil {
+		log.Printf("ERROR: failed to delete convoy %s after its setup failed: %v", convoyID, err)
+	}
+	return false
+}
+
+// nameNewConvoy applies the optional name given at creation. It writes an
+// error response and returns false if that fails.
+func (a *API) nameNewConvoy(w http.ResponseWriter, r *http.Request, convoyID, name string) bool {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return true
+	}
+	if err := a.storage.SetConvoyName(r.Context(), convoyID, name); err != nil {
+		log.Printf("ERROR: failed to name new convoy %s: %v", convoyID, err)
+		writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		return false
+	}
+	return true
+}
+
+// tagNewConvoy applies the optional metadata given at creation. It writes an
+// error response and returns false if that fails.
+func (a *API) tagNewConvoy(w http.ResponseWriter, r *http.Request, convoyID string, metadata map[string]string) bool {
+	if len(metadata) == 0 {
+		return true
+	}
+	if err := a.storage.SetConvoyMetadata(r.Context(), convoyID, metadata); err != nil {
+		log.Printf("ERROR: failed to set metadata of new convoy %s: %v", convoyID, err)
+		writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		return false
+	}
+	return true
+}
+
+// HandleSetConvoyMetadata replaces a convoy's metadata and pushes the change
+// to connected clients.
+func (a *API) HandleSetConvoyMetadata(w http.ResponseWriter, r *http.Request) {
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
 		return
 	}
 
+	var req ConvoyMetadataRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid request body"))
+		return
+	}
 	if err := req.Validate(); err != nil {
 		writeValidationError(w, err)
 		return
 	}
 
-	location := domain.LatLng{Lat: req.Lat, Lng: req.Lng}
-
-	if err := a.storage.UpdateMemberLocation(r.Context(), convoyID, memberID, location); err != nil {
-		log.Printf("ERROR: failed to update member location: %v", err)
-		writeError(w, http.StatusInternalServerError, err)
+	if err := a.storage.SetConvoyMetadata(r.Context(), convoyID, req.Metadata); err != nil {
+		if errors.Is(err, ierr.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("convoy not found"))
+		} else {
+			log.Printf("ERROR: failed to set metadata of convoy %s: %v", convoyID, err)
+			writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		}
 		return
 	}
 
-	// Log location update for testing
-	log.Printf("LOCATION_UPDATE: Member %d in convoy %s updated location to [%.6f, %.6f]",
-		memberID, convoyID, req.Lat, req.Lng)
-
-	// Broadcast the updated convoy data
-	a.broadcastUpdate(r.Context(), convoyID)
-
-	writeJSON(w, http.StatusOK, map[string]string{"message": "location updated"})
+	log.Printf("INFO: Convoy %s metadata set (%d keys)", convoyID, len(req.Metadata))
+	a.broadcastUpdateForced(r.Context(), convoyID)
+	writeJSON(w, http.StatusOK, req)
 }
 
-// HandleSetConvoyDestination sets the destination for a convoy.
-func (a *API) HandleSetConvoyDestination(w http.ResponseWriter, r *http.Request) {
-	convoyID := r.PathValue("convoyId")
+// HandleSetConvoyName renames a convoy and pushes the change to connected clients.
+func (a *API) HandleSetConvoyName(w http.ResponseWriter, r *http.Request) {
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
+		return
+	}
 
-	var req DestinationRequest
+	var req ConvoyRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeError(w, http.StatusBadRequest, errors.New("invalid request body"))
 		return
 	}
-
-	// Validate the destination request
 	if err := req.Validate(); err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		writeValidationError(w, err)
 		return
 	}
 
-	// Convert to domain object
-	destination := req.ToDomain()
-
-	if err := a.storage.SetConvoyDestination(r.Context(), convoyID, destination); err != nil {
+	name := strings.TrimSpace(req.Name)
+	if err := a.storage.SetConvoyName(r.Context(), convoyID, name); err != nil {
 		if errors.Is(err, ierr.ErrNotFound) {
 			writeError(w, http.StatusNotFound, errors.New("convoy not found"))
 		} else {
-			log.Printf("ERROR: failed to set destination for convoy %s: %v", convoyID, err)
+			log.Printf("ERROR: failed to rename convoy %s: %v", convoyID, err)
 			writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
 		}
 		return
 	}
 
-	log.Printf("INFO: Destination set for convoy %s: %s at [%.6f, %.6f]",
-		convoyID, destination.Name, destination.Lat, destination.Lng)
-
-	a.broadcastUpdate(r.Context(), convoyID)
-	writeJSON(w, http.StatusOK, map[string]string{"message": "destination set"})
+	log.Printf("INFO: Convoy %s renamed to %q", convoyID, name)
+	a.broadcastUpdateForced(r.Context(), convoyID)
+	writeJSON(w, http.StatusOK, map[string]string{"name": name})
 }
 
 // HandleLeaveConvoy removes a member from a convoy.
 func (a *API) HandleLeaveConvoy(w http.ResponseWriter, r *http.Request) {
-	convoyID := r.PathValue("convoyId")
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
+		return
+	}
 	memberIDStr := r.PathValue("memberId")
 	memberID, err := strconv.ParseInt(memberIDStr, 10, 64)
 	if err != nil {
@@ -248,6 +1052,17 @@ func (a *API) HandleLeaveConvoy(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("INFO: Attempting to remove member %d from convoy %s", memberID, convoyID)
 
+	// Look up the name first; the member is gone once LeaveConvoy succeeds
+	var memberName string
+	if convoy, err := a.storage.GetConvoy(r.Context(), convoyID); err == nil {
+		for _, m := range convoy.Members {
+			if m.ID == memberID {
+				memberName = m.Name
+				break
+			}
+		}
+	}
+
 	if err := a.storage.LeaveConvoy(r.Context(), convoyID, memberID); err != nil {
 		if errors.Is(err, ierr.ErrNotFound) {
 			log.Printf("ERROR: convoy %s or member %d not found during leave operation", convoyID, memberID)
@@ -260,14 +1075,31 @@ func (a *API) HandleLeaveConvoy(w http.ResponseWriter, r *http.Request) {
 	}
 
 	log.Printf("INFO: Member %d successfully left convoy %s", memberID, convoyID)
+	a.broadcastMembershipEvent(convoyID, domain.EventMemberLeft, memberID, memberName)
 	a.broadcastUpdate(r.Context(), convoyID)
 	writeJSON(w, http.StatusOK, map[string]string{"message": "member left convoy"})
 }
 
+// broadcastMembershipEvent tells clients a member joined or left, so they can
+// show it without diffing snapshots. Call it only after storage is updated.
+func (a *API) broadcastMembershipEvent(convoyID, eventType string, memberID int64, memberName string) {
+	a.wsHub.Broadcast(convoyID, &domain.ConvoyAlert{
+		EventType:  eventType,
+		ConvoyID:   convoyID,
+		MemberID:   memberID,
+		MemberName: memberName,
+		Timestamp:  time.Now(),
+	})
+}
+
 func (a *API) broadcastUpdate(ctx context.Context, convoyID string) {
-	// Check if we should throttle this broadcast
+	// A throttled update is not dropped: the convoy state is sent once the
+	// interval is over, so a change such as a member lagging still arrives
 	if !a.broadcastThrottler.ShouldBroadcast(convoyID) {
 		log.Printf("DEBUG: Throttling broadcast for convoy %s", convoyID)
+		a.broadcastThrottler.ScheduleTrailing(convoyID, func() {
+			a.broadcastUpdate(context.Background(), convoyID)
+		})
 		return
 	}
 
@@ -282,6 +1114,38 @@ func (a *API) broadcastUpdate(ctx context.Context, convoyID string) {
 	a.wsHub.Broadcast(convoyID, convoy)
 }
 
+// broadcastMemberMoved sends just the member whose location changed. It isn't
+// throttled: unlike a skipped snapshot, a skipped delta is never caught up.
+func (a *API) broadcastMemberMoved(ctx context.Context, convoyID string, memberID int64) {
+	convoy, err := a.storage.GetConvoy(ctx, convoyID)
+	if err != nil {
+		log.Printf("ERROR: failed to get convoy %s for broadcast: %v", convoyID, err)
+		return
+	}
+	for _, member := range convoy.Members {
+		if member.ID == memberID {
+			a.wsHub.Broadcast(convoyID, &domain.MemberMoved{
+				EventType: domain.EventMemberMoved,
+				ConvoyID:  convoyID,
+				Member:    member,
+				Timestamp: time.Now(),
+			})
+			return
+		}
+	}
+}
+
+// BroadcastConvoyUpdate sends the convoy state after the monitor changed member
+// statuses. Updates for safety-critical events skip the throttle; routine ones
+// share it with location updates.
+func (a *API) BroadcastConvoyUpdate(ctx context.Context, convoyID string, eventTypes []string) {
+	if a.broadcastThrottler.IsForced(eventTypes...) {
+		a.broadcastUpdateForced(ctx, convoyID)
+		return
+	}
+	a.broadcastUpdate(ctx, convoyID)
+}
+
 // broadcastUpdateForced forces a broadcast without throttling (for critical updates)
 func (a *API) broadcastUpdateForced(ctx context.Context, convoyID string) {
 	convoy, err := a.storage.GetConvoy(ctx, convoyID)
@@ -311,6 +1175,10 @@ func writeError(w http.ResponseWriter, status int, err error) {
 
 // HandleCreateConvoyWithVerification creates a new convoy with email verification
 func (a *API) HandleCreateConvoyWithVerification(w http.ResponseWriter, r *http.Request) {
+	if a.rejectIfShedding(w) {
+		return
+	}
+
 	var req CreateConvoyWithVerificationRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeError(w, http.StatusBadRequest, errors.New("invalid request body"))
@@ -318,9 +1186,32 @@ func (a *API) HandleCreateConvoyWithVerification(w http.ResponseWriter, r *http.
 	}
 
 	if err := req.Validate(); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	// A retried request returns the original convoy without sending another
+	// email or counting against the rate limits
+	key, err := idempotencyKey(r, "create-with-verification")
+	if err != nil {
 		writeError(w, http.StatusBadRequest, err)
 		return
 	}
+	replay, ok := a.reserveIdempotentConvoy(w, r, key, requestFingerprint(req))
+	if !ok {
+		return
+	}
+	if replay != nil && replay.VerificationExpiresAt != nil {
+		w.Header().Set("Idempotent-Replayed", "true")
+		writeJSON(w, http.StatusCreated, map[string]interface{}{
+			"convoyId":             replay.ID,
+			"verificationRequired": true,
+			"emailSent":            a.emailService.IsConfigured(),
+			"expiresAt":            replay.VerificationExpiresAt.Format(time.RFC3339),
+		})
+		return
+	}
+	defer a.releaseIdempotentConvoy(r.Context(), key)
 
 	// Get client IP for rate limiting
 	clientIP := getClientIP(r)
@@ -354,14 +1245,16 @@ func (a *API) HandleCreateConvoyWithVerification(w http.ResponseWriter, r *http.
 	expiresAt := time.Now().Add(30 * time.Minute)
 	convoy, err := a.storage.CreateConvoyWithVerification(r.Context(), req.Email, req.LeaderName, token, expiresAt)
 	if err != nil {
-		log.Printf("ERROR: failed to create convoy with verification: %v", err)
-		writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		writeCreateConvoyError(w, " with verification", err)
+		return
+	}
+	if !a.setUpNewConvoy(w, r, convoy.ID, req.Name, req.Metadata) {
 		return
 	}
 
 	// Send verification email
 	if a.emailService.IsConfigured() {
-		if err := a.emailService.SendVerificationEmail(req.Email, req.LeaderName, token); err != nil {
+		if err := a.emailService.SendVerificationEmailWithBaseURL(req.Email, req.LeaderName, token, a.forwardedBaseURL(r)); err != nil {
 			log.Printf("ERROR: failed to send verification email: %v", err)
 			writeError(w, http.StatusInternalServerError, errors.New("failed to send verification email"))
 			return
@@ -373,6 +1266,7 @@ func (a *API) HandleCreateConvoyWithVerification(w http.ResponseWriter, r *http.
 	// Record rate limit usage
 	a.rateLimiter.RecordEmailRequest(req.Email)
 	a.rateLimiter.RecordIPRequest(clientIP)
+	a.rememberIdempotentConvoy(r.Context(), key, convoy.ID)
 
 	log.Printf("SUCCESS: Convoy created with verification - ID: %s, Email: %s", convoy.ID, req.Email)
 
@@ -397,13 +1291,14 @@ func (a *API) HandleVerifyConvoy(w http.ResponseWriter, r *http.Request) {
 	convoy, err := a.storage.VerifyConvoy(r.Context(), token)
 	if err != nil {
 		log.Printf("ERROR: verification failed for token %s: %v", token, err)
-		if strings.Contains(err.Error(), "not found") {
+		switch {
+		case errors.Is(err, ierr.ErrNotFound):
 			writeErrorWithCode(w, http.StatusNotFound, "Invalid verification token", "INVALID_TOKEN")
-		} else if strings.Contains(err.Error(), "expired") {
+		case errors.Is(err, ierr.ErrExpired):
 			writeErrorWithCode(w, http.StatusGone, "Verification token has expired", "TOKEN_EXPIRED")
-		} else if strings.Contains(err.Error(), "already been used") {
+		case errors.Is(err, ierr.ErrTokenUsed):
 			writeErrorWithCode(w, http.StatusConflict, "Verification token has already been used", "TOKEN_USED")
-		} else {
+		default:
 			writeError(w, http.StatusInternalServerError, errors.New("verification failed"))
 		}
 		return
@@ -424,7 +1319,10 @@ func (a *API) HandleVerifyConvoy(w http.ResponseWriter, r *http.Request) {
 
 // HandleResendVerification resends verification email for a convoy
 func (a *API) HandleResendVerification(w http.ResponseWriter, r *http.Request) {
-	convoyID := r.PathValue("convoyId")
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
+		return
+	}
 	if convoyID == "" {
 		writeError(w, http.StatusBadRequest, errors.New("convoy ID is required"))
 		return
@@ -475,7 +1373,7 @@ func (a *API) HandleResendVerification(w http.ResponseWriter, r *http.Request) {
 			leaderName = convoy.Members[0].Name
 		}
 
-		if err := a.emailService.SendVerificationEmail(convoy.CreatedByEmail, leaderName, newToken); err != nil {
+		if err := a.emailService.SendVerificationEmailWithBaseURL(convoy.CreatedByEmail, leaderName, newToken, a.forwardedBaseURL(r)); err != nil {
 			log.Printf("ERROR: failed to send verification email: %v", err)
 			writeError(w, http.StatusInternalServerError, errors.New("failed to send verification email"))
 			return
@@ -490,9 +1388,140 @@ func (a *API) HandleResendVerification(w http.ResponseWriter, r *http.Request) {
 	log.Printf("SUCCESS: Verification email resent for convoy %s", convoyID)
 
 	response := map[string]interface{}{
-		"emailSent":           a.emailService.IsConfigured(),
-		"expiresAt":           expiresAt.Format(time.RFC3339),
-		"rateLimitRemaining":  a.rateLimiter.GetRemainingEmailRequests(convoy.CreatedByEmail, 3),
+		"emailSent":          a.emailService.IsConfigured(),
+		"expiresAt":          expiresAt.Format(time.RFC3339),
+		"rateLimitRemaining": a.rateLimiter.GetRemainingEmailRequests(convoy.CreatedByEmail, 3),
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// HandleCreateConvoyWithSMS creates a new convoy verified by an SMS code
+func (a *API) HandleCreateConvoyWithSMS(w http.ResponseWriter, r *http.Request) {
+	if a.rejectIfShedding(w) {
+		return
+	}
+
+	var req CreateConvoyWithSMSRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	clientIP := getClientIP(r)
+
+	// Check rate limits
+	if !a.rateLimiter.CheckPhoneLimit(req.Phone, 3) {
+		remaining := a.rateLimiter.GetRemainingPhoneRequests(req.Phone, 3)
+		writeErrorWithCode(w, http.StatusTooManyRequests,
+			fmt.Sprintf("Too many verification codes sent. Try again later. Remaining: %d", remaining),
+			"RATE_LIMIT_PHONE")
+		return
+	}
+
+	if !a.rateLimiter.CheckIPLimit(clientIP, 5) {
+		remaining := a.rateLimiter.GetRemainingIPRequests(clientIP, 5)
+		writeErrorWithCode(w, http.StatusTooManyRequests,
+			fmt.Sprintf("Too many convoy creation attempts. Try again later. Remaining: %d", remaining),
+			"RATE_LIMIT_IP")
+		return
+	}
+
+	code, err := sms.GenerateVerificationCode()
+	if err != nil {
+		log.Printf("ERROR: failed to generate verification code: %v", err)
+		writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		return
+	}
+
+	// SMS codes are short, so they expire sooner than email links
+	expiresAt := time.Now().Add(10 * time.Minute)
+	convoy, err := a.storage.CreateConvoyWithSMSVerification(r.Context(), req.Phone, req.LeaderName, code, expiresAt)
+	if err != nil {
+		writeCreateConvoyError(w, " with SMS verification", err)
+		return
+	}
+	if !a.setUpNewConvoy(w, r, convoy.ID, req.Name, req.Metadata) {
+		return
+	}
+
+	if a.smsService.IsConfigured() {
+		if err := a.smsService.SendVerificationCode(req.Phone, code); err != nil {
+			log.Printf("ERROR: failed to send verification SMS: %v", err)
+			writeError(w, http.StatusInternalServerError, errors.New("failed to send verification SMS"))
+			return
+		}
+	} else {
+		log.Printf("WARNING: SMS service not configured, verification code not sent")
+	}
+
+	// Record rate limit usage
+	a.rateLimiter.RecordPhoneRequest(req.Phone)
+	a.rateLimiter.RecordIPRequest(clientIP)
+
+	log.Printf("SUCCESS: Convoy created with SMS verification - ID: %s", convoy.ID)
+
+	response := map[string]interface{}{
+		"convoyId":             convoy.ID,
+		"verificationRequired": true,
+		"smsSent":              a.smsService.IsConfigured(),
+		"expiresAt":            expiresAt.Format(time.RFC3339),
+	}
+
+	writeJSON(w, http.StatusCreated, response)
+}
+
+// HandleVerifySMS verifies a convoy using the SMS code
+func (a *API) HandleVerifySMS(w http.ResponseWriter, r *http.Request) {
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req VerifySMSRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	convoy, err := a.storage.VerifyConvoyCode(r.Context(), convoyID, req.Code)
+	if err != nil {
+		log.Printf("ERROR: SMS verification failed for convoy %s: %v", convoyID, err)
+		switch {
+		case errors.Is(err, ierr.ErrNotFound):
+			writeErrorWithCode(w, http.StatusNotFound, "No pending SMS verification for this convoy", "INVALID_TOKEN")
+		case errors.Is(err, ierr.ErrExpired):
+			writeErrorWithCode(w, http.StatusGone, "Verification code has expired", "TOKEN_EXPIRED")
+		case errors.Is(err, ierr.ErrTokenUsed):
+			writeErrorWithCode(w, http.StatusConflict, "Verification code has already been used", "TOKEN_USED")
+		case errors.Is(err, ierr.ErrTooManyAttempts):
+			writeErrorWithCode(w, http.StatusTooManyRequests, "Too many incorrect codes, create the convoy again to get a new one", "TOO_MANY_ATTEMPTS")
+		case errors.Is(err, ierr.ErrInvalidToken):
+			writeErrorWithCode(w, http.StatusUnauthorized, "Invalid verification code", "INVALID_CODE")
+		default:
+			writeError(w, http.StatusInternalServerError, errors.New("verification failed"))
+		}
+		return
+	}
+
+	log.Printf("SUCCESS: Convoy verified via SMS - ID: %s", convoy.ID)
+
+	response := map[string]interface{}{
+		"success":     true,
+		"convoyId":    convoy.ID,
+		"leaderName":  convoy.LeaderName,
+		"redirectUrl": fmt.Sprintf("/convoy/%s", convoy.ID),
+		"verifiedAt":  convoy.VerifiedAt.Format(time.RFC3339),
 	}
 
 	writeJSON(w, http.StatusOK, response)
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"convoy-app/backend/src/config"
+	"convoy-app/backend/src/storage"
+	"convoy-app/backend/src/ws"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// missingConvoyID is well formed but never created
+const missingConvoyID = "0123456789abcdef0123456789abcdef"
+
+// newTestAPI returns an API backed by fresh in-memory storage and a routed mux
+func newTestAPI(t *testing.T) (*API, *storage.MemoryStorage, *http.ServeMux) {
+	t.Helper()
+	memStorage := storage.NewMemoryStorage()
+	apiServer := New(memStorage, ws.NewHub(), config.Load())
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/convoys/status", apiServer.HandleGetConvoysStatus)
+	mux.HandleFunc("GET /api/convoys/{convoyId}", apiServer.HandleGetConvoy)
+	mux.HandleFunc("GET /api/convoys/{convoyId}/bounds", apiServer.HandleGetConvoyBounds)
+	mux.HandleFunc("GET /api/convoys/{convoyId}/events", apiServer.HandleGetConvoyEvents)
+	mux.HandleFunc("GET /api/convoys/{convoyId}/summary", apiServer.HandleGetConvoySummary)
+	mux.HandleFunc("GET /api/convoys/{convoyId}/export", apiServer.HandleExportConvoy)
+	mux.HandleFunc("GET /api/convoys/{convoyId}/verification", apiServer.HandleGetVerificationStatus)
+	mux.HandleFunc("PUT /api/convoys/{convoyId}/name", apiServer.HandleSetConvoyName)
+	mux.HandleFunc("PUT /api/convoys/{convoyId}/metadata", apiServer.HandleSetConvoyMetadata)
+	mux.HandleFunc("GET /api/convoys/{convoyId}/members", apiServer.HandleListMembers)
+	mux.HandleFunc("POST /api/convoys/{convoyId}/members", apiServer.HandleAddMember)
+	mux.HandleFunc("POST /api/convoys/{convoyId}/members/bulk", apiServer.HandleAddMembersBulk)
+	mux.HandleFunc("POST /api/convoys/{convoyId}/invites", apiServer.HandleCreateInvite)
+	mux.HandleFunc("POST /api/convoys/{convoyId}/join", apiServer.HandleJoinWithInvite)
+	mux.HandleFunc("PUT /api/convoys/{convoyId}/members/{memberId}/location", apiServer.HandleUpdateMemberLocation)
+	mux.HandleFunc("GET /api/convoys/{convoyId}/members/{memberId}", apiServer.HandleGetMember)
+	mux.HandleFunc("PATCH /api/convoys/{convoyId}/members/{memberId}", apiServer.HandleUpdateMember)
+	mux.HandleFunc("DELETE /api/convoys/{convoyId}/members/{memberId}", apiServer.HandleLeaveConvoy)
+	mux.HandleFunc("GET /api/convoys/{convoyId}/members/{memberId}/nearest", apiServer.HandleGetNearestMember)
+	mux.HandleFunc("GET /api/convoys/{convoyId}/members/{memberId}/address", apiServer.HandleGetMemberAddress)
+	mux.HandleFunc("POST /api/convoys/{convoyId}/members/{memberId}/alert", apiServer.HandleRaiseAttention)
+	mux.HandleFunc("POST /api/convoys/{convoyId}/destination", apiServer.HandleSetConvoyDestination)
+	mux.HandleFunc("PATCH /api/convoys/{convoyId}/destination", apiServer.HandlePatchConvoyDestination)
+	mux.HandleFunc("POST /api/convoys/{convoyId}/destination/search", apiServer.HandleSearchConvoyDestination)
+	mux.HandleFunc("GET /api/convoys/{convoyId}/members/{memberId}/track.gpx", apiServer.HandleExportMemberTrackGPX)
+	mux.HandleFunc("POST /api/convoys/{convoyId}/start", apiServer.HandleStartConvoy)
+	mux.HandleFunc("POST /api/admin/convoys/{convoyId}/announce", apiServer.HandleAdminAnnounce)
+	return apiServer, memStorage, mux
+}
+
+// doRequest serves a request with a JSON body through the mux
+func doRequest(mux *http.ServeMux, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/ierr"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// ExportConvoy is the convoy metadata section of a full export. Verification
+// tokens and the creator's contact details are left out.
+type ExportConvoy struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name,omitempty"`
+	CreatedAt  time.Time         `json:"createdAt"`
+	StartedAt  *time.Time        `json:"startedAt,omitempty"`
+	IsVerified bool              `json:"isVerified"`
+	VerifiedAt *time.Time        `json:"verifiedAt,omitempty"`
+	Paused     bool              `json:"paused"`
+	LeaderID   int64             `json:"leaderId,omitempty"`
+	LeaderName string            `json:"leaderName,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+}
+
+// ExportMemberStats summarizes a member's recorded track
+type ExportMemberStats struct {
+	TrackPoints int        `json:"trackPoints"`
+	DistanceKm  float64    `json:"distanceKm"` // lower bound: tracks only keep recent points
+	FirstPoint  *time.Time `json:"firstPoint,omitempty"`
+	LastPoint   *time.Time `json:"lastPoint,omitempty"`
+}
+
+// ExportMember is a member with its breadcrumb track and track stats
+type ExportMember struct {
+	*domain.Member
+	Track []domain.TrackPoint `json:"track"`
+	Stats ExportMemberStats   `json:"stats"`
+}
+
+// HandleExportConvoy serves a convoy's complete history as a single JSON
+// document: metadata, destination, every member with its track and stats,
+// and the event log. Members are encoded one at a time straight to the
+// response, so a large convoy is never held in memory as one document.
+func (a *API) HandleExportConvoy(w http.ResponseWriter, r *http.Request) {
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	convoy, err := a.storage.GetConvoy(r.Context(), convoyID)
+	if err != nil {
+		if errors.Is(err, ierr.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("convoy not found"))
+		} else {
+			log.Printf("ERROR: failed to get convoy %s: %v", convoyID, err)
+			writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		}
+		return
+	}
+	events, err := a.storage.GetConvoyEvents(r.Context(), convoyID, time.Time{})
+	if err != nil {
+		log.Printf("ERROR: failed to get events for convoy %s: %v", convoyID, err)
+		writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="convoy-%s.json"`, convoyID))
+	w.WriteHeader(http.StatusOK)
+
+	// Past this point the status is sent; a failure can only cut the document short
+	bw := bufio.NewWriter(w)
+	if err := a.writeConvoyExport(r.Context(), bw, a.convoyFor(r, convoy), events); err != nil {
+		log.Printf("ERROR: export of convoy %s was cut short: %v", convoyID, err)
+		return
+	}
+	if err := bw.Flush(); err != nil {
+		log.Printf("ERROR: export of convoy %s was cut short: %v", convoyID, err)
+	}
+}
+
+// writeConvoyExport writes the export document section by section
+func (a *API) writeConvoyExport(ctx context.Context, bw *bufio.Writer, convoy *domain.Convoy, events []domain.ConvoyAlert) error {
+	enc := json.NewEncoder(bw)
+	// field writes a "key": value pair; Encode's trailing newline is valid JSON whitespace
+	field := func(prefix, key string, value any) error {
+		fmt.Fprintf(bw, "%s%q:", prefix, key)
+		return enc.Encode(value)
+	}
+
+	if err := field("{", "exportedAt", time.Now().UTC()); err != nil {
+		return err
+	}
+	if err := field(",", "convoy", ExportConvoy{
+		ID:         convoy.ID,
+		Name:       convoy.Name,
+		CreatedAt:  convoy.CreatedAt,
+		StartedAt:  convoy.StartedAt,
+		IsVerified: convoy.IsVerified,
+		VerifiedAt: convoy.VerifiedAt,
+		Paused:     convoy.Paused,
+		LeaderID:   convoy.LeaderID,
+		LeaderName: convoy.LeaderName,
+		Metadata:   convoy.Metadata,
+	}); err != nil {
+		return err
+	}
+	if err := field(",", "destination", convoy.Destination); err != nil {
+		return err
+	}
+
+	bw.WriteString(`,"members":[`)
+	for i, member := range convoy.Members {
+		track, err := a.storage.GetMemberTrack(ctx, convoy.ID, member.ID)
+		if err != nil {
+			return fmt.Errorf("track of member %d: %w", member.ID, err)
+		}
+		if track == nil {
+			track = []domain.TrackPoint{}
+		}
+		if i > 0 {
+			bw.WriteByte(',')
+		}
+		if err := enc.Encode(ExportMember{Member: member, Track: track, Stats: trackStats(track)}); err != nil {
+			return err
+		}
+	}
+	bw.WriteString("]")
+
+	if events == nil {
+		events = []domain.ConvoyAlert{}
+	}
+	if err := field(",", "events", events); err != nil {
+		return err
+	}
+	_, err := bw.WriteString("}\n")
+	return err
+}
+
+// trackStats summarizes a track; an empty track has zero stats
+func trackStats(track []domain.TrackPoint) ExportMemberStats {
+	stats := ExportMemberStats{TrackPoints: len(track)}
+	if len(track) == 0 {
+		return stats
+	}
+	stats.DistanceKm, _ = trackDistanceKm(track)
+	first, last := track[0].Timestamp, track[len(track)-1].Timestamp
+	stats.FirstPoint, stats.LastPoint = &first, &last
+	return stats
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"context"
+	"convoy-app/backend/src/domain"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestHandleExportConvoy(t *testing.T) {
+	_, memStorage, mux := newTestAPI(t)
+	ctx := context.Background()
+
+	convoy, err := memStorage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	walker := &domain.Member{ID: 1, Name: "Walker", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}}
+	idle := &domain.Member{ID: 2, Name: "Idle"}
+	for _, member := range []*domain.Member{walker, idle} {
+		if err := memStorage.AddMember(ctx, convoy.ID, member); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+	}
+	for _, lat := range []float64{40.001, 40.002} {
+		if err := memStorage.UpdateMemberLocation(ctx, convoy.ID, walker.ID, domain.LatLng{Lat: lat, Lng: -74.0}); err != nil {
+			t.Fatalf("Failed to update location: %v", err)
+		}
+	}
+	if err := memStorage.SetConvoyDestination(ctx, convoy.ID, &domain.Destination{Name: "Camp", Lat: 41.0, Lng: -74.0}); err != nil {
+		t.Fatalf("Failed to set destination: %v", err)
+	}
+	if err := memStorage.AppendConvoyEvent(ctx, &domain.ConvoyAlert{EventType: domain.EventMemberLagging, ConvoyID: convoy.ID, MemberID: 2, Timestamp: time.Now()}); err != nil {
+		t.Fatalf("Failed to append event: %v", err)
+	}
+
+	rec := doRequest(mux, http.MethodGet, "/api/convoys/"+convoy.ID+"/export", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var export struct {
+		ExportedAt  time.Time           `json:"exportedAt"`
+		Convoy      ExportConvoy        `json:"convoy"`
+		Destination *domain.Destination `json:"destination"`
+		Members     []struct {
+			ID    int64               `json:"id"`
+			Name  string              `json:"name"`
+			Track []domain.TrackPoint `json:"track"`
+			Stats ExportMemberStats   `json:"stats"`
+		} `json:"members"`
+		Events []domain.ConvoyAlert `json:"events"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &export); err != nil {
+		t.Fatalf("Export is not valid JSON: %v\n%s", err, rec.Body.String())
+	}
+
+	if export.ExportedAt.IsZero() || export.Convoy.ID != convoy.ID {
+		t.Errorf("Expected convoy metadata, got %+v", export.Convoy)
+	}
+	if export.Destination == nil || export.Destination.Name != "Camp" {
+		t.Errorf("Expected the destination, got %+v", export.Destination)
+	}
+	if len(export.Members) != 2 {
+		t.Fatalf("Expected 2 members, got %d", len(export.Members))
+	}
+	if m := export.Members[0]; m.Name != "Walker" || len(m.Track) != 3 || m.Stats.TrackPoints != 3 || m.Stats.DistanceKm <= 0 || m.Stats.FirstPoint == nil {
+		t.Errorf("Expected Walker's track and stats, got %+v", m)
+	}
+	if m := export.Members[1]; m.Track == nil || len(m.Track) != 0 || m.Stats.TrackPoints != 0 {
+		t.Errorf("Expected an empty track for Idle, got %+v", m)
+	}
+	if len(export.Events) != 1 || export.Events[0].EventType != domain.EventMemberLagging {
+		t.Errorf("Expected the event log, got %+v", export.Events)
+	}
+
+	if rec := doRequest(mux, http.MethodGet, "/api/convoys/"+missingConvoyID+"/export", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown convoy, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/ierr"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HandleRaiseAttention lets a member flag the convoy, e.g. to ask for a stop.
+// It broadcasts MEMBER_ATTENTION with the optional reason to every client and
+// records it in the convoy's event log. Unlike monitoring alerts it is raised
+// by a person, so it is rate-limited per member rather than deduplicated.
+func (a *API) HandleRaiseAttention(w http.ResponseWriter, r *http.Request) {
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
+		return
+	}
+	memberID, err := strconv.ParseInt(r.PathValue("memberId"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid member ID"))
+		return
+	}
+
+	// The body is optional: a bare POST raises the signal without a reason
+	var req AttentionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	convoy, err := a.storage.GetConvoy(r.Context(), convoyID)
+	if err != nil {
+		if errors.Is(err, ierr.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("convoy not found"))
+		} else {
+			log.Printf("ERROR: failed to get convoy %s: %v", convoyID, err)
+			writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		}
+		return
+	}
+
+	var memberName string
+	for _, m := range convoy.Members {
+		if m.ID == memberID {
+			memberName = m.Name
+			break
+		}
+	}
+	if memberName == "" {
+		writeError(w, http.StatusNotFound, errors.New("member not found"))
+		return
+	}
+
+	if ok, retryAfter := a.attentionLimiter.Allow(convoyID, memberID); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		writeErrorWithCode(w, http.StatusTooManyRequests, "Attention was raised too recently", "ATTENTION_TOO_FREQUENT")
+		return
+	}
+
+	alert := &domain.ConvoyAlert{
+		EventType:  domain.EventMemberAttention,
+		ConvoyID:   convoyID,
+		MemberID:   memberID,
+		MemberName: memberName,
+		Reason:     strings.TrimSpace(req.Reason),
+		Timestamp:  time.Now(),
+	}
+	if err := a.storage.AppendConvoyEvent(r.Context(), alert); err != nil {
+		log.Printf("WARNING: failed to record attention from member %d in convoy %s: %v", memberID, convoyID, err)
+	}
+
+	log.Printf("INFO: Member %d raised attention in convoy %s: %q", memberID, convoyID, alert.Reason)
+	a.wsHub.Broadcast(convoyID, alert)
+	writeJSON(w, http.StatusOK, map[string]string{"message": "attention raised"})
+}
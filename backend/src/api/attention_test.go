@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"convoy-app/backend/src/domain"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRaiseAttentionBroadcastsAndIsRateLimited(t *testing.T) {
+	apiServer, memStorage, mux := newTestAPI(t)
+	apiServer.attentionLimiter = NewMemberRateLimiter(30 * time.Second)
+
+	convoy, err := memStorage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	for _, id := range []int64{1, 2} {
+		if err := memStorage.AddMember(context.Background(), convoy.ID, &domain.Member{ID: id, Name: "TestMember"}); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+	}
+	path := "/api/convoys/" + convoy.ID + "/members/1/alert"
+
+	rec := doRequest(mux, http.MethodPost, path, `{"reason": "I need to stop"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if stats := apiServer.wsHub.GetConvoyStats(convoy.ID); stats.Messages != 1 {
+		t.Errorf("Expected one broadcast to the convoy, got %d", stats.Messages)
+	}
+	events, err := memStorage.GetConvoyEvents(context.Background(), convoy.ID, time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to get events: %v", err)
+	}
+	if len(events) != 1 || events[0].EventType != domain.EventMemberAttention || events[0].MemberID != 1 || events[0].Reason != "I need to stop" {
+		t.Errorf("Expected a MEMBER_ATTENTION event with the reason, got %+v", events)
+	}
+
+	rec = doRequest(mux, http.MethodPost, path, "")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 for a repeated signal, got %d", rec.Code)
+	}
+	if code := errorCode(t, rec); code != "ATTENTION_TOO_FREQUENT" {
+		t.Errorf("Expected code ATTENTION_TOO_FREQUENT, got %q", code)
+	}
+	if stats := apiServer.wsHub.GetConvoyStats(convoy.ID); stats.Messages != 1 {
+		t.Errorf("Expected the rate-limited signal not to be broadcast, got %d broadcasts", stats.Messages)
+	}
+
+	// Other members have their own limit, and the reason is optional
+	if rec := doRequest(mux, http.MethodPost, "/api/convoys/"+convoy.ID+"/members/2/alert", ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected another member's signal to be accepted, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestRaiseAttentionErrors(t *testing.T) {
+	_, memStorage, mux := newTestAPI(t)
+	convoy, err := memStorage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	if err := memStorage.AddMember(context.Background(), convoy.ID, &domain.Member{ID: 1, Name: "TestMember1"}); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{"unknown convoy", "/api/convoys/" + missingConvoyID + "/members/1/alert", "", http.StatusNotFound},
+		{"unknown member", "/api/convoys/" + convoy.ID + "/members/99/alert", "", http.StatusNotFound},
+		{"invalid member ID", "/api/convoys/" + convoy.ID + "/members/abc/alert", "", http.StatusBadRequest},
+		{"reason too long", "/api/convoys/" + convoy.ID + "/members/1/alert", `{"reason": "` + strings.Repeat("x", 201) + `"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := doRequest(mux, http.MethodPost, tt.path, tt.body); rec.Code != tt.expectedStatus {
+				t.Errorf("Expected %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
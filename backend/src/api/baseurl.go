@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// forwardedBaseURL returns the origin the client used to reach us, as reported
+// by a reverse proxy in X-Forwarded-Proto and X-Forwarded-Host. It is only a
+// fallback for when APP_BASE_URL isn't set, and since these headers are
+// trivially spoofed, they are only honoured when TRUST_PROXY is set.
+// Returns "" when the configured APP_BASE_URL should be used instead.
+func (a *API) forwardedBaseURL(r *http.Request) string {
+	if !a.trustProxy || (a.emailService != nil && a.emailService.HasConfiguredBaseURL()) {
+		return ""
+	}
+
+	host := lastHeaderValue(r, "X-Forwarded-Host")
+	if host == "" {
+		return ""
+	}
+
+	proto := strings.ToLower(lastHeaderValue(r, "X-Forwarded-Proto"))
+	if proto == "" {
+		proto = "http"
+		if r.TLS != nil {
+			proto = "https"
+		}
+	}
+	if proto != "http" && proto != "https" {
+		return ""
+	}
+
+	// Reject anything that isn't a bare host[:port], e.g. "evil.com/path" or "user@host"
+	u, err := url.Parse(proto + "://" + host)
+	if err != nil || u.Host != host || u.User != nil || u.Path != "" {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// lastHeaderValue returns the last comma-separated value of a header. Proxies
+// append to whatever the client sent, so only the last value comes from the
+// trusted proxy in front of us.
+func lastHeaderValue(r *http.Request, name string) string {
+	values := r.Header.Values(name)
+	if len(values) == 0 {
+		return ""
+	}
+	last := values[len(values)-1]
+	if i := strings.LastIndex(last, ","); i >= 0 {
+		last = last[i+1:]
+	}
+	return strings.TrimSpace(last)
+}
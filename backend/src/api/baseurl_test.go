@@ -0,0 +1,68 @@
+package api
+
+import (
+	"convoy-app/backend/src/email"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestForwardedBaseURL(t *testing.T) {
+	tests := []struct {
+		name       string
+		trustProxy bool
+		proto      string
+		host       string
+		expected   string
+	}{
+		{"proxy not trusted", false, "https", "abc123.ngrok-free.dev", ""},
+		{"forwarded https", true, "https", "abc123.ngrok-free.dev", "https://abc123.ngrok-free.dev"},
+		{"forwarded with port", true, "https", "192.168.1.18:8443", "https://192.168.1.18:8443"},
+		{"proxy chain", true, "http, https", "evil.com, convoy.example.com", "https://convoy.example.com"},
+		{"missing proto", true, "", "convoy.example.com", "http://convoy.example.com"},
+		{"no forwarded host", true, "https", "", ""},
+		{"unsupported proto", true, "javascript", "convoy.example.com", ""},
+		{"host with path", true, "https", "evil.com/phish", ""},
+		{"host with credentials", true, "https", "user@evil.com", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &API{trustProxy: tt.trustProxy}
+			req := httptest.NewRequest(http.MethodPost, "/api/convoys/create-with-verification", nil)
+			if tt.proto != "" {
+				req.Header.Set("X-Forwarded-Proto", tt.proto)
+			}
+			if tt.host != "" {
+				req.Header.Set("X-Forwarded-Host", tt.host)
+			}
+
+			if got := a.forwardedBaseURL(req); got != tt.expected {
+				t.Errorf("forwardedBaseURL() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestForwardedBaseURLIsOnlyAFallback(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/convoys/create-with-verification", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Forwarded-Host", "abc123.ngrok-free.dev")
+
+	configured := &API{trustProxy: true, emailService: email.NewService(email.Config{BaseURL: "https://convoy.example.com", DryRun: true})}
+	if got := configured.forwardedBaseURL(req); got != "" {
+		t.Errorf("Expected APP_BASE_URL to win over forwarded headers, got %q", got)
+	}
+
+	unconfigured := &API{trustProxy: true, emailService: email.NewService(email.Config{DryRun: true})}
+	if got := unconfigured.forwardedBaseURL(req); got != "https://abc123.ngrok-free.dev" {
+		t.Errorf("Expected the forwarded origin without APP_BASE_URL, got %q", got)
+	}
+
+	// A client-sent header line before the proxy's own doesn't count
+	req.Header.Set("X-Forwarded-Host", "evil.com")
+	req.Header.Add("X-Forwarded-Host", "abc123.ngrok-free.dev")
+	if got := unconfigured.forwardedBaseURL(req); got != "https://abc123.ngrok-free.dev" {
+		t.Errorf("Expected the proxy-appended host, got %q", got)
+	}
+}
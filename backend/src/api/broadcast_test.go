@@ -0,0 +1,388 @@
+package api
+
+import (
+	"context"
+	"convoy-app/backend/src/config"
+	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/storage"
+	"convoy-app/backend/src/ws"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestBroadcastThrottler(t *testing.T) {
+	bt := NewBroadcastThrottler(time.Hour, domain.EventMemberDisconnected)
+
+	if !bt.ShouldBroadcast("convoy-1") {
+		t.Error("Expected the first broadcast to be allowed")
+	}
+	bt.RecordBroadcast("convoy-1")
+	if bt.ShouldBroadcast("convoy-1") {
+		t.Error("Expected a second broadcast within the interval to be throttled")
+	}
+	if !bt.ShouldBroadcast("convoy-2") {
+		t.Error("Expected other convoys to be unaffected")
+	}
+
+	if !bt.IsForced(domain.EventMemberLagging, domain.EventMemberDisconnected) {
+		t.Error("Expected MEMBER_DISCONNECTED to bypass throttling")
+	}
+	if bt.IsForced(domain.EventMemberLagging) || bt.IsForced() {
+		t.Error("Expected routine updates to be throttled")
+	}
+}
+
+func TestBroadcastConvoyUpdateThrottledVsForced(t *testing.T) {
+	memStorage := storage.NewMemoryStorage()
+	wsHub := ws.NewHub()
+	wsHub.SetConvoyProvider(memStorage)
+	cfg := config.Load()
+	cfg.BroadcastThrottleInterval = time.Hour
+	apiServer := New(memStorage, wsHub, cfg)
+
+	ctx := context.Background()
+	convoy, err := memStorage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	if err := memStorage.AddMember(ctx, convoy.ID, &domain.Member{ID: 1, Name: "TestMember1"}); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /ws/convoys/{convoyId}", wsHub.Handler)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws/convoys/"+convoy.ID, nil)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	// received reports whether a frame arrives within the wait
+	received := func(wait time.Duration) bool {
+		conn.SetReadDeadline(time.Now().Add(wait))
+		_, _, err := conn.ReadMessage()
+		return err == nil
+	}
+	if !received(2 * time.Second) {
+		t.Fatal("Expected initial snapshot")
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for wsHub.GetConnectionCount(convoy.ID) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	apiServer.BroadcastConvoyUpdate(ctx, convoy.ID, []string{domain.EventMemberLagging})
+	if !received(2 * time.Second) {
+		t.Fatal("Expected the first routine update to be sent")
+	}
+
+	apiServer.BroadcastConvoyUpdate(ctx, convoy.ID, []string{domain.EventMemberLagging})
+	if received(200 * time.Millisecond) {
+		t.Fatal("Expected a second routine update within the interval to be throttled")
+	}
+
+	// A timed-out read leaves the connection unusable, so reconnect
+	conn.Close()
+	conn, _, err = websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws/convoys/"+convoy.ID, nil)
+	if err != nil {
+		t.Fatalf("Failed to redial: %v", err)
+	}
+	if !received(2 * time.Second) {
+		t.Fatal("Expected initial snapshot after redial")
+	}
+	deadline = time.Now().Add(2 * time.Second)
+	for wsHub.GetConnectionCount(convoy.ID) != 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	apiServer.BroadcastConvoyUpdate(ctx, convoy.ID, []string{domain.EventMemberDisconnected})
+	if !received(2 * time.Second) {
+		t.Error("Expected a MEMBER_DISCONNECTED update to bypass throttling")
+	}
+}
+
+func TestThrottledUpdateIsSentAfterInterval(t *testing.T) {
+	memStorage := storage.NewMemoryStorage()
+	wsHub := ws.NewHub()
+	wsHub.SetConvoyProvider(memStorage)
+	wsHub.SetReplayRetention(0)
+	cfg := config.Load()
+	cfg.BroadcastThrottleInterval = 300 * time.Millisecond
+	apiServer := New(memStorage, wsHub, cfg)
+
+	ctx := context.Background()
+	convoy, err := memStorage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	if err := memStorage.AddMember(ctx, convoy.ID, &domain.Member{ID: 1, Name: "TestMember1"}); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /ws/convoys/{convoyId}", wsHub.Handler)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws/convoys/"+convoy.ID, nil)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+	readConvoy := func() domain.Convoy {
+		t.Helper()
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		var received domain.Convoy
+		if err := conn.ReadJSON(&received); err != nil {
+			t.Fatalf("Expected a convoy update, got %v", err)
+		}
+		return received
+	}
+	readConvoy() // the snapshot
+	deadline := time.Now().Add(2 * time.Second)
+	for wsHub.GetConnectionCount(convoy.ID) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	apiServer.BroadcastConvoyUpdate(ctx, convoy.ID, []string{domain.EventMemberLagging})
+	readConvoy()
+
+	// Two status changes inside the interval arrive together once it is over
+	start := time.Now()
+	memStorage.UpdateMemberStatus(ctx, convoy.ID, 1, domain.StatusLagging)
+	apiServer.BroadcastConvoyUpdate(ctx, convoy.ID, []string{domain.EventMemberLagging})
+	memStorage.UpdateMemberStatus(ctx, convoy.ID, 1, domain.StatusConnected)
+	apiServer.BroadcastConvoyUpdate(ctx, convoy.ID, []string{domain.EventMemberReconnected})
+
+	trailing := readConvoy()
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("Expected the trailing update to wait for the interval, got it after %v", elapsed)
+	}
+	if trailing.Members[0].Status != domain.StatusConnected {
+		t.Errorf("Expected the trailing update to carry the latest status, got %q", trailing.Members[0].Status)
+	}
+	conn.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
+	if _, _, err := conn.ReadMessage(); err == nil {
+		t.Error("Expected a single trailing update for both throttled changes")
+	}
+}
+
+func TestLocationUpdateBudgetFloodDoesNotStarveOthers(t *testing.T) {
+	budget := NewLocationUpdateBudget(5, time.Hour)
+
+	allowed := 0
+	for i := 0; i < 50; i++ {
+		if budget.Allow("convoy-1", 1) {
+			allowed++
+		}
+	}
+	if allowed != 5 {
+		t.Errorf("Expected the flooding member to get the 5-update budget, got %d", allowed)
+	}
+
+	// Other members still get their first update through
+	for memberID := int64(2); memberID <= 4; memberID++ {
+		if !budget.Allow("convoy-1", memberID) {
+			t.Errorf("Expected member %d's first update to be processed despite the flood", memberID)
+		}
+		if budget.Allow("convoy-1", memberID) {
+			t.Errorf("Expected member %d's second update to count against the spent budget", memberID)
+		}
+	}
+
+	if !budget.Allow("convoy-2", 1) {
+		t.Error("Expected other convoys to have their own budget")
+	}
+}
+
+func TestLocationUpdateBudgetResetsEachWindow(t *testing.T) {
+	budget := NewLocationUpdateBudget(1, 20*time.Millisecond)
+	if !budget.Allow("convoy-1", 1) || budget.Allow("convoy-1", 1) {
+		t.Fatal("Expected one update per window")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if !budget.Allow("convoy-1", 1) {
+		t.Error("Expected the budget to refill in the next window")
+	}
+
+	unlimited := NewLocationUpdateBudget(0, time.Hour)
+	for i := 0; i < 100; i++ {
+		if !unlimited.Allow("convoy-1", 1) {
+			t.Fatal("Expected a zero budget to allow everything")
+		}
+	}
+}
+
+func TestHandleUpdateMemberLocationStoresUpdatesOverBudget(t *testing.T) {
+	apiServer, memStorage, mux := newTestAPI(t)
+	apiServer.updateBudget = NewLocationUpdateBudget(1, time.Hour)
+	apiServer.locationLimiter = NewMemberRateLimiter(0) // back-to-back updates on purpose
+	convoy, err := memStorage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	if err := memStorage.AddMember(context.Background(), convoy.ID, &domain.Member{ID: 1, Name: "TestMember1", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}}); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+
+	for _, lat := range []string{"40.0001", "40.0002"} {
+		rec := doRequest(mux, http.MethodPut, "/api/convoys/"+convoy.ID+"/members/1/location", `{"lat":`+lat+`,"lng":-74.0}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+	if got := convoy.Members[0].Location.Lat; got != 40.0002 {
+		t.Errorf("Expected the latest location to be stored over budget, got %v", got)
+	}
+}
+
+func TestHandleUpdateMemberLocationRejectsTooFrequentUpdates(t *testing.T) {
+	apiServer, memStorage, mux := newTestAPI(t)
+	apiServer.locationLimiter = NewMemberRateLimiter(2 * time.Second)
+	convoy, err := memStorage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	for _, id := range []int64{1, 2} {
+		if err := memStorage.AddMember(context.Background(), convoy.ID, &domain.Member{ID: id, Name: "TestMember", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}}); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+	}
+	path := "/api/convoys/" + convoy.ID + "/members/1/location"
+
+	if rec := doRequest(mux, http.MethodPut, path, `{"lat":40.0001,"lng":-74.0}`); rec.Code != http.StatusOK {
+		t.Fatalf("Expected the first update to be accepted, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec := doRequest(mux, http.MethodPut, path, `{"lat":40.0002,"lng":-74.0}`)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 for a back-to-back update, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") != "2" {
+		t.Errorf("Expected Retry-After: 2, got %q", rec.Header().Get("Retry-After"))
+	}
+	if code := errorCode(t, rec); code != "LOCATION_UPDATE_TOO_FREQUENT" {
+		t.Errorf("Expected code LOCATION_UPDATE_TOO_FREQUENT, got %q", code)
+	}
+	if got := convoy.Members[0].Location.Lat; got != 40.0001 {
+		t.Errorf("Expected the throttled update not to be stored, got %v", got)
+	}
+
+	// Each member has its own interval
+	if rec := doRequest(mux, http.MethodPut, "/api/convoys/"+convoy.ID+"/members/2/location", `{"lat":40.0001,"lng":-74.0}`); rec.Code != http.StatusOK {
+		t.Errorf("Expected another member's update to be accepted, got %d", rec.Code)
+	}
+}
+
+func TestMemberRateLimiterAllowsAfterInterval(t *testing.T) {
+	limiter := NewMemberRateLimiter(20 * time.Millisecond)
+	if ok, _ := limiter.Allow("convoy-1", 1); !ok {
+		t.Fatal("Expected the first update to be allowed")
+	}
+	if ok, retryAfter := limiter.Allow("convoy-1", 1); ok || retryAfter <= 0 || retryAfter > 20*time.Millisecond {
+		t.Fatalf("Expected a rejection with a wait of up to 20ms, got %v %v", ok, retryAfter)
+	}
+	time.Sleep(25 * time.Millisecond)
+	if ok, _ := limiter.Allow("convoy-1", 1); !ok {
+		t.Error("Expected an update after the interval to be allowed")
+	}
+}
+
+func TestHandleUpdateMemberLocationAfterLeaving(t *testing.T) {
+	_, memStorage, mux := newTestAPI(t)
+	convoy, _ := memStorage.CreateConvoy(context.Background())
+	if err := memStorage.AddMember(context.Background(), convoy.ID, &domain.Member{ID: 1, Name: "TestMember1"}); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+	if rec := doRequest(mux, http.MethodDelete, "/api/convoys/"+convoy.ID+"/members/1", ""); rec.Code >= 300 {
+		t.Fatalf("Failed to leave: %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec := doRequest(mux, http.MethodPut, "/api/convoys/"+convoy.ID+"/members/1/location", `{"lat":40.0,"lng":-74.0}`)
+	if rec.Code != http.StatusGone || errorCode(t, rec) != "MEMBER_LEFT" {
+		t.Errorf("Expected 410 MEMBER_LEFT for a member who left, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = doRequest(mux, http.MethodPut, "/api/convoys/"+convoy.ID+"/members/2/location", `{"lat":40.0,"lng":-74.0}`)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a member who never joined, got %d", rec.Code)
+	}
+}
+
+func TestHandleUpdateMemberLocationBroadcastMode(t *testing.T) {
+	tests := []struct {
+		mode      string
+		wantDelta bool
+	}{
+		{config.BroadcastModeSnapshot, false},
+		{config.BroadcastModeDelta, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			apiServer, memStorage, mux := newTestAPI(t)
+			apiServer.broadcastMode = tt.mode
+			apiServer.wsHub.SetConvoyProvider(memStorage)
+			mux.HandleFunc("GET /ws/convoys/{convoyId}", apiServer.wsHub.Handler)
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			ctx := context.Background()
+			convoy, err := memStorage.CreateConvoy(ctx)
+			if err != nil {
+				t.Fatalf("Failed to create convoy: %v", err)
+			}
+			for _, member := range []*domain.Member{{ID: 1, Name: "Driver"}, {ID: 2, Name: "Passenger"}} {
+				if err := memStorage.AddMember(ctx, convoy.ID, member); err != nil {
+					t.Fatalf("Failed to add member: %v", err)
+				}
+			}
+
+			conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws/convoys/"+convoy.ID, nil)
+			if err != nil {
+				t.Fatalf("Failed to dial: %v", err)
+			}
+			defer conn.Close()
+			conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+			if _, _, err := conn.ReadMessage(); err != nil {
+				t.Fatalf("Expected initial snapshot: %v", err)
+			}
+			deadline := time.Now().Add(2 * time.Second)
+			for apiServer.wsHub.GetConnectionCount(convoy.ID) == 0 && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			rec := doRequest(mux, http.MethodPut, "/api/convoys/"+convoy.ID+"/members/2/location", `{"lat":40.0001,"lng":-74.0}`)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+			}
+
+			var frame struct {
+				EventType string           `json:"eventType"`
+				Member    *domain.Member   `json:"member"`
+				Members   []*domain.Member `json:"members"`
+			}
+			conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+			if err := conn.ReadJSON(&frame); err != nil {
+				t.Fatalf("Expected a broadcast after the location update: %v", err)
+			}
+			if !tt.wantDelta {
+				if frame.EventType != "" || len(frame.Members) != 2 {
+					t.Fatalf("Expected a full convoy snapshot, got %+v", frame)
+				}
+				return
+			}
+			if frame.EventType != domain.EventMemberMoved || frame.Members != nil {
+				t.Fatalf("Expected a %s delta instead of a snapshot, got %+v", domain.EventMemberMoved, frame)
+			}
+			if frame.Member == nil || frame.Member.ID != 2 || frame.Member.Location.Lat != 40.0001 {
+				t.Errorf("Expected the moved member's new location, got %+v", frame.Member)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"sync"
+	"time"
+)
+
+// LocationUpdateBudget caps how many location updates per convoy are
+// broadcast in each window. This is separate from the BroadcastThrottler,
+// which only spaces broadcasts out: a member flooding updates uses up the
+// budget, but every member's first update in a window is still processed so
+// one client can't starve the rest of the convoy.
+type LocationUpdateBudget struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	convoys map[string]*budgetWindow
+}
+
+// budgetWindow tracks one convoy's usage in the current window
+type budgetWindow struct {
+	start   time.Time
+	used    int
+	members map[int64]bool // members with an update processed in this window
+}
+
+// NewLocationUpdateBudget creates a budget of limit updates per window per
+// convoy. A limit of 0 or less allows everything.
+func NewLocationUpdateBudget(limit int, window time.Duration) *LocationUpdateBudget {
+	return &LocationUpdateBudget{
+		limit:   limit,
+		window:  window,
+		convoys: make(map[string]*budgetWindow),
+	}
+}
+
+// Allow reports whether a location update from the member should be
+// processed, and counts it against the convoy's budget if so.
+func (b *LocationUpdateBudget) Allow(convoyID string, memberID int64) bool {
+	if b.limit <= 0 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	current, ok := b.convoys[convoyID]
+	if !ok || now.Sub(current.start) >= b.window {
+		b.pruneExpired(now)
+		current = &budgetWindow{start: now, members: make(map[int64]bool)}
+		b.convoys[convoyID] = current
+	}
+
+	if current.used >= b.limit && current.members[memberID] {
+		return false
+	}
+	current.used++
+	current.members[memberID] = true
+	return true
+}
+
+// pruneExpired forgets convoys whose window has passed. Callers must hold b.mu.
+func (b *LocationUpdateBudget) pruneExpired(now time.Time) {
+	for convoyID, w := range b.convoys {
+		if now.Sub(w.start) >= b.window {
+			delete(b.convoys, convoyID)
+		}
+	}
+}
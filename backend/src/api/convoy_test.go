@@ -0,0 +1,367 @@
+package api
+
+import (
+	"context"
+	"convoy-app/backend/src/config"
+	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/geo"
+	"convoy-app/backend/src/storage"
+	"convoy-app/backend/src/ws"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestHandleCreateConvoyWithName(t *testing.T) {
+	apiServer, _, _ := newTestAPI(t)
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedName   string
+	}{
+		{"no body", "", http.StatusCreated, ""},
+		{"empty object", `{}`, http.StatusCreated, ""},
+		{"named", `{"name":"  Road Trip  "}`, http.StatusCreated, "Road Trip"},
+		{"name too long", `{"name":"` + strings.Repeat("n", 101) + `"}`, http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/convoys", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			apiServer.HandleCreateConvoy(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+			if rec.Code != http.StatusCreated {
+				return
+			}
+			var convoy struct {
+				Name string `json:"name"`
+			}
+			json.NewDecoder(rec.Body).Decode(&convoy)
+			if convoy.Name != tt.expectedName {
+				t.Errorf("Expected name %q, got %q", tt.expectedName, convoy.Name)
+			}
+		})
+	}
+}
+
+func TestHandleCreateConvoyAtCapacity(t *testing.T) {
+	apiServer, memStorage, _ := newTestAPI(t)
+	memStorage.SetMaxConvoys(1)
+
+	for i, expected := range []int{http.StatusCreated, http.StatusServiceUnavailable} {
+		req := httptest.NewRequest(http.MethodPost, "/api/convoys", nil)
+		rec := httptest.NewRecorder()
+		apiServer.HandleCreateConvoy(rec, req)
+
+		if rec.Code != expected {
+			t.Fatalf("Request %d: expected status %d, got %d: %s", i+1, expected, rec.Code, rec.Body.String())
+		}
+		if expected == http.StatusServiceUnavailable && !strings.Contains(rec.Body.String(), "SERVER_AT_CAPACITY") {
+			t.Errorf("Expected SERVER_AT_CAPACITY code, got %s", rec.Body.String())
+		}
+	}
+}
+
+func TestHandleCreateConvoyWhileShedding(t *testing.T) {
+	apiServer, _, _ := newTestAPI(t)
+	apiServer.wsHub.SetConnectionLimits(50, 2)
+	apiServer.wsHub.SetLoadShedding(0.5, 0.5)
+
+	wsMux := http.NewServeMux()
+	wsMux.HandleFunc("GET /ws/convoys/{convoyId}", apiServer.wsHub.Handler)
+	server := httptest.NewServer(wsMux)
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws/convoys/"+missingConvoyID, nil)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for apiServer.wsHub.GetTotalConnections() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/convoys", nil)
+	rec := httptest.NewRecorder()
+	apiServer.HandleCreateConvoy(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusServiceUnavailable, rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "SERVER_OVERLOADED") {
+		t.Errorf("Expected SERVER_OVERLOADED code, got %s", rec.Body.String())
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("Expected a Retry-After header")
+	}
+}
+
+func TestHandleSetConvoyName(t *testing.T) {
+	_, memStorage, mux := newTestAPI(t)
+
+	convoy, err := memStorage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	path := "/api/convoys/" + convoy.ID + "/name"
+
+	if rec := doRequest(mux, http.MethodPut, path, `{"name":""}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for empty name, got %d", rec.Code)
+	}
+	if rec := doRequest(mux, http.MethodPut, path, `{"name":"`+strings.Repeat("n", 101)+`"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for long name, got %d", rec.Code)
+	}
+	if rec := doRequest(mux, http.MethodPut, "/api/convoys/"+missingConvoyID+"/name", `{"name":"Trip"}`); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown convoy, got %d", rec.Code)
+	}
+
+	if rec := doRequest(mux, http.MethodPut, path, `{"name":"Weekend Trip"}`); rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if convoy.Name != "Weekend Trip" {
+		t.Errorf("Expected convoy to be renamed, got %q", convoy.Name)
+	}
+}
+
+// metadataFailingStorage is in-memory storage that can't store metadata
+type metadataFailingStorage struct {
+	*storage.MemoryStorage
+}
+
+func (metadataFailingStorage) SetConvoyMetadata(ctx context.Context, convoyID string, metadata map[string]string) error {
+	return errors.New("connection refused")
+}
+
+func TestCreateConvoyDeletedWhenSetupFails(t *testing.T) {
+	memStorage := storage.NewMemoryStorage()
+	apiServer := New(metadataFailingStorage{memStorage}, ws.NewHub(), config.Load())
+
+	rec := httptest.NewRecorder()
+	body := `{"name":"Road Trip","metadata":{"tripId":"T-1042"}}`
+	apiServer.HandleCreateConvoy(rec, httptest.NewRequest(http.MethodPost, "/api/convoys", strings.NewReader(body)))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status 500, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	convoys, err := memStorage.GetAllConvoys(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to list convoys: %v", err)
+	}
+	if len(convoys) != 0 {
+		t.Errorf("Expected the half set up convoy to be deleted, got %d convoys", len(convoys))
+	}
+}
+
+func TestHandleConvoyMetadata(t *testing.T) {
+	apiServer, _, mux := newTestAPI(t)
+
+	create := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		apiServer.HandleCreateConvoy(rec, httptest.NewRequest(http.MethodPost, "/api/convoys", strings.NewReader(body)))
+		return rec
+	}
+
+	// Set at creation...
+	rec := create(`{"metadata":{"tripId":"T-1042","org":"Acme Tours"}}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created domain.Convoy
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to decode convoy: %v", err)
+	}
+
+	// ...returned untouched by GET...
+	getMetadata := func() map[string]string {
+		t.Helper()
+		rec := doRequest(mux, http.MethodGet, "/api/convoys/"+created.ID, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var got domain.Convoy
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("Failed to decode convoy: %v", err)
+		}
+		return got.Metadata
+	}
+	if got := getMetadata(); len(got) != 2 || got["tripId"] != "T-1042" || got["org"] != "Acme Tours" {
+		t.Fatalf("Expected the metadata given at creation, got %v", got)
+	}
+
+	// ...and replaced as a whole by the dedicated endpoint
+	path := "/api/convoys/" + created.ID + "/metadata"
+	if rec := doRequest(mux, http.MethodPut, path, `{"metadata":{"tripId":" T-2000 "}}`); rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := getMetadata(); len(got) != 1 || got["tripId"] != " T-2000 " {
+		t.Fatalf("Expected the metadata replaced and kept as given, got %v", got)
+	}
+	if rec := doRequest(mux, http.MethodPut, path, `{"metadata":{}}`); rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := getMetadata(); got != nil {
+		t.Fatalf("Expected the metadata cleared, got %v", got)
+	}
+
+	tooMany := make([]string, maxMetadataKeys+1)
+	for i := range tooMany {
+		tooMany[i] = `"k` + strings.Repeat("x", i) + `":"v"`
+	}
+	tooLarge := make([]string, 10)
+	for i := range tooLarge {
+		tooLarge[i] = `"key` + strings.Repeat("x", i) + `":"` + strings.Repeat("v", maxMetadataValueLength) + `"`
+	}
+	tests := []struct {
+		name           string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{"missing metadata", path, `{}`, http.StatusBadRequest},
+		{"too many keys", path, `{"metadata":{` + strings.Join(tooMany, ",") + `}}`, http.StatusBadRequest},
+		{"blank key", path, `{"metadata":{" ":"v"}}`, http.StatusBadRequest},
+		{"key too long", path, `{"metadata":{"` + strings.Repeat("k", maxMetadataKeyLength+1) + `":"v"}}`, http.StatusBadRequest},
+		{"value too long", path, `{"metadata":{"k":"` + strings.Repeat("v", maxMetadataValueLength+1) + `"}}`, http.StatusBadRequest},
+		{"too large in total", path, `{"metadata":{` + strings.Join(tooLarge, ",") + `}}`, http.StatusBadRequest},
+		{"not strings", path, `{"metadata":{"k":1}}`, http.StatusBadRequest},
+		{"unknown convoy", "/api/convoys/" + missingConvoyID + "/metadata", `{"metadata":{"k":"v"}}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(mux, http.MethodPut, tt.path, tt.body)
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+	if got := getMetadata(); got != nil {
+		t.Errorf("Rejected updates must not change the metadata, got %v", got)
+	}
+
+	if rec := create(`{"metadata":{` + strings.Join(tooLarge, ",") + `}}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for oversized metadata at creation, got %d", rec.Code)
+	}
+}
+
+func TestHandleStartConvoy(t *testing.T) {
+	_, memStorage, mux := newTestAPI(t)
+
+	convoy, err := memStorage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	path := "/api/convoys/" + convoy.ID + "/start"
+
+	if rec := doRequest(mux, http.MethodPost, "/api/convoys/"+missingConvoyID+"/start", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown convoy, got %d", rec.Code)
+	}
+
+	before := time.Now()
+	rec := doRequest(mux, http.MethodPost, path, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if convoy.StartedAt == nil || convoy.StartedAt.Before(before) {
+		t.Fatalf("Expected the start time to be recorded, got %v", convoy.StartedAt)
+	}
+	startedAt := *convoy.StartedAt
+
+	rec = doRequest(mux, http.MethodPost, path, "")
+	if code := errorCode(t, rec); rec.Code != http.StatusConflict || code != "ALREADY_STARTED" {
+		t.Fatalf("Expected 409 ALREADY_STARTED, got %d %q", rec.Code, code)
+	}
+	if !convoy.StartedAt.Equal(startedAt) {
+		t.Error("Expected starting again to keep the original start time")
+	}
+}
+
+func TestHandleGetConvoyBounds(t *testing.T) {
+	_, memStorage, mux := newTestAPI(t)
+	ctx := context.Background()
+
+	convoy, err := memStorage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+
+	if rec := doRequest(mux, http.MethodGet, "/api/convoys/"+convoy.ID+"/bounds", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a convoy without members, got %d", rec.Code)
+	}
+
+	members := []*domain.Member{
+		{ID: 1, Name: "A", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}, Status: domain.StatusConnected},
+		{ID: 2, Name: "B", Location: domain.LatLng{Lat: 41.0, Lng: -73.0}, Status: domain.StatusConnected},
+		{ID: 3, Name: "C", Location: domain.LatLng{Lat: 10.0, Lng: 10.0}, Status: domain.StatusDisconnected}, // excluded
+		{ID: 4, Name: "D", Location: domain.LatLng{Lat: 20.0, Lng: 20.0}, Status: domain.StatusLagging},      // excluded, like the center
+	}
+	for _, member := range members {
+		if err := memStorage.AddMember(ctx, convoy.ID, member); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+	}
+
+	rec := doRequest(mux, http.MethodGet, "/api/convoys/"+convoy.ID+"/bounds", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var response ConvoyBoundsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	expected := geo.Bounds{MinLat: 40.0, MinLng: -74.0, MaxLat: 41.0, MaxLng: -73.0}
+	if response.Bounds != expected {
+		t.Errorf("Expected bounds %+v, got %+v", expected, response.Bounds)
+	}
+	if response.Center != (domain.LatLng{Lat: 40.5, Lng: -73.5}) {
+		t.Errorf("Expected center {40.5 -73.5}, got %+v", response.Center)
+	}
+	if response.MemberCount != 2 {
+		t.Errorf("Expected 2 members in bounds, got %d", response.MemberCount)
+	}
+
+	if rec := doRequest(mux, http.MethodGet, "/api/convoys/"+missingConvoyID+"/bounds", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown convoy, got %d", rec.Code)
+	}
+}
+
+func TestMalformedConvoyIDs(t *testing.T) {
+	_, _, mux := newTestAPI(t)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"too short", "/api/convoys/0123abcd", http.StatusBadRequest, "INVALID_CONVOY_ID"},
+		{"non-hex", "/api/convoys/not-a-convoy-id-at-all-zzzzzzzzzz", http.StatusBadRequest, "INVALID_CONVOY_ID"},
+		{"uppercase hex", "/api/convoys/0123456789ABCDEF0123456789ABCDEF", http.StatusBadRequest, "INVALID_CONVOY_ID"},
+		{"nested route", "/api/convoys/missing/members/1", http.StatusBadRequest, "INVALID_CONVOY_ID"},
+		{"valid but missing", "/api/convoys/" + missingConvoyID, http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(mux, http.MethodGet, tt.path, "")
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("Expected %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+			if code := errorCode(t, rec); code != tt.expectedCode {
+				t.Errorf("Expected code %q, got %q", tt.expectedCode, code)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package api
+
+import (
+	"convoy-app/backend/src/domain"
+	"net/http"
+)
+
+// convoyIDFromPath returns the convoyId path value, or writes a 400
+// INVALID_CONVOY_ID and returns false if it can't be a convoy ID. This keeps
+// typos and truncated links from looking like a convoy that has expired.
+func convoyIDFromPath(w http.ResponseWriter, r *http.Request) (string, bool) {
+	convoyID := r.PathValue("convoyId")
+	if !domain.IsValidConvoyID(convoyID) {
+		writeErrorWithCode(w, http.StatusBadRequest, "invalid convoy ID", "INVALID_CONVOY_ID")
+		return "", false
+	}
+	return convoyID, true
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"convoy-app/backend/src/geocode"
+	"convoy-app/backend/src/ierr"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// HandleSearchConvoyDestination forward-geocodes a place name and sets the
+// best match as the convoy's destination. The resolved destination is returned
+// so the client can show the user what was picked.
+func (a *API) HandleSearchConvoyDestination(w http.ResponseWriter, r *http.Request) {
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req DestinationSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	// Check the convoy first so a typo in the ID doesn't cost a provider lookup
+	if _, err := a.storage.GetConvoy(r.Context(), convoyID); err != nil {
+		if errors.Is(err, ierr.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("convoy not found"))
+		} else {
+			log.Printf("ERROR: failed to get convoy %s: %v", convoyID, err)
+			writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		}
+		return
+	}
+
+	place, err := a.geocoder.Search(r.Context(), strings.TrimSpace(req.Query))
+	switch {
+	case errors.Is(err, geocode.ErrNotConfigured):
+		writeErrorWithCode(w, http.StatusServiceUnavailable, "place search is not available", "GEOCODING_UNAVAILABLE")
+		return
+	case errors.Is(err, geocode.ErrNoResults):
+		writeErrorWithCode(w, http.StatusUnprocessableEntity, "no place matches the query", "PLACE_NOT_FOUND")
+		return
+	case err != nil:
+		log.Printf("ERROR: failed to search for destination %q in convoy %s: %v", req.Query, convoyID, err)
+		writeErrorWithCode(w, http.StatusBadGateway, "place search failed", "GEOCODE_FAILED")
+		return
+	}
+
+	// Reuse the manual destination rules so searched names are truncated alike
+	destReq := DestinationRequest{
+		Name:        place.Name,
+		Description: place.DisplayName,
+		Lat:         place.Location.Lat,
+		Lng:         place.Location.Lng,
+	}
+	if err := destReq.Validate(); err != nil {
+		log.Printf("ERROR: geocoder returned an unusable place for %q: %v", req.Query, err)
+		writeErrorWithCode(w, http.StatusBadGateway, "place search failed", "GEOCODE_FAILED")
+		return
+	}
+	destination := destReq.ToDomain(a.arrivalRadius)
+
+	if err := a.storage.SetConvoyDestination(r.Context(), convoyID, destination); err != nil {
+		if errors.Is(err, ierr.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("convoy not found"))
+		} else {
+			log.Printf("ERROR: failed to set destination for convoy %s: %v", convoyID, err)
+			writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		}
+		return
+	}
+
+	log.Printf("INFO: Destination set for convoy %s from search %q: %s at [%.6f, %.6f]",
+		convoyID, req.Query, destination.Name, destination.Lat, destination.Lng)
+
+	a.broadcastUpdateForced(r.Context(), convoyID)
+	writeJSON(w, http.StatusOK, destination)
+}
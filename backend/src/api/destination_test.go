@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"convoy-app/backend/src/config"
+	"convoy-app/backend/src/domain"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestPatchConvoyDestination(t *testing.T) {
+	_, memStorage, mux := newTestAPI(t)
+	ctx := context.Background()
+
+	convoy, err := memStorage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	member := &domain.Member{ID: 1, Name: "Driver", Location: domain.LatLng{Lat: 37.0, Lng: -122.0}}
+	if err := memStorage.AddMember(ctx, convoy.ID, member); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+	path := "/api/convoys/" + convoy.ID + "/destination"
+
+	// Nothing to patch yet
+	rec := doRequest(mux, http.MethodPatch, path, `{"description": "park behind the building"}`)
+	if code := errorCode(t, rec); rec.Code != http.StatusConflict || code != "NO_DESTINATION" {
+		t.Fatalf("Expected 409 NO_DESTINATION, got %d %q", rec.Code, code)
+	}
+
+	original := &domain.Destination{Name: "Trailhead", Description: "meet here", Lat: 37.1, Lng: -122.1}
+	if err := memStorage.SetConvoyDestination(ctx, convoy.ID, original); err != nil {
+		t.Fatalf("Failed to set destination: %v", err)
+	}
+
+	// Description only
+	rec = doRequest(mux, http.MethodPatch, path, `{"description": "  park behind the building  "}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var patched domain.Destination
+	if err := json.Unmarshal(rec.Body.Bytes(), &patched); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	want := domain.Destination{Name: "Trailhead", Description: "park behind the building", Lat: 37.1, Lng: -122.1}
+	if patched != want {
+		t.Errorf("Expected %+v, got %+v", want, patched)
+	}
+	if original.Description != "meet here" {
+		t.Error("Expected the previous destination not to be modified in place")
+	}
+
+	// Coordinates move the destination and member distances with it
+	distanceBefore := *member.DistanceToDestination
+	rec = doRequest(mux, http.MethodPatch, path, `{"lat": 37.5, "lng": -122.5}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	stored, _ := memStorage.GetConvoy(ctx, convoy.ID)
+	if stored.Destination.Lat != 37.5 || stored.Destination.Description != "park behind the building" {
+		t.Errorf("Expected only the coordinates to change, got %+v", stored.Destination)
+	}
+	if *member.DistanceToDestination == distanceBefore {
+		t.Error("Expected the member's distance to the destination to be recomputed")
+	}
+
+	tests := []struct {
+		name string
+		path string
+		body string
+		code int
+	}{
+		{"empty patch", path, `{}`, http.StatusBadRequest},
+		{"empty name", path, `{"name": "  "}`, http.StatusBadRequest},
+		{"description too long", path, `{"description": "` + strings.Repeat("d", 501) + `"}`, http.StatusBadRequest},
+		{"lat without lng", path, `{"lat": 37.5}`, http.StatusBadRequest},
+		{"lat out of range", path, `{"lat": 91, "lng": 0}`, http.StatusBadRequest},
+		{"unknown field", path, `{"radius": 5}`, http.StatusBadRequest},
+		{"unknown convoy", "/api/convoys/" + missingConvoyID + "/destination", `{"name": "Lodge"}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := doRequest(mux, http.MethodPatch, tt.path, tt.body); rec.Code != tt.code {
+				t.Errorf("Expected %d, got %d: %s", tt.code, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestSetConvoyDestinationArrivalRadius(t *testing.T) {
+	_, memStorage, mux := newTestAPI(t)
+	ctx := context.Background()
+
+	convoy, err := memStorage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	path := "/api/convoys/" + convoy.ID + "/destination"
+
+	for _, body := range []string{
+		`{"name": "Stadium", "lat": 37.1, "lng": -122.1, "arrivalRadiusMeters": 0}`,
+		`{"name": "Stadium", "lat": 37.1, "lng": -122.1, "arrivalRadiusMeters": -50}`,
+		`{"name": "Stadium", "lat": 37.1, "lng": -122.1, "arrivalRadiusMeters": 5001}`,
+	} {
+		if rec := doRequest(mux, http.MethodPost, path, body); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", body, rec.Code)
+		}
+	}
+
+	// Without a radius the server default applies
+	rec := doRequest(mux, http.MethodPost, path, `{"name": "Parking lot", "lat": 37.1, "lng": -122.1}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if radius := convoy.Destination.ArrivalRadiusMeters; radius != config.DefaultArrivalRadiusMeters {
+		t.Errorf("Expected the default radius %.0f, got %.0f", config.DefaultArrivalRadiusMeters, radius)
+	}
+
+	rec = doRequest(mux, http.MethodPost, path, `{"name": "Stadium", "lat": 37.1, "lng": -122.1, "arrivalRadiusMeters": 800}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// The radius is part of the convoy JSON
+	rec = doRequest(mux, http.MethodGet, "/api/convoys/"+convoy.ID, "")
+	var got struct {
+		Destination struct {
+			ArrivalRadiusMeters float64 `json:"arrivalRadiusMeters"`
+		} `json:"destination"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to decode convoy: %v", err)
+	}
+	if got.Destination.ArrivalRadiusMeters != 800 {
+		t.Errorf("Expected arrivalRadiusMeters 800 in the convoy JSON, got %v", got.Destination.ArrivalRadiusMeters)
+	}
+}
@@ -2,14 +2,16 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 )
 
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Code    string `json:"code,omitempty"`
-	Details string `json:"details,omitempty"`
+	Error   string       `json:"error"`
+	Code    string       `json:"code,omitempty"`
+	Details string       `json:"details,omitempty"`
+	Fields  []FieldError `json:"fields,omitempty"`
 }
 
 func writeErrorWithCode(w http.ResponseWriter, statusCode int, message, code string) {
@@ -26,6 +28,21 @@ func writeErrorWithCode(w http.ResponseWriter, statusCode int, message, code str
 	}
 }
 
+// writeValidationError writes a 400 with code VALIDATION_ERROR. Field-level
+// problems from ValidationErrors are listed under "fields".
 func writeValidationError(w http.ResponseWriter, err error) {
-	writeErrorWithCode(w, http.StatusBadRequest, err.Error(), "VALIDATION_ERROR")
+	response := ErrorResponse{
+		Error: err.Error(),
+		Code:  "VALIDATION_ERROR",
+	}
+	var fieldErrs ValidationErrors
+	if errors.As(err, &fieldErrs) {
+		response.Fields = fieldErrs
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("ERROR: Failed to encode error response: %v", err)
+	}
 }
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"convoy-app/backend/src/ierr"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+)
+
+// HandleGetConvoyEvents returns the alerts the monitor has sent for a convoy,
+// oldest first, so a client that joined late can catch up. The optional
+// since query parameter (RFC 3339) limits the result to later events.
+func (a *API) HandleGetConvoyEvents(w http.ResponseWriter, r *http.Request) {
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	var since time.Time
+	if sinceStr := r.URL.Query().Get("since"); sinceStr != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, sinceStr)
+		if err != nil {
+			writeValidationError(w, ValidationErrors{{Field: "since", Message: "since must be an RFC 3339 timestamp"}})
+			return
+		}
+		since = parsed
+	}
+
+	events, err := a.storage.GetConvoyEvents(r.Context(), convoyID, since)
+	if err != nil {
+		if errors.Is(err, ierr.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("convoy not found"))
+		} else {
+			log.Printf("ERROR: failed to get events for convoy %s: %v", convoyID, err)
+			writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, events)
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"convoy-app/backend/src/domain"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestHandleGetConvoyEventsSince(t *testing.T) {
+	_, memStorage, mux := newTestAPI(t)
+	ctx := context.Background()
+
+	convoy, err := memStorage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+
+	start := time.Now().Add(-time.Hour)
+	for i, eventType := range []string{domain.EventMemberLagging, domain.EventConvoyScattered, domain.EventConvoyRegrouped} {
+		alert := &domain.ConvoyAlert{EventType: eventType, ConvoyID: convoy.ID, Timestamp: start.Add(time.Duration(i) * time.Minute)}
+		if err := memStorage.AppendConvoyEvent(ctx, alert); err != nil {
+			t.Fatalf("Failed to append event: %v", err)
+		}
+	}
+
+	get := func(query string) []domain.ConvoyAlert {
+		t.Helper()
+		rec := doRequest(mux, http.MethodGet, "/api/convoys/"+convoy.ID+"/events"+query, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for %q, got %d: %s", query, rec.Code, rec.Body.String())
+		}
+		var events []domain.ConvoyAlert
+		if err := json.NewDecoder(rec.Body).Decode(&events); err != nil {
+			t.Fatalf("Failed to decode events: %v", err)
+		}
+		return events
+	}
+
+	if events := get(""); len(events) != 3 || events[0].EventType != domain.EventMemberLagging {
+		t.Errorf("Expected all 3 events oldest first, got %+v", events)
+	}
+
+	// Only events strictly after since are returned
+	since := url.QueryEscape(start.Add(time.Minute).Format(time.RFC3339Nano))
+	events := get("?since=" + since)
+	if len(events) != 1 || events[0].EventType != domain.EventConvoyRegrouped {
+		t.Errorf("Expected only %s after since, got %+v", domain.EventConvoyRegrouped, events)
+	}
+
+	if events := get("?since=" + url.QueryEscape(time.Now().Format(time.RFC3339))); events == nil || len(events) != 0 {
+		t.Errorf("Expected an empty list for a future since, got %+v", events)
+	}
+
+	if rec := doRequest(mux, http.MethodGet, "/api/convoys/"+convoy.ID+"/events?since=yesterday", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid since, got %d", rec.Code)
+	}
+	if rec := doRequest(mux, http.MethodGet, "/api/convoys/"+missingConvoyID+"/events", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown convoy, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/ierr"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// acceptsCSV reports whether the client asked for CSV with a non-zero quality
+// in its Accept header. JSON stays the default for */* and missing headers.
+func acceptsCSV(r *http.Request) bool {
+	return headerAccepts(r.Header.Get("Accept"), "text/csv")
+}
+
+// csvCell neutralises text that spreadsheets would evaluate as a formula by
+// prefixing it with a single quote
+func csvCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// writeConvoyCSV writes one row per member
+func writeConvoyCSV(w http.ResponseWriter, convoy *domain.Convoy) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "name", "status", "lat", "lng", "lastUpdate"})
+	for _, member := range convoy.Members {
+		cw.Write([]string{
+			strconv.FormatInt(member.ID, 10),
+			csvCell(member.Name),
+			csvCell(member.Status),
+			strconv.FormatFloat(member.Location.Lat, 'f', -1, 64),
+			strconv.FormatFloat(member.Location.Lng, 'f', -1, 64),
+			member.LastUpdate.UTC().Format(time.RFC3339),
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		log.Printf("ERROR: could not write CSV for convoy %s: %v", convoy.ID, err)
+	}
+}
+
+// gpxDocument is a minimal GPX 1.1 document holding a single track
+type gpxDocument struct {
+	XMLName  xml.Name    `xml:"gpx"`
+	Xmlns    string      `xml:"xmlns,attr"`
+	Version  string      `xml:"version,attr"`
+	Creator  string      `xml:"creator,attr"`
+	Metadata gpxMetadata `xml:"metadata"`
+	Track    gpxTrack    `xml:"trk"`
+}
+
+type gpxMetadata struct {
+	Name string `xml:"name"`
+	Time string `xml:"time"`
+}
+
+type gpxTrack struct {
+	Name    string     `xml:"name"`
+	Segment gpxSegment `xml:"trkseg"`
+}
+
+type gpxSegment struct {
+	Points []gpxPoint `xml:"trkpt"`
+}
+
+type gpxPoint struct {
+	Lat  float64 `xml:"lat,attr"`
+	Lon  float64 `xml:"lon,attr"`
+	Time string  `xml:"time"`
+}
+
+// newGPXDocument converts a recorded track to GPX; an empty track yields an empty segment
+func newGPXDocument(name string, track []domain.TrackPoint) gpxDocument {
+	points := make([]gpxPoint, 0, len(track))
+	for _, p := range track {
+		points = append(points, gpxPoint{
+			Lat:  p.Lat,
+			Lon:  p.Lng,
+			Time: p.Timestamp.UTC().Format(time.RFC3339),
+		})
+	}
+
+	return gpxDocument{
+		Xmlns:    "http://www.topografix.com/GPX/1/1",
+		Version:  "1.1",
+		Creator:  "Convoy App",
+		Metadata: gpxMetadata{Name: name, Time: time.Now().UTC().Format(time.RFC3339)},
+		Track:    gpxTrack{Name: name, Segment: gpxSegment{Points: points}},
+	}
+}
+
+// HandleExportMemberTrackGPX serves a member's breadcrumb history as a GPX 1.1 download.
+func (a *API) HandleExportMemberTrackGPX(w http.ResponseWriter, r *http.Request) {
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
+		return
+	}
+	memberID, err := strconv.ParseInt(r.PathValue("memberId"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid member ID"))
+		return
+	}
+
+	track, err := a.storage.GetMemberTrack(r.Context(), convoyID, memberID)
+	if err != nil {
+		if errors.Is(err, ierr.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("convoy or member not found"))
+		} else {
+			log.Printf("ERROR: failed to get track for member %d in convoy %s: %v", memberID, convoyID, err)
+			writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		}
+		return
+	}
+
+	doc := newGPXDocument(fmt.Sprintf("Convoy %s - member %d", convoyID, memberID), track)
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		log.Printf("ERROR: failed to encode GPX for member %d in convoy %s: %v", memberID, convoyID, err)
+		writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/gpx+xml")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="convoy-%s-member-%d.gpx"`, convoyID, memberID))
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(xml.Header))
+	w.Write(data)
+}
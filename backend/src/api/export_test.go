@@ -0,0 +1,152 @@
+package api
+
+import (
+	"context"
+	"convoy-app/backend/src/domain"
+	"encoding/csv"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandleExportMemberTrackGPX(t *testing.T) {
+	_, memStorage, mux := newTestAPI(t)
+	ctx := context.Background()
+
+	convoy, err := memStorage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	walker := &domain.Member{ID: 1, Name: "Walker", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}}
+	idle := &domain.Member{ID: 2, Name: "Idle"}
+	for _, member := range []*domain.Member{walker, idle} {
+		if err := memStorage.AddMember(ctx, convoy.ID, member); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+	}
+	for _, lat := range []float64{40.001, 40.002} {
+		if err := memStorage.UpdateMemberLocation(ctx, convoy.ID, walker.ID, domain.LatLng{Lat: lat, Lng: -74.0}); err != nil {
+			t.Fatalf("Failed to update location: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name           string
+		memberID       string
+		expectedPoints int
+	}{
+		{"recorded track", "1", 3},
+		{"empty track", "2", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(mux, http.MethodGet, "/api/convoys/"+convoy.ID+"/members/"+tt.memberID+"/track.gpx", "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/gpx+xml" {
+				t.Errorf("Expected GPX content type, got %q", ct)
+			}
+			if cd := rec.Header().Get("Content-Disposition"); !strings.Contains(cd, ".gpx") {
+				t.Errorf("Expected download filename, got %q", cd)
+			}
+
+			var doc struct {
+				XMLName xml.Name `xml:"gpx"`
+				Version string   `xml:"version,attr"`
+				Points  []struct {
+					Lat  float64 `xml:"lat,attr"`
+					Lon  float64 `xml:"lon,attr"`
+					Time string  `xml:"time"`
+				} `xml:"trk>trkseg>trkpt"`
+			}
+			if err := xml.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+				t.Fatalf("Expected well-formed GPX, got %v", err)
+			}
+			if doc.Version != "1.1" {
+				t.Errorf("Expected GPX version 1.1, got %q", doc.Version)
+			}
+			if len(doc.Points) != tt.expectedPoints {
+				t.Fatalf("Expected %d track points, got %d", tt.expectedPoints, len(doc.Points))
+			}
+			for _, p := range doc.Points {
+				if p.Time == "" || p.Lon != -74.0 {
+					t.Errorf("Expected timestamped point on lon -74, got %+v", p)
+				}
+			}
+		})
+	}
+
+	if rec := doRequest(mux, http.MethodGet, "/api/convoys/"+convoy.ID+"/members/999/track.gpx", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown member, got %d", rec.Code)
+	}
+}
+
+func TestHandleGetConvoyCSV(t *testing.T) {
+	_, memStorage, mux := newTestAPI(t)
+	ctx := context.Background()
+
+	convoy, err := memStorage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	for _, member := range []*domain.Member{
+		{ID: 1, Name: "Alice", Location: domain.LatLng{Lat: 40.5, Lng: -74.25}},
+		{ID: 2, Name: "Bob, Jr.", Location: domain.LatLng{Lat: 41, Lng: -73}},
+		{ID: 3, Name: "=HYPERLINK(\"http://evil.example\")", Location: domain.LatLng{Lat: 42, Lng: -72}},
+	} {
+		if err := memStorage.AddMember(ctx, convoy.ID, member); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/convoys/"+convoy.ID, nil)
+	req.Header.Set("Accept", "text/csv")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("Expected CSV content type, got %q", ct)
+	}
+
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("Expected header and 3 rows, got %d rows", len(rows))
+	}
+	if got := strings.Join(rows[0], ","); got != "id,name,status,lat,lng,lastUpdate" {
+		t.Errorf("Unexpected header row %q", got)
+	}
+	if rows[1][0] != "1" || rows[1][1] != "Alice" || rows[1][2] != domain.StatusConnected || rows[1][3] != "40.5" || rows[1][4] != "-74.25" {
+		t.Errorf("Unexpected first row %v", rows[1])
+	}
+	if rows[2][1] != "Bob, Jr." {
+		t.Errorf("Expected quoted name to round-trip, got %q", rows[2][1])
+	}
+	if rows[3][1] != `'=HYPERLINK("http://evil.example")` {
+		t.Errorf("Expected a formula-like name to be prefixed with a quote, got %q", rows[3][1])
+	}
+
+	// A zero quality refuses CSV
+	req = httptest.NewRequest(http.MethodGet, "/api/convoys/"+convoy.ID, nil)
+	req.Header.Set("Accept", "text/csv;q=0, application/json")
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected JSON when CSV has q=0, got %q", ct)
+	}
+
+	// JSON remains the default
+	rec = doRequest(mux, http.MethodGet, "/api/convoys/"+convoy.ID, "")
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected JSON by default, got %q", ct)
+	}
+}
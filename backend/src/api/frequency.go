@@ -0,0 +1,68 @@
+package api
+
+import (
+	"sync"
+	"time"
+)
+
+// memberKey identifies a member across convoys
+type memberKey struct {
+	convoyID string
+	memberID int64
+}
+
+// MemberRateLimiter enforces a minimum interval between accepted requests
+// from each member, such as location updates. Unlike the GPS outlier filter,
+// which drops implausible points, this rejects requests that simply arrive
+// too often.
+type MemberRateLimiter struct {
+	mu           sync.Mutex
+	minInterval  time.Duration
+	lastAccepted map[memberKey]time.Time
+	lastPrune    time.Time
+}
+
+// NewMemberRateLimiter creates a limiter allowing one request per member
+// every minInterval. A minInterval of 0 or less allows everything.
+func NewMemberRateLimiter(minInterval time.Duration) *MemberRateLimiter {
+	return &MemberRateLimiter{
+		minInterval:  minInterval,
+		lastAccepted: make(map[memberKey]time.Time),
+	}
+}
+
+// Allow reports whether a request from the member may be accepted now and
+// records it if so. Otherwise it returns how long the member must wait.
+func (l *MemberRateLimiter) Allow(convoyID string, memberID int64) (bool, time.Duration) {
+	if l.minInterval <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastPrune) >= time.Minute {
+		l.pruneExpired(now)
+		l.lastPrune = now
+	}
+
+	key := memberKey{convoyID: convoyID, memberID: memberID}
+	if last, ok := l.lastAccepted[key]; ok {
+		if elapsed := now.Sub(last); elapsed < l.minInterval {
+			return false, l.minInterval - elapsed
+		}
+	}
+	l.lastAccepted[key] = now
+	return true, 0
+}
+
+// pruneExpired forgets members whose last request no longer limits them.
+// Callers must hold l.mu.
+func (l *MemberRateLimiter) pruneExpired(now time.Time) {
+	for key, last := range l.lastAccepted {
+		if now.Sub(last) >= l.minInterval {
+			delete(l.lastAccepted, key)
+		}
+	}
+}
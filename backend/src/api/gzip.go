@@ -0,0 +1,185 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DefaultGzipThreshold is the smallest JSON body worth compressing; gzip's
+// framing overhead outweighs the savings below this
+const DefaultGzipThreshold = 1024
+
+// GzipMiddleware compresses JSON responses of at least threshold bytes for
+// clients that send Accept-Encoding: gzip. Other content types, such as event
+// streams and GPX downloads, and WebSocket upgrades are passed through untouched.
+func GzipMiddleware(enabled bool, threshold int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !enabled {
+			return next
+		}
+		if threshold <= 0 {
+			threshold = DefaultGzipThreshold
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isWebSocketUpgrade(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Accept-Encoding")
+			if !acceptsGzip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			gw := &gzipResponseWriter{ResponseWriter: w, threshold: threshold, status: http.StatusOK}
+			defer gw.close()
+			next.ServeHTTP(gw, r)
+		})
+	}
+}
+
+// acceptsGzip reports whether the request lists gzip with a non-zero quality
+func acceptsGzip(r *http.Request) bool {
+	return headerAccepts(r.Header.Get("Accept-Encoding"), "gzip")
+}
+
+// headerAccepts reports whether a comma-separated Accept-style header lists
+// value with a non-zero quality. Values are compared case-insensitively.
+func headerAccepts(header, value string) bool {
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.EqualFold(strings.TrimSpace(name), value) {
+			return nonZeroQuality(params)
+		}
+	}
+	return false
+}
+
+// nonZeroQuality reports whether parameters such as "charset=utf-8; q=0.5"
+// leave a value acceptable. A missing or malformed q counts as 1.
+func nonZeroQuality(params string) bool {
+	for _, param := range strings.Split(params, ";") {
+		key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if strings.EqualFold(strings.TrimSpace(key), "q") {
+			q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			return err != nil || q > 0
+		}
+	}
+	return true
+}
+
+// gzipResponseWriter buffers a JSON body until it reaches the threshold, then
+// switches to gzip. Bodies that end below the threshold are sent as is.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	threshold int
+
+	status        int
+	statusSet     bool
+	headerWritten bool // the status line has been passed on
+	passthrough   bool // not compressing; writes go straight through
+	buf           bytes.Buffer
+	gz            *gzip.Writer
+}
+
+// WriteHeader records the status; it is sent once compression is decided
+func (g *gzipResponseWriter) WriteHeader(status int) {
+	if g.statusSet {
+		return
+	}
+	g.statusSet = true
+	g.status = status
+}
+
+func (g *gzipResponseWriter) Write(p []byte) (int, error) {
+	if g.gz != nil {
+		return g.gz.Write(p)
+	}
+	if g.passthrough {
+		return g.ResponseWriter.Write(p)
+	}
+	if g.buf.Len() == 0 && !isJSON(g.Header().Get("Content-Type")) {
+		g.startPlain()
+		return g.ResponseWriter.Write(p)
+	}
+
+	g.buf.Write(p)
+	if g.buf.Len() >= g.threshold {
+		if err := g.startGzip(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// startPlain sends the headers and any buffered body uncompressed
+func (g *gzipResponseWriter) startPlain() error {
+	g.passthrough = true
+	g.writeHeader()
+	if g.buf.Len() == 0 {
+		return nil
+	}
+	_, err := g.ResponseWriter.Write(g.buf.Bytes())
+	g.buf.Reset()
+	return err
+}
+
+// startGzip sends compressed headers and the buffered body through gzip
+func (g *gzipResponseWriter) startGzip() error {
+	header := g.Header()
+	header.Set("Content-Encoding", "gzip")
+	header.Del("Content-Length")
+	g.writeHeader()
+
+	g.gz = gzip.NewWriter(g.ResponseWriter)
+	_, err := g.gz.Write(g.buf.Bytes())
+	g.buf.Reset()
+	return err
+}
+
+func (g *gzipResponseWriter) writeHeader() {
+	if !g.headerWritten {
+		g.headerWritten = true
+		g.ResponseWriter.WriteHeader(g.status)
+	}
+}
+
+// Flush sends everything written so far, so streaming handlers keep working
+func (g *gzipResponseWriter) Flush() {
+	if g.gz != nil {
+		g.gz.Flush()
+	} else if !g.passthrough {
+		g.startPlain()
+	}
+	if flusher, ok := g.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to
+// lift the write deadline of an event stream
+func (g *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return g.ResponseWriter
+}
+
+// close finishes the response once the handler returns
+func (g *gzipResponseWriter) close() {
+	if g.gz != nil {
+		g.gz.Close()
+		return
+	}
+	if !g.passthrough {
+		g.startPlain()
+	}
+}
+
+// isJSON reports whether a Content-Type header names JSON
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "application/json"
+}
@@ -1,11 +1,17 @@
 package api
 
 import (
+    "context"
+    "convoy-app/backend/src/storage"
     "encoding/json"
+    "log"
     "net/http"
     "time"
 )
 
+// storagePingTimeout bounds the storage check done by /readyz
+const storagePingTimeout = 2 * time.Second
+
 type HealthResponse struct {
     Status              string    `json:"status"`
     Timestamp          time.Time `json:"timestamp"`
@@ -13,6 +19,13 @@ type HealthResponse struct {
     ActiveConvoys       int      `json:"active_convoys"`
 }
 
+// ReadinessResponse reports the result of each readiness check
+type ReadinessResponse struct {
+    Status    string            `json:"status"`
+    Timestamp time.Time         `json:"timestamp"`
+    Checks    map[string]string `json:"checks"`
+}
+
 func (a *API) HandleHealth(w http.ResponseWriter, r *http.Request) {
     totalConnections := a.wsHub.GetTotalConnections()
     activeConvoys := a.wsHub.GetActiveConvoyCount()
@@ -27,4 +40,55 @@ func (a *API) HandleHealth(w http.ResponseWriter, r *http.Request) {
     w.Header().Set("Content-Type", "application/json")
     w.WriteHeader(http.StatusOK)
     json.NewEncoder(w).Encode(response)
-}
\ No newline at end of file
+}
+
+// HandleLivez reports that the process is up and serving requests
+func (a *API) HandleLivez(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(map[string]string{"status": "alive"})
+}
+
+// HandleReadyz reports whether the service can take traffic: the monitor must
+// be running and, for backends with an external store, the store reachable.
+// Returns 503 when any check fails.
+func (a *API) HandleReadyz(w http.ResponseWriter, r *http.Request) {
+    checks := make(map[string]string)
+    ready := true
+
+    if a.monitor.IsRunning() {
+        checks["monitor"] = "ok"
+    } else {
+        checks["monitor"] = "not running"
+        ready = false
+    }
+
+    if pinger, ok := a.storage.(storage.Pinger); ok {
+        ctx, cancel := context.WithTimeout(r.Context(), storagePingTimeout)
+        defer cancel()
+        if err := pinger.Ping(ctx); err != nil {
+            log.Printf("WARNING: Readiness storage ping failed: %v", err)
+            checks["storage"] = "unreachable"
+            ready = false
+        } else {
+            checks["storage"] = "ok"
+        }
+    } else {
+        checks["storage"] = "ok"
+    }
+
+    response := ReadinessResponse{
+        Status:    "ready",
+        Timestamp: time.Now(),
+        Checks:    checks,
+    }
+    status := http.StatusOK
+    if !ready {
+        response.Status = "not_ready"
+        status = http.StatusServiceUnavailable
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"convoy-app/backend/src/config"
+	"convoy-app/backend/src/storage"
+	"convoy-app/backend/src/ws"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// unreachableStorage is in-memory storage whose external store never answers
+type unreachableStorage struct {
+	*storage.MemoryStorage
+}
+
+func (unreachableStorage) Ping(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+func serveReadyz(apiServer *API) (*httptest.ResponseRecorder, ReadinessResponse) {
+	rec := httptest.NewRecorder()
+	apiServer.HandleReadyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var response ReadinessResponse
+	json.NewDecoder(rec.Body).Decode(&response)
+	return rec, response
+}
+
+func TestLivezAlwaysOK(t *testing.T) {
+	apiServer, _, _ := newTestAPI(t)
+
+	rec := httptest.NewRecorder()
+	apiServer.HandleLivez(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 while the monitor is stopped, got %d", rec.Code)
+	}
+}
+
+func TestReadyzNotReadyUntilMonitorRuns(t *testing.T) {
+	apiServer, _, _ := newTestAPI(t)
+
+	rec, response := serveReadyz(apiServer)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected 503 before monitoring starts, got %d", rec.Code)
+	}
+	if response.Status != "not_ready" || response.Checks["monitor"] != "not running" {
+		t.Errorf("Expected monitor check to fail, got %+v", response)
+	}
+
+	apiServer.StartMonitoring()
+	t.Cleanup(apiServer.StopMonitoring)
+
+	rec, response = serveReadyz(apiServer)
+	if rec.Code != http.StatusOK || response.Status != "ready" {
+		t.Errorf("Expected ready once monitoring runs, got %d %+v", rec.Code, response)
+	}
+}
+
+func TestReadyzNotReadyWhenStorageUnreachable(t *testing.T) {
+	apiServer := New(unreachableStorage{storage.NewMemoryStorage()}, ws.NewHub(), config.Load())
+	apiServer.StartMonitoring()
+	t.Cleanup(apiServer.StopMonitoring)
+
+	rec, response := serveReadyz(apiServer)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected 503 when storage is unreachable, got %d", rec.Code)
+	}
+	if response.Checks["storage"] != "unreachable" || response.Checks["monitor"] != "ok" {
+		t.Errorf("Expected only the storage check to fail, got %+v", response.Checks)
+	}
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/ierr"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	// IdempotencyKeyTTL is how long a retried create request returns the original convoy
+	IdempotencyKeyTTL = 10 * time.Minute
+
+	maxIdempotencyKeyLength = 255
+)
+
+// idempotencyKey returns the request's Idempotency-Key header scoped to the
+// endpoint and the client, or "" if the client didn't send one
+func idempotencyKey(r *http.Request, scope string) (string, error) {
+	key := r.Header.Get("Idempotency-Key")
+	if key == "" {
+		return "", nil
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		return "", errors.New("Idempotency-Key header too long")
+	}
+	return scope + ":" + getClientIP(r) + ":" + key, nil
+}
+
+// requestFingerprint hashes a decoded request body so a retry can be told
+// apart from a different request reusing the same key
+func requestFingerprint(req interface{}) string {
+	body, _ := json.Marshal(req)
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// reserveIdempotentConvoy claims key for this request before anything is
+// created, so concurrent retries can't both create a convoy. It returns the
+// convoy to replay, if any, and false once it has written an error response.
+func (a *API) reserveIdempotentConvoy(w http.ResponseWriter, r *http.Request, key, fingerprint string) (*domain.Convoy, bool) {
+	if key == "" {
+		return nil, true
+	}
+	convoy, err := a.storage.ReserveIdempotencyKey(r.Context(), key, fingerprint, IdempotencyKeyTTL)
+	switch {
+	case errors.Is(err, ierr.ErrIdempotencyMismatch):
+		writeErrorWithCode(w, http.StatusUnprocessableEntity,
+			"Idempotency-Key was already used with a different request body", "IDEMPOTENCY_KEY_MISMATCH")
+		return nil, false
+	case errors.Is(err, ierr.ErrInProgress):
+		writeErrorWithCode(w, http.StatusConflict,
+			"A request with this Idempotency-Key is still being processed", "IDEMPOTENCY_KEY_IN_USE")
+		return nil, false
+	case err != nil:
+		log.Printf("ERROR: failed to reserve idempotency key: %v", err)
+		writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		return nil, false
+	}
+	if convoy != nil {
+		log.Printf("INFO: Replaying convoy %s for repeated Idempotency-Key", convoy.ID)
+	}
+	return convoy, true
+}
+
+// rememberIdempotentConvoy records the convoy created for key, if any
+func (a *API) rememberIdempotentConvoy(ctx context.Context, key, convoyID string) {
+	if key == "" {
+		return
+	}
+	if err := a.storage.SaveIdempotencyKey(ctx, key, convoyID, IdempotencyKeyTTL); err != nil {
+		log.Printf("ERROR: failed to save idempotency key for convoy %s: %v", convoyID, err)
+	}
+}
+
+// releaseIdempotentConvoy frees key if the request reserved it but never
+// created a convoy, so the client can retry. It is a no-op after
+// rememberIdempotentConvoy, which makes it safe to defer.
+func (a *API) releaseIdempotentConvoy(ctx context.Context, key string) {
+	if key == "" {
+		return
+	}
+	if err := a.storage.ReleaseIdempotencyKey(ctx, key); err != nil {
+		log.Printf("ERROR: failed to release idempotency key: %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// createConvoy posts to a create handler and returns the response status and convoy ID
+func createConvoy(t *testing.T, handler http.HandlerFunc, body, key string) (int, string) {
+	t.Helper()
+	return createConvoyFrom(t, handler, body, key, "192.0.2.1:1234")
+}
+
+// createConvoyFrom is createConvoy for a request from remoteAddr
+func createConvoyFrom(t *testing.T, handler http.HandlerFunc, body, key, remoteAddr string) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/convoys", strings.NewReader(body))
+	req.RemoteAddr = remoteAddr
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	var response struct {
+		ID       string `json:"id"`
+		ConvoyID string `json:"convoyId"`
+	}
+	json.NewDecoder(rec.Body).Decode(&response)
+	if response.ConvoyID != "" {
+		return rec.Code, response.ConvoyID
+	}
+	return rec.Code, response.ID
+}
+
+func TestHandleCreateConvoyIdempotencyKey(t *testing.T) {
+	apiServer, _, _ := newTestAPI(t)
+
+	status, first := createConvoy(t, apiServer.HandleCreateConvoy, "", "retry-1")
+	if status != http.StatusCreated || first == "" {
+		t.Fatalf("Expected convoy to be created, got status %d", status)
+	}
+
+	_, second := createConvoy(t, apiServer.HandleCreateConvoy, "", "retry-1")
+	if second != first {
+		t.Errorf("Expected repeated key to return convoy %s, got %s", first, second)
+	}
+
+	_, other := createConvoy(t, apiServer.HandleCreateConvoy, "", "retry-2")
+	if other == first {
+		t.Errorf("Expected a different key to create a new convoy")
+	}
+
+	_, unkeyed := createConvoy(t, apiServer.HandleCreateConvoy, "", "")
+	if unkeyed == first {
+		t.Errorf("Expected a request without a key to create a new convoy")
+	}
+
+	if status, _ := createConvoy(t, apiServer.HandleCreateConvoy, "", strings.Repeat("k", 256)); status != http.StatusBadRequest {
+		t.Errorf("Expected oversized key to be rejected, got status %d", status)
+	}
+}
+
+func TestHandleCreateConvoyWithVerificationIdempotencyKey(t *testing.T) {
+	apiServer, _, _ := newTestAPI(t)
+	body := `{"leaderName":"Leader","email":"leader@example.com"}`
+
+	status, first := createConvoy(t, apiServer.HandleCreateConvoyWithVerification, body, "retry-1")
+	if status != http.StatusCreated || first == "" {
+		t.Fatalf("Expected convoy to be created, got status %d", status)
+	}
+
+	// Retries must not trip the 3-per-email rate limit
+	for i := 0; i < 5; i++ {
+		status, id := createConvoy(t, apiServer.HandleCreateConvoyWithVerification, body, "retry-1")
+		if status != http.StatusCreated || id != first {
+			t.Fatalf("Expected retry %d to return convoy %s, got %s (status %d)", i, first, id, status)
+		}
+	}
+
+	// Keys are scoped per endpoint
+	_, plain := createConvoy(t, apiServer.HandleCreateConvoy, "", "retry-1")
+	if plain == first {
+		t.Errorf("Expected the same key on a different endpoint to create a new convoy")
+	}
+}
+
+func TestIdempotencyKeyRejectsDifferentBody(t *testing.T) {
+	apiServer, _, _ := newTestAPI(t)
+
+	if status, _ := createConvoy(t, apiServer.HandleCreateConvoy, `{"name":"First"}`, "reused"); status != http.StatusCreated {
+		t.Fatalf("Expected convoy to be created, got status %d", status)
+	}
+	if status, _ := createConvoy(t, apiServer.HandleCreateConvoy, `{"name":"Second"}`, "reused"); status != http.StatusUnprocessableEntity {
+		t.Errorf("Expected a different body under the same key to be rejected with 422, got %d", status)
+	}
+
+	body := `{"leaderName":"Leader","email":"leader@example.com"}`
+	if status, _ := createConvoy(t, apiServer.HandleCreateConvoyWithVerification, body, "reused"); status != http.StatusCreated {
+		t.Fatalf("Expected convoy to be created, got status %d", status)
+	}
+	other := `{"leaderName":"Leader","email":"other@example.com"}`
+	if status, _ := createConvoy(t, apiServer.HandleCreateConvoyWithVerification, other, "reused"); status != http.StatusUnprocessableEntity {
+		t.Errorf("Expected a different verification body under the same key to be rejected with 422, got %d", status)
+	}
+}
+
+func TestIdempotencyKeyIsScopedPerClient(t *testing.T) {
+	apiServer, _, _ := newTestAPI(t)
+
+	_, first := createConvoyFrom(t, apiServer.HandleCreateConvoy, "", "shared", "192.0.2.1:1234")
+	_, second := createConvoyFrom(t, apiServer.HandleCreateConvoy, "", "shared", "192.0.2.2:1234")
+	if first == "" || second == "" || first == second {
+		t.Errorf("Expected clients using the same key to get their own convoys, got %q and %q", first, second)
+	}
+}
+
+func TestIdempotencyKeyCreatesOneConvoyForConcurrentRetries(t *testing.T) {
+	apiServer, store, _ := newTestAPI(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			createConvoy(t, apiServer.HandleCreateConvoy, "", "concurrent")
+		}()
+	}
+	wg.Wait()
+
+	convoys, err := store.GetAllConvoys(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to list convoys: %v", err)
+	}
+	if len(convoys) != 1 {
+		t.Errorf("Expected concurrent retries to create one convoy, got %d", len(convoys))
+	}
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/email"
+	"convoy-app/backend/src/ierr"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	DefaultInviteTTL = time.Hour      // invite links are meant to be used right after sharing
+	MaxInviteTTL     = 24 * time.Hour // longest an invite may stay valid
+	MaxInviteUses    = 50             // matches the default per-convoy member limit
+)
+
+// InviteResponse is returned when an invite is created. The token is only
+// ever returned here; storage keeps a hash.
+type InviteResponse struct {
+	URL       string    `json:"url"`
+	Token     string    `json:"token"`
+	MaxUses   int       `json:"maxUses"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// HandleCreateInvite issues a shareable link that lets people join the convoy
+// without typing its ID. The body is optional.
+func (a *API) HandleCreateInvite(w http.ResponseWriter, r *http.Request) {
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req InviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	maxUses := req.MaxUses
+	if maxUses == 0 {
+		maxUses = 1
+	}
+	ttl := DefaultInviteTTL
+	if req.ExpiresInMinutes > 0 {
+		ttl = time.Duration(req.ExpiresInMinutes) * time.Minute
+	}
+
+	token, err := email.GenerateVerificationToken()
+	if err != nil {
+		log.Printf("ERROR: failed to generate invite token: %v", err)
+		writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		return
+	}
+
+	invite := domain.NewConvoyInvite(convoyID, token, maxUses, time.Now().Add(ttl))
+	if err := a.storage.CreateInvite(r.Context(), invite); err != nil {
+		if errors.Is(err, ierr.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("convoy not found"))
+		} else {
+			log.Printf("ERROR: failed to create invite for convoy %s: %v", convoyID, err)
+			writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		}
+		return
+	}
+
+	baseURL := a.forwardedBaseURL(r)
+	if baseURL == "" {
+		baseURL = a.emailService.BaseURL()
+	}
+
+	log.Printf("INFO: Invite created for convoy %s (max uses %d, expires %s)", convoyID, maxUses, invite.ExpiresAt.Format(time.RFC3339))
+	writeJSON(w, http.StatusCreated, InviteResponse{
+		URL:       fmt.Sprintf("%s/convoy/%s?invite=%s", baseURL, url.PathEscape(convoyID), url.QueryEscape(token)),
+		Token:     token,
+		MaxUses:   maxUses,
+		ExpiresAt: invite.ExpiresAt,
+	})
+}
+
+// HandleJoinWithInvite adds a member to the convoy using an invite token
+// from ?invite=. The body is the same as for adding a member.
+func (a *API) HandleJoinWithInvite(w http.ResponseWriter, r *http.Request) {
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	token := r.URL.Query().Get("invite")
+	if token == "" {
+		writeValidationError(w, ValidationErrors{{Field: "invite", Message: "invite token is required"}})
+		return
+	}
+
+	var req MemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	a.addMember(w, r, convoyID, req, token)
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"convoy-app/backend/src/domain"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInviteThenJoin(t *testing.T) {
+	_, memStorage, mux := newTestAPI(t)
+	convoy, err := memStorage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+
+	rec := doRequest(mux, http.MethodPost, "/api/convoys/"+convoy.ID+"/invites", `{"maxUses":2}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var invite InviteResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &invite); err != nil {
+		t.Fatalf("Failed to decode invite: %v", err)
+	}
+	if invite.Token == "" || !strings.Contains(invite.URL, "/convoy/"+convoy.ID+"?invite="+invite.Token) {
+		t.Fatalf("Expected an invite URL carrying the token, got %+v", invite)
+	}
+	if invite.MaxUses != 2 || time.Until(invite.ExpiresAt) > DefaultInviteTTL {
+		t.Errorf("Expected 2 uses within the default TTL, got %+v", invite)
+	}
+
+	joinPath := "/api/convoys/" + convoy.ID + "/join?invite=" + invite.Token
+	for _, name := range []string{"Alice", "Bob"} {
+		rec = doRequest(mux, http.MethodPost, joinPath, `{"name":"`+name+`"}`)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("Expected %s to join, got %d: %s", name, rec.Code, rec.Body.String())
+		}
+	}
+	if len(convoy.Members) != 2 {
+		t.Errorf("Expected 2 members after joining, got %d", len(convoy.Members))
+	}
+
+	rec = doRequest(mux, http.MethodPost, joinPath, `{"name":"Carol"}`)
+	if code := errorCode(t, rec); rec.Code != http.StatusConflict || code != "INVITE_USED" {
+		t.Errorf("Expected 409 INVITE_USED once the invite is used up, got %d %s", rec.Code, code)
+	}
+}
+
+func TestJoinWithInviteRejectsBadInvites(t *testing.T) {
+	_, memStorage, mux := newTestAPI(t)
+	ctx := context.Background()
+	convoy, err := memStorage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	expired := domain.NewConvoyInvite(convoy.ID, "expired-token", 1, time.Now().Add(-time.Minute))
+	if err := memStorage.CreateInvite(ctx, expired); err != nil {
+		t.Fatalf("Failed to create invite: %v", err)
+	}
+
+	rec := doRequest(mux, http.MethodPost, "/api/convoys/"+convoy.ID+"/join?invite=expired-token", `{"name":"Alice"}`)
+	if code := errorCode(t, rec); rec.Code != http.StatusGone || code != "INVITE_EXPIRED" {
+		t.Errorf("Expected 410 INVITE_EXPIRED, got %d %s", rec.Code, code)
+	}
+
+	rec = doRequest(mux, http.MethodPost, "/api/convoys/"+convoy.ID+"/join?invite=made-up", `{"name":"Alice"}`)
+	if code := errorCode(t, rec); rec.Code != http.StatusNotFound || code != "INVALID_INVITE" {
+		t.Errorf("Expected 404 INVALID_INVITE, got %d %s", rec.Code, code)
+	}
+
+	rec = doRequest(mux, http.MethodPost, "/api/convoys/"+convoy.ID+"/join", `{"name":"Alice"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without an invite, got %d", rec.Code)
+	}
+
+	rec = doRequest(mux, http.MethodPost, "/api/convoys/"+convoy.ID+"/invites", `{"maxUses":1000}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for too many uses, got %d", rec.Code)
+	}
+	if len(convoy.Members) != 0 {
+		t.Errorf("Expected nobody to join, got %d members", len(convoy.Members))
+	}
+}
+
+// errorCode decodes the code from an error response
+func errorCode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var body ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode error response: %v", err)
+	}
+	return body.Code
+}
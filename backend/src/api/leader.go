@@ -0,0 +1,54 @@
+package api
+
+import (
+	"context"
+	"convoy-app/backend/src/domain"
+	"net/http"
+	"strings"
+)
+
+// bearerToken returns the token of the request's "Authorization: Bearer"
+// header, or "" if there is none
+func bearerToken(r *http.Request) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+	return token
+}
+
+// isLeader reports whether token is the connect token issued to the convoy's
+// leader. It returns an error only if the convoy can't be looked up.
+func (a *API) isLeader(ctx context.Context, convoyID, token string) (bool, error) {
+	convoy, err := a.storage.GetConvoy(ctx, convoyID)
+	if err != nil {
+		return false, err
+	}
+	if token == "" || convoy.LeaderID == 0 {
+		return false, nil
+	}
+	return a.storage.ValidateConnectToken(ctx, convoyID, convoy.LeaderID, token) == nil, nil
+}
+
+// notesVisible reports whether member notes may go into the response: only
+// requests carrying the leader's connect token see them
+func (a *API) notesVisible(r *http.Request, convoyID string) bool {
+	leader, err := a.isLeader(r.Context(), convoyID, bearerToken(r))
+	return err == nil && leader
+}
+
+// convoyFor returns the convoy as the requester may see it
+func (a *API) convoyFor(r *http.Request, convoy *domain.Convoy) *domain.Convoy {
+	if a.notesVisible(r, convoy.ID) {
+		return convoy
+	}
+	return convoy.WithoutNotes()
+}
+
+// memberFor returns the member as the requester may see it
+func (a *API) memberFor(r *http.Request, convoyID string, member *domain.Member) *domain.Member {
+	if a.notesVisible(r, convoyID) {
+		return member
+	}
+	return member.WithoutNotes()
+}
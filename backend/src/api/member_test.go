@@ -0,0 +1,602 @@
+package api
+
+import (
+	"context"
+	"convoy-app/backend/src/domain"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestHandleUpdateMember(t *testing.T) {
+	_, memStorage, mux := newTestAPI(t)
+
+	ctx := context.Background()
+	convoy, err := memStorage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	member := &domain.Member{ID: 1, Name: "Jhon", Status: domain.StatusConnected, VehicleType: "sedan"}
+	if err := memStorage.AddMember(ctx, convoy.ID, member); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+	path := "/api/convoys/" + convoy.ID + "/members/" + strconv.FormatInt(member.ID, 10)
+
+	tests := []struct {
+		name           string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{"empty update", path, `{}`, http.StatusBadRequest},
+		{"blank name", path, `{"name":"  "}`, http.StatusBadRequest},
+		{"name too long", path, `{"name":"` + strings.Repeat("a", 51) + `"}`, http.StatusBadRequest},
+		{"vehicle type too long", path, `{"vehicleType":"` + strings.Repeat("v", 31) + `"}`, http.StatusBadRequest},
+		{"avatar not a URL", path, `{"avatarUrl":"javascript:alert(1)"}`, http.StatusBadRequest},
+		{"color not hex", path, `{"color":"red"}`, http.StatusBadRequest},
+		{"status is read-only", path, `{"status":"lagging"}`, http.StatusBadRequest},
+		{"id is read-only", path, `{"id":2,"name":"John"}`, http.StatusBadRequest},
+		{"unknown member", "/api/convoys/" + convoy.ID + "/members/999", `{"name":"John"}`, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(mux, http.MethodPatch, tt.path, tt.body)
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	if member.Name != "Jhon" || member.Status != domain.StatusConnected {
+		t.Fatalf("Rejected updates must not modify the member, got %+v", member)
+	}
+
+	// Partial update: only the name changes
+	rec := doRequest(mux, http.MethodPatch, path, `{"name":" John "}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if member.Name != "John" || member.VehicleType != "sedan" {
+		t.Errorf("Expected name to change and vehicle type to be kept, got %+v", member)
+	}
+
+	rec = doRequest(mux, http.MethodPatch, path, `{"vehicleType":"truck","avatarUrl":"https://example.com/a.png"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if member.Name != "John" || member.VehicleType != "truck" || member.AvatarURL != "https://example.com/a.png" {
+		t.Errorf("Expected metadata update to keep the name, got %+v", member)
+	}
+
+	rec = doRequest(mux, http.MethodPatch, path, `{"color":"#3CB44B"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if member.Color != "#3cb44b" {
+		t.Errorf("Expected the color override to be stored lowercased, got %q", member.Color)
+	}
+}
+
+func TestHandleUpdateMemberNotes(t *testing.T) {
+	_, memStorage, mux := newTestAPI(t)
+
+	ctx := context.Background()
+	convoy, err := memStorage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	leader := &domain.Member{ID: 1, Name: "Leader"}
+	leader.SetConnectToken("leader-token")
+	member := &domain.Member{ID: 2, Name: "Follower"}
+	member.SetConnectToken("follower-token")
+	for _, m := range []*domain.Member{leader, member} {
+		if err := memStorage.AddMember(ctx, convoy.ID, m); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+	}
+	path := "/api/convoys/" + convoy.ID + "/members/2"
+
+	patch := func(authorization, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		name           string
+		authorization  string
+		body           string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"no token", "", `{"notes":"has the coolers"}`, http.StatusUnauthorized, "UNAUTHORIZED"},
+		{"member's own token", "Bearer follower-token", `{"notes":"has the coolers"}`, http.StatusForbidden, "LEADER_ONLY"},
+		{"wrong token", "Bearer nope", `{"notes":"has the coolers"}`, http.StatusForbidden, "LEADER_ONLY"},
+		{"notes too long", "Bearer leader-token", `{"notes":"` + strings.Repeat("n", maxMemberNotesLength+1) + `"}`, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := patch(tt.authorization, tt.body)
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+			if tt.expectedCode != "" && errorCode(t, rec) != tt.expectedCode {
+				t.Errorf("Expected code %s, got %s", tt.expectedCode, errorCode(t, rec))
+			}
+		})
+	}
+	if member.Notes != "" {
+		t.Fatalf("Rejected updates must not set notes, got %q", member.Notes)
+	}
+
+	// Other fields stay open to everyone
+	if rec := patch("", `{"vehicleType":"truck"}`); rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for a profile update without a token, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec := patch("Bearer leader-token", `{"notes":" leaving at exit 40 "}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if member.Notes != "leaving at exit 40" {
+		t.Errorf("Expected trimmed notes, got %q", member.Notes)
+	}
+
+	// Every REST view of the member carries the notes only for the leader
+	get := func(method, path, authorization string) string {
+		req := httptest.NewRequest(method, path, strings.NewReader(`{"vehicleType":"truck"}`))
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for %s %s, got %d: %s", method, path, rec.Code, rec.Body.String())
+		}
+		return rec.Body.String()
+	}
+	for _, view := range []struct{ method, path string }{
+		{http.MethodGet, "/api/convoys/" + convoy.ID},
+		{http.MethodGet, "/api/convoys/" + convoy.ID + "/members"},
+		{http.MethodGet, path},
+		{http.MethodPatch, path},
+		{http.MethodGet, "/api/convoys/" + convoy.ID + "/export"},
+	} {
+		for _, authorization := range []string{"", "Bearer follower-token"} {
+			if body := get(view.method, view.path, authorization); strings.Contains(body, "exit 40") {
+				t.Errorf("Expected %s %s to hide notes from %q, got %s", view.method, view.path, authorization, body)
+			}
+		}
+		if body := get(view.method, view.path, "Bearer leader-token"); !strings.Contains(body, "exit 40") {
+			t.Errorf("Expected %s %s to show the leader notes, got %s", view.method, view.path, body)
+		}
+	}
+
+	rec = patch("Bearer leader-token", `{"notes":""}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if member.Notes != "" || member.VehicleType != "truck" {
+		t.Errorf("Expected notes cleared and the rest kept, got %+v", member)
+	}
+}
+
+func TestHandleGetMember(t *testing.T) {
+	_, memStorage, mux := newTestAPI(t)
+
+	ctx := context.Background()
+	convoy, err := memStorage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	member := &domain.Member{ID: 1, Name: "John", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}}
+	if err := memStorage.AddMember(ctx, convoy.ID, member); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+
+	rec := doRequest(mux, http.MethodGet, "/api/convoys/"+convoy.ID+"/members/1", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var got domain.Member
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to decode member: %v", err)
+	}
+	if got.ID != 1 || got.Name != "John" || got.Location != member.Location || got.Status != member.Status || !got.LastUpdate.Equal(member.LastUpdate) {
+		t.Errorf("Expected the member's record, got %+v", got)
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+	}{
+		{"unknown member", "/api/convoys/" + convoy.ID + "/members/999", http.StatusNotFound},
+		{"unknown convoy", "/api/convoys/" + missingConvoyID + "/members/1", http.StatusNotFound},
+		{"invalid member ID", "/api/convoys/" + convoy.ID + "/members/abc", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := doRequest(mux, http.MethodGet, tt.path, ""); rec.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandleGetNearestMember(t *testing.T) {
+	_, memStorage, mux := newTestAPI(t)
+
+	ctx := context.Background()
+	convoy, err := memStorage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	members := []*domain.Member{
+		{ID: 1, Name: "Origin", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}, Status: domain.StatusConnected},
+		{ID: 2, Name: "Near", Location: domain.LatLng{Lat: 40.01, Lng: -74.0}, Status: domain.StatusConnected},
+		{ID: 3, Name: "Far", Location: domain.LatLng{Lat: 40.1, Lng: -74.0}, Status: domain.StatusConnected},
+	}
+	for _, member := range members {
+		if err := memStorage.AddMember(ctx, convoy.ID, member); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+	}
+
+	rec := doRequest(mux, http.MethodGet, "/api/convoys/"+convoy.ID+"/members/1/nearest", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var nearest NearestMemberResponse
+	if err := json.NewDecoder(rec.Body).Decode(&nearest); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if nearest.MemberID != 2 || nearest.Name != "Near" || nearest.DistanceKm < 1.1 || nearest.DistanceKm > 1.12 {
+		t.Errorf("Expected Near at ~1.11km, got %+v", nearest)
+	}
+
+	if rec := doRequest(mux, http.MethodGet, "/api/convoys/"+convoy.ID+"/members/999/nearest", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown member, got %d", rec.Code)
+	}
+
+	// Single-member convoy returns null
+	solo, _ := memStorage.CreateConvoy(ctx)
+	memStorage.AddMember(ctx, solo.ID, &domain.Member{ID: 1, Name: "Solo", Status: domain.StatusConnected})
+	rec = doRequest(mux, http.MethodGet, "/api/convoys/"+solo.ID+"/members/1/nearest", "")
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != "null" {
+		t.Errorf("Expected 200 null for a single-member convoy, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHandleAddMemberSanitizesName(t *testing.T) {
+	_, memStorage, mux := newTestAPI(t)
+
+	convoy, err := memStorage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	path := "/api/convoys/" + convoy.ID + "/members"
+
+	rec := doRequest(mux, http.MethodPost, path, `{"name":"<script>alert(1)</script>"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var member domain.Member
+	if err := json.NewDecoder(rec.Body).Decode(&member); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if strings.ContainsAny(member.Name, "<>") {
+		t.Errorf("Expected angle brackets to be stripped, got %q", member.Name)
+	}
+
+	for _, body := range []string{
+		`{"name":"` + strings.Repeat("a", 51) + `"}`,
+		`{"name":""}`,
+		`{"name":"bad\u0007name"}`,
+	} {
+		if rec := doRequest(mux, http.MethodPost, path, body); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d", body, rec.Code)
+		}
+	}
+
+	if len(convoy.Members) != 1 {
+		t.Errorf("Expected only the sanitized member to be added, got %d members", len(convoy.Members))
+	}
+}
+
+func TestHandleAddMemberConvoyFull(t *testing.T) {
+	_, memStorage, mux := newTestAPI(t)
+	memStorage.SetMaxMembers(2)
+
+	convoy, err := memStorage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	path := "/api/convoys/" + convoy.ID + "/members"
+
+	for i := 0; i < 2; i++ {
+		if rec := doRequest(mux, http.MethodPost, path, `{"name":"Member"}`); rec.Code != http.StatusCreated {
+			t.Fatalf("Expected join %d to succeed, got %d", i, rec.Code)
+		}
+	}
+
+	rec := doRequest(mux, http.MethodPost, path, `{"name":"Member"}`)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("Expected status 409 when full, got %d", rec.Code)
+	}
+	var response ErrorResponse
+	json.NewDecoder(rec.Body).Decode(&response)
+	if response.Code != "CONVOY_FULL" {
+		t.Errorf("Expected code CONVOY_FULL, got %q", response.Code)
+	}
+}
+
+func TestHandleAddMemberValidationFields(t *testing.T) {
+	_, memStorage, mux := newTestAPI(t)
+
+	convoy, err := memStorage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+
+	rec := doRequest(mux, http.MethodPost, "/api/convoys/"+convoy.ID+"/members", `{"name":"","location":{"lat":100,"lng":0}}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d", rec.Code)
+	}
+
+	var response ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Code != "VALIDATION_ERROR" {
+		t.Errorf("Expected code VALIDATION_ERROR, got %q", response.Code)
+	}
+	if len(response.Fields) != 2 || response.Fields[0].Field != "name" || response.Fields[1].Field != "location.lat" {
+		t.Errorf("Expected name and location.lat field errors, got %+v", response.Fields)
+	}
+}
+
+func TestMembershipEventsBroadcast(t *testing.T) {
+	apiServer, memStorage, mux := newTestAPI(t)
+	convoy, err := memStorage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+
+	wsMux := http.NewServeMux()
+	wsMux.HandleFunc("GET /ws/convoys/{convoyId}", apiServer.wsHub.Handler)
+	server := httptest.NewServer(wsMux)
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws/convoys/"+convoy.ID, nil)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for apiServer.wsHub.GetConnectionCount(convoy.ID) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// expectEvent reads frames until the membership event arrives
+	expectEvent := func(eventType string, memberID int64, memberName string) {
+		t.Helper()
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		for {
+			var alert domain.ConvoyAlert
+			if err := conn.ReadJSON(&alert); err != nil {
+				t.Fatalf("Expected %s event, got error: %v", eventType, err)
+			}
+			if alert.EventType != eventType {
+				continue
+			}
+			if alert.MemberID != memberID || alert.MemberName != memberName {
+				t.Errorf("Expected %s for member %d (%s), got %d (%s)", eventType, memberID, memberName, alert.MemberID, alert.MemberName)
+			}
+			return
+		}
+	}
+
+	rec := doRequest(mux, http.MethodPost, "/api/convoys/"+convoy.ID+"/members", `{"name":"Alice","location":{"lat":40.0,"lng":-74.0}}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var member domain.Member
+	if err := json.Unmarshal(rec.Body.Bytes(), &member); err != nil {
+		t.Fatalf("Failed to decode member: %v", err)
+	}
+	expectEvent(domain.EventMemberJoined, member.ID, "Alice")
+
+	// The full snapshot still follows, and already includes the new member
+	var snapshot domain.Convoy
+	if err := conn.ReadJSON(&snapshot); err != nil {
+		t.Fatalf("Expected snapshot after MEMBER_JOINED, got error: %v", err)
+	}
+	if len(snapshot.Members) != 1 {
+		t.Errorf("Expected the snapshot to include the new member, got %d members", len(snapshot.Members))
+	}
+
+	rec = doRequest(mux, http.MethodDelete, "/api/convoys/"+convoy.ID+"/members/"+strconv.FormatInt(member.ID, 10), "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	expectEvent(domain.EventMemberLeft, member.ID, "Alice")
+}
+
+func TestHandleAddMembersBulk(t *testing.T) {
+	_, memStorage, mux := newTestAPI(t)
+
+	convoy, err := memStorage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	path := "/api/convoys/" + convoy.ID + "/members/bulk"
+
+	rec := doRequest(mux, http.MethodPost, path, `{"members": [
+		{"name": "Alice"},
+		{"name": " <Bob> ", "location": {"lat": 40.0, "lng": -74.0}},
+		{"name": "Carol"}
+	]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var response struct {
+		Members []struct {
+			ID           int64         `json:"id"`
+			Name         string        `json:"name"`
+			Location     domain.LatLng `json:"location"`
+			RejoinToken  string        `json:"rejoinToken"`
+			ConnectToken string        `json:"connectToken"`
+		} `json:"members"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Members) != 3 || len(convoy.Members) != 3 {
+		t.Fatalf("Expected 3 members added, got %d in the response and %d in the convoy", len(response.Members), len(convoy.Members))
+	}
+	for i, name := range []string{"Alice", "Bob", "Carol"} {
+		got := response.Members[i]
+		if got.Name != name || got.ID != convoy.Members[i].ID {
+			t.Errorf("Expected member %d to be %s with ID %d, got %s with ID %d", i, name, convoy.Members[i].ID, got.Name, got.ID)
+		}
+		if got.RejoinToken == "" || got.ConnectToken == "" {
+			t.Errorf("Expected member %d to get its tokens", i)
+		}
+	}
+	if response.Members[1].Location != (domain.LatLng{Lat: 40.0, Lng: -74.0}) {
+		t.Errorf("Expected Bob's starting location, got %+v", response.Members[1].Location)
+	}
+}
+
+func TestHandleAddMembersBulkRejectsWholeBatch(t *testing.T) {
+	_, memStorage, mux := newTestAPI(t)
+	memStorage.SetMaxMembers(3)
+
+	convoy, err := memStorage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	path := "/api/convoys/" + convoy.ID + "/members/bulk"
+
+	// One bad entry rejects the batch and names the entry
+	rec := doRequest(mux, http.MethodPost, path, `{"members": [
+		{"name": "Alice"},
+		{"name": "   "},
+		{"name": "Carol", "location": {"lat": 91, "lng": 0}}
+	]}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var response ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	var fields []string
+	for _, fieldErr := range response.Fields {
+		fields = append(fields, fieldErr.Field)
+	}
+	if strings.Join(fields, ",") != "members[1].name,members[2].location.lat" {
+		t.Errorf("Expected errors for entries 1 and 2, got %v", fields)
+	}
+	if len(convoy.Members) != 0 {
+		t.Errorf("Expected no members added from a rejected batch, got %d", len(convoy.Members))
+	}
+
+	if rec := doRequest(mux, http.MethodPost, path, `{"members": []}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an empty batch, got %d", rec.Code)
+	}
+
+	// A batch that doesn't fit under the member cap is rejected as a whole
+	if rec := doRequest(mux, http.MethodPost, "/api/convoys/"+convoy.ID+"/members", `{"name":"Leader"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("Expected the leader to join, got %d", rec.Code)
+	}
+	rec = doRequest(mux, http.MethodPost, path, `{"members": [{"name": "A"}, {"name": "B"}, {"name": "C"}]}`)
+	if code := errorCode(t, rec); rec.Code != http.StatusConflict || code != "CONVOY_FULL" {
+		t.Fatalf("Expected 409 CONVOY_FULL, got %d %q", rec.Code, code)
+	}
+	if len(convoy.Members) != 1 {
+		t.Errorf("Expected only the leader after an oversized batch, got %d members", len(convoy.Members))
+	}
+
+	if rec := doRequest(mux, http.MethodPost, "/api/convoys/"+missingConvoyID+"/members/bulk", `{"members": [{"name": "A"}]}`); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown convoy, got %d", rec.Code)
+	}
+}
+
+func TestHandleListMembersStatusFilter(t *testing.T) {
+	_, memStorage, mux := newTestAPI(t)
+	ctx := context.Background()
+
+	convoy, err := memStorage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	for i, status := range []string{domain.StatusConnected, domain.StatusLagging, domain.StatusDisconnected, domain.StatusLagging} {
+		if err := memStorage.AddMember(ctx, convoy.ID, &domain.Member{ID: int64(i + 1), Name: "Member", Status: status}); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+	}
+	path := "/api/convoys/" + convoy.ID + "/members"
+
+	memberIDs := func(query string) []int64 {
+		t.Helper()
+		rec := doRequest(mux, http.MethodGet, path+query, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for %q, got %d: %s", query, rec.Code, rec.Body.String())
+		}
+		var members []domain.Member
+		if err := json.Unmarshal(rec.Body.Bytes(), &members); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		ids := []int64{}
+		for _, member := range members {
+			ids = append(ids, member.ID)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		query string
+		want  []int64
+	}{
+		{"", []int64{1, 2, 3, 4}},
+		{"?status=lagging", []int64{2, 4}},
+		{"?status=lagging,disconnected", []int64{2, 3, 4}},
+		{"?status=inactive", []int64{}},
+	}
+	for _, tt := range tests {
+		if got := memberIDs(tt.query); !slices.Equal(got, tt.want) {
+			t.Errorf("Expected members %v for %q, got %v", tt.want, tt.query, got)
+		}
+	}
+
+	rec := doRequest(mux, http.MethodGet, path+"?status=lagging,lost", "")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400 for an unknown status, got %d", rec.Code)
+	}
+	var response ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Fields) != 1 || response.Fields[0].Field != "status" || !strings.Contains(response.Fields[0].Message, `"lost"`) {
+		t.Errorf("Expected a status field error naming the value, got %+v", response.Fields)
+	}
+
+	if rec := doRequest(mux, http.MethodGet, "/api/convoys/"+missingConvoyID+"/members", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown convoy, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/ierr"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// HandleListMembers returns a convoy's members. The optional status query
+// parameter, a comma-separated list such as "lagging,disconnected", limits the
+// result to members in one of those statuses, e.g. for a problems panel.
+func (a *API) HandleListMembers(w http.ResponseWriter, r *http.Request) {
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	var statuses []string
+	if raw := r.URL.Query().Get("status"); raw != "" {
+		var errs ValidationErrors
+		for _, status := range strings.Split(raw, ",") {
+			status = strings.TrimSpace(status)
+			if !slices.Contains(domain.MemberStatuses, status) {
+				errs.Add("status", fmt.Sprintf("unknown status %q; expected one of %s", status, strings.Join(domain.MemberStatuses, ", ")))
+				continue
+			}
+			statuses = append(statuses, status)
+		}
+		if err := errs.Err(); err != nil {
+			writeValidationError(w, err)
+			return
+		}
+	}
+
+	convoy, err := a.storage.GetConvoy(r.Context(), convoyID)
+	if err != nil {
+		if errors.Is(err, ierr.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("convoy not found"))
+		} else {
+			log.Printf("ERROR: failed to get convoy %s: %v", convoyID, err)
+			writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		}
+		return
+	}
+
+	convoy = a.convoyFor(r, convoy)
+	members := make([]*domain.Member, 0, len(convoy.Members))
+	for _, member := range convoy.Members {
+		if statuses == nil || slices.Contains(statuses, member.Status) {
+			members = append(members, member)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, members)
+}
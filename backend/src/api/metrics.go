@@ -0,0 +1,48 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// HandleMetrics exposes hub traffic counters in the Prometheus text format.
+// Convoy IDs grant access to a convoy, so the endpoint requires the admin
+// token like the other operator views; configure the scraper to send it.
+func (a *API) HandleMetrics(w http.ResponseWriter, r *http.Request) {
+	if !a.isAdmin(r) {
+		log.Printf("WARNING: Rejected metrics request from %s", getClientIP(r))
+		writeErrorWithCode(w, http.StatusUnauthorized, "Admin token required", "UNAUTHORIZED")
+		return
+	}
+
+	stats := a.wsHub.GetAllConvoyStats()
+	convoyIDs := make([]string, 0, len(stats))
+	for convoyID := range stats {
+		convoyIDs = append(convoyIDs, convoyID)
+	}
+	sort.Strings(convoyIDs)
+
+	var b strings.Builder
+	fmt.Fprintln(&b, "# HELP convoy_websocket_connections Open WebSocket member connections.")
+	fmt.Fprintln(&b, "# TYPE convoy_websocket_connections gauge")
+	fmt.Fprintf(&b, "convoy_websocket_connections %d\n", a.wsHub.GetTotalConnections())
+
+	fmt.Fprintln(&b, "# HELP convoy_broadcast_messages_total WebSocket broadcasts made to a convoy.")
+	fmt.Fprintln(&b, "# TYPE convoy_broadcast_messages_total counter")
+	for _, convoyID := range convoyIDs {
+		fmt.Fprintf(&b, "convoy_broadcast_messages_total{convoy=%q} %d\n", convoyID, stats[convoyID].Messages)
+	}
+
+	fmt.Fprintln(&b, "# HELP convoy_broadcast_bytes_total Bytes broadcast to a convoy, summed over receiving connections.")
+	fmt.Fprintln(&b, "# TYPE convoy_broadcast_bytes_total counter")
+	for _, convoyID := range convoyIDs {
+		fmt.Fprintf(&b, "convoy_broadcast_bytes_total{convoy=%q} %d\n", convoyID, stats[convoyID].Bytes)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(b.String()))
+}
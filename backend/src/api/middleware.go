@@ -4,12 +4,25 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 )
 
-func timeoutMiddleware(timeout time.Duration) func(http.Handler) http.Handler {
+// TimeoutMiddleware cancels each request's context after timeout so storage
+// calls bound to r.Context() stop once the client can no longer be answered in
+// time. WebSocket upgrades are long-lived and are passed through untouched.
+func TimeoutMiddleware(timeout time.Duration) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
+		if timeout <= 0 {
+			return next
+		}
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Long-lived streams end when the client leaves, not on a timer
+			if isWebSocketUpgrade(r) || isEventStream(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			ctx, cancel := context.WithTimeout(r.Context(), timeout)
 			defer cancel()
 
@@ -26,3 +39,14 @@ func loggingMiddleware(next http.Handler) http.Handler {
 		log.Printf("%s %s %v", r.Method, r.URL.Path, time.Since(start))
 	})
 }
+
+// isWebSocketUpgrade reports whether the request asks to switch to WebSocket
+func isWebSocketUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
+
+// isEventStream reports whether the request asks for Server-Sent Events, as
+// EventSource does
+func isEventStream(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"convoy-app/backend/src/config"
+	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/storage"
+	"convoy-app/backend/src/ws"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// slowStorage is in-memory storage whose GetConvoy blocks until its context ends
+type slowStorage struct {
+	*storage.MemoryStorage
+	cancelled chan error
+}
+
+func (s *slowStorage) GetConvoy(ctx context.Context, convoyID string) (*domain.Convoy, error) {
+	select {
+	case <-ctx.Done():
+		s.cancelled <- ctx.Err()
+		return nil, ctx.Err()
+	case <-time.After(5 * time.Second):
+		return s.MemoryStorage.GetConvoy(ctx, convoyID)
+	}
+}
+
+func TestRequestTimeoutCancelsSlowStorage(t *testing.T) {
+	slow := &slowStorage{MemoryStorage: storage.NewMemoryStorage(), cancelled: make(chan error, 1)}
+	apiServer := New(slow, ws.NewHub(), config.Load())
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/convoys/{convoyId}", apiServer.HandleGetConvoy)
+	handler := TimeoutMiddleware(50 * time.Millisecond)(mux)
+
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/convoys/"+missingConvoyID, nil))
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the request to be cut short, took %v", elapsed)
+	}
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("Expected 504, got %d", rec.Code)
+	}
+	select {
+	case err := <-slow.cancelled:
+		if err != context.DeadlineExceeded {
+			t.Errorf("Expected storage to see DeadlineExceeded, got %v", err)
+		}
+	default:
+		t.Error("Expected the storage call to be cancelled")
+	}
+}
+
+func TestRequestTimeoutSkipsWebSocketUpgrade(t *testing.T) {
+	var hasDeadline bool
+	handler := TimeoutMiddleware(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, hasDeadline = r.Context().Deadline()
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/ws/convoys/convoy-1", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if hasDeadline {
+		t.Error("Expected WebSocket upgrades to have no request deadline")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/convoys/convoy-1/events/stream", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if hasDeadline {
+		t.Error("Expected event streams to have no request deadline")
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/convoys/"+missingConvoyID, nil))
+	if !hasDeadline {
+		t.Error("Expected regular requests to have a deadline")
+	}
+}
+
+func TestGzipMiddlewareCompressesLargeJSON(t *testing.T) {
+	memStorage := storage.NewMemoryStorage()
+	apiServer := New(memStorage, ws.NewHub(), config.Load())
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/convoys/{convoyId}", apiServer.HandleGetConvoy)
+	handler := GzipMiddleware(true, 1024)(mux)
+
+	convoy, err := memStorage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	for i := int64(1); i <= 20; i++ {
+		member := &domain.Member{ID: i, Name: fmt.Sprintf("Member %d", i), Location: domain.LatLng{Lat: 40.0, Lng: -74.0}}
+		if err := memStorage.AddMember(context.Background(), convoy.ID, member); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+	}
+
+	get := func(acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/convoys/"+convoy.ID, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	plain := get("")
+	if plain.Code != http.StatusOK || plain.Header().Get("Content-Encoding") != "" {
+		t.Fatalf("Expected a plain 200 without Accept-Encoding, got %d %q", plain.Code, plain.Header().Get("Content-Encoding"))
+	}
+	if plain.Body.Len() < 1024 {
+		t.Fatalf("Expected a response above the threshold, got %d bytes", plain.Body.Len())
+	}
+
+	compressed := get("gzip, deflate")
+	if compressed.Code != http.StatusOK || compressed.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Expected a gzipped 200, got %d %q", compressed.Code, compressed.Header().Get("Content-Encoding"))
+	}
+	reader, err := gzip.NewReader(compressed.Body)
+	if err != nil {
+		t.Fatalf("Failed to open gzip body: %v", err)
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to decompress body: %v", err)
+	}
+	if !bytes.Equal(body, plain.Body.Bytes()) {
+		t.Error("Expected the decompressed body to match the plain one")
+	}
+
+	// Small responses aren't worth compressing
+	small := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/convoys/"+missingConvoyID, nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	handler.ServeHTTP(small, req)
+	if small.Code != http.StatusNotFound || small.Header().Get("Content-Encoding") != "" {
+		t.Errorf("Expected a plain 404, got %d %q", small.Code, small.Header().Get("Content-Encoding"))
+	}
+}
+
+func TestGzipMiddlewareSkipsWebSocketUpgrade(t *testing.T) {
+	var wrapped bool
+	handler := GzipMiddleware(true, 1)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, wrapped = w.(*gzipResponseWriter)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/ws/convoys/convoy-1", nil)
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Accept-Encoding", "gzip")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if wrapped {
+		t.Error("Expected WebSocket upgrades to get the original response writer so they can be hijacked")
+	}
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// MaxBulkStatusIDs caps how many convoys one status request may ask about
+const MaxBulkStatusIDs = 50
+
+// ConvoyStatusSummary is the compact per-convoy view returned by the bulk
+// status endpoint. Only Found is set for unknown convoy IDs.
+type ConvoyStatusSummary struct {
+	Found       bool `json:"found"`
+	MemberCount int  `json:"memberCount,omitempty"`
+	Scattered   bool `json:"scattered,omitempty"`
+	Arrived     bool `json:"arrived,omitempty"`
+	Paused      bool `json:"paused,omitempty"`
+}
+
+// HandleGetConvoysStatus returns a status summary for each convoy in the
+// comma-separated ids query parameter, keyed by convoy ID
+func (a *API) HandleGetConvoysStatus(w http.ResponseWriter, r *http.Request) {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, id := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		if id = strings.TrimSpace(id); id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	var errs ValidationErrors
+	if len(ids) == 0 {
+		errs.Add("ids", "at least one convoy ID is required")
+	} else if len(ids) > MaxBulkStatusIDs {
+		errs.Add("ids", fmt.Sprintf("at most %d convoy IDs may be requested at once", MaxBulkStatusIDs))
+	}
+	if err := errs.Err(); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	convoys, err := a.storage.GetConvoysByID(r.Context(), ids)
+	if err != nil {
+		log.Printf("ERROR: failed to get convoys for status: %v", err)
+		writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+		return
+	}
+
+	statuses := make(map[string]ConvoyStatusSummary, len(ids))
+	for _, id := range ids {
+		convoy, ok := convoys[id]
+		if !ok {
+			statuses[id] = ConvoyStatusSummary{Found: false}
+			continue
+		}
+		statuses[id] = ConvoyStatusSummary{
+			Found:       true,
+			MemberCount: len(convoy.Members),
+			Scattered:   a.monitor.IsScattered(id),
+			Arrived:     a.monitor.ConvoyArrived(convoy),
+			Paused:      convoy.Paused,
+		}
+	}
+
+	writeJSON(w, http.StatusOK, statuses)
+}
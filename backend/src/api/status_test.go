@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"convoy-app/backend/src/domain"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestHandleGetConvoysStatusPartialBatch(t *testing.T) {
+	_, memStorage, mux := newTestAPI(t)
+	ctx := context.Background()
+
+	moving, err := memStorage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	arrived, err := memStorage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	if err := memStorage.SetConvoyDestination(ctx, arrived.ID, &domain.Destination{Name: "Camp", Lat: 40.0, Lng: -74.0}); err != nil {
+		t.Fatalf("Failed to set destination: %v", err)
+	}
+	for _, add := range []struct {
+		convoyID string
+		member   *domain.Member
+	}{
+		{moving.ID, &domain.Member{ID: 1, Name: "A", Location: domain.LatLng{Lat: 41.0, Lng: -74.0}}},
+		{moving.ID, &domain.Member{ID: 2, Name: "B", Location: domain.LatLng{Lat: 41.0, Lng: -74.0}}},
+		{arrived.ID, &domain.Member{ID: 1, Name: "C", Location: domain.LatLng{Lat: 40.0005, Lng: -74.0}}},
+	} {
+		if err := memStorage.AddMember(ctx, add.convoyID, add.member); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+	}
+
+	rec := doRequest(mux, http.MethodGet, "/api/convoys/status?ids="+moving.ID+",missing,"+arrived.ID+","+moving.ID, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var statuses map[string]ConvoyStatusSummary
+	if err := json.NewDecoder(rec.Body).Decode(&statuses); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(statuses) != 3 {
+		t.Fatalf("Expected 3 entries, got %+v", statuses)
+	}
+	if s := statuses[moving.ID]; !s.Found || s.MemberCount != 2 || s.Arrived {
+		t.Errorf("Unexpected status for moving convoy: %+v", s)
+	}
+	if s := statuses[arrived.ID]; !s.Found || s.MemberCount != 1 || !s.Arrived {
+		t.Errorf("Unexpected status for arrived convoy: %+v", s)
+	}
+	if s, ok := statuses["missing"]; !ok || s.Found {
+		t.Errorf("Expected unknown convoy to be marked not found, got %+v", s)
+	}
+}
+
+func TestHandleGetConvoysStatusLimits(t *testing.T) {
+	_, _, mux := newTestAPI(t)
+
+	if rec := doRequest(mux, http.MethodGet, "/api/convoys/status", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without ids, got %d", rec.Code)
+	}
+
+	ids := make([]string, MaxBulkStatusIDs+1)
+	for i := range ids {
+		ids[i] = "convoy-" + strings.Repeat("x", i+1)
+	}
+	if rec := doRequest(mux, http.MethodGet, "/api/convoys/status?ids="+strings.Join(ids, ","), ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for more than %d ids, got %d", MaxBulkStatusIDs, rec.Code)
+	}
+}
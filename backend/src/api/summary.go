@@ -0,0 +1,85 @@
+package api
+
+import (
+	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/geo"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ConvoySummary is a compact trip recap for share cards. It leaves out the
+// member list.
+type ConvoySummary struct {
+	ID              string   `json:"id"`
+	Name            string   `json:"name,omitempty"`
+	MemberCount     int      `json:"memberCount"`
+	DistanceKm      *float64 `json:"distanceKm,omitempty"` // omitted until a member has recorded a track
+	DurationSeconds int64    `json:"durationSeconds"`      // from the first recorded location, or the start if later, to the last
+	DestinationName string   `json:"destinationName,omitempty"`
+	Arrived         bool     `json:"arrived"`
+}
+
+// HandleGetConvoySummary returns a trip recap for a convoy
+func (a *API) HandleGetConvoySummary(w http.ResponseWriter, r *http.Request) {
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	convoy, err := a.storage.GetConvoy(r.Context(), convoyID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, errors.New("convoy not found"))
+		return
+	}
+
+	summary := ConvoySummary{
+		ID:          convoy.ID,
+		Name:        convoy.Name,
+		MemberCount: len(convoy.Members),
+		Arrived:     a.monitor.ConvoyArrived(convoy),
+	}
+	if convoy.Destination != nil {
+		summary.DestinationName = convoy.Destination.Name
+	}
+
+	// Members travel together, so the convoy covered as much ground as its
+	// longest track. Tracks only keep recent points, so this is a lower bound.
+	var start, end time.Time
+	for _, member := range convoy.Members {
+		if len(member.Track) == 0 {
+			continue
+		}
+		if distance, ok := trackDistanceKm(member.Track); ok && (summary.DistanceKm == nil || distance > *summary.DistanceKm) {
+			summary.DistanceKm = &distance
+		}
+		first, last := member.Track[0].Timestamp, member.Track[len(member.Track)-1].Timestamp
+		if start.IsZero() || first.Before(start) {
+			start = first
+		}
+		if last.After(end) {
+			end = last
+		}
+	}
+	// Time spent gathering before departure isn't part of the trip
+	if convoy.StartedAt != nil && convoy.StartedAt.After(start) {
+		start = *convoy.StartedAt
+	}
+	if !start.IsZero() && end.After(start) {
+		summary.DurationSeconds = int64(end.Sub(start).Seconds())
+	}
+
+	writeJSON(w, http.StatusOK, summary)
+}
+
+// trackDistanceKm sums the legs of a track. ok is false for tracks too short
+// to have moved.
+func trackDistanceKm(track []domain.TrackPoint) (distance float64, ok bool) {
+	if len(track) < 2 {
+		return 0, false
+	}
+	for i := 1; i < len(track); i++ {
+		distance += geo.Distance(track[i-1].LatLng, track[i].LatLng)
+	}
+	return distance, true
+}
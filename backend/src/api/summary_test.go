@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"convoy-app/backend/src/domain"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandleGetConvoySummaryCompletedTrip(t *testing.T) {
+	_, memStorage, mux := newTestAPI(t)
+	ctx := context.Background()
+
+	convoy, err := memStorage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	if err := memStorage.SetConvoyName(ctx, convoy.ID, "Beach Trip"); err != nil {
+		t.Fatalf("Failed to name convoy: %v", err)
+	}
+	if err := memStorage.SetConvoyDestination(ctx, convoy.ID, &domain.Destination{Name: "Beach", Lat: 40.02, Lng: -74.0}); err != nil {
+		t.Fatalf("Failed to set destination: %v", err)
+	}
+
+	start := time.Now().Add(-2 * time.Hour)
+	for _, member := range []*domain.Member{
+		{ID: 1, Name: "Driver", Location: domain.LatLng{Lat: 40.02, Lng: -74.0}},
+		{ID: 2, Name: "Passenger", Location: domain.LatLng{Lat: 40.02, Lng: -74.0}},
+	} {
+		if err := memStorage.AddMember(ctx, convoy.ID, member); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+	}
+	// The driver drove the whole way; the passenger's phone joined halfway
+	convoy.Members[0].Track = []domain.TrackPoint{
+		{LatLng: domain.LatLng{Lat: 40.0, Lng: -74.0}, Timestamp: start},
+		{LatLng: domain.LatLng{Lat: 40.01, Lng: -74.0}, Timestamp: start.Add(time.Hour)},
+		{LatLng: domain.LatLng{Lat: 40.02, Lng: -74.0}, Timestamp: start.Add(90 * time.Minute)},
+	}
+	convoy.Members[1].Track = []domain.TrackPoint{
+		{LatLng: domain.LatLng{Lat: 40.01, Lng: -74.0}, Timestamp: start.Add(time.Hour)},
+		{LatLng: domain.LatLng{Lat: 40.02, Lng: -74.0}, Timestamp: start.Add(90 * time.Minute)},
+	}
+
+	rec := doRequest(mux, http.MethodGet, "/api/convoys/"+convoy.ID+"/summary", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), `"members"`) {
+		t.Errorf("Expected no member list in the summary, got %s", rec.Body.String())
+	}
+
+	var summary ConvoySummary
+	if err := json.Unmarshal(rec.Body.Bytes(), &summary); err != nil {
+		t.Fatalf("Failed to decode summary: %v", err)
+	}
+	if summary.Name != "Beach Trip" || summary.MemberCount != 2 || summary.DestinationName != "Beach" || !summary.Arrived {
+		t.Errorf("Unexpected summary %+v", summary)
+	}
+	if summary.DistanceKm == nil || math.Abs(*summary.DistanceKm-2.22) > 0.05 {
+		t.Errorf("Expected about 2.22km travelled, got %v", summary.DistanceKm)
+	}
+	if summary.DurationSeconds != int64((90 * time.Minute).Seconds()) {
+		t.Errorf("Expected a 90 minute trip, got %ds", summary.DurationSeconds)
+	}
+}
+
+func TestHandleGetConvoySummaryWithoutDestination(t *testing.T) {
+	_, memStorage, mux := newTestAPI(t)
+	convoy, err := memStorage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+
+	rec := doRequest(mux, http.MethodGet, "/api/convoys/"+convoy.ID+"/summary", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var summary ConvoySummary
+	if err := json.Unmarshal(rec.Body.Bytes(), &summary); err != nil {
+		t.Fatalf("Failed to decode summary: %v", err)
+	}
+	if summary.DestinationName != "" || summary.Arrived || summary.DistanceKm != nil || summary.DurationSeconds != 0 {
+		t.Errorf("Expected an empty summary, got %+v", summary)
+	}
+
+	if rec := doRequest(mux, http.MethodGet, "/api/convoys/"+missingConvoyID+"/summary", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown convoy, got %d", rec.Code)
+	}
+}
@@ -2,13 +2,58 @@ package api
 
 import (
 	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/sms"
 	"errors"
+	"fmt"
+	"maps"
+	"net/url"
 	"regexp"
+	"slices"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
+// FieldError describes a problem with a single request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors collects every field problem found in a request, so
+// clients can show them all at once instead of one per round trip
+type ValidationErrors []FieldError
+
+func (v ValidationErrors) Error() string {
+	messages := make([]string, len(v))
+	for i, fieldErr := range v {
+		messages[i] = fieldErr.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Add records a problem with field
+func (v *ValidationErrors) Add(field, message string) {
+	*v = append(*v, FieldError{Field: field, Message: message})
+}
+
+// Err returns the collected errors, or nil if there are none
+func (v ValidationErrors) Err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	return v
+}
+
 type ConvoyRequest struct {
-	Name string `json:"name"`
+	Name     string            `json:"name"`
+	Metadata map[string]string `json:"metadata,omitempty"` // only read when creating a convoy
+}
+
+// ConvoyMetadataRequest replaces a convoy's metadata; an empty object clears it
+type ConvoyMetadataRequest struct {
+	Metadata map[string]string `json:"metadata"`
 }
 
 type MemberRequest struct {
@@ -16,21 +61,81 @@ type MemberRequest struct {
 	Location *domain.LatLng `json:"location,omitempty"`
 }
 
+// BulkMemberRequest adds a whole roster of members in one go
+type BulkMemberRequest struct {
+	Members []MemberRequest `json:"members"`
+}
+
+// UpdateMemberRequest is a partial member update; omitted fields are left unchanged.
+// Validate sanitizes Name in place.
+type UpdateMemberRequest struct {
+	Name        *string `json:"name,omitempty"`
+	VehicleType *string `json:"vehicleType,omitempty"`
+	AvatarURL   *string `json:"avatarUrl,omitempty"`
+	Color       *string `json:"color,omitempty"` // "#rrggbb"
+	Notes       *string `json:"notes,omitempty"` // leader only; "" clears the notes
+}
+
 type LocationRequest struct {
-	Lat float64 `json:"lat"`
-	Lng float64 `json:"lng"`
+	Lat      float64  `json:"lat"`
+	Lng      float64  `json:"lng"`
+	Accuracy *float64 `json:"accuracy,omitempty"` // meters, as reported by the device
 }
 
 type DestinationRequest struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description,omitempty"`
-	Lat         float64 `json:"lat"`
-	Lng         float64 `json:"lng"`
+	Name                string   `json:"name"`
+	Description         string   `json:"description,omitempty"`
+	Lat                 float64  `json:"lat"`
+	Lng                 float64  `json:"lng"`
+	ArrivalRadiusMeters *float64 `json:"arrivalRadiusMeters,omitempty"` // optional; the server default when omitted
+}
+
+// DestinationPatchRequest changes part of an existing destination; omitted
+// fields are left unchanged. Lat and lng must be given together.
+type DestinationPatchRequest struct {
+	Name        *string  `json:"name,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	Lat         *float64 `json:"lat,omitempty"`
+	Lng         *float64 `json:"lng,omitempty"`
+}
+
+// DestinationSearchRequest names a place to forward-geocode as the destination
+type DestinationSearchRequest struct {
+	Query string `json:"query"`
+}
+
+// AttentionRequest carries the optional reason a member raises an attention signal
+type AttentionRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// AnnouncementRequest carries the text of an operator announcement
+type AnnouncementRequest struct {
+	Message string `json:"message"`
+}
+
+// InviteRequest configures a new invite link; zero values use the defaults
+type InviteRequest struct {
+	MaxUses          int `json:"maxUses,omitempty"`
+	ExpiresInMinutes int `json:"expiresInMinutes,omitempty"`
 }
 
 type CreateConvoyWithVerificationRequest struct {
-	LeaderName string `json:"leaderName"`
-	Email      string `json:"email"`
+	Name       string            `json:"name,omitempty"` // optional convoy name
+	LeaderName string            `json:"leaderName"`
+	Email      string            `json:"email"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+}
+
+type CreateConvoyWithSMSRequest struct {
+	Name       string            `json:"name,omitempty"` // optional convoy name
+	LeaderName string            `json:"leaderName"`
+	Phone      string            `json:"phone"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+}
+
+type VerifySMSRequest struct {
+	Code string `json:"code"`
 }
 
 type ResendVerificationRequest struct {
@@ -38,54 +143,328 @@ type ResendVerificationRequest struct {
 }
 
 func (r *ConvoyRequest) Validate() error {
-	if strings.TrimSpace(r.Name) == "" {
-		return errors.New("convoy name is required")
+	var errs ValidationErrors
+	validateConvoyName(&errs, r.Name)
+	return errs.Err()
+}
+
+func validateConvoyName(errs *ValidationErrors, name string) {
+	if strings.TrimSpace(name) == "" {
+		errs.Add("name", "convoy name is required")
+	} else if len(name) > 100 {
+		errs.Add("name", "convoy name too long")
+	}
+}
+
+// validateOptionalConvoyName applies the ConvoyRequest rules to a name that may be omitted
+func validateOptionalConvoyName(errs *ValidationErrors, name string) {
+	if name != "" {
+		validateConvoyName(errs, name)
+	}
+}
+
+// Limits on convoy metadata, which integrators can fill with anything: it is
+// stored per convoy and sent with every convoy update
+const (
+	maxMetadataKeys        = 20
+	maxMetadataKeyLength   = 64
+	maxMetadataValueLength = 512
+	maxMetadataBytes       = 4096 // keys and values together
+)
+
+// validateConvoyMetadata checks metadata against the size limits
+func validateConvoyMetadata(errs *ValidationErrors, metadata map[string]string) {
+	if len(metadata) > maxMetadataKeys {
+		errs.Add("metadata", fmt.Sprintf("at most %d keys are allowed", maxMetadataKeys))
+		return
+	}
+	total := 0
+	for _, key := range slices.Sorted(maps.Keys(metadata)) {
+		value := metadata[key]
+		total += len(key) + len(value)
+		if strings.TrimSpace(key) == "" {
+			errs.Add("metadata", "keys must not be blank")
+		} else if len(key) > maxMetadataKeyLength {
+			errs.Add("metadata", fmt.Sprintf("keys must be at most %d bytes", maxMetadataKeyLength))
+		} else if len(value) > maxMetadataValueLength {
+			errs.Add("metadata."+key, fmt.Sprintf("value too long (max %d bytes)", maxMetadataValueLength))
+		}
 	}
-	if len(r.Name) > 100 {
-		return errors.New("convoy name too long")
+	if total > maxMetadataBytes {
+		errs.Add("metadata", fmt.Sprintf("metadata too large (max %d bytes of keys and values)", maxMetadataBytes))
 	}
-	return nil
 }
 
+// Validate checks the metadata is present and within the size limits
+func (r *ConvoyMetadataRequest) Validate() error {
+	var errs ValidationErrors
+	if r.Metadata == nil {
+		errs.Add("metadata", "metadata is required; send {} to clear it")
+	} else {
+		validateConvoyMetadata(&errs, r.Metadata)
+	}
+	return errs.Err()
+}
+
+// memberColorRegex matches the "#rrggbb" colors members are drawn with
+var memberColorRegex = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// maxMemberNameLength is the longest member name accepted, in characters
+const maxMemberNameLength = 50
+
+// maxBulkMembers caps a single bulk import; the convoy's member limit still applies
+const maxBulkMembers = 100
+
+// maxDestinationDescriptionLength fits a short note such as where to park
+const maxDestinationDescriptionLength = 500
+
+// maxArrivalRadiusMeters covers the largest venues; beyond this a member
+// would count as arrived while still on the approach roads
+const maxArrivalRadiusMeters = 5000
+
+// maxMemberNotesLength fits a quick reminder such as "has the coolers"
+const maxMemberNotesLength = 200
+
+// maxAnnouncementLength keeps operator announcements short enough to read at a glance
+const maxAnnouncementLength = 280
+
+// Validate checks the request and sanitizes Name in place.
 func (r *MemberRequest) Validate() error {
-	if strings.TrimSpace(r.Name) == "" {
-		return errors.New("member name is required")
+	var errs ValidationErrors
+	name, err := sanitizeMemberName(r.Name)
+	if err != nil {
+		errs.Add("name", err.Error())
+	} else {
+		r.Name = name
+	}
+	if r.Location != nil {
+		validateLatLng(&errs, "location.lat", "location.lng", r.Location.Lat, r.Location.Lng)
+	}
+	return errs.Err()
+}
+
+// Validate checks every member, sanitizing names in place. Problems are
+// reported under "members[i].<field>" so clients can point at the entry.
+func (r *BulkMemberRequest) Validate() error {
+	var errs ValidationErrors
+	if len(r.Members) == 0 {
+		errs.Add("members", "at least one member is required")
+	} else if len(r.Members) > maxBulkMembers {
+		errs.Add("members", fmt.Sprintf("at most %d members can be added at once", maxBulkMembers))
+	}
+	for i := range r.Members {
+		var memberErrs ValidationErrors
+		if errors.As(r.Members[i].Validate(), &memberErrs) {
+			for _, fieldErr := range memberErrs {
+				errs.Add(fmt.Sprintf("members[%d].%s", i, fieldErr.Field), fmt.Sprintf("member %d: %s", i, fieldErr.Message))
+			}
+		}
+	}
+	return errs.Err()
+}
+
+// sanitizeMemberName rejects control characters, strips angle brackets so a
+// name can never form markup, trims whitespace and enforces the length cap.
+func sanitizeMemberName(name string) (string, error) {
+	for _, c := range name {
+		if unicode.IsControl(c) {
+			return "", errors.New("member name contains invalid characters")
+		}
+	}
+
+	name = strings.TrimSpace(strings.NewReplacer("<", "", ">", "").Replace(name))
+	if name == "" {
+		return "", errors.New("member name is required")
+	}
+	if utf8.RuneCountInString(name) > maxMemberNameLength {
+		return "", errors.New("member name too long (max 50 characters)")
+	}
+	return name, nil
+}
+
+func (r *UpdateMemberRequest) Validate() error {
+	if r.Name == nil && r.VehicleType == nil && r.AvatarURL == nil && r.Color == nil && r.Notes == nil {
+		return errors.New("at least one of name, vehicleType, avatarUrl, color or notes is required")
+	}
+	var errs ValidationErrors
+	if r.Name != nil {
+		name, err := sanitizeMemberName(*r.Name)
+		if err != nil {
+			errs.Add("name", err.Error())
+		} else {
+			r.Name = &name
+		}
+	}
+	if r.VehicleType != nil && len(*r.VehicleType) > 30 {
+		errs.Add("vehicleType", "vehicle type too long (max 30 characters)")
+	}
+	if r.AvatarURL != nil && *r.AvatarURL != "" {
+		if len(*r.AvatarURL) > 500 {
+			errs.Add("avatarUrl", "avatar URL too long (max 500 characters)")
+		} else if u, err := url.Parse(*r.AvatarURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs.Add("avatarUrl", "avatar URL must be an http(s) URL")
+		}
+	}
+	if r.Color != nil && !memberColorRegex.MatchString(strings.TrimSpace(*r.Color)) {
+		errs.Add("color", "color must be a hex color like #3cb44b")
 	}
-	if len(r.Name) > 50 {
-		return errors.New("member name too long")
+	if r.Notes != nil && utf8.RuneCountInString(strings.TrimSpace(*r.Notes)) > maxMemberNotesLength {
+		errs.Add("notes", fmt.Sprintf("notes must be at most %d characters", maxMemberNotesLength))
+	}
+	return errs.Err()
+}
+
+// ToDomain converts the request to a storage update, trimming whitespace
+func (r *UpdateMemberRequest) ToDomain() domain.MemberUpdate {
+	trim := func(s *string) *string {
+		if s == nil {
+			return nil
+		}
+		trimmed := strings.TrimSpace(*s)
+		return &trimmed
+	}
+	lower := func(s *string) *string {
+		if s == nil {
+			return nil
+		}
+		lowered := strings.ToLower(*s)
+		return &lowered
+	}
+	return domain.MemberUpdate{
+		Name:        trim(r.Name),
+		VehicleType: trim(r.VehicleType),
+		AvatarURL:   trim(r.AvatarURL),
+		Color:       lower(trim(r.Color)),
+		Notes:       trim(r.Notes),
 	}
-	return nil
 }
 
 func (r *LocationRequest) Validate() error {
-	if r.Lat < -90 || r.Lat > 90 {
-		return errors.New("latitude must be between -90 and 90")
+	var errs ValidationErrors
+	validateLatLng(&errs, "lat", "lng", r.Lat, r.Lng)
+	if r.Accuracy != nil && *r.Accuracy < 0 {
+		errs.Add("accuracy", "accuracy must not be negative")
+	}
+	return errs.Err()
+}
+
+// validateLatLng checks coordinate ranges, reporting problems under the given field names
+func validateLatLng(errs *ValidationErrors, latField, lngField string, lat, lng float64) {
+	if lat < -90 || lat > 90 {
+		errs.Add(latField, "latitude must be between -90 and 90")
 	}
-	if r.Lng < -180 || r.Lng > 180 {
-		return errors.New("longitude must be between -180 and 180")
+	if lng < -180 || lng > 180 {
+		errs.Add(lngField, "longitude must be between -180 and 180")
 	}
-	return nil
 }
 
 func (r *DestinationRequest) Validate() error {
+	var errs ValidationErrors
 	if strings.TrimSpace(r.Name) == "" {
-		return errors.New("destination name is required")
+		errs.Add("name", "destination name is required")
+	} else if len(r.Name) > 100 {
+		errs.Add("name", "destination name too long")
+	}
+	if len(r.Description) > maxDestinationDescriptionLength {
+		errs.Add("description", fmt.Sprintf("description too long (max %d characters)", maxDestinationDescriptionLength))
 	}
-	if len(r.Name) > 100 {
-		return errors.New("destination name too long")
+	validateLatLng(&errs, "lat", "lng", r.Lat, r.Lng)
+	if r.ArrivalRadiusMeters != nil {
+		if radius := *r.ArrivalRadiusMeters; radius <= 0 || radius > maxArrivalRadiusMeters {
+			errs.Add("arrivalRadiusMeters", fmt.Sprintf("arrival radius must be greater than 0 and at most %d meters", maxArrivalRadiusMeters))
+		}
 	}
-	if r.Lat < -90 || r.Lat > 90 {
-		return errors.New("latitude must be between -90 and 90")
+	return errs.Err()
+}
+
+func (r *DestinationPatchRequest) Validate() error {
+	if r.Name == nil && r.Description == nil && r.Lat == nil && r.Lng == nil {
+		return errors.New("at least one of name, description or lat/lng is required")
 	}
-	if r.Lng < -180 || r.Lng > 180 {
-		return errors.New("longitude must be between -180 and 180")
+	var errs ValidationErrors
+	if r.Name != nil {
+		if strings.TrimSpace(*r.Name) == "" {
+			errs.Add("name", "destination name must not be empty")
+		} else if len(*r.Name) > 100 {
+			errs.Add("name", "destination name too long")
+		}
 	}
-	return nil
+	if r.Description != nil && len(*r.Description) > maxDestinationDescriptionLength {
+		errs.Add("description", fmt.Sprintf("description too long (max %d characters)", maxDestinationDescriptionLength))
+	}
+	if (r.Lat == nil) != (r.Lng == nil) {
+		errs.Add("lat", "lat and lng must be given together")
+	} else if r.Lat != nil {
+		validateLatLng(&errs, "lat", "lng", *r.Lat, *r.Lng)
+	}
+	return errs.Err()
 }
 
-func (r *DestinationRequest) ToDomain() *domain.Destination {
+// ToDomain converts the request to a storage update, trimming whitespace
+func (r *DestinationPatchRequest) ToDomain() domain.DestinationUpdate {
+	var update domain.DestinationUpdate
+	if r.Name != nil {
+		name := destinationName(*r.Name)
+		update.Name = &name
+	}
+	if r.Description != nil {
+		description := strings.TrimSpace(*r.Description)
+		update.Description = &description
+	}
+	if r.Lat != nil && r.Lng != nil {
+		update.Location = &domain.LatLng{Lat: *r.Lat, Lng: *r.Lng}
+	}
+	return update
+}
+
+func (r *DestinationSearchRequest) Validate() error {
+	var errs ValidationErrors
+	if strings.TrimSpace(r.Query) == "" {
+		errs.Add("query", "search query is required")
+	} else if len(r.Query) > 200 {
+		errs.Add("query", "search query too long")
+	}
+	return errs.Err()
+}
+
+func (r *AttentionRequest) Validate() error {
+	var errs ValidationErrors
+	if len(r.Reason) > 200 {
+		errs.Add("reason", "reason too long")
+	}
+	return errs.Err()
+}
+
+func (r *AnnouncementRequest) Validate() error {
+	var errs ValidationErrors
+	if strings.TrimSpace(r.Message) == "" {
+		errs.Add("message", "message is required")
+	} else if utf8.RuneCountInString(r.Message) > maxAnnouncementLength {
+		errs.Add("message", fmt.Sprintf("message must be at most %d characters", maxAnnouncementLength))
+	}
+	return errs.Err()
+}
+
+// ToDomain converts the request to a destination. Without an arrival radius
+// in the request, defaultRadiusMeters is used.
+func (r *DestinationRequest) ToDomain(defaultRadiusMeters float64) *domain.Destination {
+	destination := &domain.Destination{
+		Name:                destinationName(r.Name),
+		Description:         strings.TrimSpace(r.Description),
+		Lat:                 r.Lat,
+		Lng:                 r.Lng,
+		ArrivalRadiusMeters: defaultRadiusMeters,
+	}
+	if r.ArrivalRadiusMeters != nil {
+		destination.ArrivalRadiusMeters = *r.ArrivalRadiusMeters
+	}
+	return destination
+}
+
+// destinationName shortens a geocoded address to its first part, e.g. the
+// place name before the street and city
+func destinationName(name string) string {
 	// Smart name truncation: extract portion before first comma
-	name := r.Name
 	if commaIndex := strings.Index(name, ","); commaIndex != -1 {
 		name = name[:commaIndex]
 	}
@@ -94,29 +473,66 @@ func (r *DestinationRequest) ToDomain() *domain.Destination {
 	if len(name) > 100 {
 		name = name[:100]
 	}
+	return strings.TrimSpace(name)
+}
 
-	return &domain.Destination{
-		Name:        strings.TrimSpace(name),
-		Description: strings.TrimSpace(r.Description),
-		Lat:         r.Lat,
-		Lng:         r.Lng,
+func (r *InviteRequest) Validate() error {
+	var errs ValidationErrors
+	if r.MaxUses < 0 || r.MaxUses > MaxInviteUses {
+		errs.Add("maxUses", fmt.Sprintf("maxUses must be between 1 and %d", MaxInviteUses))
 	}
+	if r.ExpiresInMinutes < 0 || time.Duration(r.ExpiresInMinutes)*time.Minute > MaxInviteTTL {
+		errs.Add("expiresInMinutes", fmt.Sprintf("expiresInMinutes must be between 1 and %d", int(MaxInviteTTL.Minutes())))
+	}
+	return errs.Err()
 }
 
 func (r *CreateConvoyWithVerificationRequest) Validate() error {
-	if strings.TrimSpace(r.LeaderName) == "" {
-		return errors.New("leader name is required")
+	var errs ValidationErrors
+	validateOptionalConvoyName(&errs, r.Name)
+	validateLeaderName(&errs, r.LeaderName)
+	validateConvoyMetadata(&errs, r.Metadata)
+	if strings.TrimSpace(r.Email) == "" {
+		errs.Add("email", "email is required")
+	} else if !isValidEmail(r.Email) {
+		errs.Add("email", "invalid email format")
+	}
+	return errs.Err()
+}
+
+func (r *CreateConvoyWithSMSRequest) Validate() error {
+	var errs ValidationErrors
+	validateOptionalConvoyName(&errs, r.Name)
+	validateLeaderName(&errs, r.LeaderName)
+	validateConvoyMetadata(&errs, r.Metadata)
+	if strings.TrimSpace(r.Phone) == "" {
+		errs.Add("phone", "phone number is required")
+	} else if !sms.IsValidPhone(r.Phone) {
+		errs.Add("phone", "invalid phone number format (expected E.164, e.g. +15551234567)")
 	}
-	if len(r.LeaderName) > 50 {
-		return errors.New("leader name too long (max 50 characters)")
+	return errs.Err()
+}
+
+func validateLeaderName(errs *ValidationErrors, leaderName string) {
+	if strings.TrimSpace(leaderName) == "" {
+		errs.Add("leaderName", "leader name is required")
+	} else if len(leaderName) > 50 {
+		errs.Add("leaderName", "leader name too long (max 50 characters)")
 	}
-	if strings.TrimSpace(r.Email) == "" {
-		return errors.New("email is required")
+}
+
+func (r *VerifySMSRequest) Validate() error {
+	var errs ValidationErrors
+	valid := len(r.Code) == 6
+	for _, c := range r.Code {
+		if c < '0' || c > '9' {
+			valid = false
+		}
 	}
-	if !isValidEmail(r.Email) {
-		return errors.New("invalid email format")
+	if !valid {
+		errs.Add("code", "verification code must be 6 digits")
 	}
-	return nil
+	return errs.Err()
 }
 
 func isValidEmail(email string) bool {
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMemberRequestValidateSanitizesName(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{"plain name", "Alice", "Alice", false},
+		{"surrounding whitespace", "  Alice  ", "Alice", false},
+		{"script tag", "<script>alert(1)</script>", "scriptalert(1)/script", false},
+		{"angle brackets only", "<<>>", "", true},
+		{"control character", "Ali\x00ce", "", true},
+		{"newline", "Alice\nBob", "", true},
+		{"empty", "   ", "", true},
+		{"50 characters", strings.Repeat("a", 50), strings.Repeat("a", 50), false},
+		{"51 characters", strings.Repeat("a", 51), "", true},
+		{"50 multibyte characters", strings.Repeat("é", 50), strings.Repeat("é", 50), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := MemberRequest{Name: tt.input}
+			err := req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if err == nil && req.Name != tt.expected {
+				t.Errorf("Validate(%q) name = %q, expected %q", tt.input, req.Name, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateReportsAllFieldErrors(t *testing.T) {
+	req := CreateConvoyWithSMSRequest{Name: strings.Repeat("n", 101), LeaderName: " ", Phone: "555"}
+	err := req.Validate()
+
+	fieldErrs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("Expected ValidationErrors, got %T %v", err, err)
+	}
+	fields := make([]string, len(fieldErrs))
+	for i, fieldErr := range fieldErrs {
+		fields[i] = fieldErr.Field
+	}
+	if strings.Join(fields, ",") != "name,leaderName,phone" {
+		t.Errorf("Expected errors for name, leaderName and phone, got %v", fieldErrs)
+	}
+
+	location := LocationRequest{Lat: 91, Lng: -181}
+	if err := location.Validate(); err == nil || len(err.(ValidationErrors)) != 2 {
+		t.Errorf("Expected both coordinates to be reported, got %v", err)
+	}
+	location = LocationRequest{Lat: 40, Lng: -74}
+	if err := location.Validate(); err != nil {
+		t.Errorf("Expected valid location to pass, got %v", err)
+	}
+
+	negative, zero := -1.0, 0.0
+	location = LocationRequest{Lat: 40, Lng: -74, Accuracy: &negative}
+	if err := location.Validate(); err == nil {
+		t.Error("Expected negative accuracy to be rejected")
+	}
+	location.Accuracy = &zero
+	if err := location.Validate(); err != nil {
+		t.Errorf("Expected zero accuracy to pass, got %v", err)
+	}
+}
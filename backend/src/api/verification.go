@@ -0,0 +1,43 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// VerificationStatusResponse reports whether a convoy's email verification has
+// completed. The token itself is never included.
+type VerificationStatusResponse struct {
+	Verified  bool      `json:"verified"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Expired   bool      `json:"expired"` // the link expired before it was used; resend to get a new one
+}
+
+// HandleGetVerificationStatus lets a client that created a convoy with
+// verification poll until the leader clicks the emailed link.
+func (a *API) HandleGetVerificationStatus(w http.ResponseWriter, r *http.Request) {
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	convoy, err := a.storage.GetConvoy(r.Context(), convoyID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, errors.New("convoy not found"))
+		return
+	}
+
+	verification, err := a.storage.GetVerification(r.Context(), convoyID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, errors.New("verification not found"))
+		return
+	}
+
+	verified := convoy.IsVerified || verification.IsVerified()
+	writeJSON(w, http.StatusOK, VerificationStatusResponse{
+		Verified:  verified,
+		ExpiresAt: verification.ExpiresAt,
+		Expired:   !verified && verification.IsExpired(),
+	})
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandleGetVerificationStatus(t *testing.T) {
+	_, memStorage, mux := newTestAPI(t)
+	ctx := context.Background()
+
+	getStatus := func(convoyID string) (int, VerificationStatusResponse, string) {
+		t.Helper()
+		rec := doRequest(mux, http.MethodGet, "/api/convoys/"+convoyID+"/verification", "")
+		var status VerificationStatusResponse
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+		}
+		return rec.Code, status, rec.Body.String()
+	}
+
+	pending, err := memStorage.CreateConvoyWithVerification(ctx, "leader@example.com", "Leader", "pending-token", time.Now().Add(30*time.Minute))
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	code, status, body := getStatus(pending.ID)
+	if code != http.StatusOK || status.Verified || status.Expired {
+		t.Errorf("Expected a pending verification, got %d %+v", code, status)
+	}
+	if strings.Contains(body, "pending-token") {
+		t.Errorf("Expected the token not to be exposed, got %s", body)
+	}
+
+	if _, err := memStorage.VerifyConvoy(ctx, "pending-token"); err != nil {
+		t.Fatalf("Failed to verify convoy: %v", err)
+	}
+	if code, status, _ := getStatus(pending.ID); code != http.StatusOK || !status.Verified || status.Expired {
+		t.Errorf("Expected a verified convoy, got %d %+v", code, status)
+	}
+
+	expired, err := memStorage.CreateConvoyWithVerification(ctx, "late@example.com", "Late", "expired-token", time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	if code, status, _ := getStatus(expired.ID); code != http.StatusOK || status.Verified || !status.Expired {
+		t.Errorf("Expected an expired verification, got %d %+v", code, status)
+	}
+
+	// Convoys created without verification have nothing to report
+	plain, err := memStorage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	if code, _, _ := getStatus(plain.ID); code != http.StatusNotFound {
+		t.Errorf("Expected 404 without a verification, got %d", code)
+	}
+	if code, _, _ := getStatus(missingConvoyID); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown convoy, got %d", code)
+	}
+}
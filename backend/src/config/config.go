@@ -1,30 +1,436 @@
 package config
 
 import (
+    "convoy-app/backend/src/domain"
+    "log"
     "os"
     "strconv"
+    "strings"
     "time"
 )
 
+// Monitoring defaults - Conservative values to reduce false alerts while maintaining safety
+const (
+    DefaultMaxDistanceFromConvoy        = 3.0               // kilometers - accommodates normal highway convoy spread and traffic separation
+    DefaultDisconnectedTimeout          = 60 * time.Second  // reduces false alerts from temporary GPS/network issues while maintaining timely detection
+    DefaultInactiveCleanupTimeout       = 1 * time.Hour     // time without location updates before a connection may be closed
+    DefaultWatchingTimeout              = 3 * time.Minute   // time a socket may go without a pong or message before it counts as dead
+    DefaultScatteredThreshold           = 0.5               // 50% of members far from center
+    DefaultSingleMemberScatteredTimeout = 5 * time.Minute   // for single-member convoys
+    DefaultScatteredReminderInterval    = 15 * time.Minute  // a convoy that stays scattered is re-alerted this often
+    DefaultScatteredEscalateAfter       = 30 * time.Minute  // scattered this long raises an escalated alert
+    DefaultHeartbeatTimeout             = 90 * time.Second  // clients heartbeat every ~30s; tolerates a couple of missed frames
+    DefaultStalledSpeedKmh              = 5.0               // walking pace; slower than this counts as stopped
+    DefaultStalledDuration              = 3 * time.Minute   // long enough to ignore traffic lights and short queues
+    DefaultLeaderReassignGrace          = 2 * time.Minute   // rides out tunnels and dead zones before handing over leadership
+    DefaultArrivalRadiusMeters          = 200.0             // a member this close to the destination has arrived
+    DefaultArrivedRemovalGrace          = 10 * time.Minute  // long enough to reconnect after parking before being dropped
+    DefaultDisconnectedRemovalGrace     = 0                 // disconnected members are kept until they leave
+    DefaultReconnectGracePeriod         = 10 * time.Second  // rides out mobile network blips before alerting that a member disconnected
+    DefaultJoinGracePeriod              = 30 * time.Second  // time a new member has to open its WebSocket before counting as disconnected
+    DefaultLaggingEnterMarginKm         = 0.2               // beyond MaxDistanceFromConvoy needed to become lagging; absorbs GPS jitter
+    DefaultLaggingExitMarginKm          = 0.2               // within MaxDistanceFromConvoy needed to stop lagging
+    DefaultMonitoringInterval           = 10 * time.Second  // time between convoy health checks
+    MinMonitoringInterval               = 1 * time.Second   // anything faster just burns CPU on GPS noise
+)
+
+// Load shedding defaults, as ratios of MaxTotalConnections
+const (
+    DefaultLoadShedHighWater = 0.9
+    DefaultLoadShedLowWater  = 0.8
+)
+
+// Convoy center strategies, the reference point lagging is measured from
+const (
+    CenterStrategyCentroid = "centroid" // average position of connected members
+    CenterStrategyLeader   = "leader"   // the leader's position; falls back to the centroid while the leader is away
+    CenterStrategyMedian   = "median"   // per-axis median of connected members; ignores a few far-off stragglers
+)
+
+// Broadcast modes for routine location updates
+const (
+    BroadcastModeSnapshot = "snapshot" // resend the whole convoy
+    BroadcastModeDelta    = "delta"    // send only the member that moved, as MEMBER_MOVED
+)
+
+// DefaultBroadcastForcedEvents are the safety-critical alerts whose convoy
+// update is never throttled
+var DefaultBroadcastForcedEvents = []string{domain.EventMemberDisconnected, domain.EventConvoyScattered, domain.EventConvoyScatteredEscalated}
+
 type Config struct {
     Port                    string
+    TLSCertFile             string // serve HTTPS directly when both TLS files are set
+    TLSKeyFile              string
     MaxConnectionsPerConvoy int
     MaxTotalConnections     int
+    MaxSpectatorsPerConvoy  int // read-only watchers, limited separately from members
+    MaxMembersPerConvoy     int
+    MaxConvoys              int // convoys held in memory at once; creation fails with 503 beyond it (0 means unlimited)
+
+    // Once total connections reach LoadShedHighWater of MaxTotalConnections the
+    // server refuses new convoys and connections, until they fall below
+    // LoadShedLowWater. Both are ratios between 0 and 1; 0 disables shedding.
+    LoadShedHighWater float64
+    LoadShedLowWater  float64
+
     RequestTimeout          time.Duration
+    HTTPReadTimeout         time.Duration
+    HTTPIdleTimeout         time.Duration
+
+    // JSON responses of at least HTTPGzipThreshold bytes are gzipped for clients that accept it
+    HTTPGzipEnabled   bool
+    HTTPGzipThreshold int // bytes
+
+    // Convoy updates caused by routine changes (e.g. location updates) are sent at
+    // most once per BroadcastThrottleInterval; updates accompanying one of
+    // BroadcastForcedEvents are always sent immediately
+    BroadcastThrottleInterval time.Duration
+    BroadcastForcedEvents     []string
+    // BroadcastMode decides what a routine location update sends; structural
+    // changes (joins, leaves, destination) always send the whole convoy
+    BroadcastMode string
+
+    // Each convoy may have LocationUpdateBudget location updates broadcast per
+    // LocationUpdateBudgetWindow; past that, only a member's first update in the
+    // window is broadcast. Locations are always stored. 0 disables the budget.
+    LocationUpdateBudget       int
+    LocationUpdateBudgetWindow time.Duration
+
+    // Location updates from a member arriving less than LocationMinInterval
+    // after its last accepted one are rejected with 429; 0 disables the limit
+    LocationMinInterval time.Duration
+
+    // A member may raise an attention signal to the convoy at most once per AttentionMinInterval
+    AttentionMinInterval time.Duration
+
     WSReadTimeout           time.Duration
     WSWriteTimeout          time.Duration
     WSPingPeriod           time.Duration
+
+    // AdminToken guards the /api/admin endpoints; they are disabled when empty
+    AdminToken string
+
+    // Browser origins allowed on top of the built-in development ones, for
+    // both CORS and WebSocket upgrades. ALLOWED_ORIGIN, a single origin, is
+    // still honoured.
+    AllowedOrigins        []string
+    AllowedOriginSuffixes []string // HTTPS host suffixes, e.g. ".convoy.example.com"
+
+    // Record each convoy verification attempt for GET /api/admin/verification-attempts
+    VerificationAudit bool
+
+    // Connect and log in to the SMTP server at startup to catch bad email settings early
+    EmailVerifyOnStartup bool
+
+    // TrustProxy honours X-Forwarded-Proto/X-Forwarded-Host when building
+    // verification links and APP_BASE_URL isn't set; only enable it behind a
+    // proxy that sets them
+    TrustProxy bool
+
+    // Monitor alerts are POSTed to AlertWebhookURL when set; AlertWebhookEvents
+    // limits which event types are forwarded (all when empty)
+    AlertWebhookURL    string
+    AlertWebhookEvents []string
+
+    // Reverse geocoding of member locations; disabled unless GeocodeProvider is "nominatim"
+    GeocodeProvider  string
+    GeocodeURL       string // defaults to the public Nominatim instance
+    GeocodeUserAgent string // Nominatim's usage policy requires an identifying User-Agent
+
+    // permessage-deflate trades server CPU for bandwidth, so it is off by default;
+    // frames smaller than the threshold are always sent uncompressed
+    WSCompressionEnabled   bool
+    WSCompressionThreshold int // bytes
+
+    // Require a member connect token on WebSocket connections; off until all clients send one
+    WSRequireConnectToken bool
+
+    // Refuse a member's second WebSocket instead of replacing the first one
+    WSSingleSession bool
+
+    // Resend critical alerts a client has not ACKed; the delay doubles after each resend
+    WSAckMaxRetries int
+    WSAckRetryDelay time.Duration
+
+    // The last broadcast of each convoy is replayed to new connections for this long; 0 disables replay
+    WSReplayRetention time.Duration
+    // Stamp every WebSocket message with a per-convoy sequence number so clients can drop stale frames
+    WSSequenceNumbers bool
+    // A connection whose send buffer is full gets one more try this much later before it is dropped; 0 drops it at once
+    WSBroadcastRetryBackoff time.Duration
+
+    // Number convoys 00...01, 00...02 and so on instead of randomly, for stable
+    // local testing and documentation examples. Development only: the IDs are
+    // guessable, so it is refused when the server terminates TLS.
+    DevDeterministicIDs bool
+
+    // Monitoring loop: the jitter adds a random delay of up to this much to each
+    // tick so that several instances don't check convoys in lockstep
+    MonitoringInterval time.Duration
+    MonitoringJitter   time.Duration
+
+    // Monitoring thresholds
+    MaxDistanceFromConvoy        float64 // kilometers from convoy center before a member is lagging
+    CenterStrategy               string  // how the convoy center is found; one of the CenterStrategy constants
+    LaggingEnterMarginKm         float64 // a member becomes lagging beyond MaxDistanceFromConvoy plus this margin
+    LaggingExitMarginKm          float64 // and recovers only within MaxDistanceFromConvoy minus this one
+    DisconnectedTimeout          time.Duration
+    InactiveCleanupTimeout       time.Duration // no location updates for this long ends tracking; the connection is closed if it isn't watching either
+    WatchingTimeout              time.Duration // a socket with no pong or message for this long is no longer watching
+    ScatteredThreshold           float64 // ratio of lagging/disconnected members, between 0 and 1
+    SingleMemberScatteredTimeout time.Duration
+    ScatteredReminderInterval    time.Duration // re-send CONVOY_SCATTERED this often while a convoy stays scattered (0 disables)
+    ScatteredEscalateAfter       time.Duration // send CONVOY_SCATTERED_ESCALATED once a convoy has been scattered this long (0 disables)
+    HeartbeatTimeout             time.Duration // a member whose client stops heartbeating this long is treated as disconnected
+    StalledSpeedKmh              float64       // average speed below which a member is considered stopped
+    StalledDuration              time.Duration // how long a member must stay stopped, while the convoy moves, to be stalled
+    LeaderReassignGrace          time.Duration // how long the leader may stay disconnected before leadership moves on
+    ArrivalRadiusMeters          float64       // distance from the destination within which a member has arrived
+    AutoRemoveArrived            bool          // drop members who arrived and then disconnected for ArrivedRemovalGrace
+    ArrivedRemovalGrace          time.Duration
+    DisconnectedRemovalGrace     time.Duration // drop any member disconnected this long; within it a reconnect keeps their place (0 disables)
+    JoinGracePeriod              time.Duration // a member without a WebSocket this soon after joining is connecting, not disconnected
+    RequireStart                 bool          // keep convoys quiet, as if paused, until they are started
+    ReconnectGracePeriod         time.Duration // a member whose WebSocket closed is reconnecting, not disconnected, for this long (0 disables)
+
+    // GPS outlier filtering: a point implying a speed above MaxMemberSpeedKmh is
+    // dropped, but only when it arrives within LocationOutlierWindow of the last one
+    MaxMemberSpeedKmh     float64
+    LocationOutlierWindow time.Duration
+
+    // Locations reported with an accuracy worse than LowAccuracyMeters are
+    // stored but don't raise lagging or stalled alerts; 0 disables the check
+    LowAccuracyMeters float64
 }
 
 func Load() *Config {
-    return &Config{
+    cfg := &Config{
         Port:                    getEnv("PORT", "8080"),
+        TLSCertFile:             getEnv("TLS_CERT_FILE", ""),
+        TLSKeyFile:              getEnv("TLS_KEY_FILE", ""),
         MaxConnectionsPerConvoy: getEnvInt("MAX_CONNECTIONS_PER_CONVOY", 50),
         MaxTotalConnections:     getEnvInt("MAX_TOTAL_CONNECTIONS", 1000),
+        MaxSpectatorsPerConvoy:  getEnvInt("MAX_SPECTATORS_PER_CONVOY", 200),
+        MaxMembersPerConvoy:     getEnvInt("MAX_MEMBERS_PER_CONVOY", 50),
+        MaxConvoys:              getEnvInt("MAX_CONVOYS", 10000),
+        LoadShedHighWater:       getEnvFloat("LOAD_SHED_HIGH_WATER", DefaultLoadShedHighWater),
+        LoadShedLowWater:        getEnvFloat("LOAD_SHED_LOW_WATER", DefaultLoadShedLowWater),
         RequestTimeout:          getEnvDuration("REQUEST_TIMEOUT", 30*time.Second),
+        HTTPReadTimeout:         getEnvDuration("HTTP_READ_TIMEOUT", 15*time.Second),
+        HTTPIdleTimeout:         getEnvDuration("HTTP_IDLE_TIMEOUT", 120*time.Second),
+        HTTPGzipEnabled:         getEnvBool("HTTP_GZIP_ENABLED", true),
+        HTTPGzipThreshold:       getEnvInt("HTTP_GZIP_THRESHOLD", 1024),
+
+        BroadcastThrottleInterval: getEnvDuration("BROADCAST_THROTTLE_INTERVAL", time.Second),
+        BroadcastForcedEvents:     getEnvListOr("BROADCAST_FORCED_EVENTS", DefaultBroadcastForcedEvents),
+        BroadcastMode:             getEnv("BROADCAST_MODE", BroadcastModeSnapshot),
+
+        LocationUpdateBudget:       getEnvInt("LOCATION_UPDATE_BUDGET", 100),
+        LocationUpdateBudgetWindow: getEnvDuration("LOCATION_UPDATE_BUDGET_WINDOW", 10*time.Second),
+        LocationMinInterval:        getEnvDuration("LOCATION_MIN_INTERVAL", 2*time.Second),
+        AttentionMinInterval:       getEnvDuration("ATTENTION_MIN_INTERVAL", 30*time.Second),
+
         WSReadTimeout:           getEnvDuration("WS_READ_TIMEOUT", 60*time.Second),
         WSWriteTimeout:          getEnvDuration("WS_WRITE_TIMEOUT", 10*time.Second),
         WSPingPeriod:           getEnvDuration("WS_PING_PERIOD", 54*time.Second),
+        WSCompressionEnabled:   getEnvBool("WS_COMPRESSION_ENABLED", false),
+        WSCompressionThreshold: getEnvInt("WS_COMPRESSION_THRESHOLD", 1024),
+        WSRequireConnectToken:  getEnvBool("WS_REQUIRE_CONNECT_TOKEN", false),
+        WSSingleSession:        getEnvBool("WS_SINGLE_SESSION", false),
+        WSAckMaxRetries:        getEnvInt("WS_ACK_MAX_RETRIES", 3),
+        WSAckRetryDelay:        getEnvDuration("WS_ACK_RETRY_DELAY", 2*time.Second),
+        WSReplayRetention:      getEnvDuration("WS_REPLAY_RETENTION", 10*time.Minute),
+        WSSequenceNumbers:      getEnvBool("WS_SEQUENCE_NUMBERS", false),
+        WSBroadcastRetryBackoff: getEnvDuration("WS_BROADCAST_RETRY_BACKOFF", 50*time.Millisecond),
+        DevDeterministicIDs:    getEnvBool("DEV_DETERMINISTIC_IDS", false),
+        AdminToken:             getEnv("ADMIN_TOKEN", ""),
+        AllowedOrigins:         allowedOrigins(),
+        AllowedOriginSuffixes:  getEnvList("ALLOWED_ORIGIN_SUFFIXES"),
+        VerificationAudit:      getEnvBool("VERIFICATION_AUDIT", false),
+        TrustProxy:             getEnvBool("TRUST_PROXY", false),
+        EmailVerifyOnStartup:   getEnvBool("EMAIL_VERIFY_ON_STARTUP", false),
+        AlertWebhookURL:        getEnv("ALERT_WEBHOOK_URL", ""),
+        AlertWebhookEvents:     getEnvList("ALERT_WEBHOOK_EVENTS"),
+        GeocodeProvider:        getEnv("GEOCODE_PROVIDER", ""),
+        GeocodeURL:             getEnv("GEOCODE_URL", ""),
+        GeocodeUserAgent:       getEnv("GEOCODE_USER_AGENT", "convoy-app"),
+
+        MonitoringInterval:           getEnvDuration("MONITOR_INTERVAL", DefaultMonitoringInterval),
+        MonitoringJitter:             getEnvDuration("MONITOR_INTERVAL_JITTER", 0),
+        MaxDistanceFromConvoy:        getEnvFloat("MONITOR_MAX_DISTANCE_KM", DefaultMaxDistanceFromConvoy),
+        CenterStrategy:               getEnv("MONITOR_CENTER_STRATEGY", CenterStrategyCentroid),
+        LaggingEnterMarginKm:         getEnvFloat("MONITOR_LAGGING_ENTER_MARGIN_KM", DefaultLaggingEnterMarginKm),
+        LaggingExitMarginKm:          getEnvFloat("MONITOR_LAGGING_EXIT_MARGIN_KM", DefaultLaggingExitMarginKm),
+        DisconnectedTimeout:          getEnvDuration("MONITOR_DISCONNECTED_TIMEOUT", DefaultDisconnectedTimeout),
+        InactiveCleanupTimeout:       getEnvDuration("MONITOR_INACTIVE_CLEANUP_TIMEOUT", DefaultInactiveCleanupTimeout),
+        WatchingTimeout:              getEnvDuration("MONITOR_WATCHING_TIMEOUT", DefaultWatchingTimeout),
+        ScatteredThreshold:           getEnvFloat("MONITOR_SCATTERED_THRESHOLD", DefaultScatteredThreshold),
+        SingleMemberScatteredTimeout: getEnvDuration("MONITOR_SINGLE_MEMBER_SCATTERED_TIMEOUT", DefaultSingleMemberScatteredTimeout),
+        ScatteredReminderInterval:    getEnvDuration("MONITOR_SCATTERED_REMINDER_INTERVAL", DefaultScatteredReminderInterval),
+        ScatteredEscalateAfter:       getEnvDuration("MONITOR_SCATTERED_ESCALATE_AFTER", DefaultScatteredEscalateAfter),
+        HeartbeatTimeout:             getEnvDuration("MONITOR_HEARTBEAT_TIMEOUT", DefaultHeartbeatTimeout),
+        StalledSpeedKmh:              getEnvFloat("MONITOR_STALLED_SPEED_KMH", DefaultStalledSpeedKmh),
+        StalledDuration:              getEnvDuration("MONITOR_STALLED_DURATION", DefaultStalledDuration),
+        LeaderReassignGrace:          getEnvDuration("MONITOR_LEADER_REASSIGN_GRACE", DefaultLeaderReassignGrace),
+        ArrivalRadiusMeters:          getEnvFloat("ARRIVAL_RADIUS_METERS", DefaultArrivalRadiusMeters),
+        AutoRemoveArrived:            getEnvBool("MONITOR_AUTO_REMOVE_ARRIVED", false),
+        ArrivedRemovalGrace:          getEnvDuration("MONITOR_ARRIVED_REMOVAL_GRACE", DefaultArrivedRemovalGrace),
+        DisconnectedRemovalGrace:     getEnvDuration("MONITOR_DISCONNECTED_REMOVAL_GRACE", DefaultDisconnectedRemovalGrace),
+        JoinGracePeriod:              getEnvDuration("MONITOR_JOIN_GRACE_PERIOD", DefaultJoinGracePeriod),
+        RequireStart:                 getEnvBool("MONITOR_REQUIRE_START", false),
+        ReconnectGracePeriod:         getEnvDuration("MONITOR_RECONNECT_GRACE_PERIOD", DefaultReconnectGracePeriod),
+
+        MaxMemberSpeedKmh:     getEnvFloat("MAX_MEMBER_SPEED_KMH", 300),
+        LocationOutlierWindow: getEnvDuration("LOCATION_OUTLIER_WINDOW", 30*time.Second),
+        LowAccuracyMeters:     getEnvFloat("LOCATION_LOW_ACCURACY_METERS", 100),
+    }
+    cfg.validateMonitoring()
+    cfg.validateTLS()
+    cfg.validateLoadShedding()
+    cfg.validateBroadcastMode()
+    cfg.validateDevIDs()
+    return cfg
+}
+
+// TLSEnabled reports whether the server should terminate TLS itself
+func (c *Config) TLSEnabled() bool {
+    return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+// validateTLS exits when only one of the TLS files is configured. Serving
+// plain HTTP to an operator who asked for HTTPS would be a silent downgrade.
+func (c *Config) validateTLS() {
+    if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+        log.Fatalf("ERROR: TLS_CERT_FILE and TLS_KEY_FILE must both be set to enable HTTPS")
+    }
+}
+
+// validateLoadShedding replaces water marks outside 0-1, and a low-water mark
+// above the high one, with defaults
+func (c *Config) validateLoadShedding() {
+    if c.LoadShedHighWater < 0 || c.LoadShedHighWater > 1 {
+        log.Printf("WARNING: LOAD_SHED_HIGH_WATER must be between 0 and 1, using default %.2f", DefaultLoadShedHighWater)
+        c.LoadShedHighWater = DefaultLoadShedHighWater
+    }
+    if c.LoadShedLowWater < 0 || c.LoadShedLowWater > c.LoadShedHighWater {
+        low := min(DefaultLoadShedLowWater, c.LoadShedHighWater)
+        log.Printf("WARNING: LOAD_SHED_LOW_WATER must be between 0 and the high-water mark, using %.2f", low)
+        c.LoadShedLowWater = low
+    }
+}
+
+// validateBroadcastMode falls back to full snapshots for unknown modes, which
+// every client understands
+func (c *Config) validateBroadcastMode() {
+    switch c.BroadcastMode {
+    case BroadcastModeSnapshot, BroadcastModeDelta:
+    default:
+        log.Printf("WARNING: Unknown BROADCAST_MODE %q, using %q", c.BroadcastMode, BroadcastModeSnapshot)
+        c.BroadcastMode = BroadcastModeSnapshot
+    }
+}
+
+// validateDevIDs turns off deterministic IDs on a server that terminates TLS
+// itself, which is how it runs in production; anyone could guess the convoy
+// IDs there
+func (c *Config) validateDevIDs() {
+    if c.DevDeterministicIDs && c.TLSEnabled() {
+        log.Printf("WARNING: DEV_DETERMINISTIC_IDS is for development only and is ignored while TLS is enabled")
+        c.DevDeterministicIDs = false
+    }
+}
+
+// validateMonitoring replaces nonsensical monitoring thresholds with defaults
+func (c *Config) validateMonitoring() {
+    if c.MonitoringInterval < MinMonitoringInterval {
+        log.Printf("WARNING: MONITOR_INTERVAL must be at least %v, using default %v", MinMonitoringInterval, DefaultMonitoringInterval)
+        c.MonitoringInterval = DefaultMonitoringInterval
+    }
+    if c.MonitoringJitter < 0 {
+        log.Printf("WARNING: MONITOR_INTERVAL_JITTER must not be negative, disabling jitter")
+        c.MonitoringJitter = 0
+    }
+    switch c.CenterStrategy {
+    case CenterStrategyCentroid, CenterStrategyLeader, CenterStrategyMedian:
+    default:
+        log.Printf("WARNING: Unknown MONITOR_CENTER_STRATEGY %q, using %q", c.CenterStrategy, CenterStrategyCentroid)
+        c.CenterStrategy = CenterStrategyCentroid
+    }
+    if c.MaxDistanceFromConvoy <= 0 {
+        log.Printf("WARNING: MONITOR_MAX_DISTANCE_KM must be positive, using default %.1f", DefaultMaxDistanceFromConvoy)
+        c.MaxDistanceFromConvoy = DefaultMaxDistanceFromConvoy
+    }
+    if c.LaggingEnterMarginKm < 0 {
+        log.Printf("WARNING: MONITOR_LAGGING_ENTER_MARGIN_KM must not be negative, using default %.1f", DefaultLaggingEnterMarginKm)
+        c.LaggingEnterMarginKm = DefaultLaggingEnterMarginKm
+    }
+    if c.LaggingExitMarginKm < 0 || c.LaggingExitMarginKm >= c.MaxDistanceFromConvoy {
+        log.Printf("WARNING: MONITOR_LAGGING_EXIT_MARGIN_KM must be between 0 and the max distance, using default %.1f", DefaultLaggingExitMarginKm)
+        c.LaggingExitMarginKm = DefaultLaggingExitMarginKm
+    }
+    if c.DisconnectedTimeout <= 0 {
+        log.Printf("WARNING: MONITOR_DISCONNECTED_TIMEOUT must be positive, using default %v", DefaultDisconnectedTimeout)
+        c.DisconnectedTimeout = DefaultDisconnectedTimeout
+    }
+    if c.InactiveCleanupTimeout <= c.DisconnectedTimeout {
+        log.Printf("WARNING: MONITOR_INACTIVE_CLEANUP_TIMEOUT must exceed the disconnected timeout, using default %v", DefaultInactiveCleanupTimeout)
+        c.InactiveCleanupTimeout = DefaultInactiveCleanupTimeout
+    }
+    if c.WatchingTimeout <= 0 {
+        log.Printf("WARNING: MONITOR_WATCHING_TIMEOUT must be positive, using default %v", DefaultWatchingTimeout)
+        c.WatchingTimeout = DefaultWatchingTimeout
+    }
+    if c.ScatteredThreshold <= 0 || c.ScatteredThreshold > 1 {
+        log.Printf("WARNING: MONITOR_SCATTERED_THRESHOLD must be between 0 and 1, using default %.2f", DefaultScatteredThreshold)
+        c.ScatteredThreshold = DefaultScatteredThreshold
+    }
+    if c.SingleMemberScatteredTimeout <= 0 {
+        log.Printf("WARNING: MONITOR_SINGLE_MEMBER_SCATTERED_TIMEOUT must be positive, using default %v", DefaultSingleMemberScatteredTimeout)
+        c.SingleMemberScatteredTimeout = DefaultSingleMemberScatteredTimeout
+    }
+    if c.ScatteredReminderInterval < 0 {
+        log.Printf("WARNING: MONITOR_SCATTERED_REMINDER_INTERVAL must not be negative, using default %v", DefaultScatteredReminderInterval)
+        c.ScatteredReminderInterval = DefaultScatteredReminderInterval
+    }
+    if c.ScatteredEscalateAfter < 0 {
+        log.Printf("WARNING: MONITOR_SCATTERED_ESCALATE_AFTER must not be negative, using default %v", DefaultScatteredEscalateAfter)
+        c.ScatteredEscalateAfter = DefaultScatteredEscalateAfter
+    }
+    if c.HeartbeatTimeout <= 0 {
+        log.Printf("WARNING: MONITOR_HEARTBEAT_TIMEOUT must be positive, using default %v", DefaultHeartbeatTimeout)
+        c.HeartbeatTimeout = DefaultHeartbeatTimeout
+    }
+    if c.StalledSpeedKmh <= 0 {
+        log.Printf("WARNING: MONITOR_STALLED_SPEED_KMH must be positive, using default %.1f", DefaultStalledSpeedKmh)
+        c.StalledSpeedKmh = DefaultStalledSpeedKmh
+    }
+    if c.StalledDuration <= 0 {
+        log.Printf("WARNING: MONITOR_STALLED_DURATION must be positive, using default %v", DefaultStalledDuration)
+        c.StalledDuration = DefaultStalledDuration
+    }
+    if c.LeaderReassignGrace <= 0 {
+        log.Printf("WARNING: MONITOR_LEADER_REASSIGN_GRACE must be positive, using default %v", DefaultLeaderReassignGrace)
+        c.LeaderReassignGrace = DefaultLeaderReassignGrace
+    }
+    if c.ArrivalRadiusMeters <= 0 {
+        log.Printf("WARNING: ARRIVAL_RADIUS_METERS must be positive, using default %.0f", DefaultArrivalRadiusMeters)
+        c.ArrivalRadiusMeters = DefaultArrivalRadiusMeters
+    }
+    if c.ArrivedRemovalGrace <= 0 {
+        log.Printf("WARNING: MONITOR_ARRIVED_REMOVAL_GRACE must be positive, using default %v", DefaultArrivedRemovalGrace)
+        c.ArrivedRemovalGrace = DefaultArrivedRemovalGrace
+    }
+    if c.DisconnectedRemovalGrace < 0 {
+        log.Printf("WARNING: MONITOR_DISCONNECTED_REMOVAL_GRACE must not be negative, disabling removal of disconnected members")
+        c.DisconnectedRemovalGrace = 0
+    }
+    if c.ReconnectGracePeriod < 0 {
+        log.Printf("WARNING: MONITOR_RECONNECT_GRACE_PERIOD must not be negative, using default %v", DefaultReconnectGracePeriod)
+        c.ReconnectGracePeriod = DefaultReconnectGracePeriod
+    }
+    if c.JoinGracePeriod < 0 {
+        log.Printf("WARNING: MONITOR_JOIN_GRACE_PERIOD must not be negative, using default %v", DefaultJoinGracePeriod)
+        c.JoinGracePeriod = DefaultJoinGracePeriod
     }
 }
 
@@ -35,6 +441,34 @@ func getEnv(key, defaultValue string) string {
     return defaultValue
 }
 
+// getEnvList splits a comma-separated variable, dropping empty entries
+func getEnvList(key string) []string {
+    var values []string
+    for _, value := range strings.Split(os.Getenv(key), ",") {
+        if value = strings.TrimSpace(value); value != "" {
+            values = append(values, value)
+        }
+    }
+    return values
+}
+
+// allowedOrigins merges ALLOWED_ORIGINS with the older single ALLOWED_ORIGIN
+func allowedOrigins() []string {
+    origins := getEnvList("ALLOWED_ORIGINS")
+    if origin := strings.TrimSpace(os.Getenv("ALLOWED_ORIGIN")); origin != "" {
+        origins = append(origins, origin)
+    }
+    return origins
+}
+
+// getEnvListOr is getEnvList with a default for when the variable is unset or empty
+func getEnvListOr(key string, defaultValue []string) []string {
+    if values := getEnvList(key); len(values) > 0 {
+        return values
+    }
+    return defaultValue
+}
+
 func getEnvInt(key string, defaultValue int) int {
     if value := os.Getenv(key); value != "" {
         if intValue, err := strconv.Atoi(value); err == nil {
@@ -44,6 +478,24 @@ func getEnvInt(key string, defaultValue int) int {
     return defaultValue
 }
 
+func getEnvBool(key string, defaultValue bool) bool {
+    if value := os.Getenv(key); value != "" {
+        if boolValue, err := strconv.ParseBool(value); err == nil {
+            return boolValue
+        }
+    }
+    return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+    if value := os.Getenv(key); value != "" {
+        if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+            return floatValue
+        }
+    }
+    return defaultValue
+}
+
 func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
     if value := os.Getenv(key); value != "" {
         if duration, err := time.ParseDuration(value); err == nil {
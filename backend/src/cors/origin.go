@@ -0,0 +1,117 @@
+package cors
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// Policy decides which browser origins may call the API and open WebSockets.
+type Policy struct {
+	// Origins are exact origins such as "http://localhost:3000". Default ports
+	// are ignored, so "https://host" and "https://host:443" are equivalent.
+	Origins []string
+	// Suffixes are HTTPS host suffixes such as ".ngrok-free.dev"; the origin's
+	// hostname must end with the suffix, with the port stripped.
+	Suffixes []string
+	// PrivateNetworkPort allows plain-HTTP origins on this port whose host is a
+	// private or loopback IP (mobile testing against a dev server on the LAN).
+	PrivateNetworkPort string
+}
+
+// DefaultPolicy returns the built-in development allowlist: localhost, the
+// LAN HTTPS proxy and ngrok tunnels.
+func DefaultPolicy() *Policy {
+	return &Policy{
+		Origins: []string{
+			// Localhost development (both HTTP and HTTPS)
+			"http://localhost:3000", "http://127.0.0.1:3000",
+			"https://localhost:3000", "https://127.0.0.1:3000",
+			"http://localhost:8000", "http://127.0.0.1:8000",
+			"https://localhost:8000", "https://127.0.0.1:8000",
+			// Caddy HTTPS proxy
+			"https://192.168.1.18",
+		},
+		Suffixes: []string{
+			".ngrok-free.dev", // current free tier domain
+			".ngrok-free.app", // older free tier domain
+			".ngrok.app",      // paid tier domain
+			".ngrok.io",       // legacy domain
+		},
+		PrivateNetworkPort: "3000",
+	}
+}
+
+// NewPolicy returns the built-in allowlist extended with configured origins
+// and HTTPS host suffixes, e.g. a production domain.
+func NewPolicy(origins, suffixes []string) *Policy {
+	policy := DefaultPolicy()
+	policy.Origins = append(policy.Origins, origins...)
+	policy.Suffixes = append(policy.Suffixes, suffixes...)
+	return policy
+}
+
+// Allows reports whether the given Origin header value is permitted.
+func (p *Policy) Allows(origin string) bool {
+	normalized, u, ok := normalizeOrigin(origin)
+	if !ok {
+		return false
+	}
+
+	for _, allowed := range p.Origins {
+		if allowedNormalized, _, ok := normalizeOrigin(allowed); ok && allowedNormalized == normalized {
+			return true
+		}
+	}
+
+	hostname := strings.ToLower(u.Hostname())
+
+	if u.Scheme == "https" {
+		for _, suffix := range p.Suffixes {
+			suffix = strings.ToLower(strings.TrimPrefix(suffix, "*"))
+			if !strings.HasPrefix(suffix, ".") {
+				suffix = "." + suffix
+			}
+			if len(hostname) > len(suffix) && strings.HasSuffix(hostname, suffix) {
+				return true
+			}
+		}
+	}
+
+	if u.Scheme == "http" && p.PrivateNetworkPort != "" && u.Port() == p.PrivateNetworkPort {
+		if ip := net.ParseIP(hostname); ip != nil && (ip.IsPrivate() || ip.IsLoopback()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeOrigin parses an origin and returns it as scheme://host[:port] with
+// the default port for the scheme removed. Anything that isn't a bare
+// http(s) origin (paths, credentials, queries) is rejected.
+func normalizeOrigin(origin string) (string, *url.URL, bool) {
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" || u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return "", nil, false
+	}
+	if u.Path != "" && u.Path != "/" {
+		return "", nil, false
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", nil, false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if (u.Scheme == "https" && port == "443") || (u.Scheme == "http" && port == "80") {
+		port = ""
+	}
+	if port != "" {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]" // bare IPv6 literal
+	}
+
+	return u.Scheme + "://" + host, u, true
+}
@@ -0,0 +1,88 @@
+package cors
+
+import "testing"
+
+func TestDefaultPolicyAllows(t *testing.T) {
+	policy := NewPolicy([]string{"https://convoy.example.com"}, nil)
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"http://localhost:3000", true},
+		{"https://127.0.0.1:8000", true},
+		{"https://192.168.1.18", true},
+		{"https://192.168.1.18:443", true},
+		{"https://abc123.ngrok-free.dev", true},
+		{"https://abc123.ngrok-free.dev:8443", true},
+		{"https://abc123.ngrok-free.app", true},
+		{"https://abc123.ngrok.app", true},
+		{"https://abc123.ngrok.io", true},
+		{"https://convoy.example.com", true},
+		{"http://192.168.1.50:3000", true},
+		{"http://10.0.0.5:3000", true},
+		{"http://172.16.4.2:3000", true},
+
+		{"", false},
+		{"null", false},
+		{"http://localhost:4000", false},
+		{"https://evil-ngrok-free.dev.attacker.com", false},
+		{"https://abc.ngrok-free.dev.attacker.com", false},
+		{"https://evilngrok-free.dev", false},
+		{"https://ngrok-free.dev", false},
+		{"http://abc123.ngrok-free.dev", false},
+		{"https://abc123.ngrok-free.dev@attacker.com", false},
+		{"https://abc123.ngrok-free.dev/path", false},
+		{"http://8.8.8.8:3000", false},
+		{"http://172.32.0.1:3000", false},
+		{"http://192.168.1.50:3001", false},
+		{"http://192.168.evil.com:3000", false},
+		{"ftp://localhost:3000", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			if got := policy.Allows(tt.origin); got != tt.allowed {
+				t.Errorf("Allows(%q) = %v, expected %v", tt.origin, got, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestNewPolicyAddsConfiguredOriginsAndSuffixes(t *testing.T) {
+	policy := NewPolicy(
+		[]string{"https://convoy.example.com", "https://app.example.org:8443"},
+		[]string{".convoy.example.net", "*.preview.example.com"},
+	)
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://convoy.example.com", true},
+		{"https://convoy.example.com:443", true},
+		{"https://app.example.org:8443", true},
+		{"https://eu.convoy.example.net", true},
+		{"https://pr-42.preview.example.com", true},
+		{"http://localhost:3000", true}, // built-ins are kept
+
+		{"https://app.example.org", false},
+		{"http://convoy.example.com", false},
+		{"https://convoy.example.net", false},
+		{"http://eu.convoy.example.net", false},
+		{"https://eu.convoy.example.net.attacker.com", false},
+		{"https://evilconvoy.example.net", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			if got := policy.Allows(tt.origin); got != tt.allowed {
+				t.Errorf("Allows(%q) = %v, expected %v", tt.origin, got, tt.allowed)
+			}
+		})
+	}
+
+	if DefaultPolicy().Allows("https://convoy.example.com") {
+		t.Error("Expected configured origins not to leak into the default policy")
+	}
+}
@@ -0,0 +1,13 @@
+package domain
+
+import "regexp"
+
+// convoyIDPattern matches the 32 lowercase hex characters storage generates
+var convoyIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// IsValidConvoyID reports whether id has the format of a generated convoy ID.
+// Handlers check it before looking a convoy up, so typos and truncated links
+// don't look like a convoy that has expired.
+func IsValidConvoyID(id string) bool {
+	return convoyIDPattern.MatchString(id)
+}
@@ -1,21 +1,42 @@
 package domain
 
 import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"sync/atomic"
 	"time"
 )
 
 // Convoy represents a group of members traveling together.
 type Convoy struct {
-	ID                string       `json:"id"`
-	Members           []*Member    `json:"members"`
-	Destination       *Destination `json:"destination,omitempty"`
-	IsVerified        bool         `json:"isVerified"`
-	CreatedByEmail    string       `json:"createdByEmail"`
-	LeaderName        string       `json:"leaderName,omitempty"`
-	VerificationToken string       `json:"verificationToken,omitempty"`
-	VerificationExpiresAt *time.Time `json:"verificationExpiresAt,omitempty"`
-	VerifiedAt        *time.Time   `json:"verifiedAt,omitempty"`
-	CreatedAt         time.Time    `json:"createdAt"`
+	ID                    string            `json:"id"`
+	Name                  string            `json:"name"` // human-readable label; empty until the leader sets one
+	Members               []*Member         `json:"members"`
+	Destination           *Destination      `json:"destination,omitempty"`
+	IsVerified            bool              `json:"isVerified"`
+	CreatedByEmail        string            `json:"createdByEmail"`
+	CreatedByPhone        string            `json:"-"` // SMS-verified creator; only shown to admins
+	LeaderName            string            `json:"leaderName,omitempty"`
+	VerificationToken     string            `json:"verificationToken,omitempty"`
+	VerificationExpiresAt *time.Time        `json:"verificationExpiresAt,omitempty"`
+	VerifiedAt            *time.Time        `json:"verifiedAt,omitempty"`
+	CreatedAt             time.Time         `json:"createdAt"`
+	StartedAt             *time.Time        `json:"startedAt,omitempty"` // departure; until then the group is gathering
+	Paused                bool              `json:"paused"`              // suppresses monitoring alerts while the group is stopped
+	LeaderID              int64             `json:"leaderId,omitempty"`  // first member to join; reassigned if the leader drops out
+	Aggregate             *ConvoyAggregate  `json:"aggregate,omitempty"` // headline numbers from the last monitoring check
+	Metadata              map[string]string `json:"metadata,omitempty"`  // integrators' own data, e.g. a trip ID; stored and returned as given
+}
+
+// ConvoyAggregate summarizes the members with live locations, so clients
+// don't each recompute it from the member list. All zeros when none are live.
+type ConvoyAggregate struct {
+	LiveMembers     int       `json:"liveMembers"`     // connected or lagging members the numbers are drawn from
+	AverageSpeedKmh float64   `json:"averageSpeedKmh"` // over live members with a recent speed
+	ArrivedFraction float64   `json:"arrivedFraction"` // 0-1; 0 without a destination
+	SpreadKm        float64   `json:"spreadKm"`        // largest distance between any two live members
+	UpdatedAt       time.Time `json:"updatedAt"`
 }
 
 // Member represents a user in a convoy.
@@ -25,6 +46,91 @@ type Member struct {
 	Location   LatLng    `json:"location"`
 	Status     string    `json:"status"`     // connected, lagging, disconnected
 	LastUpdate time.Time `json:"lastUpdate"` // timestamp of last location update
+	LastSeen   time.Time `json:"lastSeen"`   // timestamp of last client heartbeat or location update
+
+	DistanceToDestination *float64 `json:"distanceToDestination,omitempty"` // kilometers; nil without a destination or location
+
+	Accuracy      *float64 `json:"accuracy,omitempty"`      // meters, as reported with the latest location; nil if not reported
+	LowConfidence bool     `json:"lowConfidence,omitempty"` // the latest location is too inaccurate to raise lagging or stalled alerts
+
+	JoinedAt       time.Time `json:"joinedAt"` // when the member was added; starts the join grace period
+	ConnectedSince time.Time `json:"-"`        // start of the current stretch without a disconnect; zero while disconnected
+
+	VehicleType string `json:"vehicleType,omitempty"`
+	AvatarURL   string `json:"avatarUrl,omitempty"`
+	Color       string `json:"color,omitempty"` // "#rrggbb" for drawing the member on maps; assigned from MemberColors on join
+	Notes       string `json:"notes,omitempty"` // the leader's reminder about this member; only sent to the leader
+
+	Track []TrackPoint `json:"-"` // recent accepted locations, oldest first; served separately as GPX
+
+	RejoinTokenHash  string `json:"-"` // SHA-256 of the token that lets this member reclaim its identity
+	ConnectTokenHash string `json:"-"` // SHA-256 of the token that authorizes this member's WebSocket
+}
+
+// MemberUpdate is a partial update to a member's profile; nil fields are left unchanged.
+type MemberUpdate struct {
+	Name        *string
+	VehicleType *string
+	AvatarURL   *string
+	Color       *string
+	Notes       *string
+}
+
+// MemberColors is the palette members are drawn from, ordered so that
+// neighbouring colors are easy to tell apart
+var MemberColors = []string{
+	"#e6194b", // red
+	"#3cb44b", // green
+	"#4363d8", // blue
+	"#f58231", // orange
+	"#911eb4", // purple
+	"#42d4f4", // cyan
+	"#f032e6", // magenta
+	"#bfef45", // lime
+	"#9a6324", // brown
+	"#469990", // teal
+	"#800000", // maroon
+	"#000075", // navy
+}
+
+// NextMemberColor picks the color for a member joining a convoy with the
+// given members: the first palette color nobody uses yet or, once the palette
+// has wrapped, the least used one that differs from the latest member's.
+func NextMemberColor(members []*Member) string {
+	counts := make(map[string]int, len(MemberColors))
+	for _, member := range members {
+		counts[member.Color]++
+	}
+	var last string
+	if len(members) > 0 {
+		last = members[len(members)-1].Color
+	}
+
+	best := ""
+	for _, color := range MemberColors {
+		if color == last {
+			continue
+		}
+		if best == "" || counts[color] < counts[best] {
+			best = color
+		}
+	}
+	return best
+}
+
+// lastMemberID is the most recently issued member ID
+var lastMemberID atomic.Int64
+
+func init() {
+	lastMemberID.Store(time.Now().UnixMilli())
+}
+
+// NewMemberID returns a member ID that is unique within this process. IDs
+// start from the process start time in milliseconds, so they keep the shape
+// of the old clock-derived IDs, but each call takes the next value so members
+// joining in the same millisecond never collide.
+func NewMemberID() int64 {
+	return lastMemberID.Add(1)
 }
 
 // Destination represents a named location with coordinates and metadata.
@@ -33,6 +139,16 @@ type Destination struct {
 	Description string  `json:"description,omitempty"`
 	Lat         float64 `json:"lat"`
 	Lng         float64 `json:"lng"`
+	// ArrivalRadiusMeters is how close a member must get to count as arrived;
+	// zero means the server-wide default
+	ArrivalRadiusMeters float64 `json:"arrivalRadiusMeters,omitempty"`
+}
+
+// DestinationUpdate is a partial update to a convoy's destination; nil fields are left unchanged.
+type DestinationUpdate struct {
+	Name        *string
+	Description *string
+	Location    *LatLng
 }
 
 // LatLng represents a geographical coordinate.
@@ -41,14 +157,25 @@ type LatLng struct {
 	Lng float64 `json:"lng"`
 }
 
+// TrackPoint is a recorded location with the time it was received.
+type TrackPoint struct {
+	LatLng
+	Timestamp time.Time `json:"timestamp"`
+}
+
 // Member status constants
 const (
 	StatusConnected    = "connected"    // Active WebSocket + recent location updates
+	StatusConnecting   = "connecting"   // Just joined, WebSocket not open yet
 	StatusInactive     = "inactive"     // Active WebSocket + no recent location updates
 	StatusLagging      = "lagging"      // Active WebSocket + far from convoy center
+	StatusReconnecting = "reconnecting" // WebSocket just closed; disconnected unless it comes back within the grace period
 	StatusDisconnected = "disconnected" // No WebSocket connection
 )
 
+// MemberStatuses lists every member status, e.g. for validating filters
+var MemberStatuses = []string{StatusConnected, StatusConnecting, StatusInactive, StatusLagging, StatusReconnecting, StatusDisconnected}
+
 // ToLatLng converts a Destination to LatLng coordinates.
 func (d *Destination) ToLatLng() LatLng {
 	return LatLng{Lat: d.Lat, Lng: d.Lng}
@@ -69,32 +196,173 @@ func (m *Member) IsDisconnected() bool {
 	return m.Status == StatusDisconnected
 }
 
+// SetRejoinToken stores a hash of the rejoin token issued to the member.
+func (m *Member) SetRejoinToken(token string) {
+	m.RejoinTokenHash = hashToken(token)
+}
+
+// MatchesRejoinToken returns true if the token matches the stored hash.
+func (m *Member) MatchesRejoinToken(token string) bool {
+	return matchesTokenHash(token, m.RejoinTokenHash)
+}
+
+// SetConnectToken stores a hash of the WebSocket connect token issued to the member.
+func (m *Member) SetConnectToken(token string) {
+	m.ConnectTokenHash = hashToken(token)
+}
+
+// MatchesConnectToken returns true if the token matches the stored hash.
+func (m *Member) MatchesConnectToken(token string) bool {
+	return matchesTokenHash(token, m.ConnectTokenHash)
+}
+
+// hashToken returns the hex SHA-256 of a token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// matchesTokenHash compares a token against a stored hash in constant time
+func matchesTokenHash(token, hash string) bool {
+	if hash == "" || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hashToken(token)), []byte(hash)) == 1
+}
+
 // UpdateStatus updates the member's status and last update time.
 func (m *Member) UpdateStatus(status string) {
 	m.Status = status
 	m.LastUpdate = time.Now()
+
+	if status == StatusDisconnected {
+		m.ConnectedSince = time.Time{}
+	} else if m.ConnectedSince.IsZero() {
+		m.ConnectedSince = m.LastUpdate
+	}
+}
+
+// WithoutNotes returns the member as anyone but the convoy leader sees it:
+// a copy without notes, or the member itself when it has none
+func (m *Member) WithoutNotes() *Member {
+	if m.Notes == "" {
+		return m
+	}
+	stripped := *m
+	stripped.Notes = ""
+	return &stripped
+}
+
+// WithoutNotes returns the convoy as members other than the leader see it: a
+// copy whose members carry no notes. The convoy itself is returned when no
+// member has notes.
+func (c *Convoy) WithoutNotes() *Convoy {
+	hasNotes := false
+	for _, member := range c.Members {
+		if member.Notes != "" {
+			hasNotes = true
+			break
+		}
+	}
+	if !hasNotes {
+		return c
+	}
+
+	public := *c
+	public.Members = make([]*Member, len(c.Members))
+	for i, member := range c.Members {
+		public.Members[i] = member.WithoutNotes()
+	}
+	return &public
 }
 
 // WebSocket event types for convoy monitoring
 const (
-	EventMemberLagging      = "MEMBER_LAGGING"
-	EventMemberDisconnected = "MEMBER_DISCONNECTED"
-	EventMemberInactive     = "MEMBER_INACTIVE"
-	EventMemberReactivated  = "MEMBER_REACTIVATED"
-	EventConvoyScattered    = "CONVOY_SCATTERED"
-	EventMemberReconnected  = "MEMBER_RECONNECTED"
+	EventMemberLagging            = "MEMBER_LAGGING"
+	EventMemberDisconnected       = "MEMBER_DISCONNECTED"
+	EventMemberInactive           = "MEMBER_INACTIVE"
+	EventMemberReactivated        = "MEMBER_REACTIVATED"
+	EventConvoyScattered          = "CONVOY_SCATTERED"
+	EventConvoyRegrouped          = "CONVOY_REGROUPED"
+	EventConvoyScatteredEscalated = "CONVOY_SCATTERED_ESCALATED" // still scattered after the escalation threshold
+	EventMemberStalled            = "MEMBER_STALLED"
+	EventMemberMoving             = "MEMBER_MOVING" // a stalled member is moving again
+	EventMemberReconnected        = "MEMBER_RECONNECTED"
+	EventMemberReconnecting       = "MEMBER_RECONNECTING" // soft: the socket dropped, MEMBER_DISCONNECTED follows only if it stays down
+	EventConvoyPaused             = "CONVOY_PAUSED"
+	EventConvoyResumed            = "CONVOY_RESUMED"
+	EventConvoyStarted            = "CONVOY_STARTED"
+	EventLeaderChanged            = "LEADER_CHANGED"
+	EventMemberJoined             = "MEMBER_JOINED"
+	EventMemberLeft               = "MEMBER_LEFT"
+	EventMemberAttention          = "MEMBER_ATTENTION"      // raised by a member, e.g. "I need to stop"
+	EventOperatorAnnouncement     = "OPERATOR_ANNOUNCEMENT" // pushed by an operator, e.g. "Route closure ahead on Main St"
+	EventMemberMoved              = "MEMBER_MOVED"          // a single member's location update, sent instead of the whole convoy in delta mode
 )
 
+// MemberMoved carries one member's latest state after a location update.
+// Clients replace the member with the same ID in their copy of the convoy.
+type MemberMoved struct {
+	EventType string    `json:"eventType"` // always EventMemberMoved
+	ConvoyID  string    `json:"convoyId"`
+	Member    *Member   `json:"member"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
 // ConvoyAlert represents an alert event for WebSocket broadcasting
 type ConvoyAlert struct {
-	EventType      string    `json:"eventType"`
-	ConvoyID       string    `json:"convoyId"`
-	MemberID       int64     `json:"memberId,omitempty"`
-	MemberName     string    `json:"memberName,omitempty"`
-	Distance       float64   `json:"distance,omitempty"`
-	LastSeen       time.Time `json:"lastSeen,omitempty"`
-	ScatteredCount int       `json:"scatteredCount,omitempty"`
-	Timestamp      time.Time `json:"timestamp"`
+	AlertID          string     `json:"alertId,omitempty"` // set on critical alerts, which clients must ACK
+	EventType        string     `json:"eventType"`
+	ConvoyID         string     `json:"convoyId"`
+	MemberID         int64      `json:"memberId,omitempty"`
+	MemberName       string     `json:"memberName,omitempty"`
+	Distance         float64    `json:"distance,omitempty"`
+	LastSeen         time.Time  `json:"lastSeen,omitempty"`
+	ScatteredCount   int        `json:"scatteredCount,omitempty"`
+	ScatteredSince   *time.Time `json:"scatteredSince,omitempty"` // on CONVOY_SCATTERED and its escalation: when the convoy became scattered
+	MemberCount      int        `json:"memberCount,omitempty"`
+	PreviousLeaderID int64      `json:"previousLeaderId,omitempty"`
+	Reason           string     `json:"reason,omitempty"`           // free text given with MEMBER_ATTENTION
+	Message          string     `json:"message,omitempty"`          // text of an OPERATOR_ANNOUNCEMENT
+	ReconnectAfterMs int64      `json:"reconnectAfterMs,omitempty"` // on MEMBER_DISCONNECTED: how long clients should wait before reconnecting
+	Timestamp        time.Time  `json:"timestamp"`
+}
+
+// ConvoyInvite lets someone join a convoy from a shared link. Only a hash of
+// the invite token is kept.
+type ConvoyInvite struct {
+	TokenHash string    `json:"-"`
+	ConvoyID  string    `json:"convoyId"`
+	MaxUses   int       `json:"maxUses"`
+	Uses      int       `json:"uses"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// NewConvoyInvite creates an invite for the token that allows maxUses joins until expiresAt.
+func NewConvoyInvite(convoyID, token string, maxUses int, expiresAt time.Time) *ConvoyInvite {
+	return &ConvoyInvite{
+		TokenHash: hashToken(token),
+		ConvoyID:  convoyID,
+		MaxUses:   maxUses,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+}
+
+// MatchesToken returns true if the token matches the stored hash.
+func (i *ConvoyInvite) MatchesToken(token string) bool {
+	return matchesTokenHash(token, i.TokenHash)
+}
+
+// IsExpired returns true if the invite can no longer be used because of its age.
+func (i *ConvoyInvite) IsExpired() bool {
+	return time.Now().After(i.ExpiresAt)
+}
+
+// IsUsedUp returns true if the invite has been redeemed MaxUses times.
+func (i *ConvoyInvite) IsUsedUp() bool {
+	return i.Uses >= i.MaxUses
 }
 
 // ConvoyVerification represents an email verification record
@@ -102,7 +370,10 @@ type ConvoyVerification struct {
 	ID          string     `json:"id"`
 	ConvoyID    string     `json:"convoyId"`
 	Email       string     `json:"email"`
+	Phone       string     `json:"phone,omitempty"`
 	Token       string     `json:"token"`
+	Code        string     `json:"-"`        // 6-digit SMS code, never serialized
+	Attempts    int        `json:"attempts"` // failed SMS code attempts
 	ExpiresAt   time.Time  `json:"expiresAt"`
 	VerifiedAt  *time.Time `json:"verifiedAt,omitempty"`
 	CreatedAt   time.Time  `json:"createdAt"`
@@ -110,6 +381,27 @@ type ConvoyVerification struct {
 	UserAgent   string     `json:"userAgent,omitempty"`
 }
 
+// Verification attempt outcomes recorded for auditing
+const (
+	VerificationValid           = "valid"
+	VerificationInvalid         = "invalid" // unknown token or wrong code
+	VerificationExpired         = "expired"
+	VerificationAlreadyUsed     = "already_used"
+	VerificationTooManyAttempts = "too_many_attempts"
+	VerificationError           = "error"
+)
+
+// VerificationAttempt is one audited call to verify a convoy. Only a prefix of
+// an email token is kept, enough to correlate attempts without replaying them;
+// SMS codes are too short for any part of them to be kept safely.
+type VerificationAttempt struct {
+	Method      string    `json:"method"`             // "email" or "sms"
+	ConvoyID    string    `json:"convoyId,omitempty"` // unknown for email tokens that match no convoy
+	TokenPrefix string    `json:"tokenPrefix,omitempty"`
+	Outcome     string    `json:"outcome"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
 // IsExpired returns true if the verification token has expired
 func (cv *ConvoyVerification) IsExpired() bool {
 	return time.Now().After(cv.ExpiresAt)
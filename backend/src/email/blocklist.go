@@ -0,0 +1,76 @@
+package email
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// defaultBlockedDomains are disposable email services that are always rejected
+var defaultBlockedDomains = []string{
+	"10minutemail.com",
+	"tempmail.org",
+	"guerrillamail.com",
+	"mailinator.com",
+	"throwaway.email",
+	"temp-mail.org",
+	"getnada.com",
+	"maildrop.cc",
+}
+
+var (
+	blockedMu      sync.RWMutex
+	blockedDomains = buildBlocklist(nil)
+)
+
+// buildBlocklist merges extra domains with the built-in defaults
+func buildBlocklist(extra []string) map[string]bool {
+	blocked := make(map[string]bool, len(defaultBlockedDomains)+len(extra))
+	for _, domain := range defaultBlockedDomains {
+		blocked[domain] = true
+	}
+	for _, domain := range extra {
+		blocked[strings.ToLower(domain)] = true
+	}
+	return blocked
+}
+
+// LoadBlockedDomains reads disposable email domains from a file, one per line,
+// and blocks them in addition to the built-in defaults. Blank lines and lines
+// starting with # are ignored. It returns the number of domains read; on error
+// the current blocklist is left unchanged.
+func LoadBlockedDomains(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open blocklist: %w", err)
+	}
+	defer file.Close()
+
+	var domains []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domains = append(domains, strings.TrimPrefix(line, "@"))
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read blocklist: %w", err)
+	}
+
+	blocked := buildBlocklist(domains)
+	blockedMu.Lock()
+	blockedDomains = blocked
+	blockedMu.Unlock()
+	return len(domains), nil
+}
+
+// isBlockedDomain reports whether the lowercase domain is on the blocklist
+func isBlockedDomain(domain string) bool {
+	blockedMu.RLock()
+	defer blockedMu.RUnlock()
+	return blockedDomains[domain]
+}
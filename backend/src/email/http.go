@@ -0,0 +1,83 @@
+package email
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const defaultSendGridEndpoint = "https://api.sendgrid.com/v3/mail/send"
+
+// HTTPSender sends email through a SendGrid-style HTTP API, for hosts where
+// outbound SMTP ports are blocked
+type HTTPSender struct {
+	apiKey    string
+	endpoint  string
+	fromName  string
+	fromEmail string
+	client    *http.Client
+}
+
+type mailAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type mailPersonalization struct {
+	To []mailAddress `json:"to"`
+}
+
+type mailContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type mailRequest struct {
+	Personalizations []mailPersonalization `json:"personalizations"`
+	From             mailAddress           `json:"from"`
+	Subject          string                `json:"subject"`
+	Content          []mailContent         `json:"content"`
+}
+
+// Send posts the message to the mail API
+func (h *HTTPSender) Send(to, subject, body string) error {
+	if h.apiKey == "" {
+		return fmt.Errorf("email API key not configured")
+	}
+
+	payload, err := json.Marshal(mailRequest{
+		Personalizations: []mailPersonalization{{To: []mailAddress{{Email: to}}}},
+		From:             mailAddress{Email: h.fromEmail, Name: h.fromName},
+		Subject:          subject,
+		Content:          []mailContent{{Type: "text/html", Value: body}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode email request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, h.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to build email request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+h.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("email API returned status %d: %s", resp.StatusCode, respBody)
+	}
+	return nil
+}
+
+// IsConfigured returns true if the API key, endpoint and sender address are present
+func (h *HTTPSender) IsConfigured() bool {
+	return h.apiKey != "" && h.endpoint != "" && h.fromEmail != ""
+}
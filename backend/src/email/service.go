@@ -3,26 +3,61 @@ package email
 import (
 	"bytes"
 	"crypto/rand"
-	"crypto/tls"
 	"encoding/hex"
 	"fmt"
 	"html/template"
-	"net/smtp"
+	"log"
+	"net/http"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
 
+// EmailSender delivers a rendered HTML email. Implementations wrap a specific
+// transport (raw SMTP, an HTTP mail API, ...).
+type EmailSender interface {
+	Send(to, subject, htmlBody string) error
+	IsConfigured() bool
+}
+
 // Service handles email sending functionality
 type Service struct {
-	host     string
-	port     string
-	username string
-	password string
-	fromName string
-	fromEmail string
-	baseURL  string
+	sender            EmailSender
+	baseURL           string
+	baseURLConfigured bool // APP_BASE_URL was set rather than defaulted
+	dryRun            bool // log verification links instead of sending, for local development
+	brand             Branding
+}
+
+// Branding is the operator-facing identity shown in emails
+type Branding struct {
+	Name         string // shown in the header, footer and subject line
+	PrimaryColor string // hex color for the header, heading and button, e.g. #2E86DE
+	SupportURL   string // optional; adds a help link to the footer
+}
+
+// DefaultBranding matches the stock Convoy App design
+var DefaultBranding = Branding{
+	Name:         "Convoy App",
+	PrimaryColor: "#2E86DE",
+}
+
+var brandColorRegex = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// withDefaults fills any unset field from DefaultBranding
+func (b Branding) withDefaults() Branding {
+	if b.Name == "" {
+		b.Name = DefaultBranding.Name
+	}
+	if !brandColorRegex.MatchString(b.PrimaryColor) {
+		if b.PrimaryColor != "" {
+			log.Printf("WARNING: Invalid email brand color %q, using %s", b.PrimaryColor, DefaultBranding.PrimaryColor)
+		}
+		b.PrimaryColor = DefaultBranding.PrimaryColor
+	}
+	return b
 }
 
 // Config holds email service configuration
@@ -34,31 +69,101 @@ type Config struct {
 	FromName  string
 	FromEmail string
 	BaseURL   string
+	Sender    EmailSender // Optional; defaults to SMTP built from the fields above
+	DryRun    bool        // Log verification links instead of sending
+	Brand     Branding    // Optional; unset fields use DefaultBranding
+
+	DialTimeout time.Duration // SMTP connect timeout; zero uses DefaultSMTPDialTimeout
+	SendTimeout time.Duration // SMTP deadline for the whole send; zero uses DefaultSMTPSendTimeout
+}
+
+// Verifier is implemented by senders that can check their configuration
+// without sending mail
+type Verifier interface {
+	Verify() error
 }
 
 // NewService creates a new email service instance
 func NewService(config Config) *Service {
+	sender := config.Sender
+	if sender == nil {
+		sender = &SMTPSender{
+			host:        config.Host,
+			port:        config.Port,
+			username:    config.Username,
+			password:    config.Password,
+			fromName:    config.FromName,
+			fromEmail:   config.FromEmail,
+			dialTimeout: config.DialTimeout,
+			sendTimeout: config.SendTimeout,
+		}
+	}
+
 	return &Service{
-		host:      config.Host,
-		port:      config.Port,
-		username:  config.Username,
-		password:  config.Password,
-		fromName:  config.FromName,
-		fromEmail: config.FromEmail,
-		baseURL:   config.BaseURL,
+		sender:            sender,
+		baseURL:           config.BaseURL,
+		baseURLConfigured: config.BaseURL != "",
+		dryRun:            config.DryRun,
+		brand:             config.Brand.withDefaults(),
 	}
 }
 
-// NewServiceFromEnv creates a new email service from environment variables
+// NewServiceFromEnv creates a new email service from environment variables.
+// EMAIL_PROVIDER selects the transport: "smtp" (default) or "sendgrid".
 func NewServiceFromEnv() *Service {
+	fromName := getEnv("SMTP_FROM_NAME", "Convoy App")
+	fromEmail := getEnv("SMTP_FROM_EMAIL", "convoy@example.com")
+
+	var sender EmailSender
+	switch provider := strings.ToLower(getEnv("EMAIL_PROVIDER", "smtp")); provider {
+	case "sendgrid", "http":
+		sender = &HTTPSender{
+			apiKey:    getEnv("EMAIL_API_KEY", ""),
+			endpoint:  getEnv("EMAIL_API_URL", defaultSendGridEndpoint),
+			fromName:  fromName,
+			fromEmail: fromEmail,
+			client:    &http.Client{Timeout: 10 * time.Second},
+		}
+	default:
+		if provider != "smtp" {
+			log.Printf("WARNING: Unknown EMAIL_PROVIDER %q, falling back to smtp", provider)
+		}
+		sender = &SMTPSender{
+			host:        getEnv("SMTP_HOST", "smtp.gmail.com"),
+			port:        getEnv("SMTP_PORT", "587"),
+			username:    getEnv("SMTP_USERNAME", ""),
+			password:    getEnv("SMTP_PASSWORD", ""),
+			fromName:    fromName,
+			fromEmail:   fromEmail,
+			dialTimeout: getEnvDuration("SMTP_DIAL_TIMEOUT", DefaultSMTPDialTimeout),
+			sendTimeout: getEnvDuration("SMTP_SEND_TIMEOUT", DefaultSMTPSendTimeout),
+		}
+	}
+
+	// Operators can block newly found disposable domains without a rebuild
+	if path := getEnv("EMAIL_BLOCKLIST_FILE", ""); path != "" {
+		if count, err := LoadBlockedDomains(path); err != nil {
+			log.Printf("WARNING: %v; using the built-in blocklist only", err)
+		} else {
+			log.Printf("Loaded %d blocked email domains from %s", count, path)
+		}
+	}
+
+	dryRun, _ := strconv.ParseBool(getEnv("EMAIL_DRY_RUN", "false"))
+	if dryRun {
+		log.Println("EMAIL_DRY_RUN enabled: verification emails will be logged, not sent")
+	}
+
 	return &Service{
-		host:      getEnv("SMTP_HOST", "smtp.gmail.com"),
-		port:      getEnv("SMTP_PORT", "587"),
-		username:  getEnv("SMTP_USERNAME", ""),
-		password:  getEnv("SMTP_PASSWORD", ""),
-		fromName:  getEnv("SMTP_FROM_NAME", "Convoy App"),
-		fromEmail: getEnv("SMTP_FROM_EMAIL", "convoy@example.com"),
-		baseURL:   getEnv("APP_BASE_URL", "http://localhost:8000"),
+		sender:            sender,
+		baseURL:           getEnv("APP_BASE_URL", "http://localhost:8000"),
+		baseURLConfigured: os.Getenv("APP_BASE_URL") != "",
+		dryRun:            dryRun,
+		brand: Branding{
+			Name:         getEnv("EMAIL_BRAND_NAME", DefaultBranding.Name),
+			PrimaryColor: getEnv("EMAIL_BRAND_COLOR", DefaultBranding.PrimaryColor),
+			SupportURL:   getEnv("EMAIL_SUPPORT_URL", ""),
+		}.withDefaults(),
 	}
 }
 
@@ -88,33 +193,36 @@ func IsValidEmail(email string) bool {
 
 	// Check for blocked domains (disposable email services)
 	domain := strings.ToLower(strings.Split(email, "@")[1])
-	blockedDomains := []string{
-		"10minutemail.com",
-		"tempmail.org",
-		"guerrillamail.com",
-		"mailinator.com",
-		"throwaway.email",
-		"temp-mail.org",
-		"getnada.com",
-		"maildrop.cc",
-	}
+	return !isBlockedDomain(domain)
+}
 
-	for _, blocked := range blockedDomains {
-		if domain == blocked {
-			return false
-		}
-	}
+// BaseURL returns the configured APP_BASE_URL that links point at
+func (s *Service) BaseURL() string {
+	return s.baseURL
+}
 
-	return true
+// HasConfiguredBaseURL reports whether APP_BASE_URL was set, as opposed to
+// falling back to the local development default
+func (s *Service) HasConfiguredBaseURL() bool {
+	return s.baseURLConfigured
 }
 
 // SendVerificationEmail sends a verification email with magic link
 func (s *Service) SendVerificationEmail(to, leaderName, token string) error {
+	return s.SendVerificationEmailWithBaseURL(to, leaderName, token, "")
+}
+
+// SendVerificationEmailWithBaseURL sends a verification email whose magic link
+// points at baseURL instead of APP_BASE_URL. An empty baseURL uses APP_BASE_URL.
+func (s *Service) SendVerificationEmailWithBaseURL(to, leaderName, token, baseURL string) error {
 	if !IsValidEmail(to) {
 		return fmt.Errorf("invalid email address: %s", to)
 	}
 
-	verificationURL := fmt.Sprintf("%s/verify/%s", s.baseURL, token)
+	if baseURL == "" {
+		baseURL = s.baseURL
+	}
+	verificationURL := fmt.Sprintf("%s/verify/%s", baseURL, token)
 	expiresAt := time.Now().Add(30 * time.Minute)
 
 	data := VerificationEmail{
@@ -123,13 +231,18 @@ func (s *Service) SendVerificationEmail(to, leaderName, token string) error {
 		ExpiresAt:       expiresAt,
 	}
 
-	subject := "Verify Your Convoy - Convoy App"
+	subject := "Verify Your Convoy - " + s.brand.withDefaults().Name
 	body, err := s.renderVerificationTemplate(data)
 	if err != nil {
 		return fmt.Errorf("failed to render email template: %w", err)
 	}
 
-	return s.sendEmail(to, subject, body)
+	if s.dryRun {
+		log.Printf("EMAIL_DRY_RUN: verification email for %s not sent; verify at %s", to, verificationURL)
+		return nil
+	}
+
+	return s.sender.Send(to, subject, body)
 }
 
 // renderVerificationTemplate renders the HTML email template
@@ -144,12 +257,12 @@ func (s *Service) renderVerificationTemplate(data VerificationEmail) (string, er
 <body style="margin: 0; padding: 0; font-family: 'Poppins', -apple-system, BlinkMacSystemFont, 'Segoe UI', sans-serif; background-color: #f8f9fa;">
     <div style="max-width: 600px; margin: 0 auto; background: white; border-radius: 16px; padding: 40px; margin-top: 20px; margin-bottom: 20px; box-shadow: 0 8px 32px rgba(0, 0, 0, 0.1);">
         <div style="text-align: center; margin-bottom: 30px;">
-            <h1 style="color: #2E86DE; font-size: 28px; font-weight: 600; margin: 0; letter-spacing: -0.5px;">
-                🚗 Convoy App
+            <h1 style="color: {{.Brand.PrimaryColor}}; font-size: 28px; font-weight: 600; margin: 0; letter-spacing: -0.5px;">
+                🚗 {{.Brand.Name}}
             </h1>
         </div>
         
-        <h2 style="color: #2E86DE; text-align: center; font-size: 24px; font-weight: 600; margin-bottom: 20px; letter-spacing: -0.5px;">
+        <h2 style="color: {{.Brand.PrimaryColor}}; text-align: center; font-size: 24px; font-weight: 600; margin-bottom: 20px; letter-spacing: -0.5px;">
             Verify Your Convoy
         </h2>
         
@@ -163,7 +276,7 @@ func (s *Service) renderVerificationTemplate(data VerificationEmail) (string, er
         
         <div style="text-align: center; margin: 40px 0;">
             <a href="{{.VerificationURL}}" 
-               style="background: #2E86DE; color: white; padding: 16px 32px; border-radius: 12px; text-decoration: none; display: inline-block; font-weight: 600; font-size: 16px; box-shadow: 0 4px 12px rgba(46, 134, 222, 0.3); transition: all 0.2s ease;">
+               style="background: {{.Brand.PrimaryColor}}; color: white; padding: 16px 32px; border-radius: 12px; text-decoration: none; display: inline-block; font-weight: 600; font-size: 16px; box-shadow: 0 4px 12px rgba(0, 0, 0, 0.15); transition: all 0.2s ease;">
                 ✅ Verify & Start Convoy
             </a>
         </div>
@@ -178,8 +291,11 @@ func (s *Service) renderVerificationTemplate(data VerificationEmail) (string, er
         
         <div style="text-align: center; margin-top: 30px; padding-top: 20px; border-top: 1px solid #eee;">
             <p style="color: #999; font-size: 12px; margin: 0;">
-                Convoy App - Real-time location sharing for groups
-            </p>
+                {{.Brand.Name}} - Real-time location sharing for groups
+            </p>{{if .Brand.SupportURL}}
+            <p style="color: #999; font-size: 12px; margin: 8px 0 0;">
+                Need help? <a href="{{.Brand.SupportURL}}" style="color: {{.Brand.PrimaryColor}};">Contact support</a>
+            </p>{{end}}
         </div>
     </div>
 </body>
@@ -190,96 +306,37 @@ func (s *Service) renderVerificationTemplate(data VerificationEmail) (string, er
 		return "", err
 	}
 
+	view := struct {
+		VerificationEmail
+		Brand Branding
+	}{data, s.brand.withDefaults()}
+
 	var buf bytes.Buffer
-	if err := t.Execute(&buf, data); err != nil {
+	if err := t.Execute(&buf, view); err != nil {
 		return "", err
 	}
 
 	return buf.String(), nil
 }
 
-// sendEmail sends an email using SMTP with support for both TLS (587) and SSL (465)
-func (s *Service) sendEmail(to, subject, body string) error {
-	if s.username == "" || s.password == "" {
-		return fmt.Errorf("SMTP credentials not configured")
+// Verify checks that the configured transport accepts our credentials, without
+// sending mail. Dry-run mode and transports that can't be checked this way
+// always pass.
+func (s *Service) Verify() error {
+	if s.dryRun {
+		return nil
 	}
-
-	msg := fmt.Sprintf("From: %s <%s>\r\n"+
-		"To: %s\r\n"+
-		"Subject: %s\r\n"+
-		"MIME-Version: 1.0\r\n"+
-		"Content-Type: text/html; charset=UTF-8\r\n"+
-		"\r\n"+
-		"%s", s.fromName, s.fromEmail, to, subject, body)
-
-	addr := fmt.Sprintf("%s:%s", s.host, s.port)
-	auth := smtp.PlainAuth("", s.username, s.password, s.host)
-
-	// Handle different SMTP configurations
-	if s.port == "465" {
-		// SSL connection (port 465)
-		return s.sendEmailSSL(addr, auth, s.fromEmail, []string{to}, []byte(msg))
-	} else {
-		// TLS connection (port 587 or others)
-		return smtp.SendMail(addr, auth, s.fromEmail, []string{to}, []byte(msg))
+	verifier, ok := s.sender.(Verifier)
+	if !ok {
+		return nil
 	}
+	return verifier.Verify()
 }
 
-// sendEmailSSL sends email using SSL connection (for port 465)
-func (s *Service) sendEmailSSL(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
-	// Create TLS connection
-	tlsConfig := &tls.Config{
-		ServerName: s.host,
-	}
-
-	conn, err := tls.Dial("tcp", addr, tlsConfig)
-	if err != nil {
-		return fmt.Errorf("failed to connect to SMTP server: %w", err)
-	}
-	defer conn.Close()
-
-	// Create SMTP client
-	client, err := smtp.NewClient(conn, s.host)
-	if err != nil {
-		return fmt.Errorf("failed to create SMTP client: %w", err)
-	}
-	defer client.Quit()
-
-	// Authenticate
-	if err := client.Auth(auth); err != nil {
-		return fmt.Errorf("SMTP authentication failed: %w", err)
-	}
-
-	// Set sender
-	if err := client.Mail(from); err != nil {
-		return fmt.Errorf("failed to set sender: %w", err)
-	}
-
-	// Set recipients
-	for _, recipient := range to {
-		if err := client.Rcpt(recipient); err != nil {
-			return fmt.Errorf("failed to set recipient %s: %w", recipient, err)
-		}
-	}
-
-	// Send message
-	writer, err := client.Data()
-	if err != nil {
-		return fmt.Errorf("failed to get data writer: %w", err)
-	}
-
-	_, err = writer.Write(msg)
-	if err != nil {
-		writer.Close()
-		return fmt.Errorf("failed to write message: %w", err)
-	}
-
-	return writer.Close()
-}
-
-// IsConfigured returns true if the email service is properly configured
+// IsConfigured returns true if the email service is properly configured.
+// Dry-run mode needs no transport, so it always counts as configured.
 func (s *Service) IsConfigured() bool {
-	return s.host != "" && s.port != "" && s.username != "" && s.password != "" && s.fromEmail != ""
+	return s.dryRun || (s.sender != nil && s.sender.IsConfigured())
 }
 
 // getEnv gets environment variable with fallback
@@ -289,3 +346,18 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// getEnvDuration parses a duration such as "15s" from the environment, falling
+// back when it is unset or not a positive duration
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("WARNING: Invalid %s %q, using %v", key, value, fallback)
+		return fallback
+	}
+	return d
+}
@@ -0,0 +1,166 @@
+package email
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderVerificationTemplateEscapesLeaderName(t *testing.T) {
+	s := &Service{baseURL: "https://convoy.example.com"}
+
+	body, err := s.renderVerificationTemplate(VerificationEmail{
+		LeaderName:      `<script>alert("x")</script>`,
+		VerificationURL: "https://convoy.example.com/verify/token",
+	})
+	if err != nil {
+		t.Fatalf("Failed to render template: %v", err)
+	}
+
+	if strings.Contains(body, "<script>") {
+		t.Errorf("Expected leader name to be HTML-escaped, found raw <script> in body")
+	}
+	if !strings.Contains(body, "&lt;script&gt;") {
+		t.Errorf("Expected escaped leader name in body")
+	}
+}
+
+// recordingSender counts the emails handed to it
+type recordingSender struct {
+	sent int
+}
+
+func (r *recordingSender) Send(to, subject, htmlBody string) error {
+	r.sent++
+	return nil
+}
+
+func (r *recordingSender) IsConfigured() bool {
+	return false
+}
+
+func TestDryRunLogsVerificationURL(t *testing.T) {
+	sender := &recordingSender{}
+	s := NewService(Config{BaseURL: "https://convoy.example.com", Sender: sender, DryRun: true})
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	if !s.IsConfigured() {
+		t.Error("Expected dry-run service to report itself configured")
+	}
+	if err := s.SendVerificationEmail("leader@example.com", "Leader", "abc123"); err != nil {
+		t.Fatalf("Expected dry run to succeed, got %v", err)
+	}
+
+	if sender.sent != 0 {
+		t.Errorf("Expected no email to be sent in dry run, got %d", sender.sent)
+	}
+	if !strings.Contains(logs.String(), "https://convoy.example.com/verify/abc123") {
+		t.Errorf("Expected the verification URL to be logged, got %q", logs.String())
+	}
+}
+
+func TestVerificationURLUsesBaseURLOverride(t *testing.T) {
+	s := NewService(Config{BaseURL: "https://configured.example.com", Sender: &recordingSender{}, DryRun: true})
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	if err := s.SendVerificationEmailWithBaseURL("leader@example.com", "Leader", "abc123", "https://abc123.ngrok-free.dev"); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+	if err := s.SendVerificationEmailWithBaseURL("leader@example.com", "Leader", "def456", ""); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+
+	if !strings.Contains(logs.String(), "https://abc123.ngrok-free.dev/verify/abc123") {
+		t.Errorf("Expected the forwarded base URL to be used, got %q", logs.String())
+	}
+	if !strings.Contains(logs.String(), "https://configured.example.com/verify/def456") {
+		t.Errorf("Expected APP_BASE_URL without an override, got %q", logs.String())
+	}
+}
+
+func TestLoadBlockedDomainsFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	contents := "# newly found disposable services\nFreshTrash.io\n\n@burner.example\n"
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("Failed to write blocklist: %v", err)
+	}
+	t.Cleanup(func() { blockedDomains = buildBlocklist(nil) })
+
+	count, err := LoadBlockedDomains(path)
+	if err != nil {
+		t.Fatalf("Failed to load blocklist: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 domains loaded, got %d", count)
+	}
+
+	for _, address := range []string{"someone@freshtrash.io", "someone@BURNER.example", "someone@mailinator.com"} {
+		if IsValidEmail(address) {
+			t.Errorf("Expected %s to be blocked", address)
+		}
+	}
+	if !IsValidEmail("someone@example.com") {
+		t.Error("Expected an unlisted domain to be allowed")
+	}
+
+	// A missing file keeps the current list
+	if _, err := LoadBlockedDomains(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("Expected an error for a missing blocklist file")
+	}
+	if IsValidEmail("someone@freshtrash.io") {
+		t.Error("Expected a failed load to leave the blocklist unchanged")
+	}
+}
+
+func TestRenderVerificationTemplateUsesBranding(t *testing.T) {
+	s := NewService(Config{
+		Sender: &recordingSender{},
+		Brand: Branding{
+			Name:         "Fleet Tracker",
+			PrimaryColor: "#ff5500",
+			SupportURL:   "https://help.fleet.example.com",
+		},
+	})
+
+	body, err := s.renderVerificationTemplate(VerificationEmail{
+		LeaderName:      "Leader",
+		VerificationURL: "https://fleet.example.com/verify/token",
+	})
+	if err != nil {
+		t.Fatalf("Failed to render template: %v", err)
+	}
+
+	for _, want := range []string{"Fleet Tracker", "#ff5500", "https://help.fleet.example.com"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in the rendered email", want)
+		}
+	}
+	if strings.Contains(body, "Convoy App") || strings.Contains(body, "#2E86DE") {
+		t.Error("Expected the default branding to be replaced")
+	}
+}
+
+func TestRenderVerificationTemplateDefaultBranding(t *testing.T) {
+	s := NewService(Config{Sender: &recordingSender{}, Brand: Branding{PrimaryColor: "red;background:url(x)"}})
+
+	body, err := s.renderVerificationTemplate(VerificationEmail{LeaderName: "Leader"})
+	if err != nil {
+		t.Fatalf("Failed to render template: %v", err)
+	}
+
+	if !strings.Contains(body, "Convoy App") || !strings.Contains(body, "#2E86DE") {
+		t.Error("Expected the default name and color")
+	}
+	if strings.Contains(body, "Contact support") {
+		t.Error("Expected no support link without a support URL")
+	}
+}
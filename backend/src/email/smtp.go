@@ -0,0 +1,229 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"time"
+)
+
+// Default SMTP deadlines, used when SMTP_DIAL_TIMEOUT or SMTP_SEND_TIMEOUT is unset
+const (
+	DefaultSMTPDialTimeout = 10 * time.Second // connecting, including the TLS handshake on port 465
+	DefaultSMTPSendTimeout = 30 * time.Second // the whole exchange, from dialing to the end of the message
+)
+
+// SMTPSender sends email directly through an SMTP server
+type SMTPSender struct {
+	host        string
+	port        string
+	username    string
+	password    string
+	fromName    string
+	fromEmail   string
+	dialTimeout time.Duration // zero uses DefaultSMTPDialTimeout
+	sendTimeout time.Duration // zero uses DefaultSMTPSendTimeout
+}
+
+// Send sends an email using SMTP with support for both TLS (587) and SSL (465)
+func (s *SMTPSender) Send(to, subject, body string) error {
+	if s.username == "" || s.password == "" {
+		return fmt.Errorf("SMTP credentials not configured")
+	}
+
+	msg := fmt.Sprintf("From: %s <%s>\r\n"+
+		"To: %s\r\n"+
+		"Subject: %s\r\n"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: text/html; charset=UTF-8\r\n"+
+		"\r\n"+
+		"%s", s.fromName, s.fromEmail, to, subject, body)
+
+	addr := fmt.Sprintf("%s:%s", s.host, s.port)
+	auth := smtp.PlainAuth("", s.username, s.password, s.host)
+
+	// Handle different SMTP configurations
+	if s.port == "465" {
+		// SSL connection (port 465)
+		return s.sendEmailSSL(addr, auth, s.fromEmail, []string{to}, []byte(msg))
+	} else {
+		// TLS connection (port 587 or others)
+		return s.sendEmailSTARTTLS(addr, auth, s.fromEmail, []string{to}, []byte(msg))
+	}
+}
+
+// sendEmailSTARTTLS does what smtp.SendMail does, upgrading to TLS when the
+// server offers STARTTLS, but gives up once the send timeout has passed
+func (s *SMTPSender) sendEmailSTARTTLS(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.sendTimeoutOrDefault())
+	defer cancel()
+
+	conn, err := s.dial(ctx, addr, false)
+	if err != nil {
+		return err
+	}
+	return s.deliver(ctx, conn, auth, from, to, msg, true)
+}
+
+// sendEmailSSL sends email using SSL connection (for port 465)
+func (s *SMTPSender) sendEmailSSL(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.sendTimeoutOrDefault())
+	defer cancel()
+
+	conn, err := s.dial(ctx, addr, true)
+	if err != nil {
+		return err
+	}
+	return s.deliver(ctx, conn, auth, from, to, msg, false)
+}
+
+// dial connects to the SMTP server, over TLS from the start when implicitTLS
+// is set (port 465). Both the connect and the TLS handshake give up at the
+// dial timeout or the deadline of ctx, whichever comes first.
+func (s *SMTPSender) dial(ctx context.Context, addr string, implicitTLS bool) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: s.dialTimeoutOrDefault()}
+
+	var conn net.Conn
+	var err error
+	if implicitTLS {
+		if deadline, ok := ctx.Deadline(); ok {
+			dialer.Deadline = deadline
+		}
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: s.host})
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, s.wrapTimeout("failed to connect to SMTP server", err)
+	}
+	return conn, nil
+}
+
+// deliver runs the SMTP exchange over conn, which it closes. Every read and
+// write shares the deadline of ctx, so a server that stops answering partway
+// through can't hold the caller past it.
+func (s *SMTPSender) deliver(ctx context.Context, conn net.Conn, auth smtp.Auth, from string, to []string, msg []byte, startTLS bool) error {
+	defer conn.Close()
+	client, err := s.authenticate(ctx, conn, auth, startTLS)
+	if err != nil {
+		return err
+	}
+	defer client.Quit()
+
+	// Set sender
+	if err := client.Mail(from); err != nil {
+		return s.wrapTimeout("failed to set sender", err)
+	}
+
+	// Set recipients
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return s.wrapTimeout(fmt.Sprintf("failed to set recipient %s", recipient), err)
+		}
+	}
+
+	// Send message
+	writer, err := client.Data()
+	if err != nil {
+		return s.wrapTimeout("failed to get data writer", err)
+	}
+
+	_, err = writer.Write(msg)
+	if err != nil {
+		writer.Close()
+		return s.wrapTimeout("failed to write message", err)
+	}
+
+	if err := writer.Close(); err != nil {
+		return s.wrapTimeout("failed to finish message", err)
+	}
+	return nil
+}
+
+// authenticate opens an SMTP session on conn, upgrading to TLS first when
+// startTLS is set and the server offers it, and logs in
+func (s *SMTPSender) authenticate(ctx context.Context, conn net.Conn, auth smtp.Auth, startTLS bool) (*smtp.Client, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	// Create SMTP client
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		return nil, s.wrapTimeout("failed to create SMTP client", err)
+	}
+
+	if startTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+				client.Close()
+				return nil, s.wrapTimeout("failed to start TLS", err)
+			}
+		}
+	}
+
+	// Authenticate
+	if err := client.Auth(auth); err != nil {
+		client.Close()
+		return nil, s.wrapTimeout("SMTP authentication failed", err)
+	}
+	return client, nil
+}
+
+// Verify connects and logs in to the SMTP server without sending anything, so
+// wrong credentials or an unreachable server show up at startup
+func (s *SMTPSender) Verify() error {
+	if !s.IsConfigured() {
+		return fmt.Errorf("SMTP is not configured: host, port, username, password and from address are required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.sendTimeoutOrDefault())
+	defer cancel()
+
+	implicitTLS := s.port == "465"
+	conn, err := s.dial(ctx, net.JoinHostPort(s.host, s.port), implicitTLS)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	auth := smtp.PlainAuth("", s.username, s.password, s.host)
+	client, err := s.authenticate(ctx, conn, auth, !implicitTLS)
+	if err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// wrapTimeout adds context to an SMTP error, calling out timeouts so a hung
+// mail server is easy to tell apart from a rejected message
+func (s *SMTPSender) wrapTimeout(action string, err error) error {
+	var netErr net.Error
+	if errors.Is(err, os.ErrDeadlineExceeded) || errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return fmt.Errorf("%s: SMTP server %s:%s timed out (send timeout %v): %w", action, s.host, s.port, s.sendTimeoutOrDefault(), err)
+	}
+	return fmt.Errorf("%s: %w", action, err)
+}
+
+func (s *SMTPSender) dialTimeoutOrDefault() time.Duration {
+	if s.dialTimeout > 0 {
+		return s.dialTimeout
+	}
+	return DefaultSMTPDialTimeout
+}
+
+func (s *SMTPSender) sendTimeoutOrDefault() time.Duration {
+	if s.sendTimeout > 0 {
+		return s.sendTimeout
+	}
+	return DefaultSMTPSendTimeout
+}
+
+// IsConfigured returns true if SMTP host and credentials are present
+func (s *SMTPSender) IsConfigured() bool {
+	return s.host != "" && s.port != "" && s.username != "" && s.password != "" && s.fromEmail != ""
+}
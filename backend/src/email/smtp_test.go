@@ -0,0 +1,173 @@
+package email
+
+import (
+	"bufio"
+	"encoding/base64"
+	"net"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// silentListener accepts connections but never says anything, like an SMTP
+// server stuck behind a broken network path
+func silentListener(t *testing.T) (host, port string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	var conns []net.Conn
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conns = append(conns, conn)
+		}
+	}()
+	t.Cleanup(func() {
+		listener.Close()
+		<-done
+		for _, conn := range conns {
+			conn.Close()
+		}
+	})
+
+	host, port, _ = net.SplitHostPort(listener.Addr().String())
+	return host, port
+}
+
+func TestSMTPSendTimesOutOnSilentServer(t *testing.T) {
+	host, port := silentListener(t)
+	sender := &SMTPSender{
+		host:        host,
+		port:        port,
+		username:    "user",
+		password:    "pass",
+		fromEmail:   "convoy@example.com",
+		sendTimeout: 100 * time.Millisecond,
+	}
+
+	tests := []struct {
+		name string
+		send func() error
+	}{
+		{"starttls", func() error { return sender.Send("leader@example.com", "Subject", "Body") }},
+		{"ssl", func() error {
+			auth := smtp.PlainAuth("", "user", "pass", host)
+			return sender.sendEmailSSL(net.JoinHostPort(host, port), auth, "convoy@example.com", []string{"leader@example.com"}, []byte("Body"))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			err := tt.send()
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("Expected the send to give up after the timeout, took %v", elapsed)
+			}
+			if err == nil || !strings.Contains(err.Error(), "timed out") {
+				t.Errorf("Expected a timeout error, got %v", err)
+			}
+		})
+	}
+}
+
+// fakeSMTPServer answers just enough SMTP for a client to log in with AUTH
+// PLAIN, accepting only the given credentials
+func fakeSMTPServer(t *testing.T, username, password string) (host, port string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	want := base64.StdEncoding.EncodeToString([]byte("\x00" + username + "\x00" + password))
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.SetDeadline(time.Now().Add(5 * time.Second))
+				reader := bufio.NewReader(conn)
+				reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+				reply("220 fake.example.com ESMTP")
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					command := strings.TrimSpace(line)
+					switch {
+					case strings.HasPrefix(command, "EHLO"):
+						reply("250-fake.example.com")
+						reply("250 AUTH PLAIN")
+					case strings.HasPrefix(command, "AUTH PLAIN "):
+						if strings.TrimPrefix(command, "AUTH PLAIN ") == want {
+							reply("235 2.7.0 Authentication successful")
+						} else {
+							reply("535 5.7.8 Authentication credentials invalid")
+						}
+					case command == "QUIT":
+						reply("221 2.0.0 Bye")
+						return
+					default:
+						reply("502 5.5.2 Command not implemented")
+					}
+				}
+			}()
+		}
+	}()
+
+	host, port, _ = net.SplitHostPort(listener.Addr().String())
+	return host, port
+}
+
+func TestSMTPVerify(t *testing.T) {
+	host, port := fakeSMTPServer(t, "user", "right")
+
+	tests := []struct {
+		name     string
+		password string
+		wantErr  string
+	}{
+		{"valid credentials", "right", ""},
+		{"wrong password", "wrong", "authentication failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewService(Config{Host: host, Port: port, Username: "user", Password: tt.password, FromEmail: "convoy@example.com"})
+			err := service.Verify()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected verification to pass, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestVerifySkipsUnconfiguredChecks(t *testing.T) {
+	if err := NewService(Config{Host: "127.0.0.1", Port: "1"}).Verify(); err == nil {
+		t.Error("Expected missing SMTP credentials to fail verification")
+	}
+	if err := NewService(Config{DryRun: true}).Verify(); err != nil {
+		t.Errorf("Expected dry-run mode to pass verification, got %v", err)
+	}
+	if err := NewService(Config{Sender: &recordingSender{}}).Verify(); err != nil {
+		t.Errorf("Expected a sender that can't be verified to pass, got %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+// Package geo provides geographic calculations shared by monitoring and the API.
+package geo
+
+import (
+	"convoy-app/backend/src/domain"
+	"math"
+	"sort"
+)
+
+// EarthRadiusKm is the mean Earth radius used for great-circle calculations
+const EarthRadiusKm = 6371
+
+// Bounds is an axis-aligned bounding box in degrees.
+type Bounds struct {
+	MinLat float64 `json:"minLat"`
+	MinLng float64 `json:"minLng"`
+	MaxLat float64 `json:"maxLat"`
+	MaxLng float64 `json:"maxLng"`
+}
+
+// Distance calculates the distance between two points in kilometers using Haversine formula
+func Distance(point1, point2 domain.LatLng) float64 {
+	lat1Rad := toRadians(point1.Lat)
+	lat2Rad := toRadians(point2.Lat)
+	deltaLatRad := toRadians(point2.Lat - point1.Lat)
+	deltaLngRad := toRadians(point2.Lng - point1.Lng)
+
+	a := math.Sin(deltaLatRad/2)*math.Sin(deltaLatRad/2) +
+		math.Cos(lat1Rad)*math.Cos(lat2Rad)*
+			math.Sin(deltaLngRad/2)*math.Sin(deltaLngRad/2)
+
+	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+
+	return EarthRadiusKm * c
+}
+
+// Bearing returns the initial great-circle bearing from point1 to point2 in
+// degrees clockwise from north, in the range [0, 360).
+func Bearing(point1, point2 domain.LatLng) float64 {
+	lat1Rad := toRadians(point1.Lat)
+	lat2Rad := toRadians(point2.Lat)
+	deltaLngRad := toRadians(point2.Lng - point1.Lng)
+
+	y := math.Sin(deltaLngRad) * math.Cos(lat2Rad)
+	x := math.Cos(lat1Rad)*math.Sin(lat2Rad) -
+		math.Sin(lat1Rad)*math.Cos(lat2Rad)*math.Cos(deltaLngRad)
+
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
+
+func toRadians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+// Center calculates the geographic center of all connected members.
+// If no member is connected, all members are used.
+func Center(members []*domain.Member) domain.LatLng {
+	members = centerMembers(members)
+	if len(members) == 0 {
+		return domain.LatLng{}
+	}
+
+	var totalLat, totalLng float64
+	for _, member := range members {
+		totalLat += member.Location.Lat
+		totalLng += member.Location.Lng
+	}
+
+	return domain.LatLng{
+		Lat: totalLat / float64(len(members)),
+		Lng: totalLng / float64(len(members)),
+	}
+}
+
+// MedianCenter returns the per-axis median position of the same members
+// Center uses. Unlike the average, it stays with the bulk of the group when a
+// few members are far away.
+func MedianCenter(members []*domain.Member) domain.LatLng {
+	members = centerMembers(members)
+	if len(members) == 0 {
+		return domain.LatLng{}
+	}
+
+	lats := make([]float64, len(members))
+	lngs := make([]float64, len(members))
+	for i, member := range members {
+		lats[i] = member.Location.Lat
+		lngs[i] = member.Location.Lng
+	}
+	return domain.LatLng{Lat: median(lats), Lng: median(lngs)}
+}
+
+// CenterPoints returns the locations of the members Center averages, so a
+// bounding box drawn around them matches the center.
+func CenterPoints(members []*domain.Member) []domain.LatLng {
+	members = centerMembers(members)
+	points := make([]domain.LatLng, len(members))
+	for i, member := range members {
+		points[i] = member.Location
+	}
+	return points
+}
+
+// centerMembers returns the members a convoy center is calculated from: the
+// connected ones with a trustworthy fix, or everyone if there are none
+func centerMembers(members []*domain.Member) []*domain.Member {
+	var connected []*domain.Member
+	for _, member := range members {
+		if member.Status == domain.StatusConnected && !member.LowConfidence {
+			connected = append(connected, member)
+		}
+	}
+	if len(connected) == 0 {
+		return members
+	}
+	return connected
+}
+
+// median returns the middle value, or the mean of the two middle values for
+// an even count. The slice is sorted in place.
+func median(values []float64) float64 {
+	sort.Float64s(values)
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2
+	}
+	return values[mid]
+}
+
+// BoundsOf returns the bounding box of the given points. A single point yields
+// a zero-area box; ok is false when there are no points.
+func BoundsOf(points []domain.LatLng) (bounds Bounds, ok bool) {
+	if len(points) == 0 {
+		return Bounds{}, false
+	}
+
+	bounds = Bounds{
+		MinLat: points[0].Lat, MaxLat: points[0].Lat,
+		MinLng: points[0].Lng, MaxLng: points[0].Lng,
+	}
+	for _, p := range points[1:] {
+		bounds.MinLat = min(bounds.MinLat, p.Lat)
+		bounds.MaxLat = max(bounds.MaxLat, p.Lat)
+		bounds.MinLng = min(bounds.MinLng, p.Lng)
+		bounds.MaxLng = max(bounds.MaxLng, p.Lng)
+	}
+	return bounds, true
+}
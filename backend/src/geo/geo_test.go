@@ -0,0 +1,168 @@
+package geo
+
+import (
+	"convoy-app/backend/src/domain"
+	"math"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	// Test distance between two known points
+	// New York City to Los Angeles (approximately 3944 km)
+	nyc := domain.LatLng{Lat: 40.7128, Lng: -74.0060}
+	la := domain.LatLng{Lat: 34.0522, Lng: -118.2437}
+
+	distance := Distance(nyc, la)
+
+	// Allow for some tolerance in the calculation
+	expectedDistance := 3944.0 // km
+	tolerance := 50.0          // km
+
+	if distance < expectedDistance-tolerance || distance > expectedDistance+tolerance {
+		t.Errorf("Expected distance around %.2f km, got %.2f km", expectedDistance, distance)
+	}
+}
+
+func TestBearing(t *testing.T) {
+	origin := domain.LatLng{Lat: 0, Lng: 0}
+
+	tests := []struct {
+		name     string
+		to       domain.LatLng
+		expected float64
+	}{
+		{"north", domain.LatLng{Lat: 1, Lng: 0}, 0},
+		{"east", domain.LatLng{Lat: 0, Lng: 1}, 90},
+		{"south", domain.LatLng{Lat: -1, Lng: 0}, 180},
+		{"west", domain.LatLng{Lat: 0, Lng: -1}, 270},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if bearing := Bearing(origin, tt.to); math.Abs(bearing-tt.expected) > 0.001 {
+				t.Errorf("Expected bearing %.1f, got %.3f", tt.expected, bearing)
+			}
+		})
+	}
+
+	// New York City to Los Angeles heads west-southwest (~274 degrees)
+	nyc := domain.LatLng{Lat: 40.7128, Lng: -74.0060}
+	la := domain.LatLng{Lat: 34.0522, Lng: -118.2437}
+	if bearing := Bearing(nyc, la); math.Abs(bearing-273.7) > 1 {
+		t.Errorf("Expected NYC->LA bearing around 273.7, got %.2f", bearing)
+	}
+}
+
+func TestCenter(t *testing.T) {
+	members := []*domain.Member{
+		{
+			ID:       1,
+			Name:     "Member1",
+			Location: domain.LatLng{Lat: 40.0, Lng: -74.0},
+			Status:   domain.StatusConnected,
+		},
+		{
+			ID:       2,
+			Name:     "Member2",
+			Location: domain.LatLng{Lat: 41.0, Lng: -73.0},
+			Status:   domain.StatusConnected,
+		},
+		{
+			ID:       3,
+			Name:     "Member3",
+			Location: domain.LatLng{Lat: 39.0, Lng: -75.0},
+			Status:   domain.StatusDisconnected, // Should be excluded from center calculation
+		},
+	}
+
+	center := Center(members)
+
+	// Expected center should be average of first two members only
+	expectedLat := (40.0 + 41.0) / 2
+	expectedLng := (-74.0 + -73.0) / 2
+
+	tolerance := 0.001
+
+	if center.Lat < expectedLat-tolerance || center.Lat > expectedLat+tolerance {
+		t.Errorf("Expected center lat %.3f, got %.3f", expectedLat, center.Lat)
+	}
+
+	if center.Lng < expectedLng-tolerance || center.Lng > expectedLng+tolerance {
+		t.Errorf("Expected center lng %.3f, got %.3f", expectedLng, center.Lng)
+	}
+}
+
+func TestMedianCenter(t *testing.T) {
+	members := []*domain.Member{
+		{ID: 1, Location: domain.LatLng{Lat: 40.0, Lng: -74.0}, Status: domain.StatusConnected},
+		{ID: 2, Location: domain.LatLng{Lat: 40.1, Lng: -74.2}, Status: domain.StatusConnected},
+		{ID: 3, Location: domain.LatLng{Lat: 45.0, Lng: -70.0}, Status: domain.StatusConnected}, // far-off straggler
+		{ID: 4, Location: domain.LatLng{Lat: 30.0, Lng: -90.0}, Status: domain.StatusDisconnected},
+	}
+
+	center := MedianCenter(members)
+	if center.Lat != 40.1 || center.Lng != -74.0 {
+		t.Errorf("Expected the per-axis median of connected members (40.1, -74.0), got %+v", center)
+	}
+
+	// Even counts average the two middle values
+	members[2].Status = domain.StatusDisconnected
+	center = MedianCenter(members)
+	if math.Abs(center.Lat-40.05) > 1e-9 || math.Abs(center.Lng+74.1) > 1e-9 {
+		t.Errorf("Expected (40.05, -74.1), got %+v", center)
+	}
+
+	if center := MedianCenter(nil); center != (domain.LatLng{}) {
+		t.Errorf("Expected a zero center without members, got %+v", center)
+	}
+}
+
+func TestCenterPoints(t *testing.T) {
+	members := []*domain.Member{
+		{ID: 1, Location: domain.LatLng{Lat: 40.0, Lng: -74.0}, Status: domain.StatusConnected},
+		{ID: 2, Location: domain.LatLng{Lat: 41.0, Lng: -73.0}, Status: domain.StatusConnected, LowConfidence: true},
+		{ID: 3, Location: domain.LatLng{Lat: 42.0, Lng: -72.0}, Status: domain.StatusLagging},
+	}
+
+	points := CenterPoints(members)
+	if len(points) != 1 || points[0] != members[0].Location {
+		t.Errorf("Expected only the confident connected member, got %+v", points)
+	}
+
+	members[0].Status = domain.StatusDisconnected
+	if points := CenterPoints(members); len(points) != 3 {
+		t.Errorf("Expected every member when none is connected, got %+v", points)
+	}
+}
+
+func TestBoundsOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		points   []domain.LatLng
+		expected Bounds
+		ok       bool
+	}{
+		{"no points", nil, Bounds{}, false},
+		{
+			"single point is a degenerate box",
+			[]domain.LatLng{{Lat: 40.0, Lng: -74.0}},
+			Bounds{MinLat: 40.0, MinLng: -74.0, MaxLat: 40.0, MaxLng: -74.0},
+			true,
+		},
+		{
+			"three points",
+			[]domain.LatLng{{Lat: 40.0, Lng: -74.0}, {Lat: 41.5, Lng: -73.0}, {Lat: 39.0, Lng: -75.5}},
+			Bounds{MinLat: 39.0, MinLng: -75.5, MaxLat: 41.5, MaxLng: -73.0},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bounds, ok := BoundsOf(tt.points)
+			if ok != tt.ok || bounds != tt.expected {
+				t.Errorf("BoundsOf() = %+v, %v; expected %+v, %v", bounds, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+// Package geocode turns member coordinates into human-readable addresses.
+package geocode
+
+import (
+	"context"
+	"convoy-app/backend/src/domain"
+	"errors"
+	"math"
+	"sync"
+)
+
+var (
+	// ErrNotConfigured is returned by Search when no provider is configured
+	ErrNotConfigured = errors.New("geocoding is not configured")
+	// ErrNoResults is returned when a search matches no place
+	ErrNoResults = errors.New("no matching place")
+)
+
+const (
+	// cachePrecision rounds coordinates to 3 decimals (~110 m) before lookup,
+	// so members creeping along a road share cached addresses
+	cachePrecision = 1000
+
+	// maxCacheEntries bounds the cache; it is cleared when full
+	maxCacheEntries = 10000
+)
+
+// Place is a named location found by a search
+type Place struct {
+	Name        string        `json:"name"`        // short name, e.g. "Yosemite Valley"
+	DisplayName string        `json:"displayName"` // full address
+	Location    domain.LatLng `json:"location"`
+}
+
+// Provider looks up the address of a location and the location of a place
+type Provider interface {
+	Reverse(ctx context.Context, location domain.LatLng) (string, error)
+	// Search returns the best match for a free-form query, or ErrNoResults
+	Search(ctx context.Context, query string) (*Place, error)
+}
+
+// Service reverse-geocodes locations through a provider, caching results per
+// rounded coordinate to stay within provider rate limits. A Service without a
+// provider returns empty addresses.
+type Service struct {
+	provider Provider
+
+	mu    sync.Mutex
+	cache map[domain.LatLng]string
+}
+
+// NewService creates a Service. provider may be nil to disable lookups.
+func NewService(provider Provider) *Service {
+	return &Service{
+		provider: provider,
+		cache:    make(map[domain.LatLng]string),
+	}
+}
+
+// IsConfigured returns true if addresses can be looked up
+func (s *Service) IsConfigured() bool {
+	return s.provider != nil
+}
+
+// Address returns the address of a location, or "" if no provider is configured
+func (s *Service) Address(ctx context.Context, location domain.LatLng) (string, error) {
+	if s.provider == nil {
+		return "", nil
+	}
+
+	key := roundLocation(location)
+	s.mu.Lock()
+	address, ok := s.cache[key]
+	s.mu.Unlock()
+	if ok {
+		return address, nil
+	}
+
+	address, err := s.provider.Reverse(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	if len(s.cache) >= maxCacheEntries {
+		s.cache = make(map[domain.LatLng]string)
+	}
+	s.cache[key] = address
+	s.mu.Unlock()
+	return address, nil
+}
+
+// Search forward-geocodes a place name. Searches are not cached: they are
+// rare, user-initiated and rarely repeated.
+func (s *Service) Search(ctx context.Context, query string) (*Place, error) {
+	if s.provider == nil {
+		return nil, ErrNotConfigured
+	}
+	return s.provider.Search(ctx, query)
+}
+
+// roundLocation snaps a location to the cache grid
+func roundLocation(location domain.LatLng) domain.LatLng {
+	return domain.LatLng{
+		Lat: math.Round(location.Lat*cachePrecision) / cachePrecision,
+		Lng: math.Round(location.Lng*cachePrecision) / cachePrecision,
+	}
+}
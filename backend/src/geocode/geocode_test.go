@@ -0,0 +1,139 @@
+package geocode
+
+import (
+	"context"
+	"convoy-app/backend/src/domain"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// stubProvider returns a fixed address and counts lookups
+type stubProvider struct {
+	address string
+	err     error
+	calls   int
+}
+
+func (p *stubProvider) Reverse(ctx context.Context, location domain.LatLng) (string, error) {
+	p.calls++
+	return p.address, p.err
+}
+
+func (p *stubProvider) Search(ctx context.Context, query string) (*Place, error) {
+	p.calls++
+	return nil, ErrNoResults
+}
+
+func TestAddressCachesPerRoundedCoordinate(t *testing.T) {
+	provider := &stubProvider{address: "Exit 23, I-95"}
+	service := NewService(provider)
+	ctx := context.Background()
+
+	for _, location := range []domain.LatLng{
+		{Lat: 40.71281, Lng: -74.00601},
+		{Lat: 40.71279, Lng: -74.00598}, // a few meters away
+	} {
+		address, err := service.Address(ctx, location)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if address != provider.address {
+			t.Errorf("Expected %q, got %q", provider.address, address)
+		}
+	}
+	if provider.calls != 1 {
+		t.Errorf("Expected nearby locations to share one lookup, got %d", provider.calls)
+	}
+
+	if _, err := service.Address(ctx, domain.LatLng{Lat: 40.8, Lng: -74.0}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if provider.calls != 2 {
+		t.Errorf("Expected a distant location to be looked up, got %d calls", provider.calls)
+	}
+}
+
+func TestAddressErrorsAreNotCached(t *testing.T) {
+	provider := &stubProvider{err: errors.New("rate limited")}
+	service := NewService(provider)
+	location := domain.LatLng{Lat: 40.0, Lng: -74.0}
+
+	if _, err := service.Address(context.Background(), location); err == nil {
+		t.Fatal("Expected the provider error")
+	}
+	provider.err = nil
+	provider.address = "Camp"
+	if address, err := service.Address(context.Background(), location); err != nil || address != "Camp" {
+		t.Errorf("Expected a retry after an error, got %q (%v)", address, err)
+	}
+}
+
+func TestAddressWithoutProvider(t *testing.T) {
+	service := NewService(nil)
+	if service.IsConfigured() {
+		t.Error("Expected a service without a provider to be unconfigured")
+	}
+	address, err := service.Address(context.Background(), domain.LatLng{Lat: 40.0, Lng: -74.0})
+	if err != nil || address != "" {
+		t.Errorf("Expected an empty address, got %q (%v)", address, err)
+	}
+}
+
+func TestNominatimProviderReverse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("lat") != "40.713" || r.URL.Query().Get("lon") != "-74.006" {
+			t.Errorf("Unexpected query %s", r.URL.RawQuery)
+		}
+		if r.Header.Get("User-Agent") != "convoy-test" {
+			t.Errorf("Expected the configured User-Agent, got %q", r.Header.Get("User-Agent"))
+		}
+		w.Write([]byte(`{"display_name": "Exit 23, I-95"}`))
+	}))
+	defer server.Close()
+
+	provider := NewNominatimProvider(server.URL, "convoy-test")
+	address, err := provider.Reverse(context.Background(), domain.LatLng{Lat: 40.713, Lng: -74.006})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if address != "Exit 23, I-95" {
+		t.Errorf("Expected the display name, got %q", address)
+	}
+}
+
+func TestSearchWithoutProvider(t *testing.T) {
+	_, err := NewService(nil).Search(context.Background(), "Yosemite Valley")
+	if !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("Expected ErrNotConfigured, got %v", err)
+	}
+}
+
+func TestNominatimProviderSearch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search" {
+			t.Errorf("Expected the search endpoint, got %s", r.URL.Path)
+		}
+		switch r.URL.Query().Get("q") {
+		case "Yosemite Valley":
+			w.Write([]byte(`[{"name": "Yosemite Valley", "display_name": "Yosemite Valley, Mariposa County, California", "lat": "37.7456", "lon": "-119.5936"}]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer server.Close()
+
+	provider := NewNominatimProvider(server.URL, "convoy-test")
+	place, err := provider.Search(context.Background(), "Yosemite Valley")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if place.Name != "Yosemite Valley" || place.Location != (domain.LatLng{Lat: 37.7456, Lng: -119.5936}) {
+		t.Errorf("Unexpected place %+v", place)
+	}
+
+	if _, err := provider.Search(context.Background(), "nowhere at all"); !errors.Is(err, ErrNoResults) {
+		t.Errorf("Expected ErrNoResults for an empty result list, got %v", err)
+	}
+}
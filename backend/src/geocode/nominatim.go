@@ -0,0 +1,116 @@
+package geocode
+
+import (
+	"context"
+	"convoy-app/backend/src/domain"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultNominatimURL is the public OpenStreetMap Nominatim instance
+const DefaultNominatimURL = "https://nominatim.openstreetmap.org"
+
+// NominatimProvider geocodes through a Nominatim-compatible HTTP API. The
+// public instance requires an identifying User-Agent and allows about one
+// request per second, which the Service cache keeps us well under.
+type NominatimProvider struct {
+	baseURL   string
+	userAgent string
+	client    *http.Client
+}
+
+// NewNominatimProvider creates a provider for the instance at baseURL, or the
+// public instance when baseURL is empty
+func NewNominatimProvider(baseURL, userAgent string) *NominatimProvider {
+	if baseURL == "" {
+		baseURL = DefaultNominatimURL
+	}
+	return &NominatimProvider{
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		userAgent: userAgent,
+		client:    &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+type nominatimPlace struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	Lat         string `json:"lat"`
+	Lon         string `json:"lon"`
+}
+
+// Reverse returns the display name of the place at location. Locations with
+// no known place, such as open sea, return "".
+func (p *NominatimProvider) Reverse(ctx context.Context, location domain.LatLng) (string, error) {
+	query := url.Values{}
+	query.Set("lat", strconv.FormatFloat(location.Lat, 'f', -1, 64))
+	query.Set("lon", strconv.FormatFloat(location.Lng, 'f', -1, 64))
+
+	var result nominatimPlace
+	if err := p.get(ctx, "/reverse", query, &result); err != nil {
+		return "", err
+	}
+	return result.DisplayName, nil
+}
+
+// Search returns the best match for a free-form query
+func (p *NominatimProvider) Search(ctx context.Context, query string) (*Place, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("limit", "1")
+
+	var results []nominatimPlace
+	if err := p.get(ctx, "/search", params, &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, ErrNoResults
+	}
+
+	lat, latErr := strconv.ParseFloat(results[0].Lat, 64)
+	lng, lngErr := strconv.ParseFloat(results[0].Lon, 64)
+	if latErr != nil || lngErr != nil {
+		return nil, fmt.Errorf("geocode API returned invalid coordinates %q, %q", results[0].Lat, results[0].Lon)
+	}
+
+	name := results[0].Name
+	if name == "" {
+		name = results[0].DisplayName
+	}
+	return &Place{Name: name, DisplayName: results[0].DisplayName, Location: domain.LatLng{Lat: lat, Lng: lng}}, nil
+}
+
+// get requests a JSON-formatted Nominatim endpoint and decodes the response into v
+func (p *NominatimProvider) get(ctx context.Context, path string, query url.Values, v any) error {
+	query.Set("format", "jsonv2")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to build geocode request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if p.userAgent != "" {
+		req.Header.Set("User-Agent", p.userAgent)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("geocode request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("geocode API returned status %d: %s", resp.StatusCode, body)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode geocode response: %w", err)
+	}
+	return nil
+}
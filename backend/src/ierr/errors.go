@@ -7,4 +7,28 @@ var (
 	ErrNotFound = errors.New("not found")
 	// ErrConflict is returned when creating a resource that already exists.
 	ErrConflict = errors.New("resource already exists")
+	// ErrInvalidToken is returned when a supplied token does not match.
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrConvoyFull is returned when a convoy has reached its member limit.
+	ErrConvoyFull = errors.New("convoy is full")
+	// ErrExpired is returned when a time-limited token is used after it expired.
+	ErrExpired = errors.New("expired")
+	// ErrUsedUp is returned when a token has no uses left.
+	ErrUsedUp = errors.New("no uses left")
+	// ErrTokenUsed is returned when a single-use token is presented again.
+	ErrTokenUsed = errors.New("token already used")
+	// ErrTooManyAttempts is returned when a code has been guessed wrong too often.
+	ErrTooManyAttempts = errors.New("too many attempts")
+	// ErrNoDestination is returned when changing a destination that was never set.
+	ErrNoDestination = errors.New("no destination set")
+	// ErrCapacity is returned when the server holds as many convoys as it allows.
+	ErrCapacity = errors.New("server is at capacity")
+	// ErrMemberLeft is returned alongside ErrNotFound for updates to a member
+	// that recently left, so late requests can be told apart from bad IDs.
+	ErrMemberLeft = errors.New("member has left the convoy")
+	// ErrIdempotencyMismatch is returned when an idempotency key is reused
+	// for a different request.
+	ErrIdempotencyMismatch = errors.New("idempotency key was used for a different request")
+	// ErrInProgress is returned when an identical request is still being handled.
+	ErrInProgress = errors.New("request is already in progress")
 )
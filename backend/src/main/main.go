@@ -3,7 +3,10 @@ package main
 import (
 	"context"
 	"convoy-app/backend/src/api"
+	"convoy-app/backend/src/config"
+	"convoy-app/backend/src/cors"
 	"convoy-app/backend/src/storage"
+	"convoy-app/backend/src/ws"
 	"fmt"
 	"log"
 	"net/http"
@@ -13,13 +16,16 @@ import (
 	"time"
 )
 
+// allowedOrigins is the shared CORS origin policy
+var allowedOrigins = cors.DefaultPolicy()
+
 // corsMiddleware adds CORS headers to the response with dynamic origin detection.
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		origin := r.Header.Get("Origin")
 
 		// Allow requests from development origins (localhost and local network IPs on port 3000)
-		if isAllowedOrigin(origin) {
+		if allowedOrigins.Allows(origin) {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
 		}
 
@@ -37,77 +43,17 @@ func corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// isAllowedOrigin checks if the origin is allowed for CORS requests
-func isAllowedOrigin(origin string) bool {
-	if origin == "" {
-		return false
-	}
-
-	// Allow localhost development
-	if origin == "http://localhost:3000" || origin == "http://127.0.0.1:3000" {
-		return true
-	}
-
-	// Allow local network IPs on port 3000 (for mobile testing)
-	// This matches patterns like http://192.168.1.18:3000, http://10.0.0.5:3000, etc.
-	if len(origin) > 7 && origin[:7] == "http://" {
-		// Extract the part after "http://"
-		hostPort := origin[7:]
-
-		// Check if it ends with ":3000"
-		if len(hostPort) > 5 && hostPort[len(hostPort)-5:] == ":3000" {
-			// Extract the IP part
-			ip := hostPort[:len(hostPort)-5]
-
-			// Allow private IP ranges commonly used in local networks
-			return isPrivateIP(ip)
-		}
-	}
-
-	return false
-}
-
-// isPrivateIP checks if an IP address is in a private range
-func isPrivateIP(ip string) bool {
-	// Common private IP ranges:
-	// 192.168.x.x (most common home networks)
-	// 10.x.x.x (corporate networks)
-	// 172.16.x.x - 172.31.x.x (less common)
-	// 127.x.x.x (localhost)
-
-	if len(ip) >= 7 {
-		// Check 192.168.x.x
-		if len(ip) >= 8 && ip[:8] == "192.168." {
-			return true
-		}
-
-		// Check 10.x.x.x
-		if len(ip) >= 3 && ip[:3] == "10." {
-			return true
-		}
-
-		// Check 127.x.x.x (localhost)
-		if len(ip) >= 4 && ip[:4] == "127." {
-			return true
-		}
-
-		// Check 172.16.x.x - 172.31.x.x
-		if len(ip) >= 7 && ip[:4] == "172." {
-			// This is a simplified check - in production you'd want more precise validation
-			return true
-		}
-	}
-
-	return false
-}
-
 func main() {
 	// 1. Initialize the storage layer.
 	memStorage := storage.NewMemoryStorage()
 	log.Println("In-memory storage initialized.")
 
 	// 2. Initialize the API layer, injecting the storage dependency.
-	apiServer := api.New(memStorage)
+	cfg := config.Load()
+	allowedOrigins = cors.NewPolicy(cfg.AllowedOrigins, cfg.AllowedOriginSuffixes)
+	wsHub := ws.NewHub()
+	wsHub.SetOriginPolicy(allowedOrigins)
+	apiServer := api.New(memStorage, wsHub, cfg)
 	log.Println("API layer initialized.")
 
 	// 3. Set up the HTTP router and register our handlers.
@@ -0,0 +1,58 @@
+package monitoring
+
+import (
+	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/geo"
+	"time"
+)
+
+// memberSpeedKmh is the member's speed between its last two track points.
+// ok is false when there aren't two points to compare.
+func memberSpeedKmh(member *domain.Member) (speed float64, ok bool) {
+	if len(member.Track) < 2 {
+		return 0, false
+	}
+	last, previous := member.Track[len(member.Track)-1], member.Track[len(member.Track)-2]
+	elapsed := last.Timestamp.Sub(previous.Timestamp)
+	if elapsed <= 0 {
+		return 0, false
+	}
+	return geo.Distance(previous.LatLng, last.LatLng) / elapsed.Hours(), true
+}
+
+// convoyAggregate computes the headline numbers for the leader view from the
+// members with live locations
+func (cm *ConvoyMonitor) convoyAggregate(convoy *domain.Convoy, now time.Time) *domain.ConvoyAggregate {
+	aggregate := &domain.ConvoyAggregate{UpdatedAt: now}
+
+	var live []*domain.Member
+	for _, member := range convoy.Members {
+		if member.Status == domain.StatusConnected || member.Status == domain.StatusLagging {
+			live = append(live, member)
+		}
+	}
+	if len(live) == 0 {
+		return aggregate
+	}
+	aggregate.LiveMembers = len(live)
+
+	var totalSpeed float64
+	var withSpeed, arrived int
+	for i, member := range live {
+		if speed, ok := memberSpeedKmh(member); ok {
+			totalSpeed += speed
+			withSpeed++
+		}
+		if cm.HasArrived(convoy, member) {
+			arrived++
+		}
+		for _, other := range live[i+1:] {
+			aggregate.SpreadKm = max(aggregate.SpreadKm, geo.Distance(member.Location, other.Location))
+		}
+	}
+	if withSpeed > 0 {
+		aggregate.AverageSpeedKmh = totalSpeed / float64(withSpeed)
+	}
+	aggregate.ArrivedFraction = float64(arrived) / float64(len(live))
+	return aggregate
+}
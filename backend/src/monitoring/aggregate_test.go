@@ -0,0 +1,100 @@
+package monitoring
+
+import (
+	"context"
+	"convoy-app/backend/src/config"
+	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/storage"
+	"math"
+	"testing"
+	"time"
+)
+
+// trackAt returns a two-point track ending at to, covering the distance from
+// from in the given time
+func trackAt(from, to domain.LatLng, now time.Time, elapsed time.Duration) []domain.TrackPoint {
+	return []domain.TrackPoint{
+		{LatLng: from, Timestamp: now.Add(-elapsed)},
+		{LatLng: to, Timestamp: now},
+	}
+}
+
+func TestConvoyAggregate(t *testing.T) {
+	cfg := config.Load()
+	cfg.ArrivalRadiusMeters = 200
+	monitor := NewConvoyMonitor(nil, nil, cfg)
+	now := time.Now()
+
+	// 0.01 degrees of latitude is about 1.11 km
+	camp := domain.LatLng{Lat: 40.0, Lng: -74.0}
+	road := domain.LatLng{Lat: 40.01, Lng: -74.0}
+	farther := domain.LatLng{Lat: 40.02, Lng: -74.0}
+
+	arrived := &domain.Member{ID: 1, Status: domain.StatusConnected, Location: camp}
+	driving := &domain.Member{ID: 2, Status: domain.StatusConnected, Location: road,
+		Track: trackAt(farther, road, now, time.Minute)} // ~66.7 km/h
+	lagging := &domain.Member{ID: 3, Status: domain.StatusLagging, Location: farther,
+		Track: trackAt(farther, farther, now, time.Minute)} // stopped
+	offline := &domain.Member{ID: 4, Status: domain.StatusDisconnected, Location: domain.LatLng{Lat: 41.0, Lng: -74.0}}
+	convoy := &domain.Convoy{
+		ID:          "convoy-1",
+		Members:     []*domain.Member{arrived, driving, lagging, offline},
+		Destination: &domain.Destination{Name: "Camp", Lat: camp.Lat, Lng: camp.Lng},
+	}
+
+	aggregate := monitor.convoyAggregate(convoy, now)
+	if aggregate.LiveMembers != 3 {
+		t.Errorf("Expected 3 live members, got %d", aggregate.LiveMembers)
+	}
+	if math.Abs(aggregate.AverageSpeedKmh-33.36) > 0.5 {
+		t.Errorf("Expected an average of about 33.4 km/h over members with a speed, got %.2f", aggregate.AverageSpeedKmh)
+	}
+	if math.Abs(aggregate.ArrivedFraction-1.0/3) > 1e-9 {
+		t.Errorf("Expected a third of live members to have arrived, got %.3f", aggregate.ArrivedFraction)
+	}
+	if math.Abs(aggregate.SpreadKm-2.224) > 0.01 {
+		t.Errorf("Expected a spread of about 2.22 km between camp and the lagging member, got %.3f", aggregate.SpreadKm)
+	}
+	if !aggregate.UpdatedAt.Equal(now) {
+		t.Errorf("Expected the aggregate to be stamped with the check time")
+	}
+}
+
+func TestConvoyAggregateWithoutLiveMembers(t *testing.T) {
+	monitor := NewConvoyMonitor(nil, nil, config.Load())
+	convoy := &domain.Convoy{ID: "convoy-1", Members: []*domain.Member{
+		{ID: 1, Status: domain.StatusDisconnected, Location: domain.LatLng{Lat: 40.0, Lng: -74.0}},
+		{ID: 2, Status: domain.StatusConnecting, Location: domain.LatLng{Lat: 41.0, Lng: -74.0}},
+	}}
+
+	aggregate := monitor.convoyAggregate(convoy, time.Now())
+	if aggregate.LiveMembers != 0 || aggregate.AverageSpeedKmh != 0 || aggregate.ArrivedFraction != 0 || aggregate.SpreadKm != 0 {
+		t.Errorf("Expected zeros without live members, got %+v", aggregate)
+	}
+}
+
+func TestCheckStoresConvoyAggregate(t *testing.T) {
+	ctx := context.Background()
+	memStorage := storage.NewMemoryStorage()
+	monitor := NewConvoyMonitor(memStorage, nil, config.Load())
+
+	convoy, err := memStorage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	for _, member := range []*domain.Member{
+		{ID: 1, Name: "A", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}},
+		{ID: 2, Name: "B", Location: domain.LatLng{Lat: 40.01, Lng: -74.0}},
+	} {
+		if err := memStorage.AddMember(ctx, convoy.ID, member); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+	}
+
+	monitor.checkAllConvoys()
+
+	stored, _ := memStorage.GetConvoy(ctx, convoy.ID)
+	if stored.Aggregate == nil || stored.Aggregate.LiveMembers != 2 || math.Abs(stored.Aggregate.SpreadKm-1.112) > 0.01 {
+		t.Errorf("Expected the check to store the aggregate, got %+v", stored.Aggregate)
+	}
+}
@@ -0,0 +1,50 @@
+package monitoring
+
+import (
+	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/geo"
+)
+
+// HasArrived reports whether the member is within the arrival radius of the
+// convoy's destination. Always false when no destination is set.
+func (cm *ConvoyMonitor) HasArrived(convoy *domain.Convoy, member *domain.Member) bool {
+	if convoy.Destination == nil {
+		return false
+	}
+	distanceKm := geo.Distance(member.Location, convoy.Destination.ToLatLng())
+	return distanceKm*1000 <= cm.arrivalRadiusMeters(convoy.Destination)
+}
+
+// arrivalRadiusMeters returns the destination's own arrival radius, or the
+// configured default for destinations that don't set one
+func (cm *ConvoyMonitor) arrivalRadiusMeters(destination *domain.Destination) float64 {
+	if destination.ArrivalRadiusMeters > 0 {
+		return destination.ArrivalRadiusMeters
+	}
+	return cm.config.ArrivalRadiusMeters
+}
+
+// ConvoyArrived reports whether every member that is still connected has
+// reached the destination. Disconnected members don't hold the convoy back,
+// but at least one member must have arrived.
+func (cm *ConvoyMonitor) ConvoyArrived(convoy *domain.Convoy) bool {
+	arrived := 0
+	for _, member := range convoy.Members {
+		if member.IsDisconnected() {
+			continue
+		}
+		if !cm.HasArrived(convoy, member) {
+			return false
+		}
+		arrived++
+	}
+	return arrived > 0
+}
+
+// IsScattered reports whether the convoy was scattered as of the last check
+func (cm *ConvoyMonitor) IsScattered(convoyID string) bool {
+	cm.stateMu.Lock()
+	defer cm.stateMu.Unlock()
+	state, ok := cm.states[convoyID]
+	return ok && state.scattered != nil
+}
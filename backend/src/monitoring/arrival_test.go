@@ -0,0 +1,62 @@
+package monitoring
+
+import (
+	"convoy-app/backend/src/config"
+	"convoy-app/backend/src/domain"
+	"testing"
+)
+
+func TestConvoyArrived(t *testing.T) {
+	cfg := config.Load()
+	cfg.ArrivalRadiusMeters = 200
+	monitor := NewConvoyMonitor(nil, nil, cfg)
+
+	atCamp := &domain.Member{ID: 1, Status: domain.StatusConnected, Location: domain.LatLng{Lat: 40.001, Lng: -74.0}} // ~110m away
+	onRoad := &domain.Member{ID: 2, Status: domain.StatusConnected, Location: domain.LatLng{Lat: 40.01, Lng: -74.0}}  // ~1.1km away
+	convoy := &domain.Convoy{ID: "convoy-1", Members: []*domain.Member{atCamp, onRoad}}
+
+	if monitor.ConvoyArrived(convoy) {
+		t.Error("Expected no arrival without a destination")
+	}
+
+	convoy.Destination = &domain.Destination{Name: "Camp", Lat: 40.0, Lng: -74.0}
+	if !monitor.HasArrived(convoy, atCamp) || monitor.HasArrived(convoy, onRoad) {
+		t.Error("Expected only the member within 200m to have arrived")
+	}
+	if monitor.ConvoyArrived(convoy) {
+		t.Error("Expected the convoy to wait for the member still on the road")
+	}
+
+	// A member who dropped out doesn't hold the convoy back
+	onRoad.Status = domain.StatusDisconnected
+	if !monitor.ConvoyArrived(convoy) {
+		t.Error("Expected the convoy to have arrived once only disconnected members are away")
+	}
+}
+
+func TestArrivalUsesDestinationRadius(t *testing.T) {
+	cfg := config.Load()
+	cfg.ArrivalRadiusMeters = 200
+	monitor := NewConvoyMonitor(nil, nil, cfg)
+
+	member := &domain.Member{ID: 1, Status: domain.StatusConnected, Location: domain.LatLng{Lat: 40.005, Lng: -74.0}} // ~560m away
+	convoy := &domain.Convoy{ID: "convoy-1", Members: []*domain.Member{member}}
+
+	tests := []struct {
+		name    string
+		radius  float64
+		arrived bool
+	}{
+		{"server default", 0, false},
+		{"parking lot", 100, false},
+		{"stadium", 1000, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			convoy.Destination = &domain.Destination{Name: "Venue", Lat: 40.0, Lng: -74.0, ArrivalRadiusMeters: tt.radius}
+			if got := monitor.HasArrived(convoy, member); got != tt.arrived {
+				t.Errorf("Expected arrived=%v with a %.0fm radius, got %v", tt.arrived, tt.radius, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package monitoring
+
+import (
+	"convoy-app/backend/src/config"
+	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/geo"
+)
+
+// convoyCenter returns the reference point lagging is measured from, per the
+// configured CenterStrategy. The centroid drifts toward the middle of a group
+// strung out along a highway, flagging the front and back as lagging; the
+// leader and median strategies keep the reference with the group.
+func (cm *ConvoyMonitor) convoyCenter(convoy *domain.Convoy) domain.LatLng {
+	switch cm.config.CenterStrategy {
+	case config.CenterStrategyLeader:
+		if leader := leaderOf(convoy); leader != nil && !leader.IsDisconnected() && leader.Location != (domain.LatLng{}) {
+			return leader.Location
+		}
+	case config.CenterStrategyMedian:
+		return geo.MedianCenter(convoy.Members)
+	}
+	return geo.Center(convoy.Members)
+}
+
+// leaderOf returns the convoy's leader, or nil if it has none
+func leaderOf(convoy *domain.Convoy) *domain.Member {
+	for _, member := range convoy.Members {
+		if member.ID == convoy.LeaderID {
+			return member
+		}
+	}
+	return nil
+}
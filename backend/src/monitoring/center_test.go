@@ -0,0 +1,95 @@
+package monitoring
+
+import (
+	"context"
+	"convoy-app/backend/src/config"
+	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/storage"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestLaggingDependsOnCenterStrategy(t *testing.T) {
+	// Kilometers north of the start along a highway: three members bunched
+	// at the start, one 6km ahead and the leader 7km ahead
+	const kmPerDegree = 111.195
+	positions := []struct {
+		id int64
+		km float64
+	}{{1, 0}, {2, 0}, {3, 0}, {4, 6}, {5, 7}}
+
+	tests := []struct {
+		strategy string
+		lagging  []int64
+	}{
+		{config.CenterStrategyCentroid, []int64{5}},     // center at 2.6km
+		{config.CenterStrategyMedian, []int64{4, 5}},    // center with the bunch at 0km
+		{config.CenterStrategyLeader, []int64{1, 2, 3}}, // center on the leader at 7km
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			ctx := context.Background()
+			storage := storage.NewMemoryStorage()
+			cfg := config.Load()
+			cfg.CenterStrategy = tt.strategy
+			cfg.MaxDistanceFromConvoy = 4
+			cfg.LaggingEnterMarginKm = 0
+			cfg.LaggingExitMarginKm = 0
+			monitor := NewConvoyMonitor(storage, nil, cfg)
+
+			convoy, err := storage.CreateConvoy(ctx)
+			if err != nil {
+				t.Fatalf("Failed to create convoy: %v", err)
+			}
+			for _, position := range positions {
+				member := &domain.Member{ID: position.id, Name: "Driver", Location: domain.LatLng{Lat: 40.0 + position.km/kmPerDegree, Lng: -74.0}}
+				if err := storage.AddMember(ctx, convoy.ID, member); err != nil {
+					t.Fatalf("Failed to add member: %v", err)
+				}
+			}
+			if err := storage.SetConvoyLeader(ctx, convoy.ID, 5); err != nil {
+				t.Fatalf("Failed to set leader: %v", err)
+			}
+
+			monitor.checkConvoyHealth(convoy)
+
+			var lagging []int64
+			for _, member := range convoy.Members {
+				if member.Status == domain.StatusLagging {
+					lagging = append(lagging, member.ID)
+				}
+			}
+			if !reflect.DeepEqual(lagging, tt.lagging) {
+				t.Errorf("Expected members %v lagging, got %v", tt.lagging, lagging)
+			}
+		})
+	}
+}
+
+func TestLeaderCenterFallsBackWithoutLeader(t *testing.T) {
+	cfg := config.Load()
+	cfg.CenterStrategy = config.CenterStrategyLeader
+	monitor := NewConvoyMonitor(nil, nil, cfg)
+
+	leader := &domain.Member{ID: 1, Status: domain.StatusDisconnected, Location: domain.LatLng{Lat: 41.0, Lng: -74.0}}
+	a := &domain.Member{ID: 2, Status: domain.StatusConnected, Location: domain.LatLng{Lat: 40.0, Lng: -74.0}}
+	b := &domain.Member{ID: 3, Status: domain.StatusConnected, Location: domain.LatLng{Lat: 40.2, Lng: -74.0}}
+	convoy := &domain.Convoy{ID: "convoy-1", LeaderID: leader.ID, Members: []*domain.Member{leader, a, b}}
+
+	want := domain.LatLng{Lat: 40.1, Lng: -74.0}
+	if got := monitor.convoyCenter(convoy); !approxEqual(got, want) {
+		t.Errorf("Expected the centroid %+v while the leader is disconnected, got %+v", want, got)
+	}
+
+	leader.Status = domain.StatusConnected
+	if got := monitor.convoyCenter(convoy); got != leader.Location {
+		t.Errorf("Expected the leader's position %+v, got %+v", leader.Location, got)
+	}
+}
+
+// approxEqual compares positions up to floating point rounding
+func approxEqual(a, b domain.LatLng) bool {
+	const epsilon = 1e-9
+	return math.Abs(a.Lat-b.Lat) < epsilon && math.Abs(a.Lng-b.Lng) < epsilon
+}
@@ -0,0 +1,102 @@
+package monitoring
+
+import (
+	"convoy-app/backend/src/domain"
+	"log"
+	"time"
+)
+
+// removeDepartedMembers takes members out of the convoy once they have reached
+// the destination and then stayed disconnected for ArrivedRemovalGrace, so the
+// member list doesn't fill up with people who have finished the trip. It does
+// nothing unless AutoRemoveArrived is set. Returns whether anyone was removed.
+func (cm *ConvoyMonitor) removeDepartedMembers(convoy *domain.Convoy, now time.Time) bool {
+	if !cm.config.AutoRemoveArrived {
+		return false
+	}
+
+	arrived := func(member *domain.Member) bool { return cm.HasArrived(convoy, member) }
+	cm.stateMu.Lock()
+	state := cm.stateFor(convoy.ID)
+	var departed []*domain.Member
+	departed, state.arrivedGoneSince = overdueMembers(state.arrivedGoneSince, convoy, arrived, cm.config.ArrivedRemovalGrace, now)
+	cm.stateMu.Unlock()
+
+	removed := false
+	for _, member := range departed {
+		if cm.removeMember(convoy, member, now) {
+			removed = true
+			log.Printf("Member %d (%s) arrived and left convoy %s - removed", member.ID, member.Name, convoy.ID)
+		}
+	}
+	return removed
+}
+
+// removeDisconnectedMembers takes members out of the convoy once they have
+// stayed disconnected for DisconnectedRemovalGrace, wherever they are. Within
+// the grace period they keep their place, so a reconnect picks up where they
+// left off. It does nothing while DisconnectedRemovalGrace is 0. Returns
+// whether anyone was removed.
+func (cm *ConvoyMonitor) removeDisconnectedMembers(convoy *domain.Convoy, now time.Time) bool {
+	if cm.config.DisconnectedRemovalGrace <= 0 {
+		return false
+	}
+
+	anyone := func(*domain.Member) bool { return true }
+	cm.stateMu.Lock()
+	state := cm.stateFor(convoy.ID)
+	var gone []*domain.Member
+	gone, state.disconnectedSince = overdueMembers(state.disconnectedSince, convoy, anyone, cm.config.DisconnectedRemovalGrace, now)
+	cm.stateMu.Unlock()
+
+	removed := false
+	for _, member := range gone {
+		if cm.removeMember(convoy, member, now) {
+			removed = true
+			log.Printf("Member %d (%s) disconnected for over %v - removed from convoy %s", member.ID, member.Name, cm.config.DisconnectedRemovalGrace, convoy.ID)
+		}
+	}
+	return removed
+}
+
+// overdueMembers returns the disconnected members matching eligible who were
+// first seen disconnected at least grace ago, per tracked, along with the
+// tracking map for the next check. The map is rebuilt each check so members
+// who reconnected, stopped matching or left by themselves are forgotten, and
+// overdue members are dropped from it.
+func overdueMembers(tracked map[int64]time.Time, convoy *domain.Convoy, eligible func(*domain.Member) bool, grace time.Duration, now time.Time) (overdue []*domain.Member, goneSince map[int64]time.Time) {
+	goneSince = make(map[int64]time.Time)
+	for _, member := range convoy.Members {
+		if !member.IsDisconnected() || !eligible(member) {
+			continue
+		}
+		since, ok := tracked[member.ID]
+		if !ok {
+			since = now
+		}
+		if now.Sub(since) >= grace {
+			overdue = append(overdue, member)
+			continue
+		}
+		goneSince[member.ID] = since
+	}
+	return overdue, goneSince
+}
+
+// removeMember takes a member out of the convoy and broadcasts MEMBER_LEFT.
+// Returns whether the member was removed.
+func (cm *ConvoyMonitor) removeMember(convoy *domain.Convoy, member *domain.Member, now time.Time) bool {
+	if err := cm.storage.LeaveConvoy(cm.ctx, convoy.ID, member.ID); err != nil {
+		log.Printf("Error removing member %d from convoy %s: %v", member.ID, convoy.ID, err)
+		return false
+	}
+
+	cm.broadcast(convoy.ID, &domain.ConvoyAlert{
+		EventType:  domain.EventMemberLeft,
+		ConvoyID:   convoy.ID,
+		MemberID:   member.ID,
+		MemberName: member.Name,
+		Timestamp:  now,
+	})
+	return true
+}
@@ -0,0 +1,182 @@
+package monitoring
+
+import (
+	"context"
+	"convoy-app/backend/src/config"
+	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/storage"
+	"testing"
+	"time"
+)
+
+func TestArrivedMemberRemovedAfterGracePeriod(t *testing.T) {
+	ctx := context.Background()
+	storage := storage.NewMemoryStorage()
+	wsHub := newFakeHub(3) // members 1 and 2 have closed the app
+	cfg := config.Load()
+	cfg.AutoRemoveArrived = true
+	cfg.ArrivedRemovalGrace = 10 * time.Minute
+	cfg.JoinGracePeriod = 0 // members 1 and 2 joined long ago
+	monitor := NewConvoyMonitor(storage, wsHub, cfg)
+
+	convoy, err := storage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	if err := storage.SetConvoyDestination(ctx, convoy.ID, &domain.Destination{Name: "Camp", Lat: 40.0, Lng: -74.0}); err != nil {
+		t.Fatalf("Failed to set destination: %v", err)
+	}
+	arrived := &domain.Member{ID: 1, Name: "Arrived", Location: domain.LatLng{Lat: 40.0005, Lng: -74.0}}
+	stranded := &domain.Member{ID: 2, Name: "Stranded", Location: domain.LatLng{Lat: 40.02, Lng: -74.0}}
+	driving := &domain.Member{ID: 3, Name: "Driving", Location: domain.LatLng{Lat: 40.02, Lng: -74.0}}
+	for _, member := range []*domain.Member{arrived, stranded, driving} {
+		if err := storage.AddMember(ctx, convoy.ID, member); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+	}
+
+	// The arrived member is marked disconnected but stays during the grace period
+	monitor.checkAllConvoys()
+	if len(convoy.Members) != 3 {
+		t.Fatalf("Expected all members to stay during the grace period, got %d", len(convoy.Members))
+	}
+
+	// Pretend the arrived member has been gone for longer than the grace period
+	monitor.stateFor(convoy.ID).arrivedGoneSince[arrived.ID] = time.Now().Add(-11 * time.Minute)
+	wsHub.broadcasts = nil
+	monitor.checkAllConvoys()
+
+	if len(convoy.Members) != 2 {
+		t.Fatalf("Expected the arrived member to be removed, got %d members", len(convoy.Members))
+	}
+	for _, member := range convoy.Members {
+		if member.ID == arrived.ID {
+			t.Fatal("Expected the arrived member to be removed")
+		}
+	}
+	var left *domain.ConvoyAlert
+	for _, message := range wsHub.broadcasts {
+		if alert, ok := message.(*domain.ConvoyAlert); ok && alert.EventType == domain.EventMemberLeft {
+			left = alert
+		}
+	}
+	if left == nil || left.MemberID != arrived.ID || left.MemberName != "Arrived" {
+		t.Fatalf("Expected a %s alert for the arrived member, got %+v", domain.EventMemberLeft, left)
+	}
+}
+
+func TestArrivedMemberKeptWhenAutoRemoveDisabled(t *testing.T) {
+	ctx := context.Background()
+	storage := storage.NewMemoryStorage()
+	wsHub := newFakeHub()
+	cfg := config.Load()
+	cfg.AutoRemoveArrived = false
+	monitor := NewConvoyMonitor(storage, wsHub, cfg)
+
+	convoy, err := storage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	if err := storage.SetConvoyDestination(ctx, convoy.ID, &domain.Destination{Name: "Camp", Lat: 40.0, Lng: -74.0}); err != nil {
+		t.Fatalf("Failed to set destination: %v", err)
+	}
+	if err := storage.AddMember(ctx, convoy.ID, &domain.Member{ID: 1, Name: "Arrived", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}}); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+
+	monitor.checkAllConvoys()
+	monitor.checkAllConvoys()
+	if len(convoy.Members) != 1 {
+		t.Errorf("Expected the member to stay when auto-removal is off, got %d members", len(convoy.Members))
+	}
+	if len(monitor.stateFor(convoy.ID).arrivedGoneSince) != 0 {
+		t.Errorf("Expected no departure tracking when auto-removal is off")
+	}
+}
+
+func TestDisconnectedMemberRemovedAfterGracePeriod(t *testing.T) {
+	ctx := context.Background()
+	storage := storage.NewMemoryStorage()
+	wsHub := newFakeHub(2) // member 1 has lost its connection
+	cfg := config.Load()
+	cfg.DisconnectedRemovalGrace = 5 * time.Minute
+	cfg.JoinGracePeriod = 0
+	monitor := NewConvoyMonitor(storage, wsHub, cfg)
+
+	convoy, err := storage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	for _, member := range []*domain.Member{
+		{ID: 1, Name: "Gone", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}},
+		{ID: 2, Name: "Driving", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}},
+	} {
+		if err := storage.AddMember(ctx, convoy.ID, member); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+	}
+
+	// Within the grace period the member is disconnected but kept
+	monitor.checkAllConvoys()
+	if len(convoy.Members) != 2 {
+		t.Fatalf("Expected the disconnected member to be retained, got %d members", len(convoy.Members))
+	}
+
+	monitor.stateFor(convoy.ID).disconnectedSince[1] = time.Now().Add(-6 * time.Minute)
+	wsHub.broadcasts = nil
+	monitor.checkAllConvoys()
+
+	if len(convoy.Members) != 1 || convoy.Members[0].ID != 2 {
+		t.Fatalf("Expected only the connected member to remain, got %+v", convoy.Members)
+	}
+	var left *domain.ConvoyAlert
+	for _, message := range wsHub.broadcasts {
+		if alert, ok := message.(*domain.ConvoyAlert); ok && alert.EventType == domain.EventMemberLeft {
+			left = alert
+		}
+	}
+	if left == nil || left.MemberID != 1 || left.MemberName != "Gone" {
+		t.Fatalf("Expected a %s alert for the disconnected member, got %+v", domain.EventMemberLeft, left)
+	}
+}
+
+func TestDisconnectedMemberKeptAfterReconnectWithinGrace(t *testing.T) {
+	ctx := context.Background()
+	storage := storage.NewMemoryStorage()
+	wsHub := newFakeHub()
+	cfg := config.Load()
+	cfg.DisconnectedRemovalGrace = 5 * time.Minute
+	cfg.JoinGracePeriod = 0
+	monitor := NewConvoyMonitor(storage, wsHub, cfg)
+
+	convoy, err := storage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	member := &domain.Member{ID: 1, Name: "Tunnel", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}}
+	if err := storage.AddMember(ctx, convoy.ID, member); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+
+	monitor.checkAllConvoys()
+	if !member.IsDisconnected() {
+		t.Fatalf("Expected the member to be disconnected, got %s", member.Status)
+	}
+
+	// The member comes back before the grace period ends
+	wsHub.connected[1] = true
+	if err := storage.UpdateMemberLocation(ctx, convoy.ID, 1, domain.LatLng{Lat: 40.0, Lng: -74.0}); err != nil {
+		t.Fatalf("Failed to update location: %v", err)
+	}
+	monitor.checkAllConvoys()
+	if len(monitor.stateFor(convoy.ID).disconnectedSince) != 0 {
+		t.Error("Expected a reconnected member to be forgotten")
+	}
+
+	// A later disconnect starts a fresh grace period instead of removing them
+	delete(wsHub.connected, 1)
+	monitor.checkAllConvoys()
+	if len(convoy.Members) != 1 || member.Status != domain.StatusDisconnected {
+		t.Fatalf("Expected the member to be retained after reconnecting, got %d members", len(convoy.Members))
+	}
+}
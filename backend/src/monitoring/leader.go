@@ -0,0 +1,85 @@
+package monitoring
+
+import (
+	"convoy-app/backend/src/domain"
+	"log"
+	"time"
+)
+
+// checkLeader hands leadership to the longest-connected member once the leader
+// has been disconnected for LeaderReassignGrace, or straight away if the leader
+// has left the convoy. A leader who later reconnects does not get it back.
+// Returns the event type sent, or "" if leadership didn't change.
+func (cm *ConvoyMonitor) checkLeader(convoy *domain.Convoy, now time.Time) string {
+	var leader *domain.Member
+	for _, member := range convoy.Members {
+		if member.ID == convoy.LeaderID {
+			leader = member
+			break
+		}
+	}
+
+	cm.stateMu.Lock()
+	state := cm.stateFor(convoy.ID)
+	if leader != nil {
+		if !leader.IsDisconnected() {
+			state.leaderDownSince = time.Time{}
+			cm.stateMu.Unlock()
+			return ""
+		}
+
+		if state.leaderDownSince.IsZero() {
+			state.leaderDownSince = now
+		}
+		if now.Sub(state.leaderDownSince) < cm.config.LeaderReassignGrace {
+			cm.stateMu.Unlock()
+			return ""
+		}
+	}
+	cm.stateMu.Unlock()
+
+	successor := longestConnectedMember(convoy.Members)
+	if successor == nil {
+		return "" // nobody to hand over to; try again next tick
+	}
+
+	previousLeaderID := convoy.LeaderID
+	if err := cm.storage.SetConvoyLeader(cm.ctx, convoy.ID, successor.ID); err != nil {
+		log.Printf("Error reassigning leader of convoy %s to member %d: %v", convoy.ID, successor.ID, err)
+		return ""
+	}
+
+	cm.stateMu.Lock()
+	cm.stateFor(convoy.ID).leaderDownSince = time.Time{}
+	cm.stateMu.Unlock()
+
+	convoy.LeaderID = successor.ID
+	log.Printf("Leadership of convoy %s passed from member %d to %s (%d)",
+		convoy.ID, previousLeaderID, successor.Name, successor.ID)
+
+	cm.broadcast(convoy.ID, &domain.ConvoyAlert{
+		EventType:        domain.EventLeaderChanged,
+		ConvoyID:         convoy.ID,
+		MemberID:         successor.ID,
+		MemberName:       successor.Name,
+		PreviousLeaderID: previousLeaderID,
+		Timestamp:        now,
+	})
+	return domain.EventLeaderChanged
+}
+
+// longestConnectedMember returns the member that has gone longest without a
+// disconnect, or nil if no member is connected. Members still connecting are
+// skipped: they may never open their WebSocket.
+func longestConnectedMember(members []*domain.Member) *domain.Member {
+	var best *domain.Member
+	for _, member := range members {
+		if member.IsDisconnected() || member.Status == domain.StatusConnecting || member.ConnectedSince.IsZero() {
+			continue
+		}
+		if best == nil || member.ConnectedSince.Before(best.ConnectedSince) {
+			best = member
+		}
+	}
+	return best
+}
@@ -0,0 +1,97 @@
+package monitoring
+
+import (
+	"context"
+	"convoy-app/backend/src/config"
+	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/storage"
+	"testing"
+	"time"
+)
+
+func TestLeaderReassignedAfterGracePeriod(t *testing.T) {
+	ctx := context.Background()
+	storage := storage.NewMemoryStorage()
+	wsHub := newFakeHub(2, 3) // the leader has dropped out
+	cfg := config.Load()
+	cfg.LeaderReassignGrace = 2 * time.Minute
+	cfg.JoinGracePeriod = 0 // the leader joined long ago
+	monitor := NewConvoyMonitor(storage, wsHub, cfg)
+
+	convoy, err := storage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	leader := &domain.Member{ID: 1, Name: "Leader", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}}
+	recent := &domain.Member{ID: 2, Name: "Recent", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}}
+	veteran := &domain.Member{ID: 3, Name: "Veteran", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}}
+	for _, member := range []*domain.Member{leader, recent, veteran} {
+		if err := storage.AddMember(ctx, convoy.ID, member); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+	}
+	if convoy.LeaderID != leader.ID {
+		t.Fatalf("Expected the first member to lead, got %d", convoy.LeaderID)
+	}
+	veteran.ConnectedSince = time.Now().Add(-time.Hour)
+
+	// The leader is marked disconnected, but the grace period hasn't passed
+	monitor.checkAllConvoys()
+	if convoy.LeaderID != leader.ID {
+		t.Fatalf("Expected leadership to stay put during the grace period, got %d", convoy.LeaderID)
+	}
+
+	// Pretend the leader has been gone for longer than the grace period
+	monitor.stateFor(convoy.ID).leaderDownSince = time.Now().Add(-3 * time.Minute)
+	wsHub.broadcasts = nil
+	monitor.checkAllConvoys()
+
+	if convoy.LeaderID != veteran.ID {
+		t.Fatalf("Expected the longest-connected member to lead, got %d", convoy.LeaderID)
+	}
+	var changed *domain.ConvoyAlert
+	for _, message := range wsHub.broadcasts {
+		if alert, ok := message.(*domain.ConvoyAlert); ok && alert.EventType == domain.EventLeaderChanged {
+			changed = alert
+		}
+	}
+	if changed == nil || changed.MemberID != veteran.ID || changed.PreviousLeaderID != leader.ID {
+		t.Fatalf("Expected a %s alert from %d to %d, got %+v", domain.EventLeaderChanged, leader.ID, veteran.ID, changed)
+	}
+
+	// The original leader comes back but does not reclaim leadership
+	wsHub.connected[leader.ID] = true
+	leader.LastUpdate = time.Now()
+	monitor.checkAllConvoys()
+	if convoy.LeaderID != veteran.ID {
+		t.Errorf("Expected the new leader to keep leadership, got %d", convoy.LeaderID)
+	}
+}
+
+func TestLeaderReassignedWhenLeaderLeaves(t *testing.T) {
+	ctx := context.Background()
+	storage := storage.NewMemoryStorage()
+	wsHub := newFakeHub(1, 2)
+	monitor := NewConvoyMonitor(storage, wsHub, config.Load())
+
+	convoy, err := storage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	for _, member := range []*domain.Member{
+		{ID: 1, Name: "Leader", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}},
+		{ID: 2, Name: "Follower", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}},
+	} {
+		if err := storage.AddMember(ctx, convoy.ID, member); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+	}
+	if err := storage.LeaveConvoy(ctx, convoy.ID, 1); err != nil {
+		t.Fatalf("Failed to remove leader: %v", err)
+	}
+
+	monitor.checkAllConvoys()
+	if convoy.LeaderID != 2 {
+		t.Errorf("Expected the remaining member to lead immediately, got %d", convoy.LeaderID)
+	}
+}
@@ -2,45 +2,101 @@ package monitoring
 
 import (
 	"context"
+	"convoy-app/backend/src/config"
 	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/geo"
 	"convoy-app/backend/src/storage"
 	"convoy-app/backend/src/ws"
+	"crypto/rand"
+	"encoding/hex"
 	"log"
 	"math"
+	mathrand "math/rand/v2"
 	"sync"
 	"time"
 )
 
-// Monitoring thresholds - Conservative values to reduce false alerts while maintaining safety
-const (
-	MaxDistanceFromConvoy        = 3.0  // kilometers - accommodates normal highway convoy spread and traffic separation
-	DisconnectedTimeout          = 60   // seconds - reduces false alerts from temporary GPS/network issues while maintaining timely detection
-	InactiveCleanupTimeout       = 3600 // 1 hour - time before closing inactive WebSocket connections
-	ScatteredThreshold           = 0.5  // 50% of members far from center
-	SingleMemberScatteredTimeout = 300  // 5 minutes for single-member convoys
-	MonitoringInterval           = 10   // seconds
-)
+// Hub is the subset of the WebSocket hub the monitor depends on
+type Hub interface {
+	Broadcast(convoyID string, message interface{})
+	HasActiveConnection(convoyID string, memberID int64) bool
+	GetMemberConnection(convoyID string, memberID int64) *ws.Connection
+	UnregisterMember(convoyID string, memberID int64)
+	// LastActivity is when the member's socket last answered a ping or sent a
+	// message, or zero if the member has no connection
+	LastActivity(convoyID string, memberID int64) time.Time
+	// ReconnectAfter is the load-aware delay clients should wait before reconnecting
+	ReconnectAfter() time.Duration
+}
+
+// AlertNotifier receives every alert the monitor broadcasts, e.g. to forward
+// it to an external webhook
+type AlertNotifier interface {
+	Notify(alert *domain.ConvoyAlert)
+}
+
+// ConvoyBroadcaster sends the convoy state after a check changed member
+// statuses, given the alerts that check produced, so routine updates can be
+// throttled while safety-critical ones are not
+type ConvoyBroadcaster interface {
+	BroadcastConvoyUpdate(ctx context.Context, convoyID string, eventTypes []string)
+}
 
 // ConvoyMonitor manages convoy health monitoring
 type ConvoyMonitor struct {
-	storage storage.Storage
-	wsHub   *ws.Hub
-	ctx     context.Context
-	cancel  context.CancelFunc
-	wg      sync.WaitGroup
-	mu      sync.RWMutex
-	running bool
+	storage  storage.Storage
+	wsHub    Hub
+	notifier AlertNotifier
+	updates  ConvoyBroadcaster
+	config   *config.Config // Runtime thresholds for lagging/inactive/scattered detection, and the check interval
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	mu       sync.RWMutex
+	running  bool
+
+	stateMu sync.Mutex
+	states  map[string]*convoyState // convoyID -> what the checks remember about the convoy
+}
+
+// convoyState is what the monitor remembers about one convoy between checks.
+// It is guarded by ConvoyMonitor.stateMu and dropped once the convoy is no
+// longer active, so a convoy that refills starts from a clean slate.
+type convoyState struct {
+	scattered         *scatteredState        // present while scattered as of the last check
+	motion            *motionState           // movement of the convoy center
+	memberMotion      map[int64]*motionState // memberID -> movement of the member
+	leaderDownSince   time.Time              // when the current leader was first seen disconnected, zero while connected
+	reconnecting      map[int64]time.Time    // memberID -> when the member's WebSocket closed
+	arrivedGoneSince  map[int64]time.Time    // memberID -> when an arrived member was first seen disconnected
+	disconnectedSince map[int64]time.Time    // memberID -> when a member was first seen disconnected
 }
 
 // NewConvoyMonitor creates a new convoy monitoring service
-func NewConvoyMonitor(storage storage.Storage, wsHub *ws.Hub) *ConvoyMonitor {
+func NewConvoyMonitor(storage storage.Storage, wsHub Hub, cfg *config.Config) *ConvoyMonitor {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &ConvoyMonitor{
 		storage: storage,
 		wsHub:   wsHub,
+		config:  cfg,
 		ctx:     ctx,
 		cancel:  cancel,
+		states:  make(map[string]*convoyState),
+	}
+}
+
+// stateFor returns the state kept for a convoy, creating it on first use.
+// Callers must hold cm.stateMu.
+func (cm *ConvoyMonitor) stateFor(convoyID string) *convoyState {
+	state, ok := cm.states[convoyID]
+	if !ok {
+		state = &convoyState{
+			memberMotion: make(map[int64]*motionState),
+			reconnecting: make(map[int64]time.Time),
+		}
+		cm.states[convoyID] = state
 	}
+	return state
 }
 
 // Start begins the monitoring process
@@ -80,32 +136,77 @@ func (cm *ConvoyMonitor) Stop() {
 	log.Println("Convoy monitoring service stopped")
 }
 
+// IsRunning reports whether the monitoring loop has been started and not stopped
+func (cm *ConvoyMonitor) IsRunning() bool {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return cm.running
+}
+
+// nextInterval returns the configured delay before the next health check,
+// including a random share of the configured jitter
+func (cm *ConvoyMonitor) nextInterval() time.Duration {
+	interval := cm.config.MonitoringInterval
+	if interval <= 0 {
+		interval = config.DefaultMonitoringInterval
+	}
+	if cm.config.MonitoringJitter <= 0 {
+		return interval
+	}
+	return interval + mathrand.N(cm.config.MonitoringJitter)
+}
+
 // monitorLoop runs the main monitoring loop
 func (cm *ConvoyMonitor) monitorLoop() {
-	ticker := time.NewTicker(MonitoringInterval * time.Second)
-	defer ticker.Stop()
+	timer := time.NewTimer(cm.nextInterval())
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-cm.ctx.Done():
 			return
-		case <-ticker.C:
+		case <-timer.C:
 			cm.checkAllConvoys()
+			timer.Reset(cm.nextInterval())
 		}
 	}
 }
 
-// checkAllConvoys monitors all active convoys
+// checkAllConvoys monitors all active convoys. It works on snapshots, since
+// API requests keep adding, moving and removing members while it runs; every
+// change it makes goes through storage.
 func (cm *ConvoyMonitor) checkAllConvoys() {
-	convoys, err := cm.storage.GetAllActiveConvoys(cm.ctx)
+	convoys, err := cm.storage.GetAllActiveConvoysSnapshot(cm.ctx)
 	if err != nil {
 		log.Printf("Error getting active convoys: %v", err)
 		return
 	}
 
+	active := make(map[string]bool, len(convoys))
 	for _, convoy := range convoys {
+		active[convoy.ID] = true
 		cm.checkConvoyHealth(convoy)
 	}
+
+	// Forget convoys that emptied out or were removed
+	cm.stateMu.Lock()
+	for convoyID := range cm.states {
+		if !active[convoyID] {
+			delete(cm.states, convoyID)
+		}
+	}
+	cm.stateMu.Unlock()
+}
+
+// CheckConvoy re-evaluates a single convoy immediately instead of waiting for the next tick
+func (cm *ConvoyMonitor) CheckConvoy(convoyID string) {
+	convoy, err := cm.storage.GetConvoySnapshot(cm.ctx, convoyID)
+	if err != nil {
+		log.Printf("Error getting convoy %s for immediate check: %v", convoyID, err)
+		return
+	}
+
+	cm.checkConvoyHealth(convoy)
 }
 
 // checkConvoyHealth analyzes a single convoy's health
@@ -114,12 +215,25 @@ func (cm *ConvoyMonitor) checkConvoyHealth(convoy *domain.Convoy) {
 		return
 	}
 
+	// Paused convoys (e.g. a meal stop) keep receiving location updates,
+	// but statuses are frozen so no lagging/scattered/disconnected alerts fire
+	if convoy.Paused {
+		cm.forgetMotion(convoy.ID)
+		return
+	}
+
+	// Likewise while the group is still gathering, if monitoring waits for the start
+	if cm.config.RequireStart && convoy.StartedAt == nil {
+		return
+	}
+
 	now := time.Now()
-	convoyCenter := cm.calculateConvoyCenter(convoy.Members)
+	convoyCenter := cm.convoyCenter(convoy)
 
 	var disconnectedMembers []*domain.Member
 	var laggingMembers []*domain.Member
 	var statusChanged bool
+	var eventTypes []string
 
 	// Check each member's status
 	for _, member := range convoy.Members {
@@ -133,9 +247,13 @@ func (cm *ConvoyMonitor) checkConvoyHealth(convoy *domain.Convoy) {
 				log.Printf("Error updating member %d status: %v", member.ID, err)
 				continue
 			}
+			// Keep the snapshot in step for the convoy-level checks below
+			member.UpdateStatus(newStatus)
 
 			// Send appropriate alert
-			cm.sendMemberStatusAlert(convoy.ID, member, newStatus, oldStatus, convoyCenter)
+			if eventType := cm.sendMemberStatusAlert(convoy.ID, member, newStatus, oldStatus, convoyCenter); eventType != "" {
+				eventTypes = append(eventTypes, eventType)
+			}
 		}
 
 		// Collect members by status for convoy-level analysis
@@ -155,12 +273,28 @@ func (cm *ConvoyMonitor) checkConvoyHealth(convoy *domain.Convoy) {
 		}
 	}
 
+	if err := cm.storage.SetConvoyAggregate(cm.ctx, convoy.ID, cm.convoyAggregate(convoy, now)); err != nil {
+		log.Printf("Error updating aggregate for convoy %s: %v", convoy.ID, err)
+	}
+
 	// Check for convoy-level alerts
-	cm.checkConvoyScattered(convoy, laggingMembers, disconnectedMembers)
+	if eventType := cm.checkConvoyScattered(convoy, laggingMembers, disconnectedMembers, now); eventType != "" {
+		eventTypes = append(eventTypes, eventType)
+	}
+	cm.checkMemberMotion(convoy, convoyCenter, now)
+	if eventType := cm.checkLeader(convoy, now); eventType != "" {
+		eventTypes = append(eventTypes, eventType)
+		statusChanged = true
+	}
+	departed := cm.removeDepartedMembers(convoy, now)
+	if cm.removeDisconnectedMembers(convoy, now) || departed {
+		eventTypes = append(eventTypes, domain.EventMemberLeft)
+		statusChanged = true
+	}
 
 	// If any status changed, broadcast updated convoy data
 	if statusChanged {
-		cm.broadcastConvoyUpdate(convoy)
+		cm.broadcastConvoyUpdate(convoy, eventTypes)
 	}
 }
 
@@ -168,8 +302,15 @@ func (cm *ConvoyMonitor) checkConvoyHealth(convoy *domain.Convoy) {
 func (cm *ConvoyMonitor) determineMemberStatus(convoyID string, member *domain.Member, convoyCenter domain.LatLng, now time.Time) string {
 	// First check if member has an active WebSocket connection
 	// If no WebSocket connection, member is definitely disconnected
-	hasActiveConnection := cm.wsHub.HasActiveConnection(convoyID, member.ID)
-	if !hasActiveConnection {
+	if !cm.hasActiveConnection(convoyID, member.ID) {
+		// A member who just joined may not have opened its WebSocket yet
+		if now.Sub(member.JoinedAt) < cm.config.JoinGracePeriod {
+			return domain.StatusConnecting
+		}
+		// A socket that closed moments ago may just be a network blip
+		if cm.isReconnecting(convoyID, member.ID, now) {
+			return domain.StatusReconnecting
+		}
 		log.Printf("Member %d (%s) marked as disconnected: no active WebSocket connection", member.ID, member.Name)
 		return domain.StatusDisconnected
 	}
@@ -177,32 +318,129 @@ func (cm *ConvoyMonitor) determineMemberStatus(convoyID string, member *domain.M
 	// If WebSocket is connected, check location update recency
 	// This handles cases where connection exists but location tracking stopped
 	timeSinceUpdate := now.Sub(member.LastUpdate)
-	if timeSinceUpdate > DisconnectedTimeout*time.Second {
-		// Check if member has been inactive for too long (cleanup threshold)
-		if timeSinceUpdate > InactiveCleanupTimeout*time.Second {
-			// Close the WebSocket connection for long-term inactive members
-			log.Printf("Member %d inactive for %v (>%ds) - closing WebSocket connection", member.ID, timeSinceUpdate, InactiveCleanupTimeout)
+	if timeSinceUpdate > cm.config.DisconnectedTimeout {
+		// Long past tracking, the connection is closed only if nobody is
+		// watching either: a socket that still answers pings belongs to a
+		// member who paused GPS but keeps the app open
+		if timeSinceUpdate > cm.config.InactiveCleanupTimeout && !cm.isWatching(convoyID, member.ID, now) {
+			log.Printf("Member %d inactive for %v (>%v) and not watching - closing WebSocket connection", member.ID, timeSinceUpdate, cm.config.InactiveCleanupTimeout)
 			cm.closeInactiveConnection(convoyID, member.ID)
 			return domain.StatusDisconnected
 		}
 
-		// Member has WebSocket connection but no recent location updates
-		// Mark as inactive instead of disconnected to preserve the connection
+		// Clients that send heartbeats tell us whether the app is still open. A stale
+		// heartbeat means the app is gone and only the socket's pongs are keeping
+		// it alive. Clients that never heartbeat keep the location-only behavior.
+		if !member.LastSeen.IsZero() && now.Sub(member.LastSeen) > cm.config.HeartbeatTimeout {
+			log.Printf("Member %d has active WebSocket but no heartbeat for %v - marking as disconnected", member.ID, now.Sub(member.LastSeen))
+			return domain.StatusDisconnected
+		}
+
+		// App is open but GPS is paused: mark as inactive instead of
+		// disconnected to preserve the connection
 		log.Printf("Member %d has active WebSocket but no location updates for %v - marking as inactive", member.ID, timeSinceUpdate)
 		return domain.StatusInactive
 	}
 
-	// Check if member is lagging (too far from convoy center)
-	distance := cm.calculateDistance(member.Location, convoyCenter)
-	if distance > MaxDistanceFromConvoy {
+	// An inaccurate fix can't start or end lagging; keep what we knew
+	if member.LowConfidence {
+		if member.Status == domain.StatusLagging {
+			return domain.StatusLagging
+		}
+		return domain.StatusConnected
+	}
+
+	// Check if member is lagging (too far from convoy center). The threshold
+	// depends on the current status so a member hovering around the limit
+	// doesn't flap between lagging and connected on every check.
+	distance := geo.Distance(member.Location, convoyCenter)
+	if member.Status == domain.StatusLagging {
+		if distance > cm.config.MaxDistanceFromConvoy-cm.config.LaggingExitMarginKm {
+			return domain.StatusLagging
+		}
+	} else if distance > cm.config.MaxDistanceFromConvoy+cm.config.LaggingEnterMarginKm {
 		return domain.StatusLagging
 	}
 
 	return domain.StatusConnected
 }
 
+// hasActiveConnection checks the hub for a member's WebSocket connection.
+// Without a hub (unit tests) every member is treated as connected.
+func (cm *ConvoyMonitor) hasActiveConnection(convoyID string, memberID int64) bool {
+	if cm.wsHub == nil {
+		return true
+	}
+	return cm.wsHub.HasActiveConnection(convoyID, memberID)
+}
+
+// isWatching reports whether the member's socket has shown signs of life
+// within the watching timeout
+func (cm *ConvoyMonitor) isWatching(convoyID string, memberID int64, now time.Time) bool {
+	if cm.wsHub == nil {
+		return false
+	}
+	lastActivity := cm.wsHub.LastActivity(convoyID, memberID)
+	return !lastActivity.IsZero() && now.Sub(lastActivity) <= cm.config.WatchingTimeout
+}
+
+// SetNotifier forwards all future alerts to the given notifier
+func (cm *ConvoyMonitor) SetNotifier(notifier AlertNotifier) {
+	cm.notifier = notifier
+}
+
+// SetConvoyBroadcaster routes convoy updates through the given broadcaster
+// instead of sending them straight to the hub
+func (cm *ConvoyMonitor) SetConvoyBroadcaster(broadcaster ConvoyBroadcaster) {
+	cm.updates = broadcaster
+}
+
+// broadcast sends a message to all of a convoy's connections, if a hub is set.
+// Alerts are also recorded in the convoy's event log and passed to the notifier.
+func (cm *ConvoyMonitor) broadcast(convoyID string, message interface{}) {
+	if alert, ok := message.(*domain.ConvoyAlert); ok {
+		if alert.AlertID == "" && cm.isCritical(alert.EventType) {
+			alert.AlertID = newAlertID()
+		}
+		if cm.storage != nil {
+			if err := cm.storage.AppendConvoyEvent(cm.ctx, alert); err != nil {
+				log.Printf("Error logging %s event for convoy %s: %v", alert.EventType, convoyID, err)
+			}
+		}
+		if cm.notifier != nil {
+			cm.notifier.Notify(alert)
+		}
+	}
+	if cm.wsHub == nil {
+		return
+	}
+	cm.wsHub.Broadcast(convoyID, message)
+}
+
+// isCritical reports whether clients must acknowledge alerts of this type
+func (cm *ConvoyMonitor) isCritical(eventType string) bool {
+	for _, critical := range cm.config.BroadcastForcedEvents {
+		if critical == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// newAlertID returns a random identifier for a critical alert
+func newAlertID() string {
+	bytes := make([]byte, 8)
+	if _, err := rand.Read(bytes); err != nil {
+		return time.Now().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(bytes)
+}
+
 // closeInactiveConnection closes WebSocket connection for long-term inactive members
 func (cm *ConvoyMonitor) closeInactiveConnection(convoyID string, memberID int64) {
+	if cm.wsHub == nil {
+		return
+	}
 	if conn := cm.wsHub.GetMemberConnection(convoyID, memberID); conn != nil {
 		log.Printf("Closing inactive WebSocket connection for member %d in convoy %s", memberID, convoyID)
 		conn.Close()
@@ -210,8 +448,9 @@ func (cm *ConvoyMonitor) closeInactiveConnection(convoyID string, memberID int64
 	}
 }
 
-// sendMemberStatusAlert sends WebSocket alerts for member status changes
-func (cm *ConvoyMonitor) sendMemberStatusAlert(convoyID string, member *domain.Member, newStatus, oldStatus string, convoyCenter domain.LatLng) {
+// sendMemberStatusAlert sends WebSocket alerts for member status changes and
+// returns the event type sent, or "" if the change doesn't warrant an alert
+func (cm *ConvoyMonitor) sendMemberStatusAlert(convoyID string, member *domain.Member, newStatus, oldStatus string, convoyCenter domain.LatLng) string {
 	alert := &domain.ConvoyAlert{
 		ConvoyID:   convoyID,
 		MemberID:   member.ID,
@@ -224,23 +463,34 @@ func (cm *ConvoyMonitor) sendMemberStatusAlert(convoyID string, member *domain.M
 		if oldStatus != domain.StatusDisconnected && oldStatus != domain.StatusInactive {
 			alert.EventType = domain.EventMemberDisconnected
 			alert.LastSeen = member.LastUpdate
-			cm.wsHub.Broadcast(convoyID, alert)
+			if cm.wsHub != nil {
+				alert.ReconnectAfterMs = cm.wsHub.ReconnectAfter().Milliseconds()
+			}
+			cm.broadcast(convoyID, alert)
 			log.Printf("Member %s (%d) disconnected from convoy %s", member.Name, member.ID, convoyID)
 		}
 
+	case domain.StatusReconnecting:
+		// A soft event: clients can grey the member out without raising an
+		// alarm. Coming back in time sends nothing more.
+		alert.EventType = domain.EventMemberReconnecting
+		alert.LastSeen = member.LastUpdate
+		cm.broadcast(convoyID, alert)
+		log.Printf("Member %s (%d) is reconnecting to convoy %s", member.Name, member.ID, convoyID)
+
 	case domain.StatusInactive:
 		if oldStatus == domain.StatusConnected || oldStatus == domain.StatusLagging {
 			alert.EventType = domain.EventMemberInactive
 			alert.LastSeen = member.LastUpdate
-			cm.wsHub.Broadcast(convoyID, alert)
+			cm.broadcast(convoyID, alert)
 			log.Printf("Member %s (%d) became inactive in convoy %s (no location updates)", member.Name, member.ID, convoyID)
 		}
 
 	case domain.StatusLagging:
 		if oldStatus == domain.StatusConnected {
 			alert.EventType = domain.EventMemberLagging
-			alert.Distance = cm.calculateDistance(member.Location, convoyCenter)
-			cm.wsHub.Broadcast(convoyID, alert)
+			alert.Distance = geo.Distance(member.Location, convoyCenter)
+			cm.broadcast(convoyID, alert)
 			log.Printf("Member %s (%d) is lagging in convoy %s (%.2fkm from center)",
 				member.Name, member.ID, convoyID, alert.Distance)
 		}
@@ -248,25 +498,29 @@ func (cm *ConvoyMonitor) sendMemberStatusAlert(convoyID string, member *domain.M
 	case domain.StatusConnected:
 		if oldStatus == domain.StatusDisconnected {
 			alert.EventType = domain.EventMemberReconnected
-			cm.wsHub.Broadcast(convoyID, alert)
+			cm.broadcast(convoyID, alert)
 			log.Printf("Member %s (%d) reconnected to convoy %s", member.Name, member.ID, convoyID)
 		} else if oldStatus == domain.StatusInactive {
 			alert.EventType = domain.EventMemberReactivated
-			cm.wsHub.Broadcast(convoyID, alert)
+			cm.broadcast(convoyID, alert)
 			log.Printf("Member %s (%d) reactivated location tracking in convoy %s", member.Name, member.ID, convoyID)
 		}
 	}
+
+	return alert.EventType
 }
 
-// checkConvoyScattered checks if the convoy is scattered
-func (cm *ConvoyMonitor) checkConvoyScattered(convoy *domain.Convoy, laggingMembers, disconnectedMembers []*domain.Member) {
+// checkConvoyScattered checks if the convoy is scattered and returns the event
+// type sent, or "" if nothing changed
+func (cm *ConvoyMonitor) checkConvoyScattered(convoy *domain.Convoy, laggingMembers, disconnectedMembers []*domain.Member, now time.Time) string {
 	totalMembers := len(convoy.Members)
 	if totalMembers == 0 {
-		return
+		return ""
 	}
 
 	scatteredCount := len(laggingMembers) + len(disconnectedMembers)
 	scatteredRatio := float64(scatteredCount) / float64(totalMembers)
+	isScattered := scatteredRatio >= cm.config.ScatteredThreshold
 
 	// Special handling for single-member convoys to prevent immediate "scattered" alerts
 	if totalMembers == 1 && len(disconnectedMembers) == 1 {
@@ -274,84 +528,125 @@ func (cm *ConvoyMonitor) checkConvoyScattered(convoy *domain.Convoy, laggingMemb
 		disconnectedMember := disconnectedMembers[0]
 		timeSinceDisconnect := time.Since(disconnectedMember.LastUpdate)
 
-		if timeSinceDisconnect < SingleMemberScatteredTimeout*time.Second {
+		if timeSinceDisconnect < cm.config.SingleMemberScatteredTimeout {
 			// Don't mark as scattered yet - member might reconnect soon
-			log.Printf("Single-member convoy %s: member %s disconnected for %v (threshold: %ds)",
-				convoy.ID, disconnectedMember.Name, timeSinceDisconnect, SingleMemberScatteredTimeout)
-			return
+			log.Printf("Single-member convoy %s: member %s disconnected for %v (threshold: %v)",
+				convoy.ID, disconnectedMember.Name, timeSinceDisconnect, cm.config.SingleMemberScatteredTimeout)
+			isScattered = false
+		} else {
+			log.Printf("Single-member convoy %s marked as scattered: member %s disconnected for %v",
+				convoy.ID, disconnectedMember.Name, timeSinceDisconnect)
 		}
+	}
 
-		log.Printf("Single-member convoy %s marked as scattered: member %s disconnected for %v",
-			convoy.ID, disconnectedMember.Name, timeSinceDisconnect)
+	// Transitions are broadcast, like member status alerts. A convoy that
+	// stays scattered is reminded about after a cooldown and escalated once.
+	cm.stateMu.Lock()
+	convoyState := cm.stateFor(convoy.ID)
+	state := convoyState.scattered
+	var eventType string
+	switch {
+	case isScattered && state == nil:
+		state = &scatteredState{since: now, lastAlert: now}
+		convoyState.scattered = state
+		eventType = domain.EventConvoyScattered
+	case isScattered:
+		eventType = state.due(now, cm.config.ScatteredReminderInterval, cm.config.ScatteredEscalateAfter)
+	case state != nil:
+		convoyState.scattered = nil
+		eventType = domain.EventConvoyRegrouped
 	}
+	cm.stateMu.Unlock()
 
-	// For multi-member convoys or single-member convoys with extended disconnection
-	if scatteredRatio >= ScatteredThreshold {
-		alert := &domain.ConvoyAlert{
-			EventType:      domain.EventConvoyScattered,
-			ConvoyID:       convoy.ID,
-			ScatteredCount: scatteredCount,
-			Timestamp:      time.Now(),
-		}
+	if eventType == "" {
+		return ""
+	}
 
-		cm.wsHub.Broadcast(convoy.ID, alert)
-		log.Printf("Convoy %s is scattered: %d/%d members are far from the group",
+	alert := &domain.ConvoyAlert{
+		EventType:   eventType,
+		ConvoyID:    convoy.ID,
+		MemberCount: totalMembers,
+		Timestamp:   time.Now(),
+	}
+
+	if isScattered {
+		since := state.since
+		alert.ScatteredCount = scatteredCount
+		alert.ScatteredSince = &since
+		log.Printf("Convoy %s is scattered (%s): %d/%d members are far from the group, since %v",
+			convoy.ID, eventType, scatteredCount, totalMembers, now.Sub(since).Round(time.Second))
+	} else {
+		log.Printf("Convoy %s regrouped: %d/%d members are far from the group",
 			convoy.ID, scatteredCount, totalMembers)
 	}
+
+	cm.broadcast(convoy.ID, alert)
+	return alert.EventType
 }
 
-// broadcastConvoyUpdate sends updated convoy data to all connected clients
-func (cm *ConvoyMonitor) broadcastConvoyUpdate(convoy *domain.Convoy) {
-	cm.wsHub.Broadcast(convoy.ID, convoy)
+// scatteredState tracks a convoy that is currently scattered
+type scatteredState struct {
+	since     time.Time // when the convoy became scattered
+	lastAlert time.Time // when a scattered alert was last sent
+	escalated bool
 }
 
-// calculateConvoyCenter calculates the geographic center of all connected members
-func (cm *ConvoyMonitor) calculateConvoyCenter(members []*domain.Member) domain.LatLng {
-	if len(members) == 0 {
-		return domain.LatLng{}
+// due returns the alert a still-scattered convoy should get now, if any: the
+// escalation once it has been scattered for escalateAfter, otherwise a
+// reminder every reminderInterval. Zero durations disable either.
+func (s *scatteredState) due(now time.Time, reminderInterval, escalateAfter time.Duration) string {
+	if escalateAfter > 0 && !s.escalated && now.Sub(s.since) >= escalateAfter {
+		s.escalated = true
+		s.lastAlert = now
+		return domain.EventConvoyScatteredEscalated
 	}
-
-	var totalLat, totalLng float64
-	connectedCount := 0
-
-	for _, member := range members {
-		// Only include connected members in center calculation
-		if member.Status == domain.StatusConnected {
-			totalLat += member.Location.Lat
-			totalLng += member.Location.Lng
-			connectedCount++
-		}
+	if reminderInterval > 0 && now.Sub(s.lastAlert) >= reminderInterval {
+		s.lastAlert = now
+		return domain.EventConvoyScattered
 	}
+	return ""
+}
 
-	if connectedCount == 0 {
-		// If no connected members, use all members
-		for _, member := range members {
-			totalLat += member.Location.Lat
-			totalLng += member.Location.Lng
-		}
-		connectedCount = len(members)
+// broadcastConvoyUpdate sends updated convoy data to all connected clients,
+// through the convoy broadcaster when one is set
+func (cm *ConvoyMonitor) broadcastConvoyUpdate(convoy *domain.Convoy, eventTypes []string) {
+	if cm.updates != nil {
+		cm.updates.BroadcastConvoyUpdate(cm.ctx, convoy.ID, eventTypes)
+		return
 	}
-
-	return domain.LatLng{
-		Lat: totalLat / float64(connectedCount),
-		Lng: totalLng / float64(connectedCount),
+	// The convoy checked is a snapshot; members removed since are gone from storage
+	if cm.storage != nil {
+		if latest, err := cm.storage.GetConvoy(cm.ctx, convoy.ID); err == nil {
+			convoy = latest
+		}
 	}
+	cm.broadcast(convoy.ID, convoy)
 }
 
-// calculateDistance calculates the distance between two points in kilometers using Haversine formula
-func (cm *ConvoyMonitor) calculateDistance(point1, point2 domain.LatLng) float64 {
-	const earthRadius = 6371 // Earth's radius in kilometers
+// FindNearestMember returns the member closest to the given one, with the
+// distance in kilometers. Only members with live locations (connected or
+// lagging) are candidates; nil is returned if there are none.
+func (cm *ConvoyMonitor) FindNearestMember(convoy *domain.Convoy, member *domain.Member) (*domain.Member, float64) {
+	var nearest *domain.Member
+	nearestDistance := math.MaxFloat64
 
-	lat1Rad := point1.Lat * math.Pi / 180
-	lat2Rad := point2.Lat * math.Pi / 180
-	deltaLatRad := (point2.Lat - point1.Lat) * math.Pi / 180
-	deltaLngRad := (point2.Lng - point1.Lng) * math.Pi / 180
-
-	a := math.Sin(deltaLatRad/2)*math.Sin(deltaLatRad/2) +
-		math.Cos(lat1Rad)*math.Cos(lat2Rad)*
-			math.Sin(deltaLngRad/2)*math.Sin(deltaLngRad/2)
+	for _, other := range convoy.Members {
+		if other.ID == member.ID {
+			continue
+		}
+		if other.Status != domain.StatusConnected && other.Status != domain.StatusLagging {
+			continue
+		}
 
-	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+		distance := geo.Distance(member.Location, other.Location)
+		if distance < nearestDistance {
+			nearest = other
+			nearestDistance = distance
+		}
+	}
 
-	return earthRadius * c
+	if nearest == nil {
+		return nil, 0
+	}
+	return nearest, nearestDistance
 }
@@ -2,75 +2,70 @@ package monitoring
 
 import (
 	"context"
+	"convoy-app/backend/src/config"
 	"convoy-app/backend/src/domain"
 	"convoy-app/backend/src/storage"
 	"convoy-app/backend/src/ws"
+	"math"
+	"slices"
+	"sync/atomic"
 	"testing"
 	"time"
 )
 
-func TestCalculateDistance(t *testing.T) {
-	monitor := &ConvoyMonitor{}
-
-	// Test distance between two known points
-	// New York City to Los Angeles (approximately 3944 km)
-	nyc := domain.LatLng{Lat: 40.7128, Lng: -74.0060}
-	la := domain.LatLng{Lat: 34.0522, Lng: -118.2437}
-
-	distance := monitor.calculateDistance(nyc, la)
-
-	// Allow for some tolerance in the calculation
-	expectedDistance := 3944.0 // km
-	tolerance := 50.0 // km
+// fakeHub records broadcasts and reports members in connected as having a WebSocket
+type fakeHub struct {
+	connected  map[int64]bool
+	activity   map[int64]time.Time // last pong or message per member
+	broadcasts []interface{}
+}
 
-	if distance < expectedDistance-tolerance || distance > expectedDistance+tolerance {
-		t.Errorf("Expected distance around %.2f km, got %.2f km", expectedDistance, distance)
+func newFakeHub(connectedIDs ...int64) *fakeHub {
+	hub := &fakeHub{connected: make(map[int64]bool), activity: make(map[int64]time.Time)}
+	for _, id := range connectedIDs {
+		hub.connected[id] = true
 	}
+	return hub
 }
 
-func TestCalculateConvoyCenter(t *testing.T) {
-	monitor := &ConvoyMonitor{}
+func (f *fakeHub) Broadcast(convoyID string, message interface{}) {
+	f.broadcasts = append(f.broadcasts, message)
+}
 
-	members := []*domain.Member{
-		{
-			ID:       1,
-			Name:     "Member1",
-			Location: domain.LatLng{Lat: 40.0, Lng: -74.0},
-			Status:   domain.StatusConnected,
-		},
-		{
-			ID:       2,
-			Name:     "Member2",
-			Location: domain.LatLng{Lat: 41.0, Lng: -73.0},
-			Status:   domain.StatusConnected,
-		},
-		{
-			ID:       3,
-			Name:     "Member3",
-			Location: domain.LatLng{Lat: 39.0, Lng: -75.0},
-			Status:   domain.StatusDisconnected, // Should be excluded from center calculation
-		},
-	}
+func (f *fakeHub) HasActiveConnection(convoyID string, memberID int64) bool {
+	return f.connected[memberID]
+}
 
-	center := monitor.calculateConvoyCenter(members)
+func (f *fakeHub) ReconnectAfter() time.Duration {
+	return 5 * time.Second
+}
 
-	// Expected center should be average of first two members only
-	expectedLat := (40.0 + 41.0) / 2
-	expectedLng := (-74.0 + -73.0) / 2
+func (f *fakeHub) GetMemberConnection(convoyID string, memberID int64) *ws.Connection {
+	return nil
+}
 
-	tolerance := 0.001
+func (f *fakeHub) UnregisterMember(convoyID string, memberID int64) {
+	delete(f.connected, memberID)
+}
 
-	if center.Lat < expectedLat-tolerance || center.Lat > expectedLat+tolerance {
-		t.Errorf("Expected center lat %.3f, got %.3f", expectedLat, center.Lat)
-	}
+func (f *fakeHub) LastActivity(convoyID string, memberID int64) time.Time {
+	return f.activity[memberID]
+}
 
-	if center.Lng < expectedLng-tolerance || center.Lng > expectedLng+tolerance {
-		t.Errorf("Expected center lng %.3f, got %.3f", expectedLng, center.Lng)
+// alerts returns the event types of all ConvoyAlert broadcasts, in order
+func (f *fakeHub) alerts() []string {
+	var eventTypes []string
+	for _, message := range f.broadcasts {
+		if alert, ok := message.(*domain.ConvoyAlert); ok {
+			eventTypes = append(eventTypes, alert.EventType)
+		}
 	}
+	return eventTypes
 }
 
 func TestDetermineMemberStatus(t *testing.T) {
-	monitor := &ConvoyMonitor{}
+	// No hub: every member is treated as having an active WebSocket
+	monitor := &ConvoyMonitor{config: config.Load()}
 	now := time.Now()
 
 	convoyCenter := domain.LatLng{Lat: 40.0, Lng: -74.0}
@@ -101,12 +96,34 @@ func TestDetermineMemberStatus(t *testing.T) {
 			expectedStatus: domain.StatusLagging,
 		},
 		{
-			name: "Disconnected member",
+			name: "Inactive member",
 			member: &domain.Member{
 				ID:         3,
-				Name:       "DisconnectedMember",
+				Name:       "InactiveMember",
 				Location:   domain.LatLng{Lat: 40.001, Lng: -74.001}, // Close to center
-				LastUpdate: now.Add(-60 * time.Second),               // Old update (>30s)
+				LastUpdate: now.Add(-90 * time.Second),               // Old update (>60s) with a live socket
+			},
+			expectedStatus: domain.StatusInactive,
+		},
+		{
+			name: "GPS paused with recent heartbeat",
+			member: &domain.Member{
+				ID:         4,
+				Name:       "PausedGPSMember",
+				Location:   domain.LatLng{Lat: 40.001, Lng: -74.001},
+				LastUpdate: now.Add(-90 * time.Second),
+				LastSeen:   now.Add(-10 * time.Second), // App still open
+			},
+			expectedStatus: domain.StatusInactive,
+		},
+		{
+			name: "Stale heartbeat with live socket",
+			member: &domain.Member{
+				ID:         5,
+				Name:       "ClosedAppMember",
+				Location:   domain.LatLng{Lat: 40.001, Lng: -74.001},
+				LastUpdate: now.Add(-3 * time.Minute),
+				LastSeen:   now.Add(-2 * time.Minute), // App closed, only pongs keep the socket up
 			},
 			expectedStatus: domain.StatusDisconnected,
 		},
@@ -114,7 +131,7 @@ func TestDetermineMemberStatus(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			status := monitor.determineMemberStatus(tt.member, convoyCenter, now)
+			status := monitor.determineMemberStatus("convoy-1", tt.member, convoyCenter, now)
 			if status != tt.expectedStatus {
 				t.Errorf("Expected status %s, got %s", tt.expectedStatus, status)
 			}
@@ -123,12 +140,12 @@ func TestDetermineMemberStatus(t *testing.T) {
 }
 
 func TestMonitoringIntegration(t *testing.T) {
-	// Create test storage and WebSocket hub
+	// Create test storage and a hub where both members are connected
 	storage := storage.NewMemoryStorage()
-	wsHub := ws.NewHub()
+	wsHub := newFakeHub(1, 2)
 
 	// Create convoy monitor
-	monitor := NewConvoyMonitor(storage, wsHub)
+	monitor := NewConvoyMonitor(storage, wsHub, config.Load())
 
 	// Create a test convoy
 	convoy, err := storage.CreateConvoy(context.Background())
@@ -193,10 +210,192 @@ func TestMonitoringIntegration(t *testing.T) {
 	}
 }
 
+func TestConvoyScatterThenRegroup(t *testing.T) {
+	storage := storage.NewMemoryStorage()
+	wsHub := newFakeHub(1, 2)
+	monitor := NewConvoyMonitor(storage, wsHub, config.Load())
+
+	convoy, err := storage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+
+	member1 := &domain.Member{ID: 1, Name: "TestMember1", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}, Status: domain.StatusConnected}
+	member2 := &domain.Member{ID: 2, Name: "TestMember2", Location: domain.LatLng{Lat: 40.05, Lng: -74.05}, Status: domain.StatusConnected}
+	for _, member := range []*domain.Member{member1, member2} {
+		if err := storage.AddMember(context.Background(), convoy.ID, member); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+	}
+
+	// Both members are >3km from the midpoint, so the whole convoy is scattered
+	monitor.CheckConvoy(convoy.ID)
+	if alerts := wsHub.alerts(); len(alerts) == 0 || alerts[len(alerts)-1] != domain.EventConvoyScattered {
+		t.Fatalf("Expected %s, got %v", domain.EventConvoyScattered, alerts)
+	}
+
+	// Member 2 catches up
+	if err := storage.UpdateMemberLocation(context.Background(), convoy.ID, 2, domain.LatLng{Lat: 40.001, Lng: -74.001}); err != nil {
+		t.Fatalf("Failed to update location: %v", err)
+	}
+	wsHub.broadcasts = nil
+	monitor.CheckConvoy(convoy.ID)
+
+	var regrouped *domain.ConvoyAlert
+	for _, message := range wsHub.broadcasts {
+		if alert, ok := message.(*domain.ConvoyAlert); ok && alert.EventType == domain.EventConvoyRegrouped {
+			regrouped = alert
+		}
+	}
+	if regrouped == nil {
+		t.Fatalf("Expected %s after members regrouped, got %v", domain.EventConvoyRegrouped, wsHub.alerts())
+	}
+	if regrouped.MemberCount != 2 || regrouped.Timestamp.IsZero() {
+		t.Errorf("Expected regroup alert with member count and timestamp, got %+v", regrouped)
+	}
+
+	// Staying together doesn't re-announce the regroup
+	wsHub.broadcasts = nil
+	monitor.CheckConvoy(convoy.ID)
+	for _, eventType := range wsHub.alerts() {
+		if eventType == domain.EventConvoyRegrouped || eventType == domain.EventConvoyScattered {
+			t.Errorf("Expected no convoy-level alert while grouped, got %s", eventType)
+		}
+	}
+}
+
+func TestConvoyScatteredBroadcastOnce(t *testing.T) {
+	storage := storage.NewMemoryStorage()
+	wsHub := newFakeHub(1, 2)
+	monitor := NewConvoyMonitor(storage, wsHub, config.Load())
+
+	convoy, err := storage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	for _, member := range []*domain.Member{
+		{ID: 1, Name: "TestMember1", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}, Status: domain.StatusConnected},
+		{ID: 2, Name: "TestMember2", Location: domain.LatLng{Lat: 40.05, Lng: -74.05}, Status: domain.StatusConnected},
+	} {
+		if err := storage.AddMember(context.Background(), convoy.ID, member); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+	}
+
+	// Several monitoring ticks while the condition holds
+	for i := 0; i < 5; i++ {
+		monitor.checkAllConvoys()
+	}
+
+	scattered := 0
+	for _, eventType := range wsHub.alerts() {
+		if eventType == domain.EventConvoyScattered {
+			scattered++
+		}
+	}
+	if scattered != 1 {
+		t.Errorf("Expected exactly one %s broadcast over 5 ticks, got %d", domain.EventConvoyScattered, scattered)
+	}
+
+	// Once the convoy empties out its state is forgotten
+	for _, id := range []int64{1, 2} {
+		if err := storage.LeaveConvoy(context.Background(), convoy.ID, id); err != nil {
+			t.Fatalf("Failed to remove member: %v", err)
+		}
+	}
+	monitor.checkAllConvoys()
+	if _, ok := monitor.states[convoy.ID]; ok {
+		t.Errorf("Expected the state of an empty convoy to be cleared")
+	}
+}
+
+func TestScatteredReminderAndEscalation(t *testing.T) {
+	wsHub := newFakeHub(1, 2)
+	cfg := config.Load()
+	cfg.ScatteredReminderInterval = 10 * time.Minute
+	cfg.ScatteredEscalateAfter = 25 * time.Minute
+	monitor := NewConvoyMonitor(nil, wsHub, cfg)
+
+	lagging := []*domain.Member{
+		{ID: 1, Name: "TestMember1", Status: domain.StatusLagging},
+		{ID: 2, Name: "TestMember2", Status: domain.StatusLagging},
+	}
+	convoy := &domain.Convoy{ID: "convoy-1", Members: lagging}
+	start := time.Now()
+
+	// Minutes after becoming scattered -> alert expected then, if any
+	ticks := []struct {
+		minutes int
+		want    string
+	}{
+		{0, domain.EventConvoyScattered},
+		{5, ""},
+		{10, domain.EventConvoyScattered}, // reminder after the cooldown
+		{15, ""},
+		{20, domain.EventConvoyScattered},
+		{25, domain.EventConvoyScatteredEscalated}, // resets the reminder cooldown
+		{30, ""},
+		{35, domain.EventConvoyScattered},
+		{50, domain.EventConvoyScattered}, // a reminder, never a second escalation
+	}
+	for _, tick := range ticks {
+		now := start.Add(time.Duration(tick.minutes) * time.Minute)
+		if got := monitor.checkConvoyScattered(convoy, lagging, nil, now); got != tick.want {
+			t.Errorf("At %d minutes: expected %q, got %q", tick.minutes, tick.want, got)
+		}
+	}
+
+	for _, message := range wsHub.broadcasts {
+		alert := message.(*domain.ConvoyAlert)
+		if alert.ScatteredSince == nil || !alert.ScatteredSince.Equal(start) {
+			t.Errorf("Expected %s to carry the time the convoy scattered, got %v", alert.EventType, alert.ScatteredSince)
+		}
+	}
+
+	// Regrouping clears the state, so the next scatter starts over
+	if got := monitor.checkConvoyScattered(convoy, nil, nil, start.Add(time.Hour)); got != domain.EventConvoyRegrouped {
+		t.Errorf("Expected %s, got %q", domain.EventConvoyRegrouped, got)
+	}
+	later := start.Add(2 * time.Hour)
+	monitor.checkConvoyScattered(convoy, lagging, nil, later)
+	if got := monitor.checkConvoyScattered(convoy, lagging, nil, later.Add(24*time.Minute)); got == domain.EventConvoyScatteredEscalated {
+		t.Error("Expected escalation timing to restart after regrouping")
+	}
+}
+
+func TestFindNearestMember(t *testing.T) {
+	monitor := &ConvoyMonitor{config: config.Load()}
+
+	origin := &domain.Member{ID: 1, Name: "Origin", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}, Status: domain.StatusLagging}
+	near := &domain.Member{ID: 2, Name: "Near", Location: domain.LatLng{Lat: 40.01, Lng: -74.0}, Status: domain.StatusConnected}     // ~1.1km
+	far := &domain.Member{ID: 3, Name: "Far", Location: domain.LatLng{Lat: 40.1, Lng: -74.0}, Status: domain.StatusConnected}        // ~11km
+	gone := &domain.Member{ID: 4, Name: "Gone", Location: domain.LatLng{Lat: 40.001, Lng: -74.0}, Status: domain.StatusDisconnected} // closest but offline
+
+	convoy := &domain.Convoy{ID: "convoy-1", Members: []*domain.Member{origin, near, far, gone}}
+
+	nearest, distance := monitor.FindNearestMember(convoy, origin)
+	if nearest == nil || nearest.ID != near.ID {
+		t.Fatalf("Expected nearest member %d, got %+v", near.ID, nearest)
+	}
+	if math.Abs(distance-1.11) > 0.01 {
+		t.Errorf("Expected distance ~1.11km, got %.3f", distance)
+	}
+
+	nearest, _ = monitor.FindNearestMember(convoy, far)
+	if nearest == nil || nearest.ID != near.ID {
+		t.Errorf("Expected nearest member to far to be %d, got %+v", near.ID, nearest)
+	}
+
+	alone := &domain.Convoy{ID: "convoy-2", Members: []*domain.Member{origin}}
+	if nearest, _ := monitor.FindNearestMember(alone, origin); nearest != nil {
+		t.Errorf("Expected no nearest member in a single-member convoy, got %+v", nearest)
+	}
+}
+
 func TestMonitorStartStop(t *testing.T) {
 	storage := storage.NewMemoryStorage()
 	wsHub := ws.NewHub()
-	monitor := NewConvoyMonitor(storage, wsHub)
+	monitor := NewConvoyMonitor(storage, wsHub, config.Load())
 
 	// Test starting the monitor
 	monitor.Start()
@@ -213,3 +412,398 @@ func TestMonitorStartStop(t *testing.T) {
 	monitor.Stop()
 	monitor.Stop() // Should not cause problems
 }
+
+func TestPausedConvoySuppressesAlerts(t *testing.T) {
+	storage := storage.NewMemoryStorage()
+	wsHub := ws.NewHub()
+	cfg := config.Load()
+	cfg.JoinGracePeriod = 0
+	monitor := NewConvoyMonitor(storage, wsHub, cfg)
+
+	convoy, err := storage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+
+	// Member has no WebSocket connection, so an unpaused check would mark it disconnected
+	member := &domain.Member{
+		ID:       1,
+		Name:     "TestMember1",
+		Location: domain.LatLng{Lat: 40.0, Lng: -74.0},
+		Status:   domain.StatusConnected,
+	}
+	if err := storage.AddMember(context.Background(), convoy.ID, member); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+
+	if err := storage.SetConvoyPaused(context.Background(), convoy.ID, true); err != nil {
+		t.Fatalf("Failed to pause convoy: %v", err)
+	}
+
+	monitor.CheckConvoy(convoy.ID)
+
+	if member.Status != domain.StatusConnected {
+		t.Errorf("Expected status to stay %s while paused, got %s", domain.StatusConnected, member.Status)
+	}
+
+	if err := storage.SetConvoyPaused(context.Background(), convoy.ID, false); err != nil {
+		t.Fatalf("Failed to resume convoy: %v", err)
+	}
+
+	monitor.CheckConvoy(convoy.ID)
+
+	if member.Status != domain.StatusDisconnected {
+		t.Errorf("Expected status %s after resume, got %s", domain.StatusDisconnected, member.Status)
+	}
+}
+
+func TestUnstartedConvoyQuietWhenStartRequired(t *testing.T) {
+	ctx := context.Background()
+	storage := storage.NewMemoryStorage()
+	wsHub := newFakeHub() // nobody has a WebSocket yet
+	cfg := config.Load()
+	cfg.JoinGracePeriod = 0
+	cfg.RequireStart = true
+	monitor := NewConvoyMonitor(storage, wsHub, cfg)
+
+	convoy, err := storage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	member := &domain.Member{ID: 1, Name: "Gathering", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}}
+	if err := storage.AddMember(ctx, convoy.ID, member); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+
+	monitor.CheckConvoy(convoy.ID)
+	if member.Status != domain.StatusConnected || len(wsHub.broadcasts) != 0 {
+		t.Fatalf("Expected no status changes or alerts before the start, got %s and %d broadcasts", member.Status, len(wsHub.broadcasts))
+	}
+
+	if err := storage.SetConvoyStarted(ctx, convoy.ID, time.Now()); err != nil {
+		t.Fatalf("Failed to start convoy: %v", err)
+	}
+	monitor.CheckConvoy(convoy.ID)
+	if member.Status != domain.StatusDisconnected {
+		t.Errorf("Expected status %s once started, got %s", domain.StatusDisconnected, member.Status)
+	}
+	if types := alertTypes(wsHub, member.ID); !slices.Contains(types, domain.EventMemberDisconnected) {
+		t.Errorf("Expected a %s alert once started, got %v", domain.EventMemberDisconnected, types)
+	}
+}
+
+// fakeNotifier records alerts passed to the monitor's notifier
+type fakeNotifier struct {
+	alerts []*domain.ConvoyAlert
+}
+
+func (f *fakeNotifier) Notify(alert *domain.ConvoyAlert) {
+	f.alerts = append(f.alerts, alert)
+}
+
+func TestAlertsForwardedToNotifier(t *testing.T) {
+	storage := storage.NewMemoryStorage()
+	wsHub := newFakeHub(1, 2)
+	monitor := NewConvoyMonitor(storage, wsHub, config.Load())
+	notifier := &fakeNotifier{}
+	monitor.SetNotifier(notifier)
+
+	convoy, err := storage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	for _, member := range []*domain.Member{
+		{ID: 1, Name: "TestMember1", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}, Status: domain.StatusConnected},
+		{ID: 2, Name: "TestMember2", Location: domain.LatLng{Lat: 40.05, Lng: -74.05}, Status: domain.StatusConnected},
+	} {
+		if err := storage.AddMember(context.Background(), convoy.ID, member); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+	}
+
+	monitor.checkAllConvoys()
+
+	alerts := wsHub.alerts()
+	if len(alerts) == 0 || len(notifier.alerts) != len(alerts) {
+		t.Fatalf("Expected every broadcast alert to reach the notifier, broadcast %v, notified %d", alerts, len(notifier.alerts))
+	}
+	for i, alert := range notifier.alerts {
+		if alert.EventType != alerts[i] {
+			t.Errorf("Expected notified alert %d to be %s, got %s", i, alerts[i], alert.EventType)
+		}
+	}
+	// Every alert is also kept in the convoy's event log
+	events, err := storage.GetConvoyEvents(context.Background(), convoy.ID, time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to get events: %v", err)
+	}
+	if len(events) != len(alerts) {
+		t.Errorf("Expected %d logged events, got %d", len(alerts), len(events))
+	}
+}
+
+// fakeBroadcaster records the event types passed with each convoy update
+type fakeBroadcaster struct {
+	updates [][]string
+}
+
+func (f *fakeBroadcaster) BroadcastConvoyUpdate(ctx context.Context, convoyID string, eventTypes []string) {
+	f.updates = append(f.updates, eventTypes)
+}
+
+func TestConvoyUpdateCarriesAlertEventTypes(t *testing.T) {
+	storage := storage.NewMemoryStorage()
+	wsHub := newFakeHub(1) // member 2 has no connection
+	cfg := config.Load()
+	cfg.JoinGracePeriod = 0
+	monitor := NewConvoyMonitor(storage, wsHub, cfg)
+	broadcaster := &fakeBroadcaster{}
+	monitor.SetConvoyBroadcaster(broadcaster)
+
+	convoy, err := storage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	for _, member := range []*domain.Member{
+		{ID: 1, Name: "TestMember1", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}, Status: domain.StatusConnected},
+		{ID: 2, Name: "TestMember2", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}, Status: domain.StatusConnected},
+	} {
+		if err := storage.AddMember(context.Background(), convoy.ID, member); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+	}
+
+	monitor.checkAllConvoys()
+
+	if len(broadcaster.updates) != 1 {
+		t.Fatalf("Expected one convoy update, got %d", len(broadcaster.updates))
+	}
+	found := false
+	for _, eventType := range broadcaster.updates[0] {
+		if eventType == domain.EventMemberDisconnected {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected the update to carry %s, got %v", domain.EventMemberDisconnected, broadcaster.updates[0])
+	}
+	for _, message := range wsHub.broadcasts {
+		if _, ok := message.(*domain.Convoy); ok {
+			t.Error("Expected the convoy update to go through the broadcaster, not the hub")
+		}
+	}
+}
+
+func TestCriticalAlertsGetAlertID(t *testing.T) {
+	monitor := NewConvoyMonitor(nil, newFakeHub(), config.Load())
+
+	critical := &domain.ConvoyAlert{EventType: domain.EventMemberDisconnected, ConvoyID: "convoy-1"}
+	monitor.broadcast("convoy-1", critical)
+	if critical.AlertID == "" {
+		t.Error("Expected a critical alert to be given an alertId")
+	}
+
+	routine := &domain.ConvoyAlert{EventType: domain.EventMemberLagging, ConvoyID: "convoy-1"}
+	monitor.broadcast("convoy-1", routine)
+	if routine.AlertID != "" {
+		t.Errorf("Expected no alertId on a routine alert, got %q", routine.AlertID)
+	}
+}
+
+func TestDisconnectAlertWithoutHub(t *testing.T) {
+	monitor := NewConvoyMonitor(nil, nil, config.Load())
+	member := &domain.Member{ID: 1, Name: "Member1", LastUpdate: time.Now()}
+
+	sent := monitor.sendMemberStatusAlert("convoy-1", member, domain.StatusDisconnected, domain.StatusConnected, domain.LatLng{})
+	if sent != domain.EventMemberDisconnected {
+		t.Errorf("Expected %s without a hub, got %q", domain.EventMemberDisconnected, sent)
+	}
+}
+
+// countingStorage counts how often the monitor polls for active convoys
+type countingStorage struct {
+	storage.Storage
+	polls atomic.Int32
+}
+
+func (s *countingStorage) GetAllActiveConvoysSnapshot(ctx context.Context) ([]*domain.Convoy, error) {
+	s.polls.Add(1)
+	return s.Storage.GetAllActiveConvoysSnapshot(ctx)
+}
+
+func TestMonitorLoopUsesConfiguredInterval(t *testing.T) {
+	store := &countingStorage{Storage: storage.NewMemoryStorage()}
+	cfg := config.Load()
+	cfg.MonitoringInterval = 20 * time.Millisecond
+	cfg.MonitoringJitter = 10 * time.Millisecond
+	monitor := NewConvoyMonitor(store, newFakeHub(), cfg)
+
+	monitor.Start()
+	time.Sleep(300 * time.Millisecond)
+	monitor.Stop()
+
+	// At most one check per 20ms; the 10s default would not have ticked at all
+	polls := store.polls.Load()
+	if polls < 3 || polls > 15 {
+		t.Errorf("Expected roughly one check every 20-30ms over 300ms, got %d", polls)
+	}
+}
+
+func TestInactiveCleanupSparesWatchingMembers(t *testing.T) {
+	wsHub := newFakeHub(1, 2)
+	cfg := config.Load()
+	cfg.InactiveCleanupTimeout = time.Hour
+	cfg.WatchingTimeout = 3 * time.Minute
+	monitor := &ConvoyMonitor{config: cfg, wsHub: wsHub}
+	now := time.Now()
+	convoyCenter := domain.LatLng{Lat: 40.0, Lng: -74.0}
+
+	// Neither member has sent a location for two hours; member 1's socket
+	// still answers pings, member 2's has gone silent
+	wsHub.activity[1] = now.Add(-30 * time.Second)
+	wsHub.activity[2] = now.Add(-10 * time.Minute)
+	watching := &domain.Member{ID: 1, Name: "Watching", Location: convoyCenter, LastUpdate: now.Add(-2 * time.Hour)}
+	dead := &domain.Member{ID: 2, Name: "Dead", Location: convoyCenter, LastUpdate: now.Add(-2 * time.Hour)}
+
+	if status := monitor.determineMemberStatus("convoy-1", watching, convoyCenter, now); status != domain.StatusInactive {
+		t.Errorf("Expected a watching member to be %s, got %s", domain.StatusInactive, status)
+	}
+	if status := monitor.determineMemberStatus("convoy-1", dead, convoyCenter, now); status != domain.StatusDisconnected {
+		t.Errorf("Expected a silent socket to be %s, got %s", domain.StatusDisconnected, status)
+	}
+}
+
+func TestJustJoinedMemberIsConnectingDuringGrace(t *testing.T) {
+	storage := storage.NewMemoryStorage()
+	wsHub := newFakeHub(1) // member 2 hasn't opened its WebSocket yet
+	cfg := config.Load()
+	cfg.JoinGracePeriod = 30 * time.Second
+	monitor := NewConvoyMonitor(storage, wsHub, cfg)
+
+	convoy, err := storage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	joined := &domain.Member{ID: 2, Name: "TestMember2", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}}
+	for _, member := range []*domain.Member{
+		{ID: 1, Name: "TestMember1", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}},
+		joined,
+	} {
+		if err := storage.AddMember(context.Background(), convoy.ID, member); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+	}
+
+	monitor.checkAllConvoys()
+	if joined.Status != domain.StatusConnecting {
+		t.Errorf("Expected status %s during the join grace period, got %s", domain.StatusConnecting, joined.Status)
+	}
+	if slices.Contains(wsHub.alerts(), domain.EventMemberDisconnected) {
+		t.Fatal("Expected no disconnect alert during the join grace period")
+	}
+
+	// Still no WebSocket once the grace period is over
+	joined.JoinedAt = time.Now().Add(-time.Minute)
+	monitor.checkAllConvoys()
+	if joined.Status != domain.StatusDisconnected {
+		t.Errorf("Expected status %s after the join grace period, got %s", domain.StatusDisconnected, joined.Status)
+	}
+	if alerts := wsHub.alerts(); !slices.Contains(alerts, domain.EventMemberDisconnected) {
+		t.Errorf("Expected a disconnect alert after the join grace period, got %v", alerts)
+	}
+	for _, message := range wsHub.broadcasts {
+		if alert, ok := message.(*domain.ConvoyAlert); ok && alert.EventType == domain.EventMemberDisconnected && alert.ReconnectAfterMs != 5000 {
+			t.Errorf("Expected the disconnect alert to carry the hub's reconnect hint, got %d", alert.ReconnectAfterMs)
+		}
+	}
+}
+
+func TestLaggingHysteresisPreventsFlapping(t *testing.T) {
+	cfg := config.Load()
+	cfg.MaxDistanceFromConvoy = 3.0
+	cfg.LaggingEnterMarginKm = 0.2
+	cfg.LaggingExitMarginKm = 0.2
+	monitor := &ConvoyMonitor{config: cfg}
+	center := domain.LatLng{Lat: 40.0, Lng: -74.0}
+	member := &domain.Member{ID: 1, Name: "Hovering", Status: domain.StatusConnected}
+
+	// kmNorth places the member the given distance north of the center
+	kmNorth := func(km float64) domain.LatLng {
+		return domain.LatLng{Lat: center.Lat + km/111.195, Lng: center.Lng}
+	}
+	check := func(km float64) string {
+		now := time.Now()
+		member.Location = kmNorth(km)
+		member.LastUpdate = now
+		member.Status = monitor.determineMemberStatus("convoy-1", member, center, now)
+		return member.Status
+	}
+
+	// Wobbling just either side of the limit never makes the member lagging
+	for _, km := range []float64{2.95, 3.05, 2.95, 3.1, 2.9, 3.05} {
+		if status := check(km); status != domain.StatusConnected {
+			t.Fatalf("Expected %s at %.2f km while hovering at the limit, got %s", domain.StatusConnected, km, status)
+		}
+	}
+
+	if status := check(3.3); status != domain.StatusLagging {
+		t.Fatalf("Expected %s past the enter margin, got %s", domain.StatusLagging, status)
+	}
+
+	// Once lagging, wobbling back under the limit doesn't recover the member
+	for _, km := range []float64{2.95, 3.05, 2.85, 3.1} {
+		if status := check(km); status != domain.StatusLagging {
+			t.Fatalf("Expected %s at %.2f km while hovering at the limit, got %s", domain.StatusLagging, km, status)
+		}
+	}
+
+	if status := check(2.7); status != domain.StatusConnected {
+		t.Errorf("Expected %s inside the exit margin, got %s", domain.StatusConnected, status)
+	}
+}
+
+func TestLowAccuracyLocationDoesNotTriggerLagging(t *testing.T) {
+	storage := storage.NewMemoryStorage()
+	storage.SetLowAccuracyThreshold(100)
+	wsHub := newFakeHub(1, 2, 3)
+	monitor := NewConvoyMonitor(storage, wsHub, config.Load())
+
+	convoy, err := storage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	for _, id := range []int64{1, 2, 3} {
+		member := &domain.Member{ID: id, Name: "TestMember", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}, Status: domain.StatusConnected}
+		if err := storage.AddMember(context.Background(), convoy.ID, member); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+	}
+	farAway := domain.LatLng{Lat: 40.1, Lng: -74.0} // ~11 km north
+
+	// A wild fix with poor accuracy is stored but raises nothing
+	poor := 500.0
+	if err := storage.UpdateMemberLocationWithAccuracy(context.Background(), convoy.ID, 3, farAway, &poor); err != nil {
+		t.Fatalf("Failed to update location: %v", err)
+	}
+	monitor.checkAllConvoys()
+	member := convoy.Members[2]
+	if !member.LowConfidence || member.Location != farAway {
+		t.Fatalf("Expected the inaccurate fix to be stored as low-confidence, got %+v", member)
+	}
+	if slices.Contains(wsHub.alerts(), domain.EventMemberLagging) {
+		t.Errorf("Expected no lagging alert from a low-accuracy fix, got %v", wsHub.alerts())
+	}
+
+	// The same position reported accurately does
+	good := 10.0
+	if err := storage.UpdateMemberLocationWithAccuracy(context.Background(), convoy.ID, 3, farAway, &good); err != nil {
+		t.Fatalf("Failed to update location: %v", err)
+	}
+	monitor.checkAllConvoys()
+	if member.LowConfidence {
+		t.Error("Expected an accurate fix to clear low confidence")
+	}
+	if !slices.Contains(wsHub.alerts(), domain.EventMemberLagging) {
+		t.Errorf("Expected a lagging alert from a high-accuracy fix, got %v", wsHub.alerts())
+	}
+}
@@ -0,0 +1,61 @@
+package monitoring
+
+import (
+	"log"
+	"time"
+)
+
+// MemberDisconnected is called by the WebSocket hub when a member's last
+// connection closes. Rather than waiting for the next tick to mark the member
+// disconnected, the member is held as reconnecting for ReconnectGracePeriod:
+// clients on flaky mobile networks drop and reopen their socket within a few
+// seconds, and alerting everyone about each blip is noise. The convoy is
+// checked again once the grace period is over, and only a member still
+// without a connection by then is marked disconnected.
+func (cm *ConvoyMonitor) MemberDisconnected(convoyID string, memberID int64) {
+	grace := cm.config.ReconnectGracePeriod
+	if grace <= 0 || cm.ctx.Err() != nil {
+		return
+	}
+
+	cm.holdReconnecting(convoyID, memberID, time.Now())
+	log.Printf("Member %d lost its WebSocket in convoy %s - waiting %v for it to reconnect", memberID, convoyID, grace)
+
+	// The hub may call this while delivering one of our own broadcasts, so
+	// the check must not run on the caller's goroutine
+	go cm.CheckConvoy(convoyID)
+	time.AfterFunc(grace, func() {
+		if cm.ctx.Err() != nil {
+			return
+		}
+		cm.CheckConvoy(convoyID)
+	})
+}
+
+// holdReconnecting records that the member's socket closed at now
+func (cm *ConvoyMonitor) holdReconnecting(convoyID string, memberID int64, now time.Time) {
+	cm.stateMu.Lock()
+	defer cm.stateMu.Unlock()
+	cm.stateFor(convoyID).reconnecting[memberID] = now
+}
+
+// isReconnecting reports whether the member's socket closed less than
+// ReconnectGracePeriod ago. Members past the grace period are forgotten.
+func (cm *ConvoyMonitor) isReconnecting(convoyID string, memberID int64, now time.Time) bool {
+	cm.stateMu.Lock()
+	defer cm.stateMu.Unlock()
+
+	state, ok := cm.states[convoyID]
+	if !ok {
+		return false
+	}
+	since, ok := state.reconnecting[memberID]
+	if !ok {
+		return false
+	}
+	if now.Sub(since) < cm.config.ReconnectGracePeriod {
+		return true
+	}
+	delete(state.reconnecting, memberID)
+	return false
+}
@@ -0,0 +1,118 @@
+package monitoring
+
+import (
+	"context"
+	"convoy-app/backend/src/config"
+	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/storage"
+	"testing"
+	"time"
+)
+
+// newReconnectTest returns a monitor with a convoy of two connected members,
+// the first of which is about to lose its socket
+func newReconnectTest(t *testing.T) (*ConvoyMonitor, *fakeHub, *domain.Convoy, *domain.Member) {
+	t.Helper()
+	ctx := context.Background()
+	storage := storage.NewMemoryStorage()
+	wsHub := newFakeHub(1, 2)
+	cfg := config.Load()
+	cfg.ReconnectGracePeriod = 10 * time.Second
+	cfg.JoinGracePeriod = 0
+	monitor := NewConvoyMonitor(storage, wsHub, cfg)
+
+	convoy, err := storage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	blipping := &domain.Member{ID: 1, Name: "Tunnel", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}, LastUpdate: time.Now()}
+	steady := &domain.Member{ID: 2, Name: "Steady", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}, LastUpdate: time.Now()}
+	for _, member := range []*domain.Member{blipping, steady} {
+		if err := storage.AddMember(ctx, convoy.ID, member); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+	}
+	monitor.CheckConvoy(convoy.ID)
+	if blipping.Status != domain.StatusConnected {
+		t.Fatalf("Expected member to start connected, got %q", blipping.Status)
+	}
+	wsHub.broadcasts = nil
+	return monitor, wsHub, convoy, blipping
+}
+
+// alertTypes returns the event types of the alerts broadcast for memberID
+func alertTypes(hub *fakeHub, memberID int64) []string {
+	var types []string
+	for _, message := range hub.broadcasts {
+		if alert, ok := message.(*domain.ConvoyAlert); ok && alert.MemberID == memberID {
+			types = append(types, alert.EventType)
+		}
+	}
+	return types
+}
+
+func TestMemberBlipWithinReconnectGrace(t *testing.T) {
+	monitor, wsHub, convoy, member := newReconnectTest(t)
+
+	// The socket drops...
+	delete(wsHub.connected, member.ID)
+	monitor.holdReconnecting(convoy.ID, member.ID, time.Now().Add(-3*time.Second))
+	monitor.CheckConvoy(convoy.ID)
+	if member.Status != domain.StatusReconnecting {
+		t.Fatalf("Expected member to be %q, got %q", domain.StatusReconnecting, member.Status)
+	}
+
+	// ...and comes back 3 seconds later
+	wsHub.connected[member.ID] = true
+	monitor.CheckConvoy(convoy.ID)
+	if member.Status != domain.StatusConnected {
+		t.Fatalf("Expected member to be connected again, got %q", member.Status)
+	}
+
+	types := alertTypes(wsHub, member.ID)
+	if len(types) != 1 || types[0] != domain.EventMemberReconnecting {
+		t.Fatalf("Expected only a %s alert, got %v", domain.EventMemberReconnecting, types)
+	}
+}
+
+func TestMemberDisconnectedAfterReconnectGrace(t *testing.T) {
+	monitor, wsHub, convoy, member := newReconnectTest(t)
+
+	delete(wsHub.connected, member.ID)
+	monitor.holdReconnecting(convoy.ID, member.ID, time.Now().Add(-3*time.Second))
+	monitor.CheckConvoy(convoy.ID)
+	if member.Status != domain.StatusReconnecting {
+		t.Fatalf("Expected member to be %q, got %q", domain.StatusReconnecting, member.Status)
+	}
+
+	// Still gone once the grace period is over
+	monitor.holdReconnecting(convoy.ID, member.ID, time.Now().Add(-11*time.Second))
+	monitor.CheckConvoy(convoy.ID)
+	if member.Status != domain.StatusDisconnected {
+		t.Fatalf("Expected member to be disconnected, got %q", member.Status)
+	}
+
+	types := alertTypes(wsHub, member.ID)
+	if len(types) != 2 || types[0] != domain.EventMemberReconnecting || types[1] != domain.EventMemberDisconnected {
+		t.Fatalf("Expected %s then %s, got %v", domain.EventMemberReconnecting, domain.EventMemberDisconnected, types)
+	}
+	if _, ok := monitor.stateFor(convoy.ID).reconnecting[member.ID]; ok {
+		t.Fatal("Expected the member to be forgotten once the grace period is over")
+	}
+}
+
+func TestMemberDisconnectedImmediatelyWithoutReconnectGrace(t *testing.T) {
+	monitor, wsHub, convoy, member := newReconnectTest(t)
+	monitor.config.ReconnectGracePeriod = 0
+
+	delete(wsHub.connected, member.ID)
+	monitor.MemberDisconnected(convoy.ID, member.ID)
+	monitor.CheckConvoy(convoy.ID)
+	if member.Status != domain.StatusDisconnected {
+		t.Fatalf("Expected member to be disconnected, got %q", member.Status)
+	}
+	types := alertTypes(wsHub, member.ID)
+	if len(types) != 1 || types[0] != domain.EventMemberDisconnected {
+		t.Fatalf("Expected only a %s alert, got %v", domain.EventMemberDisconnected, types)
+	}
+}
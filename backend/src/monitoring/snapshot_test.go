@@ -0,0 +1,76 @@
+package monitoring
+
+import (
+	"context"
+	"convoy-app/backend/src/config"
+	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/storage"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Run with -race: members join, move and leave while the monitor checks the
+// convoy, as they do when API requests arrive during a monitoring tick
+func TestMonitorChecksDuringMembershipChanges(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStorage()
+	cfg := config.Load()
+	cfg.JoinGracePeriod = 0
+	monitor := NewConvoyMonitor(store, nil, cfg)
+
+	convoy, err := store.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	leader := &domain.Member{ID: 1, Name: "Leader", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}, LastUpdate: time.Now()}
+	if err := store.AddMember(ctx, convoy.ID, leader); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for id := int64(2); ; id++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			now := time.Now()
+			member := &domain.Member{ID: id, Name: "Passing", Location: domain.LatLng{Lat: 40.5, Lng: -74.0}, LastUpdate: now, JoinedAt: now}
+			if err := store.AddMember(ctx, convoy.ID, member); err != nil {
+				t.Errorf("Failed to add member %d: %v", id, err)
+				return
+			}
+			store.UpdateMemberLocation(ctx, convoy.ID, id, domain.LatLng{Lat: 40.6, Lng: -74.0})
+			if err := store.LeaveConvoy(ctx, convoy.ID, id); err != nil {
+				t.Errorf("Failed to remove member %d: %v", id, err)
+				return
+			}
+		}
+	}()
+
+	// The leader keeps moving too
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			store.UpdateMemberLocation(ctx, convoy.ID, leader.ID, domain.LatLng{Lat: 40.0 + float64(i%10)*0.001, Lng: -74.0})
+		}
+	}()
+
+	for i := 0; i < 500; i++ {
+		monitor.checkAllConvoys()
+		monitor.CheckConvoy(convoy.ID)
+	}
+	close(stop)
+	wg.Wait()
+}
@@ -0,0 +1,128 @@
+package monitoring
+
+import (
+	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/geo"
+	"log"
+	"time"
+)
+
+// motionState tracks whether a point (a member or the convoy center) is moving.
+// Speed is averaged from an anchor that is reset whenever the point is seen
+// moving, so a stop is measured over its whole duration rather than per tick.
+type motionState struct {
+	anchor   domain.LatLng
+	since    time.Time
+	moving   bool // convoy center only: last known moving state
+	stalled  bool // members only: MEMBER_STALLED has been sent
+	observed bool
+}
+
+// observe updates the state with a new position and reports whether the point
+// averaged at least minSpeedKmh since the anchor. Returns the time spent slow.
+func (m *motionState) observe(location domain.LatLng, now time.Time, minSpeedKmh float64) (moving bool, slowFor time.Duration) {
+	if !m.observed {
+		m.anchor, m.since, m.observed = location, now, true
+		return false, 0
+	}
+
+	elapsed := now.Sub(m.since)
+	if elapsed <= 0 {
+		return m.moving, 0
+	}
+
+	speedKmh := geo.Distance(m.anchor, location) / elapsed.Hours()
+	if speedKmh >= minSpeedKmh {
+		m.anchor, m.since = location, now
+		return true, 0
+	}
+	return false, elapsed
+}
+
+// checkMemberMotion emits MEMBER_STALLED for members that have stopped while the
+// rest of the convoy keeps moving, and MEMBER_MOVING once they get going again
+func (cm *ConvoyMonitor) checkMemberMotion(convoy *domain.Convoy, convoyCenter domain.LatLng, now time.Time) {
+	var alerts []*domain.ConvoyAlert
+
+	cm.stateMu.Lock()
+	convoyState := cm.stateFor(convoy.ID)
+	if convoyState.motion == nil {
+		convoyState.motion = &motionState{}
+	}
+	center := convoyState.motion
+	if moving, slowFor := center.observe(convoyCenter, now, cm.config.StalledSpeedKmh); moving {
+		center.moving = true
+	} else if slowFor >= cm.config.StalledDuration {
+		// The whole convoy has stopped, e.g. at a rest area
+		center.moving = false
+	}
+
+	members := convoyState.memberMotion
+
+	seen := make(map[int64]bool, len(convoy.Members))
+	for _, member := range convoy.Members {
+		if member.Status != domain.StatusConnected && member.Status != domain.StatusLagging {
+			continue
+		}
+		seen[member.ID] = true
+
+		state, ok := members[member.ID]
+		if !ok {
+			state = &motionState{}
+			members[member.ID] = state
+		}
+		// An inaccurate fix says nothing reliable about movement
+		if member.LowConfidence {
+			continue
+		}
+
+		moving, slowFor := state.observe(member.Location, now, cm.config.StalledSpeedKmh)
+		switch {
+		case moving && state.stalled:
+			state.stalled = false
+			alerts = append(alerts, &domain.ConvoyAlert{
+				EventType:  domain.EventMemberMoving,
+				ConvoyID:   convoy.ID,
+				MemberID:   member.ID,
+				MemberName: member.Name,
+				Timestamp:  now,
+			})
+			log.Printf("Member %s (%d) is moving again in convoy %s", member.Name, member.ID, convoy.ID)
+
+		case !moving && !state.stalled && slowFor >= cm.config.StalledDuration && center.moving:
+			state.stalled = true
+			alerts = append(alerts, &domain.ConvoyAlert{
+				EventType:  domain.EventMemberStalled,
+				ConvoyID:   convoy.ID,
+				MemberID:   member.ID,
+				MemberName: member.Name,
+				LastSeen:   member.LastUpdate,
+				Timestamp:  now,
+			})
+			log.Printf("Member %s (%d) stalled in convoy %s: below %.1f km/h for %v",
+				member.Name, member.ID, convoy.ID, cm.config.StalledSpeedKmh, slowFor)
+		}
+	}
+
+	// Members that left or lost their connection start fresh when they return
+	for memberID := range members {
+		if !seen[memberID] {
+			delete(members, memberID)
+		}
+	}
+	cm.stateMu.Unlock()
+
+	for _, alert := range alerts {
+		cm.broadcast(convoy.ID, alert)
+	}
+}
+
+// forgetMotion drops movement history for a convoy, e.g. while it is paused
+func (cm *ConvoyMonitor) forgetMotion(convoyID string) {
+	cm.stateMu.Lock()
+	defer cm.stateMu.Unlock()
+	if state, ok := cm.states[convoyID]; ok {
+		state.motion = nil
+		state.memberMotion = make(map[int64]*motionState)
+	}
+}
@@ -0,0 +1,74 @@
+package monitoring
+
+import (
+	"convoy-app/backend/src/config"
+	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/geo"
+	"testing"
+	"time"
+)
+
+func TestMemberStalledWhileConvoyMoves(t *testing.T) {
+	wsHub := newFakeHub()
+	monitor := NewConvoyMonitor(nil, wsHub, config.Load())
+
+	leader := &domain.Member{ID: 1, Name: "Leader", Status: domain.StatusConnected}
+	follower := &domain.Member{ID: 2, Name: "Follower", Status: domain.StatusConnected}
+	broken := &domain.Member{ID: 3, Name: "BrokenDown", Status: domain.StatusConnected, Location: domain.LatLng{Lat: 40.0, Lng: -74.0}}
+	convoy := &domain.Convoy{ID: "convoy-1", Members: []*domain.Member{leader, follower, broken}}
+
+	start := time.Now()
+	// tick advances one minute: the leader and follower drive ~2km north, the broken-down member stays put
+	tick := func(minute int) {
+		lat := 40.0 + float64(minute)*0.018
+		leader.Location = domain.LatLng{Lat: lat, Lng: -74.0}
+		follower.Location = domain.LatLng{Lat: lat - 0.001, Lng: -74.0}
+		monitor.checkMemberMotion(convoy, geo.Center(convoy.Members), start.Add(time.Duration(minute)*time.Minute))
+	}
+
+	for minute := 0; minute <= 5; minute++ {
+		tick(minute)
+	}
+
+	stalled := 0
+	for _, eventType := range wsHub.alerts() {
+		if eventType == domain.EventMemberStalled {
+			stalled++
+		}
+	}
+	if stalled != 1 {
+		t.Fatalf("Expected exactly one %s alert, got %v", domain.EventMemberStalled, wsHub.alerts())
+	}
+	if alert := wsHub.broadcasts[0].(*domain.ConvoyAlert); alert.MemberID != broken.ID {
+		t.Errorf("Expected member %d to be stalled, got %d", broken.ID, alert.MemberID)
+	}
+
+	// The broken-down member gets going again
+	wsHub.broadcasts = nil
+	broken.Location = domain.LatLng{Lat: 40.02, Lng: -74.0}
+	tick(6)
+
+	if alerts := wsHub.alerts(); len(alerts) != 1 || alerts[0] != domain.EventMemberMoving {
+		t.Errorf("Expected a single %s alert, got %v", domain.EventMemberMoving, alerts)
+	}
+}
+
+func TestNoStalledAlertWhenWholeConvoyStops(t *testing.T) {
+	wsHub := newFakeHub()
+	monitor := NewConvoyMonitor(nil, wsHub, config.Load())
+
+	members := []*domain.Member{
+		{ID: 1, Name: "A", Status: domain.StatusConnected, Location: domain.LatLng{Lat: 40.0, Lng: -74.0}},
+		{ID: 2, Name: "B", Status: domain.StatusConnected, Location: domain.LatLng{Lat: 40.001, Lng: -74.0}},
+	}
+	convoy := &domain.Convoy{ID: "convoy-1", Members: members}
+
+	start := time.Now()
+	for minute := 0; minute <= 10; minute++ {
+		monitor.checkMemberMotion(convoy, geo.Center(members), start.Add(time.Duration(minute)*time.Minute))
+	}
+
+	if alerts := wsHub.alerts(); len(alerts) != 0 {
+		t.Errorf("Expected no alerts while the whole convoy is stopped, got %v", alerts)
+	}
+}
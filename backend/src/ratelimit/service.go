@@ -9,6 +9,7 @@ import (
 type Limiter struct {
 	emailLimits map[string][]time.Time // email -> timestamps
 	ipLimits    map[string][]time.Time // IP -> timestamps
+	phoneLimits map[string][]time.Time // phone number -> timestamps
 	mu          sync.RWMutex
 }
 
@@ -33,6 +34,7 @@ func NewLimiter(config Config) *Limiter {
 	limiter := &Limiter{
 		emailLimits: make(map[string][]time.Time),
 		ipLimits:    make(map[string][]time.Time),
+		phoneLimits: make(map[string][]time.Time),
 	}
 
 	// Start cleanup goroutine
@@ -85,6 +87,11 @@ func (l *Limiter) CheckIPLimit(ip string, maxPerHour int) bool {
 	return count < maxPerHour
 }
 
+// CheckPhoneLimit checks if a phone number has exceeded the rate limit
+func (l *Limiter) CheckPhoneLimit(phone string, maxPerHour int) bool {
+	return l.GetPhoneRequestCount(phone) < maxPerHour
+}
+
 // RecordEmailRequest records a request for an email address
 func (l *Limiter) RecordEmailRequest(email string) {
 	l.mu.Lock()
@@ -109,6 +116,14 @@ func (l *Limiter) RecordIPRequest(ip string) {
 	l.ipLimits[ip] = append(l.ipLimits[ip], now)
 }
 
+// RecordPhoneRequest records a request for a phone number
+func (l *Limiter) RecordPhoneRequest(phone string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.phoneLimits[phone] = append(l.phoneLimits[phone], time.Now())
+}
+
 // GetEmailRequestCount returns the number of requests for an email in the last hour
 func (l *Limiter) GetEmailRequestCount(email string) int {
 	l.mu.RLock()
@@ -151,6 +166,22 @@ func (l *Limiter) GetIPRequestCount(ip string) int {
 	return count
 }
 
+// GetPhoneRequestCount returns the number of requests for a phone number in the last hour
+func (l *Limiter) GetPhoneRequestCount(phone string) int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	cutoff := time.Now().Add(-time.Hour)
+	count := 0
+	for _, timestamp := range l.phoneLimits[phone] {
+		if timestamp.After(cutoff) {
+			count++
+		}
+	}
+
+	return count
+}
+
 // GetRemainingEmailRequests returns the number of remaining requests for an email
 func (l *Limiter) GetRemainingEmailRequests(email string, maxPerHour int) int {
 	current := l.GetEmailRequestCount(email)
@@ -171,6 +202,15 @@ func (l *Limiter) GetRemainingIPRequests(ip string, maxPerHour int) int {
 	return remaining
 }
 
+// GetRemainingPhoneRequests returns the number of remaining requests for a phone number
+func (l *Limiter) GetRemainingPhoneRequests(phone string, maxPerHour int) int {
+	remaining := maxPerHour - l.GetPhoneRequestCount(phone)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
 // startCleanup starts a goroutine that periodically cleans up old entries
 func (l *Limiter) startCleanup(interval time.Duration) {
 	ticker := time.NewTicker(interval)
@@ -217,6 +257,21 @@ func (l *Limiter) cleanup() {
 			l.ipLimits[ip] = filtered
 		}
 	}
+
+	// Clean up phone limits
+	for phone, timestamps := range l.phoneLimits {
+		filtered := make([]time.Time, 0)
+		for _, timestamp := range timestamps {
+			if timestamp.After(cutoff) {
+				filtered = append(filtered, timestamp)
+			}
+		}
+		if len(filtered) == 0 {
+			delete(l.phoneLimits, phone)
+		} else {
+			l.phoneLimits[phone] = filtered
+		}
+	}
 }
 
 // Reset clears all rate limiting data (useful for testing)
@@ -226,4 +281,5 @@ func (l *Limiter) Reset() {
 
 	l.emailLimits = make(map[string][]time.Time)
 	l.ipLimits = make(map[string][]time.Time)
+	l.phoneLimits = make(map[string][]time.Time)
 }
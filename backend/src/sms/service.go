@@ -0,0 +1,137 @@
+package sms
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Provider sends a text message to a phone number. Implementations wrap a
+// specific SMS gateway (Twilio, Vonage, ...).
+type Provider interface {
+	Send(to, body string) error
+	IsConfigured() bool
+}
+
+// Service handles SMS sending functionality
+type Service struct {
+	provider Provider
+	fromName string
+}
+
+// Config holds SMS service configuration
+type Config struct {
+	Provider Provider
+	FromName string
+}
+
+// NewService creates a new SMS service instance
+func NewService(config Config) *Service {
+	return &Service{
+		provider: config.Provider,
+		fromName: config.FromName,
+	}
+}
+
+// NewServiceFromEnv creates a new SMS service from environment variables
+func NewServiceFromEnv() *Service {
+	return &Service{
+		provider: &TwilioProvider{
+			accountSID: getEnv("TWILIO_ACCOUNT_SID", ""),
+			authToken:  getEnv("TWILIO_AUTH_TOKEN", ""),
+			fromNumber: getEnv("TWILIO_FROM_NUMBER", ""),
+			client:     &http.Client{Timeout: 10 * time.Second},
+		},
+		fromName: getEnv("SMS_FROM_NAME", "Convoy App"),
+	}
+}
+
+// GenerateVerificationCode creates a cryptographically secure 6-digit numeric code
+func GenerateVerificationCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate verification code: %w", err)
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// IsValidPhone validates that a phone number is in E.164 format (e.g. +15551234567)
+func IsValidPhone(phone string) bool {
+	phoneRegex := regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+	return phoneRegex.MatchString(phone)
+}
+
+// SendVerificationCode sends a verification code to the given phone number
+func (s *Service) SendVerificationCode(to, code string) error {
+	if !IsValidPhone(to) {
+		return fmt.Errorf("invalid phone number: %s", to)
+	}
+	if !s.IsConfigured() {
+		return fmt.Errorf("SMS provider not configured")
+	}
+
+	body := fmt.Sprintf("%s: your convoy verification code is %s. It expires in 10 minutes.", s.fromName, code)
+	return s.provider.Send(to, body)
+}
+
+// IsConfigured returns true if the SMS service has a usable provider
+func (s *Service) IsConfigured() bool {
+	return s.provider != nil && s.provider.IsConfigured()
+}
+
+// TwilioProvider sends SMS messages through the Twilio REST API
+type TwilioProvider struct {
+	accountSID string
+	authToken  string
+	fromNumber string
+	client     *http.Client
+}
+
+// Send posts a message to the Twilio Messages endpoint
+func (p *TwilioProvider) Send(to, body string) error {
+	endpoint := fmt.Sprintf("https://api.twilio.com/2010-04-01/Accounts/%s/Messages.json", p.accountSID)
+
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("From", p.fromNumber)
+	form.Set("Body", body)
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to build SMS request: %w", err)
+	}
+	req.SetBasicAuth(p.accountSID, p.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send SMS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("SMS provider returned status %d: %s", resp.StatusCode, respBody)
+	}
+	return nil
+}
+
+// IsConfigured returns true if the Twilio credentials are present
+func (p *TwilioProvider) IsConfigured() bool {
+	return p.accountSID != "" && p.authToken != "" && p.fromNumber != ""
+}
+
+// getEnv gets environment variable with fallback
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,78 @@
+package sms
+
+import (
+	"strings"
+	"testing"
+)
+
+// recordingProvider remembers the messages it was asked to send
+type recordingProvider struct {
+	configured bool
+	to, body   string
+}
+
+func (p *recordingProvider) Send(to, body string) error {
+	p.to, p.body = to, body
+	return nil
+}
+
+func (p *recordingProvider) IsConfigured() bool {
+	return p.configured
+}
+
+func TestGenerateVerificationCode(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		code, err := GenerateVerificationCode()
+		if err != nil {
+			t.Fatalf("Failed to generate code: %v", err)
+		}
+		if len(code) != 6 || strings.Trim(code, "0123456789") != "" {
+			t.Errorf("Expected a 6-digit code, got %q", code)
+		}
+	}
+}
+
+func TestIsValidPhone(t *testing.T) {
+	tests := []struct {
+		phone string
+		valid bool
+	}{
+		{"+15551234567", true},
+		{"+447911123456", true},
+		{"15551234567", false},
+		{"+05551234567", false},
+		{"+1555", false},
+		{"+1555123456789012", false},
+		{"+1 555 123 4567", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsValidPhone(tt.phone); got != tt.valid {
+			t.Errorf("IsValidPhone(%q) = %v, expected %v", tt.phone, got, tt.valid)
+		}
+	}
+}
+
+func TestSendVerificationCode(t *testing.T) {
+	provider := &recordingProvider{configured: true}
+	service := NewService(Config{Provider: provider, FromName: "Convoy"})
+
+	if err := service.SendVerificationCode("+15551234567", "123456"); err != nil {
+		t.Fatalf("Failed to send code: %v", err)
+	}
+	if provider.to != "+15551234567" || !strings.Contains(provider.body, "123456") || !strings.HasPrefix(provider.body, "Convoy:") {
+		t.Errorf("Unexpected message to %q: %q", provider.to, provider.body)
+	}
+
+	if err := service.SendVerificationCode("555", "123456"); err == nil {
+		t.Errorf("Expected an invalid phone number to be rejected")
+	}
+
+	provider.configured = false
+	if err := service.SendVerificationCode("+15551234567", "123456"); err == nil {
+		t.Errorf("Expected an error without a configured provider")
+	}
+	if NewService(Config{}).IsConfigured() {
+		t.Errorf("Expected a service without a provider to be unconfigured")
+	}
+}
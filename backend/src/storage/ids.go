@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// IDGenerator produces convoy IDs. IDs must be 32 lowercase hex characters,
+// the only form the API accepts in URLs.
+type IDGenerator interface {
+	NewID() (string, error)
+}
+
+// RandomIDs generates unguessable random IDs. It is the default.
+type RandomIDs struct{}
+
+// NewID returns a random ID
+func (RandomIDs) NewID() (string, error) {
+	return generateID()
+}
+
+// SequentialIDs generates predictable IDs (00...01, 00...02, and so on) so
+// local runs and documentation examples stay the same from one start to the
+// next. Anyone can guess them, so they are for development only.
+type SequentialIDs struct {
+	last atomic.Uint64
+}
+
+// NewID returns the next ID in the sequence
+func (g *SequentialIDs) NewID() (string, error) {
+	return fmt.Sprintf("%032x", g.last.Add(1)), nil
+}
@@ -3,20 +3,73 @@ package storage
 import (
 	"context"
 	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/geo"
 	"convoy-app/backend/src/ierr"
 	"crypto/rand"
+	"crypto/subtle"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"log"
+	"maps"
+	"math"
 	"sync"
 	"time"
 )
 
+// MaxCodeAttempts is the number of wrong SMS codes accepted before a verification is locked
+const MaxCodeAttempts = 5
+
+// MaxTrackPoints bounds each member's breadcrumb history; the oldest points are dropped first
+const MaxTrackPoints = 5000
+
+// SnapshotTrackPoints is how much of each member's track a snapshot keeps: the
+// monitor only needs the last two points to estimate speed
+const SnapshotTrackPoints = 2
+
+// MaxConvoyEvents bounds each convoy's alert log; the oldest events are dropped first
+const MaxConvoyEvents = 500
+
+// MemberTombstoneTTL is how long a member who left is remembered, so location
+// updates and heartbeats already in flight are rejected as ErrMemberLeft
+const MemberTombstoneTTL = 2 * time.Minute
+
+// MaxVerificationAttempts bounds the verification audit log; the oldest attempts are dropped first
+const MaxVerificationAttempts = 1000
+
+// auditTokenPrefixLength is how much of an email token the audit log keeps
+const auditTokenPrefixLength = 6
+
 // MemoryStorage is an in-memory implementation of the Storage interface.
 type MemoryStorage struct {
 	mu            sync.RWMutex
 	convoys       map[string]*domain.Convoy
 	verifications map[string]*domain.ConvoyVerification // token -> verification
 	wsHub         WebSocketHub                          // WebSocket hub for checking connection status
+	ids           IDGenerator                           // convoy IDs; RandomIDs unless set for development
+
+	idempotencyKeys map[string]idempotencyEntry       // Idempotency-Key -> convoy created for it
+	events          map[string][]domain.ConvoyAlert   // convoyID -> alerts in the order they were sent
+	invites         map[string][]*domain.ConvoyInvite // convoyID -> invites that haven't expired
+	tombstones      map[string]map[int64]time.Time    // convoyID -> memberID -> when the member left
+
+	maxSpeedKmh   float64       // implied speed above which a location update is treated as a GPS glitch (0 disables)
+	outlierWindow time.Duration // only updates arriving within this window of the previous one are checked
+	maxMembers    int           // members allowed per convoy (0 means unlimited)
+	maxConvoys    int           // convoys allowed in total (0 means unlimited)
+
+	lowAccuracyMeters float64 // locations reported less accurate than this are low-confidence (0 disables)
+
+	auditVerifications   bool                         // record verification attempts for operators
+	verificationAttempts []domain.VerificationAttempt // oldest first
+}
+
+// idempotencyEntry remembers which convoy a client's create request produced.
+// convoyID is empty while the request is still being handled.
+type idempotencyEntry struct {
+	convoyID    string
+	fingerprint string // hash of the request body the key was first used with
+	expiresAt   time.Time
 }
 
 // NewMemoryStorage creates and returns a new MemoryStorage instance.
@@ -24,6 +77,12 @@ func NewMemoryStorage() *MemoryStorage {
 	return &MemoryStorage{
 		convoys:       make(map[string]*domain.Convoy),
 		verifications: make(map[string]*domain.ConvoyVerification),
+		ids:           RandomIDs{},
+
+		idempotencyKeys: make(map[string]idempotencyEntry),
+		events:          make(map[string][]domain.ConvoyAlert),
+		invites:         make(map[string][]*domain.ConvoyInvite),
+		tombstones:      make(map[string]map[int64]time.Time),
 	}
 }
 
@@ -32,6 +91,73 @@ func (s *MemoryStorage) SetWebSocketHub(wsHub WebSocketHub) {
 	s.wsHub = wsHub
 }
 
+// SetOutlierFilter configures rejection of implausible GPS jumps in UpdateMemberLocation
+func (s *MemoryStorage) SetOutlierFilter(maxSpeedKmh float64, window time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxSpeedKmh = maxSpeedKmh
+	s.outlierWindow = window
+}
+
+// SetLowAccuracyThreshold marks locations whose reported accuracy is worse
+// than meters as low-confidence. 0 disables the check.
+func (s *MemoryStorage) SetLowAccuracyThreshold(meters float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lowAccuracyMeters = meters
+}
+
+// SetMaxConvoys caps how many convoys the create methods accept in total (0 means unlimited)
+func (s *MemoryStorage) SetMaxConvoys(maxConvoys int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxConvoys = maxConvoys
+}
+
+// checkConvoyCapacity returns ErrCapacity if another convoy would exceed the
+// cap. Unverified convoys whose verification has expired are not counted: the
+// cleanup sweep will remove them and they can no longer become usable.
+// Callers must hold s.mu.
+func (s *MemoryStorage) checkConvoyCapacity() error {
+	if s.maxConvoys <= 0 || len(s.convoys) < s.maxConvoys {
+		return nil
+	}
+	now := time.Now()
+	live := 0
+	for _, convoy := range s.convoys {
+		if !convoy.IsVerified && convoy.VerificationExpiresAt != nil && now.After(*convoy.VerificationExpiresAt) {
+			continue
+		}
+		live++
+	}
+	if live >= s.maxConvoys {
+		return fmt.Errorf("%d convoys: %w", live, ierr.ErrCapacity)
+	}
+	return nil
+}
+
+// SetMaxMembers caps how many members AddMember and AddMembers accept per convoy (0 means unlimited)
+func (s *MemoryStorage) SetMaxMembers(maxMembers int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxMembers = maxMembers
+}
+
+// SetVerificationAudit turns recording of verification attempts on or off
+func (s *MemoryStorage) SetVerificationAudit(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.auditVerifications = enabled
+}
+
+// SetIDGenerator replaces how convoy IDs are generated. Verification tokens
+// stay random.
+func (s *MemoryStorage) SetIDGenerator(ids IDGenerator) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ids = ids
+}
+
 // generateID creates a random, URL-friendly ID.
 func generateID() (string, error) {
 	bytes := make([]byte, 16)
@@ -50,7 +176,11 @@ func (s *MemoryStorage) CreateConvoy(ctx context.Context) (*domain.Convoy, error
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	id, err := generateID()
+	if err := s.checkConvoyCapacity(); err != nil {
+		return nil, err
+	}
+
+	id, err := s.ids.NewID()
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate convoy id: %w", err)
 	}
@@ -70,7 +200,11 @@ func (s *MemoryStorage) CreateConvoyWithVerification(ctx context.Context, email,
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	id, err := generateID()
+	if err := s.checkConvoyCapacity(); err != nil {
+		return nil, err
+	}
+
+	id, err := s.ids.NewID()
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate convoy id: %w", err)
 	}
@@ -102,61 +236,464 @@ func (s *MemoryStorage) CreateConvoyWithVerification(ctx context.Context, email,
 	return convoy, nil
 }
 
+// CreateConvoyWithSMSVerification creates an unverified convoy that is confirmed with an SMS code
+func (s *MemoryStorage) CreateConvoyWithSMSVerification(ctx context.Context, phone, leaderName, code string, expiresAt time.Time) (*domain.Convoy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkConvoyCapacity(); err != nil {
+		return nil, err
+	}
+
+	id, err := s.ids.NewID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate convoy id: %w", err)
+	}
+
+	// SMS codes are short and not unique, so the record is keyed by a random token instead
+	token, err := generateID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate verification token: %w", err)
+	}
+
+	now := time.Now()
+	convoy := &domain.Convoy{
+		ID:                    id,
+		Members:               []*domain.Member{},
+		IsVerified:            false,
+		CreatedByPhone:        phone,
+		LeaderName:            leaderName,
+		VerificationExpiresAt: &expiresAt,
+		CreatedAt:             now,
+	}
+
+	verification := &domain.ConvoyVerification{
+		ID:        generateVerificationID(),
+		ConvoyID:  id,
+		Phone:     phone,
+		Token:     token,
+		Code:      code,
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	}
+
+	s.convoys[id] = convoy
+	s.verifications[token] = verification
+
+	return convoy, nil
+}
+
 func (s *MemoryStorage) GetConvoy(ctx context.Context, convoyID string) (*domain.Convoy, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	convoy, ok := s.convoys[convoyID]
 	if !ok {
-		return nil, fmt.Errorf("convoy with id %s not found", convoyID)
+		return nil, fmt.Errorf("convoy with id %s %w", convoyID, ierr.ErrNotFound)
 	}
 	return convoy, nil
 }
 
+// GetConvoysByID looks up several convoys under one lock. Unknown IDs are
+// left out of the result.
+func (s *MemoryStorage) GetConvoysByID(ctx context.Context, convoyIDs []string) (map[string]*domain.Convoy, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	convoys := make(map[string]*domain.Convoy, len(convoyIDs))
+	for _, convoyID := range convoyIDs {
+		if convoy, ok := s.convoys[convoyID]; ok {
+			convoys[convoyID] = convoy
+		}
+	}
+	return convoys, nil
+}
+
+// ReserveIdempotencyKey claims key for a create request whose body hashes to
+// fingerprint. It returns (nil, nil) when the caller now owns the key and
+// should create the convoy, or the convoy already created for the same
+// request. A different request under the key returns
+// ierr.ErrIdempotencyMismatch, and one still being created returns
+// ierr.ErrInProgress. Expired keys are pruned on each call.
+func (s *MemoryStorage) ReserveIdempotencyKey(ctx context.Context, key, fingerprint string, ttl time.Duration) (*domain.Convoy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range s.idempotencyKeys {
+		if now.After(entry.expiresAt) {
+			delete(s.idempotencyKeys, k)
+		}
+	}
+
+	if entry, ok := s.idempotencyKeys[key]; ok {
+		if entry.fingerprint != fingerprint {
+			return nil, ierr.ErrIdempotencyMismatch
+		}
+		if entry.convoyID == "" {
+			return nil, ierr.ErrInProgress
+		}
+		if convoy, ok := s.convoys[entry.convoyID]; ok {
+			return convoy, nil
+		}
+	}
+
+	s.idempotencyKeys[key] = idempotencyEntry{fingerprint: fingerprint, expiresAt: now.Add(ttl)}
+	return nil, nil
+}
+
+// SaveIdempotencyKey completes a reservation by mapping key to the convoy
+// created for it for ttl.
+func (s *MemoryStorage) SaveIdempotencyKey(ctx context.Context, key, convoyID string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := s.idempotencyKeys[key]
+	entry.convoyID = convoyID
+	entry.expiresAt = time.Now().Add(ttl)
+	s.idempotencyKeys[key] = entry
+	return nil
+}
+
+// ReleaseIdempotencyKey drops a reservation whose create request failed, so
+// the client can retry with the same key.
+func (s *MemoryStorage) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.idempotencyKeys[key]; ok && entry.convoyID == "" {
+		delete(s.idempotencyKeys, key)
+	}
+	return nil
+}
+
 func (s *MemoryStorage) AddMember(ctx context.Context, convoyID string, member *domain.Member) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	convoy, ok := s.convoys[convoyID]
 	if !ok {
-		return fmt.Errorf("convoy with id %s not found", convoyID)
+		return fmt.Errorf("convoy with id %s %w", convoyID, ierr.ErrNotFound)
+	}
+	if err := s.checkNewMember(convoy, member); err != nil {
+		return err
+	}
+
+	appendMember(convoy, member, time.Now())
+	return nil
+}
+
+// checkNewMember returns the error for adding member to the convoy, if its ID
+// is taken or the convoy is at the member cap. The caller must hold s.mu.
+func (s *MemoryStorage) checkNewMember(convoy *domain.Convoy, member *domain.Member) error {
+	for _, existing := range convoy.Members {
+		if existing.ID == member.ID {
+			return fmt.Errorf("member %d already in convoy %s: %w", member.ID, convoy.ID, ierr.ErrConflict)
+		}
 	}
 
+	if s.maxMembers > 0 && len(convoy.Members) >= s.maxMembers {
+		return ierr.ErrConvoyFull
+	}
+	return nil
+}
+
+// AddMembers adds several members at once. Either all of them are added or,
+// if any ID is taken or they don't all fit under the member cap, none are.
+func (s *MemoryStorage) AddMembers(ctx context.Context, convoyID string, members []*domain.Member) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	convoy, ok := s.convoys[convoyID]
+	if !ok {
+		return fmt.Errorf("convoy with id %s %w", convoyID, ierr.ErrNotFound)
+	}
+
+	ids := make(map[int64]bool, len(convoy.Members)+len(members))
+	for _, existing := range convoy.Members {
+		ids[existing.ID] = true
+	}
+	for _, member := range members {
+		if ids[member.ID] {
+			return fmt.Errorf("member %d already in convoy %s: %w", member.ID, convoyID, ierr.ErrConflict)
+		}
+		ids[member.ID] = true
+	}
+
+	if s.maxMembers > 0 && len(convoy.Members)+len(members) > s.maxMembers {
+		return ierr.ErrConvoyFull
+	}
+
+	now := time.Now()
+	for _, member := range members {
+		appendMember(convoy, member, now)
+	}
+	return nil
+}
+
+// appendMember initializes a new member's status, timestamps and color and
+// adds it to the convoy. The caller must hold s.mu.
+func appendMember(convoy *domain.Convoy, member *domain.Member, now time.Time) {
 	// Initialize member status and timestamp
 	if member.Status == "" {
 		member.Status = domain.StatusConnected
 	}
-	member.LastUpdate = time.Now()
+	member.LastUpdate = now
+	member.JoinedAt = member.LastUpdate
+	if member.Status != domain.StatusDisconnected {
+		member.ConnectedSince = member.LastUpdate
+	}
+	if member.Location != (domain.LatLng{}) {
+		appendTrackPoint(member, member.Location, member.LastUpdate)
+	}
+	updateDistanceToDestination(member, convoy.Destination)
+	if member.Color == "" {
+		member.Color = domain.NextMemberColor(convoy.Members)
+	}
+
+	// The first member to join leads the convoy
+	if convoy.LeaderID == 0 {
+		convoy.LeaderID = member.ID
+	}
 
-	// In a real application, you'd check for member ID conflicts
 	convoy.Members = append(convoy.Members, member)
-	return nil
+}
+
+// ValidateConnectToken checks a member's WebSocket connect token
+func (s *MemoryStorage) ValidateConnectToken(ctx context.Context, convoyID string, memberID int64, token string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	convoy, ok := s.convoys[convoyID]
+	if !ok {
+		return ierr.ErrNotFound
+	}
+
+	for _, member := range convoy.Members {
+		if member.ID == memberID {
+			if !member.MatchesConnectToken(token) {
+				return ierr.ErrInvalidToken
+			}
+			return nil
+		}
+	}
+
+	return ierr.ErrNotFound
+}
+
+// RejoinMember re-activates an existing member whose client lost its connection,
+// provided the rejoin token issued at join time matches.
+func (s *MemoryStorage) RejoinMember(ctx context.Context, convoyID string, memberID int64, token string) (*domain.Member, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	convoy, ok := s.convoys[convoyID]
+	if !ok {
+		return nil, ierr.ErrNotFound
+	}
+
+	for _, member := range convoy.Members {
+		if member.ID == memberID {
+			if !member.MatchesRejoinToken(token) {
+				return nil, ierr.ErrInvalidToken
+			}
+
+			// Status follows the actual socket state; the monitor flips it to
+			// connected (with a reconnect alert) once the client's WebSocket is back
+			if s.hasActiveConnection(convoyID, memberID) {
+				member.UpdateStatus(domain.StatusConnected)
+			} else {
+				member.UpdateStatus(domain.StatusDisconnected)
+			}
+			return member, nil
+		}
+	}
+
+	return nil, ierr.ErrNotFound
+}
+
+// UpdateMember applies a partial profile update to a member
+func (s *MemoryStorage) UpdateMember(ctx context.Context, convoyID string, memberID int64, update domain.MemberUpdate) (*domain.Member, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	convoy, ok := s.convoys[convoyID]
+	if !ok {
+		return nil, ierr.ErrNotFound
+	}
+
+	for _, member := range convoy.Members {
+		if member.ID == memberID {
+			if update.Name != nil {
+				member.Name = *update.Name
+			}
+			if update.VehicleType != nil {
+				member.VehicleType = *update.VehicleType
+			}
+			if update.AvatarURL != nil {
+				member.AvatarURL = *update.AvatarURL
+			}
+			if update.Color != nil {
+				member.Color = *update.Color
+			}
+			if update.Notes != nil {
+				member.Notes = *update.Notes
+			}
+			return member, nil
+		}
+	}
+
+	return nil, ierr.ErrNotFound
 }
 
 func (s *MemoryStorage) UpdateMemberLocation(ctx context.Context, convoyID string, memberID int64, location domain.LatLng) error {
+	return s.UpdateMemberLocationWithAccuracy(ctx, convoyID, memberID, location, nil)
+}
+
+// UpdateMemberLocationWithAccuracy stores a location like UpdateMemberLocation.
+// A location less accurate than the configured threshold is still stored but
+// marked low-confidence, so monitoring doesn't raise alerts off it.
+func (s *MemoryStorage) UpdateMemberLocationWithAccuracy(ctx context.Context, convoyID string, memberID int64, location domain.LatLng, accuracy *float64) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	convoy, ok := s.convoys[convoyID]
 	if !ok {
-		return fmt.Errorf("convoy with id %s not found", convoyID)
+		return fmt.Errorf("convoy with id %s %w", convoyID, ierr.ErrNotFound)
 	}
 
 	for _, member := range convoy.Members {
 		if member.ID == memberID {
+			if s.isOutlier(member, location) {
+				// Keep the prior location and timestamp so a genuinely fast member
+				// is accepted again once the window has passed
+				log.Printf("WARNING: Rejected GPS outlier for member %d in convoy %s: [%.6f, %.6f] -> [%.6f, %.6f]",
+					memberID, convoyID, member.Location.Lat, member.Location.Lng, location.Lat, location.Lng)
+				return nil
+			}
+
 			member.Location = location
-			member.LastUpdate = time.Now() // Update last seen timestamp
+			member.LastUpdate = time.Now()
+			member.LastSeen = member.LastUpdate
+			member.Accuracy = accuracy
+			member.LowConfidence = accuracy != nil && s.lowAccuracyMeters > 0 && *accuracy > s.lowAccuracyMeters
+			appendTrackPoint(member, location, member.LastUpdate)
+			updateDistanceToDestination(member, convoy.Destination)
 
 			// Only mark as connected if there's an active WebSocket connection
 			// This fixes the race condition where location updates would override disconnected status
-			if member.Status == "" || (member.Status == domain.StatusDisconnected && s.hasActiveConnection(convoyID, memberID)) {
+			if member.Status == "" || ((member.Status == domain.StatusDisconnected || member.Status == domain.StatusReconnecting) && s.hasActiveConnection(convoyID, memberID)) {
 				member.Status = domain.StatusConnected
 			}
 			return nil
 		}
 	}
 
-	return fmt.Errorf("member with id %d not found in convoy %s", memberID, convoyID)
+	return s.memberNotFound(convoyID, memberID)
+}
+
+// RecordHeartbeat marks the member's app as alive without touching its location
+// or LastUpdate, so a paused GPS still ages into the inactive status.
+func (s *MemoryStorage) RecordHeartbeat(ctx context.Context, convoyID string, memberID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	convoy, ok := s.convoys[convoyID]
+	if !ok {
+		return fmt.Errorf("convoy with id %s %w", convoyID, ierr.ErrNotFound)
+	}
+
+	for _, member := range convoy.Members {
+		if member.ID == memberID {
+			member.LastSeen = time.Now()
+			return nil
+		}
+	}
+
+	return s.memberNotFound(convoyID, memberID)
+}
+
+// updateDistanceToDestination recomputes how far the member is from the
+// destination. It is left unset without a destination or a known location.
+func updateDistanceToDestination(member *domain.Member, destination *domain.Destination) {
+	if destination == nil || member.Location == (domain.LatLng{}) {
+		member.DistanceToDestination = nil
+		return
+	}
+	distance := geo.Distance(member.Location, destination.ToLatLng())
+	member.DistanceToDestination = &distance
+}
+
+// appendTrackPoint records a location in the member's breadcrumb history
+func appendTrackPoint(member *domain.Member, location domain.LatLng, at time.Time) {
+	if len(member.Track) >= MaxTrackPoints {
+		copy(member.Track, member.Track[1:])
+		member.Track = member.Track[:len(member.Track)-1]
+	}
+	member.Track = append(member.Track, domain.TrackPoint{LatLng: location, Timestamp: at})
+}
+
+// GetMemberTrack returns a copy of the member's recorded track, oldest first
+// GetMember returns a copy of the member, so callers can read it after the lock is released
+func (s *MemoryStorage) GetMember(ctx context.Context, convoyID string, memberID int64) (*domain.Member, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	convoy, ok := s.convoys[convoyID]
+	if !ok {
+		return nil, fmt.Errorf("convoy with id %s %w", convoyID, ierr.ErrNotFound)
+	}
+
+	for _, member := range convoy.Members {
+		if member.ID == memberID {
+			found := *member
+			return &found, nil
+		}
+	}
+
+	return nil, fmt.Errorf("member with id %d %w in convoy %s", memberID, ierr.ErrNotFound, convoyID)
+}
+
+func (s *MemoryStorage) GetMemberTrack(ctx context.Context, convoyID string, memberID int64) ([]domain.TrackPoint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	convoy, ok := s.convoys[convoyID]
+	if !ok {
+		return nil, ierr.ErrNotFound
+	}
+
+	for _, member := range convoy.Members {
+		if member.ID == memberID {
+			track := make([]domain.TrackPoint, len(member.Track))
+			copy(track, member.Track)
+			return track, nil
+		}
+	}
+
+	return nil, ierr.ErrNotFound
+}
+
+// isOutlier reports whether moving a member to location implies an impossible speed.
+// The check only applies to updates arriving shortly after the previous one; after
+// a longer gap any jump is plausible.
+func (s *MemoryStorage) isOutlier(member *domain.Member, location domain.LatLng) bool {
+	if s.maxSpeedKmh <= 0 || member.LastUpdate.IsZero() {
+		return false
+	}
+	// No previous fix (member joined without a location)
+	if member.Location == (domain.LatLng{}) {
+		return false
+	}
+
+	elapsed := time.Since(member.LastUpdate)
+	if elapsed > s.outlierWindow {
+		return false
+	}
+
+	// Guard against division by ~zero for back-to-back updates
+	hours := math.Max(elapsed.Hours(), time.Second.Hours())
+	return geo.Distance(member.Location, location)/hours > s.maxSpeedKmh
 }
 
 // hasActiveConnection checks if a member has an active WebSocket connection
@@ -174,7 +711,7 @@ func (s *MemoryStorage) UpdateMemberStatus(ctx context.Context, convoyID string,
 
 	convoy, ok := s.convoys[convoyID]
 	if !ok {
-		return fmt.Errorf("convoy with id %s not found", convoyID)
+		return fmt.Errorf("convoy with id %s %w", convoyID, ierr.ErrNotFound)
 	}
 
 	for _, member := range convoy.Members {
@@ -184,7 +721,7 @@ func (s *MemoryStorage) UpdateMemberStatus(ctx context.Context, convoyID string,
 		}
 	}
 
-	return fmt.Errorf("member with id %d not found in convoy %s", memberID, convoyID)
+	return s.memberNotFound(convoyID, memberID)
 }
 
 func (s *MemoryStorage) SetConvoyDestination(ctx context.Context, convoyID string, destination *domain.Destination) error {
@@ -205,9 +742,146 @@ func (s *MemoryStorage) SetConvoyDestination(ctx context.Context, convoyID strin
 	}
 
 	convoy.Destination = destination
+	for _, member := range convoy.Members {
+		updateDistanceToDestination(member, destination)
+	}
+	return nil
+}
+
+// UpdateConvoyDestination applies a partial update to the convoy's destination.
+// The destination is replaced rather than modified in place, since broadcasts
+// in flight may still hold the old one.
+func (s *MemoryStorage) UpdateConvoyDestination(ctx context.Context, convoyID string, update domain.DestinationUpdate) (*domain.Destination, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	convoy, ok := s.convoys[convoyID]
+	if !ok {
+		return nil, fmt.Errorf("convoy with id %s %w", convoyID, ierr.ErrNotFound)
+	}
+	if convoy.Destination == nil {
+		return nil, fmt.Errorf("convoy %s: %w", convoyID, ierr.ErrNoDestination)
+	}
+
+	destination := *convoy.Destination
+	if update.Name != nil {
+		destination.Name = *update.Name
+	}
+	if update.Description != nil {
+		destination.Description = *update.Description
+	}
+	if update.Location != nil {
+		destination.Lat = update.Location.Lat
+		destination.Lng = update.Location.Lng
+	}
+
+	convoy.Destination = &destination
+	if update.Location != nil {
+		for _, member := range convoy.Members {
+			updateDistanceToDestination(member, convoy.Destination)
+		}
+	}
+	return convoy.Destination, nil
+}
+
+// SetConvoyPaused pauses or resumes monitoring alerts for a convoy.
+func (s *MemoryStorage) SetConvoyPaused(ctx context.Context, convoyID string, paused bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	convoy, ok := s.convoys[convoyID]
+	if !ok {
+		return ierr.ErrNotFound
+	}
+
+	convoy.Paused = paused
+	return nil
+}
+
+// SetConvoyStarted records when the convoy departed. Starting a convoy that
+// has already started returns ErrConflict and keeps the original time.
+func (s *MemoryStorage) SetConvoyStarted(ctx context.Context, convoyID string, startedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	convoy, ok := s.convoys[convoyID]
+	if !ok {
+		return fmt.Errorf("convoy with id %s %w", convoyID, ierr.ErrNotFound)
+	}
+	if convoy.StartedAt != nil {
+		return fmt.Errorf("convoy %s started at %v: %w", convoyID, *convoy.StartedAt, ierr.ErrConflict)
+	}
+
+	convoy.StartedAt = &startedAt
+	return nil
+}
+
+// SetConvoyName sets the convoy's human-readable label
+func (s *MemoryStorage) SetConvoyName(ctx context.Context, convoyID, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	convoy, ok := s.convoys[convoyID]
+	if !ok {
+		return ierr.ErrNotFound
+	}
+
+	convoy.Name = name
+	return nil
+}
+
+// SetConvoyMetadata replaces the convoy's metadata with a copy of metadata
+func (s *MemoryStorage) SetConvoyMetadata(ctx context.Context, convoyID string, metadata map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	convoy, ok := s.convoys[convoyID]
+	if !ok {
+		return ierr.ErrNotFound
+	}
+
+	if len(metadata) == 0 {
+		convoy.Metadata = nil
+	} else {
+		convoy.Metadata = maps.Clone(metadata)
+	}
+	return nil
+}
+
+// SetConvoyAggregate stores the headline numbers computed by the monitor.
+func (s *MemoryStorage) SetConvoyAggregate(ctx context.Context, convoyID string, aggregate *domain.ConvoyAggregate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	convoy, ok := s.convoys[convoyID]
+	if !ok {
+		return ierr.ErrNotFound
+	}
+
+	convoy.Aggregate = aggregate
 	return nil
 }
 
+// SetConvoyLeader hands leadership of a convoy to one of its members.
+func (s *MemoryStorage) SetConvoyLeader(ctx context.Context, convoyID string, memberID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	convoy, ok := s.convoys[convoyID]
+	if !ok {
+		return ierr.ErrNotFound
+	}
+
+	for _, member := range convoy.Members {
+		if member.ID == memberID {
+			convoy.LeaderID = memberID
+			return nil
+		}
+	}
+
+	return ierr.ErrNotFound
+}
+
 // LeaveConvoy removes a member from a convoy in memory.
 func (s *MemoryStorage) LeaveConvoy(ctx context.Context, convoyID string, memberID int64) error {
 	s.mu.Lock()
@@ -221,6 +895,7 @@ func (s *MemoryStorage) LeaveConvoy(ctx context.Context, convoyID string, member
 	for i, member := range convoy.Members {
 		if member.ID == memberID {
 			convoy.Members = append(convoy.Members[:i], convoy.Members[i+1:]...)
+			s.addTombstone(convoyID, memberID, time.Now())
 			return nil
 		}
 	}
@@ -228,6 +903,123 @@ func (s *MemoryStorage) LeaveConvoy(ctx context.Context, convoyID string, member
 	return ierr.ErrNotFound // Member not found
 }
 
+// addTombstone remembers that a member left, dropping the convoy's expired
+// tombstones. Must be called with s.mu held for writing.
+func (s *MemoryStorage) addTombstone(convoyID string, memberID int64, now time.Time) {
+	tombstones := s.tombstones[convoyID]
+	if tombstones == nil {
+		tombstones = make(map[int64]time.Time)
+		s.tombstones[convoyID] = tombstones
+	}
+	for id, leftAt := range tombstones {
+		if now.Sub(leftAt) >= MemberTombstoneTTL {
+			delete(tombstones, id)
+		}
+	}
+	tombstones[memberID] = now
+}
+
+// memberNotFound builds the error for an update to a member that isn't in the
+// convoy. It also matches ierr.ErrMemberLeft if the member left recently.
+// Must be called with s.mu held.
+func (s *MemoryStorage) memberNotFound(convoyID string, memberID int64) error {
+	if leftAt, ok := s.tombstones[convoyID][memberID]; ok && time.Since(leftAt) < MemberTombstoneTTL {
+		return fmt.Errorf("member with id %d %w in convoy %s: %w", memberID, ierr.ErrNotFound, convoyID, ierr.ErrMemberLeft)
+	}
+	return fmt.Errorf("member with id %d %w in convoy %s", memberID, ierr.ErrNotFound, convoyID)
+}
+
+// CreateInvite stores an invite for an existing convoy, dropping the convoy's expired ones
+func (s *MemoryStorage) CreateInvite(ctx context.Context, invite *domain.ConvoyInvite) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.convoys[invite.ConvoyID]; !ok {
+		return ierr.ErrNotFound
+	}
+
+	live := []*domain.ConvoyInvite{invite}
+	for _, existing := range s.invites[invite.ConvoyID] {
+		if !existing.IsExpired() {
+			live = append(live, existing)
+		}
+	}
+	s.invites[invite.ConvoyID] = live
+	return nil
+}
+
+// RedeemInvite adds member to the convoy on the invite matching the token,
+// using up one of its joins. The invite is only used up if the member is
+// added. It returns ierr.ErrInvalidToken if no invite matches,
+// ierr.ErrExpired if it has expired and ierr.ErrUsedUp if it has no joins
+// left, and AddMember's errors otherwise.
+func (s *MemoryStorage) RedeemInvite(ctx context.Context, convoyID, token string, member *domain.Member) (*domain.ConvoyInvite, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	convoy, ok := s.convoys[convoyID]
+	if !ok {
+		return nil, ierr.ErrNotFound
+	}
+
+	for _, invite := range s.invites[convoyID] {
+		if !invite.MatchesToken(token) {
+			continue
+		}
+		if invite.IsExpired() {
+			return nil, ierr.ErrExpired
+		}
+		if invite.IsUsedUp() {
+			return nil, ierr.ErrUsedUp
+		}
+		if err := s.checkNewMember(convoy, member); err != nil {
+			return nil, err
+		}
+		appendMember(convoy, member, time.Now())
+		invite.Uses++
+		redeemed := *invite
+		return &redeemed, nil
+	}
+	return nil, ierr.ErrInvalidToken
+}
+
+// AppendConvoyEvent adds an alert to its convoy's event log
+func (s *MemoryStorage) AppendConvoyEvent(ctx context.Context, alert *domain.ConvoyAlert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.convoys[alert.ConvoyID]; !ok {
+		return ierr.ErrNotFound
+	}
+
+	events := s.events[alert.ConvoyID]
+	if len(events) >= MaxConvoyEvents {
+		copy(events, events[1:])
+		events = events[:len(events)-1]
+	}
+	s.events[alert.ConvoyID] = append(events, *alert)
+	return nil
+}
+
+// GetConvoyEvents returns the convoy's logged alerts with a timestamp after
+// since, oldest first. A zero since returns the whole log.
+func (s *MemoryStorage) GetConvoyEvents(ctx context.Context, convoyID string, since time.Time) ([]domain.ConvoyAlert, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.convoys[convoyID]; !ok {
+		return nil, ierr.ErrNotFound
+	}
+
+	events := make([]domain.ConvoyAlert, 0)
+	for _, event := range s.events[convoyID] {
+		if event.Timestamp.After(since) {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
 // GetAllActiveConvoys returns all convoys that have at least one member.
 func (s *MemoryStorage) GetAllActiveConvoys(ctx context.Context) ([]*domain.Convoy, error) {
 	s.mu.RLock()
@@ -243,27 +1035,112 @@ func (s *MemoryStorage) GetAllActiveConvoys(ctx context.Context) ([]*domain.Conv
 	return activeConvoys, nil
 }
 
+// GetAllActiveConvoysSnapshot returns deep copies of all convoys that have at
+// least one member. Unlike GetAllActiveConvoys, the result can be read after
+// the lock is released while other requests keep adding, moving and removing
+// members. Member tracks are cut to the last SnapshotTrackPoints points.
+func (s *MemoryStorage) GetAllActiveConvoysSnapshot(ctx context.Context) ([]*domain.Convoy, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var snapshots []*domain.Convoy
+	for _, convoy := range s.convoys {
+		if len(convoy.Members) > 0 {
+			snapshots = append(snapshots, snapshotConvoy(convoy))
+		}
+	}
+
+	return snapshots, nil
+}
+
+// GetConvoySnapshot returns a deep copy of the convoy, see GetAllActiveConvoysSnapshot
+func (s *MemoryStorage) GetConvoySnapshot(ctx context.Context, convoyID string) (*domain.Convoy, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	convoy, ok := s.convoys[convoyID]
+	if !ok {
+		return nil, fmt.Errorf("convoy with id %s %w", convoyID, ierr.ErrNotFound)
+	}
+	return snapshotConvoy(convoy), nil
+}
+
+// snapshotConvoy deep-copies a convoy and its members, keeping only the tail of
+// each track rather than up to MaxTrackPoints points. Callers must hold s.mu.
+func snapshotConvoy(convoy *domain.Convoy) *domain.Convoy {
+	snapshot := *convoy
+	snapshot.Members = make([]*domain.Member, len(convoy.Members))
+	for i, member := range convoy.Members {
+		copied := *member
+		copied.DistanceToDestination = copyOf(member.DistanceToDestination)
+		copied.Accuracy = copyOf(member.Accuracy)
+		copied.Track = append([]domain.TrackPoint(nil), member.Track[max(0, len(member.Track)-SnapshotTrackPoints):]...)
+		snapshot.Members[i] = &copied
+	}
+	snapshot.Destination = copyOf(convoy.Destination)
+	snapshot.VerificationExpiresAt = copyOf(convoy.VerificationExpiresAt)
+	snapshot.VerifiedAt = copyOf(convoy.VerifiedAt)
+	snapshot.StartedAt = copyOf(convoy.StartedAt)
+	snapshot.Aggregate = copyOf(convoy.Aggregate)
+	snapshot.Metadata = maps.Clone(convoy.Metadata)
+	return &snapshot
+}
+
+// copyOf returns a pointer to a copy of *p, or nil if p is nil
+func copyOf[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	copied := *p
+	return &copied
+}
+
+// GetAllConvoys returns every convoy, including ones with no members
+func (s *MemoryStorage) GetAllConvoys(ctx context.Context) ([]*domain.Convoy, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	convoys := make([]*domain.Convoy, 0, len(s.convoys))
+	for _, convoy := range s.convoys {
+		convoys = append(convoys, convoy)
+	}
+
+	return convoys, nil
+}
+
 // VerifyConvoy verifies a convoy using the verification token
 func (s *MemoryStorage) VerifyConvoy(ctx context.Context, token string) (*domain.Convoy, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	convoy, err := s.verifyConvoyToken(token)
+
+	attempt := domain.VerificationAttempt{Method: "email", TokenPrefix: token[:min(len(token), auditTokenPrefixLength)]}
+	if verification, ok := s.verifications[token]; ok {
+		attempt.ConvoyID = verification.ConvoyID
+	}
+	s.recordVerificationAttempt(attempt, err)
+	return convoy, err
+}
+
+// verifyConvoyToken does the work of VerifyConvoy. Callers must hold s.mu.
+func (s *MemoryStorage) verifyConvoyToken(token string) (*domain.Convoy, error) {
 	verification, ok := s.verifications[token]
 	if !ok {
-		return nil, fmt.Errorf("verification token not found")
+		return nil, fmt.Errorf("verification token %w", ierr.ErrNotFound)
 	}
 
 	if verification.IsExpired() {
-		return nil, fmt.Errorf("verification token has expired")
+		return nil, fmt.Errorf("verification token %w", ierr.ErrExpired)
 	}
 
 	if verification.IsVerified() {
-		return nil, fmt.Errorf("verification token has already been used")
+		return nil, fmt.Errorf("verification token: %w", ierr.ErrTokenUsed)
 	}
 
 	convoy, ok := s.convoys[verification.ConvoyID]
 	if !ok {
-		return nil, fmt.Errorf("convoy not found")
+		return nil, fmt.Errorf("convoy %w", ierr.ErrNotFound)
 	}
 
 	// Mark verification as completed
@@ -277,6 +1154,107 @@ func (s *MemoryStorage) VerifyConvoy(ctx context.Context, token string) (*domain
 	return convoy, nil
 }
 
+// VerifyConvoyCode verifies a convoy using the SMS code sent to the leader's phone
+func (s *MemoryStorage) VerifyConvoyCode(ctx context.Context, convoyID, code string) (*domain.Convoy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	convoy, err := s.verifyConvoyCode(convoyID, code)
+	s.recordVerificationAttempt(domain.VerificationAttempt{Method: "sms", ConvoyID: convoyID}, err)
+	return convoy, err
+}
+
+// verifyConvoyCode does the work of VerifyConvoyCode. Callers must hold s.mu.
+func (s *MemoryStorage) verifyConvoyCode(convoyID, code string) (*domain.Convoy, error) {
+	var verification *domain.ConvoyVerification
+	for _, v := range s.verifications {
+		if v.ConvoyID == convoyID && v.Code != "" {
+			verification = v
+			break
+		}
+	}
+	if verification == nil {
+		return nil, fmt.Errorf("verification code %w", ierr.ErrNotFound)
+	}
+
+	if verification.IsExpired() {
+		return nil, fmt.Errorf("verification code %w", ierr.ErrExpired)
+	}
+
+	if verification.IsVerified() {
+		return nil, fmt.Errorf("verification code: %w", ierr.ErrTokenUsed)
+	}
+
+	if verification.Attempts >= MaxCodeAttempts {
+		return nil, fmt.Errorf("verification code: %w", ierr.ErrTooManyAttempts)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(verification.Code), []byte(code)) != 1 {
+		verification.Attempts++
+		return nil, fmt.Errorf("verification code: %w", ierr.ErrInvalidToken)
+	}
+
+	convoy, ok := s.convoys[convoyID]
+	if !ok {
+		return nil, fmt.Errorf("convoy %w", ierr.ErrNotFound)
+	}
+
+	now := time.Now()
+	verification.VerifiedAt = &now
+
+	convoy.IsVerified = true
+	convoy.VerifiedAt = &now
+
+	return convoy, nil
+}
+
+// recordVerificationAttempt adds an attempt with the outcome implied by err to
+// the audit log, if auditing is on. Callers must hold s.mu.
+func (s *MemoryStorage) recordVerificationAttempt(attempt domain.VerificationAttempt, err error) {
+	if !s.auditVerifications {
+		return
+	}
+
+	switch {
+	case err == nil:
+		attempt.Outcome = domain.VerificationValid
+	case errors.Is(err, ierr.ErrNotFound), errors.Is(err, ierr.ErrInvalidToken):
+		attempt.Outcome = domain.VerificationInvalid
+	case errors.Is(err, ierr.ErrExpired):
+		attempt.Outcome = domain.VerificationExpired
+	case errors.Is(err, ierr.ErrTokenUsed):
+		attempt.Outcome = domain.VerificationAlreadyUsed
+	case errors.Is(err, ierr.ErrTooManyAttempts):
+		attempt.Outcome = domain.VerificationTooManyAttempts
+	default:
+		attempt.Outcome = domain.VerificationError
+	}
+	attempt.Timestamp = time.Now()
+
+	if len(s.verificationAttempts) >= MaxVerificationAttempts {
+		copy(s.verificationAttempts, s.verificationAttempts[1:])
+		s.verificationAttempts = s.verificationAttempts[:len(s.verificationAttempts)-1]
+	}
+	s.verificationAttempts = append(s.verificationAttempts, attempt)
+}
+
+// GetVerificationAttempts returns up to limit audited verification attempts,
+// newest first. A limit of 0 or less returns all of them.
+func (s *MemoryStorage) GetVerificationAttempts(ctx context.Context, limit int) ([]domain.VerificationAttempt, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := len(s.verificationAttempts)
+	if limit > 0 && limit < count {
+		count = limit
+	}
+	attempts := make([]domain.VerificationAttempt, 0, count)
+	for i := len(s.verificationAttempts) - 1; i >= 0 && len(attempts) < count; i-- {
+		attempts = append(attempts, s.verificationAttempts[i])
+	}
+	return attempts, nil
+}
+
 // GetVerification retrieves verification information for a convoy
 func (s *MemoryStorage) GetVerification(ctx context.Context, convoyID string) (*domain.ConvoyVerification, error) {
 	s.mu.RLock()
@@ -288,7 +1266,7 @@ func (s *MemoryStorage) GetVerification(ctx context.Context, convoyID string) (*
 		}
 	}
 
-	return nil, fmt.Errorf("verification not found for convoy %s", convoyID)
+	return nil, fmt.Errorf("verification for convoy %s %w", convoyID, ierr.ErrNotFound)
 }
 
 // UpdateVerificationToken updates the verification token for a convoy (for resend functionality)
@@ -298,7 +1276,7 @@ func (s *MemoryStorage) UpdateVerificationToken(ctx context.Context, convoyID, t
 
 	convoy, ok := s.convoys[convoyID]
 	if !ok {
-		return fmt.Errorf("convoy not found")
+		return fmt.Errorf("convoy %w", ierr.ErrNotFound)
 	}
 
 	// Find existing verification
@@ -311,7 +1289,7 @@ func (s *MemoryStorage) UpdateVerificationToken(ctx context.Context, convoyID, t
 	}
 
 	if existingVerification == nil {
-		return fmt.Errorf("verification not found for convoy")
+		return fmt.Errorf("verification for convoy %s %w", convoyID, ierr.ErrNotFound)
 	}
 
 	// Remove old token
@@ -356,9 +1334,41 @@ func (s *MemoryStorage) CleanupExpiredVerifications(ctx context.Context) error {
 	// Remove expired unverified convoys
 	for _, convoyID := range expiredConvoyIDs {
 		if convoy, exists := s.convoys[convoyID]; exists && !convoy.IsVerified {
-			delete(s.convoys, convoyID)
+			s.removeConvoy(convoyID)
 		}
 	}
 
 	return nil
 }
+
+// DeleteConvoy removes a convoy together with its verifications, events and
+// invites. It returns ierr.ErrNotFound if there is no such convoy.
+func (s *MemoryStorage) DeleteConvoy(ctx context.Context, convoyID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.convoys[convoyID]; !ok {
+		return fmt.Errorf("convoy with id %s %w", convoyID, ierr.ErrNotFound)
+	}
+	s.removeConvoy(convoyID)
+	return nil
+}
+
+// removeConvoy deletes a convoy together with everything kept per convoy, so
+// nothing outlives it. Callers must hold s.mu.
+func (s *MemoryStorage) removeConvoy(convoyID string) {
+	delete(s.convoys, convoyID)
+	delete(s.events, convoyID)
+	delete(s.invites, convoyID)
+	delete(s.tombstones, convoyID)
+	for token, verification := range s.verifications {
+		if verification.ConvoyID == convoyID {
+			delete(s.verifications, token)
+		}
+	}
+	for key, entry := range s.idempotencyKeys {
+		if entry.convoyID == convoyID {
+			delete(s.idempotencyKeys, key)
+		}
+	}
+}
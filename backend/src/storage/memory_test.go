@@ -0,0 +1,727 @@
+package storage
+
+import (
+	"context"
+	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/ierr"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUpdateMemberLocationRejectsTeleportOutlier(t *testing.T) {
+	storage := NewMemoryStorage()
+	storage.SetOutlierFilter(300, 30*time.Second)
+	ctx := context.Background()
+
+	convoy, err := storage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+
+	start := domain.LatLng{Lat: 40.0, Lng: -74.0}
+	member := &domain.Member{ID: 1, Name: "TestMember1", Location: start}
+	if err := storage.AddMember(ctx, convoy.ID, member); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+
+	// ~11 km in a second is far beyond 300 km/h
+	teleport := domain.LatLng{Lat: 40.1, Lng: -74.0}
+	if err := storage.UpdateMemberLocation(ctx, convoy.ID, member.ID, teleport); err != nil {
+		t.Fatalf("Failed to update location: %v", err)
+	}
+	if member.Location != start {
+		t.Errorf("Expected outlier to be rejected, location moved to %+v", member.Location)
+	}
+
+	// A small, plausible move is accepted
+	nearby := domain.LatLng{Lat: 40.0001, Lng: -74.0}
+	if err := storage.UpdateMemberLocation(ctx, convoy.ID, member.ID, nearby); err != nil {
+		t.Fatalf("Failed to update location: %v", err)
+	}
+	if member.Location != nearby {
+		t.Errorf("Expected plausible move to be accepted, got %+v", member.Location)
+	}
+
+	// After a long gap the same jump is plausible and must not be rejected forever
+	member.LastUpdate = time.Now().Add(-5 * time.Minute)
+	if err := storage.UpdateMemberLocation(ctx, convoy.ID, member.ID, teleport); err != nil {
+		t.Fatalf("Failed to update location: %v", err)
+	}
+	if member.Location != teleport {
+		t.Errorf("Expected jump after long gap to be accepted, got %+v", member.Location)
+	}
+}
+
+func TestAddMemberRejectsFullConvoy(t *testing.T) {
+	storage := NewMemoryStorage()
+	storage.SetMaxMembers(3)
+	ctx := context.Background()
+
+	convoy, err := storage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+
+	for i := int64(1); i <= 3; i++ {
+		if err := storage.AddMember(ctx, convoy.ID, &domain.Member{ID: i, Name: "Member"}); err != nil {
+			t.Fatalf("Failed to add member %d: %v", i, err)
+		}
+	}
+
+	err = storage.AddMember(ctx, convoy.ID, &domain.Member{ID: 4, Name: "Member"})
+	if !errors.Is(err, ierr.ErrConvoyFull) {
+		t.Fatalf("Expected ErrConvoyFull, got %v", err)
+	}
+	if len(convoy.Members) != 3 {
+		t.Errorf("Expected 3 members, got %d", len(convoy.Members))
+	}
+}
+
+func TestRedeemInviteKeepsUseWhenJoinFails(t *testing.T) {
+	storage := NewMemoryStorage()
+	storage.SetMaxMembers(1)
+	ctx := context.Background()
+
+	convoy, err := storage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	if err := storage.AddMember(ctx, convoy.ID, &domain.Member{ID: 1, Name: "Leader"}); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+	invite := domain.NewConvoyInvite(convoy.ID, "invite", 1, time.Now().Add(time.Hour))
+	if err := storage.CreateInvite(ctx, invite); err != nil {
+		t.Fatalf("Failed to create invite: %v", err)
+	}
+
+	_, err = storage.RedeemInvite(ctx, convoy.ID, "invite", &domain.Member{ID: 2, Name: "Member"})
+	if !errors.Is(err, ierr.ErrConvoyFull) {
+		t.Fatalf("Expected ErrConvoyFull, got %v", err)
+	}
+	if invite.Uses != 0 || len(convoy.Members) != 1 {
+		t.Errorf("Expected a failed join to leave the invite unused, got %d uses and %d members", invite.Uses, len(convoy.Members))
+	}
+
+	storage.SetMaxMembers(2)
+	if _, err := storage.RedeemInvite(ctx, convoy.ID, "invite", &domain.Member{ID: 2, Name: "Member"}); err != nil {
+		t.Fatalf("Expected the invite to still be usable, got %v", err)
+	}
+	if invite.Uses != 1 || len(convoy.Members) != 2 {
+		t.Errorf("Expected the join to use the invite, got %d uses and %d members", invite.Uses, len(convoy.Members))
+	}
+}
+
+func TestCreateConvoyRejectedAtCapacity(t *testing.T) {
+	storage := NewMemoryStorage()
+	storage.SetMaxConvoys(2)
+	ctx := context.Background()
+
+	if _, err := storage.CreateConvoy(ctx); err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	if _, err := storage.CreateConvoyWithVerification(ctx, "a@example.com", "Leader", "token", time.Now().Add(30*time.Millisecond)); err != nil {
+		t.Fatalf("Failed to create convoy with verification: %v", err)
+	}
+
+	if _, err := storage.CreateConvoy(ctx); !errors.Is(err, ierr.ErrCapacity) {
+		t.Fatalf("Expected ErrCapacity, got %v", err)
+	}
+	if _, err := storage.CreateConvoyWithSMSVerification(ctx, "+15550100", "Leader", "123456", time.Now().Add(time.Minute)); !errors.Is(err, ierr.ErrCapacity) {
+		t.Fatalf("Expected ErrCapacity for SMS verification, got %v", err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if err := storage.CleanupExpiredVerifications(ctx); err != nil {
+		t.Fatalf("Cleanup failed: %v", err)
+	}
+
+	if _, err := storage.CreateConvoy(ctx); err != nil {
+		t.Fatalf("Expected creation to succeed after cleanup, got %v", err)
+	}
+	if _, err := storage.CreateConvoy(ctx); !errors.Is(err, ierr.ErrCapacity) {
+		t.Fatalf("Expected ErrCapacity once full again, got %v", err)
+	}
+}
+
+func TestCleanupRemovesAllStateOfExpiredConvoy(t *testing.T) {
+	storage := NewMemoryStorage()
+	ctx := context.Background()
+
+	convoy, err := storage.CreateConvoyWithVerification(ctx, "a@example.com", "Leader", "token", time.Now().Add(-time.Second))
+	if err != nil {
+		t.Fatalf("Failed to create convoy with verification: %v", err)
+	}
+	if err := storage.AddMember(ctx, convoy.ID, &domain.Member{ID: 1, Name: "Leader"}); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+	if err := storage.LeaveConvoy(ctx, convoy.ID, 1); err != nil {
+		t.Fatalf("Failed to leave convoy: %v", err)
+	}
+	if err := storage.AppendConvoyEvent(ctx, &domain.ConvoyAlert{EventType: domain.EventMemberJoined, ConvoyID: convoy.ID}); err != nil {
+		t.Fatalf("Failed to append event: %v", err)
+	}
+	if err := storage.CreateInvite(ctx, &domain.ConvoyInvite{ConvoyID: convoy.ID, TokenHash: "invite", MaxUses: 1, ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("Failed to create invite: %v", err)
+	}
+	if err := storage.SaveIdempotencyKey(ctx, "key", convoy.ID, time.Hour); err != nil {
+		t.Fatalf("Failed to save idempotency key: %v", err)
+	}
+
+	if err := storage.CleanupExpiredVerifications(ctx); err != nil {
+		t.Fatalf("Cleanup failed: %v", err)
+	}
+
+	if len(storage.convoys) != 0 || len(storage.verifications) != 0 || len(storage.events) != 0 ||
+		len(storage.invites) != 0 || len(storage.tombstones) != 0 || len(storage.idempotencyKeys) != 0 {
+		t.Errorf("Expected no state left for the removed convoy, got convoys=%d verifications=%d events=%d invites=%d tombstones=%d idempotencyKeys=%d",
+			len(storage.convoys), len(storage.verifications), len(storage.events), len(storage.invites), len(storage.tombstones), len(storage.idempotencyKeys))
+	}
+}
+
+func TestExpiredVerificationDoesNotCountTowardCapacity(t *testing.T) {
+	storage := NewMemoryStorage()
+	storage.SetMaxConvoys(1)
+	ctx := context.Background()
+
+	if _, err := storage.CreateConvoyWithVerification(ctx, "a@example.com", "Leader", "token", time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("Failed to create convoy with verification: %v", err)
+	}
+
+	// The sweeper has not run, but the expired convoy can never be verified
+	if _, err := storage.CreateConvoy(ctx); err != nil {
+		t.Fatalf("Expected expired convoy to be ignored, got %v", err)
+	}
+}
+
+func TestAddMemberAssignsDistinctColors(t *testing.T) {
+	storage := NewMemoryStorage()
+	ctx := context.Background()
+
+	convoy, err := storage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	palette := len(domain.MemberColors)
+	for i := int64(1); i <= int64(palette); i++ {
+		member := &domain.Member{ID: i, Name: "Member"}
+		if err := storage.AddMember(ctx, convoy.ID, member); err != nil {
+			t.Fatalf("Failed to add member %d: %v", i, err)
+		}
+		if !slices.Contains(domain.MemberColors, member.Color) {
+			t.Fatalf("Expected a palette color for member %d, got %q", i, member.Color)
+		}
+		if seen[member.Color] {
+			t.Fatalf("Expected distinct colors before the palette wraps, member %d repeated %q", i, member.Color)
+		}
+		seen[member.Color] = true
+	}
+
+	// Once the palette wraps, a color repeats but never the previous member's
+	previous := convoy.Members[len(convoy.Members)-1].Color
+	wrapped := &domain.Member{ID: int64(palette) + 1, Name: "Member"}
+	if err := storage.AddMember(ctx, convoy.ID, wrapped); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+	if wrapped.Color == "" || wrapped.Color == previous {
+		t.Errorf("Expected a palette color other than %q after wrapping, got %q", previous, wrapped.Color)
+	}
+
+	// A color chosen by the client is kept
+	chosen := &domain.Member{ID: int64(palette) + 2, Name: "Member", Color: "#123456"}
+	if err := storage.AddMember(ctx, convoy.ID, chosen); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+	if chosen.Color != "#123456" {
+		t.Errorf("Expected the preset color to be kept, got %q", chosen.Color)
+	}
+}
+
+func TestGetMember(t *testing.T) {
+	storage := NewMemoryStorage()
+	ctx := context.Background()
+
+	convoy, err := storage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	if err := storage.AddMember(ctx, convoy.ID, &domain.Member{ID: 1, Name: "Member"}); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+
+	member, err := storage.GetMember(ctx, convoy.ID, 1)
+	if err != nil {
+		t.Fatalf("Failed to get member: %v", err)
+	}
+	member.Name = "Changed"
+	if convoy.Members[0].Name != "Member" {
+		t.Error("Expected GetMember to return a copy")
+	}
+
+	if _, err := storage.GetMember(ctx, convoy.ID, 2); !errors.Is(err, ierr.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an unknown member, got %v", err)
+	}
+	if _, err := storage.GetMember(ctx, "missing", 1); !errors.Is(err, ierr.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an unknown convoy, got %v", err)
+	}
+}
+
+func TestConvoyEventLogEvictsOldest(t *testing.T) {
+	storage := NewMemoryStorage()
+	ctx := context.Background()
+
+	convoy, err := storage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+
+	start := time.Now()
+	for i := 0; i < MaxConvoyEvents+10; i++ {
+		alert := &domain.ConvoyAlert{EventType: domain.EventMemberLagging, ConvoyID: convoy.ID, MemberID: int64(i), Timestamp: start.Add(time.Duration(i) * time.Second)}
+		if err := storage.AppendConvoyEvent(ctx, alert); err != nil {
+			t.Fatalf("Failed to append event: %v", err)
+		}
+	}
+
+	events, err := storage.GetConvoyEvents(ctx, convoy.ID, time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to get events: %v", err)
+	}
+	if len(events) != MaxConvoyEvents {
+		t.Fatalf("Expected %d events, got %d", MaxConvoyEvents, len(events))
+	}
+	if events[0].MemberID != 10 || events[len(events)-1].MemberID != MaxConvoyEvents+9 {
+		t.Errorf("Expected the oldest events to be evicted, got first %d last %d", events[0].MemberID, events[len(events)-1].MemberID)
+	}
+}
+
+func TestAddMemberConcurrentIDsAreUnique(t *testing.T) {
+	storage := NewMemoryStorage()
+	ctx := context.Background()
+
+	convoy, err := storage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+
+	const joins = 200
+	var wg sync.WaitGroup
+	errs := make(chan error, joins)
+	for i := 0; i < joins; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- storage.AddMember(ctx, convoy.ID, &domain.Member{ID: domain.NewMemberID(), Name: "Member"})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+	}
+
+	updated, _ := storage.GetConvoy(ctx, convoy.ID)
+	seen := make(map[int64]bool, joins)
+	for _, member := range updated.Members {
+		if seen[member.ID] {
+			t.Fatalf("Member ID %d issued twice", member.ID)
+		}
+		seen[member.ID] = true
+	}
+	if len(seen) != joins {
+		t.Errorf("Expected %d members, got %d", joins, len(seen))
+	}
+}
+
+func TestAddMemberRejectsDuplicateID(t *testing.T) {
+	storage := NewMemoryStorage()
+	ctx := context.Background()
+
+	convoy, err := storage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	if err := storage.AddMember(ctx, convoy.ID, &domain.Member{ID: 1, Name: "TestMember1"}); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+
+	err = storage.AddMember(ctx, convoy.ID, &domain.Member{ID: 1, Name: "TestMember2"})
+	if !errors.Is(err, ierr.ErrConflict) {
+		t.Fatalf("Expected ErrConflict for a duplicate member ID, got %v", err)
+	}
+	updated, _ := storage.GetConvoy(ctx, convoy.ID)
+	if len(updated.Members) != 1 {
+		t.Errorf("Expected the duplicate to be rejected, got %d members", len(updated.Members))
+	}
+}
+
+func TestDistanceToDestination(t *testing.T) {
+	storage := NewMemoryStorage()
+	ctx := context.Background()
+
+	convoy, err := storage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	member := &domain.Member{ID: 1, Name: "TestMember1", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}}
+	if err := storage.AddMember(ctx, convoy.ID, member); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+	if member.DistanceToDestination != nil {
+		t.Fatalf("Expected no distance without a destination, got %v", *member.DistanceToDestination)
+	}
+
+	// Setting the destination computes the distance without a location update
+	destination := &domain.Destination{Name: "Depot", Lat: 40.1, Lng: -74.0}
+	if err := storage.SetConvoyDestination(ctx, convoy.ID, destination); err != nil {
+		t.Fatalf("Failed to set destination: %v", err)
+	}
+	if member.DistanceToDestination == nil || *member.DistanceToDestination < 11.0 || *member.DistanceToDestination > 11.2 {
+		t.Fatalf("Expected ~11.1 km to the destination, got %v", member.DistanceToDestination)
+	}
+
+	if err := storage.UpdateMemberLocation(ctx, convoy.ID, member.ID, domain.LatLng{Lat: 40.05, Lng: -74.0}); err != nil {
+		t.Fatalf("Failed to update location: %v", err)
+	}
+	if member.DistanceToDestination == nil || *member.DistanceToDestination > 5.6 {
+		t.Errorf("Expected the distance to shrink after moving, got %v", member.DistanceToDestination)
+	}
+}
+
+func TestStorageErrorsWrapSentinels(t *testing.T) {
+	storage := NewMemoryStorage()
+	ctx := context.Background()
+
+	convoy, err := storage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+
+	notFound := map[string]error{
+		"GetConvoy":            func() error { _, err := storage.GetConvoy(ctx, "missing"); return err }(),
+		"AddMember":            storage.AddMember(ctx, "missing", &domain.Member{ID: 1}),
+		"UpdateMemberLocation": storage.UpdateMemberLocation(ctx, convoy.ID, 99, domain.LatLng{Lat: 40.0, Lng: -74.0}),
+		"RecordHeartbeat":      storage.RecordHeartbeat(ctx, convoy.ID, 99),
+		"UpdateMemberStatus":   storage.UpdateMemberStatus(ctx, "missing", 1, domain.StatusConnected),
+		"GetVerification":      func() error { _, err := storage.GetVerification(ctx, convoy.ID); return err }(),
+		"VerifyConvoy":         func() error { _, err := storage.VerifyConvoy(ctx, "missing"); return err }(),
+		"VerifyConvoyCode":     func() error { _, err := storage.VerifyConvoyCode(ctx, convoy.ID, "123456"); return err }(),
+	}
+	for method, err := range notFound {
+		if !errors.Is(err, ierr.ErrNotFound) {
+			t.Errorf("Expected %s to return ErrNotFound, got %v", method, err)
+		}
+	}
+}
+
+func TestVerifyConvoyErrors(t *testing.T) {
+	storage := NewMemoryStorage()
+	ctx := context.Background()
+
+	if _, err := storage.CreateConvoyWithVerification(ctx, "lead@example.com", "Lead", "expired-token", time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	if _, err := storage.VerifyConvoy(ctx, "expired-token"); !errors.Is(err, ierr.ErrExpired) {
+		t.Errorf("Expected ErrExpired, got %v", err)
+	}
+
+	if _, err := storage.CreateConvoyWithVerification(ctx, "lead@example.com", "Lead", "valid-token", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	if _, err := storage.VerifyConvoy(ctx, "valid-token"); err != nil {
+		t.Fatalf("Expected the first verification to succeed, got %v", err)
+	}
+	if _, err := storage.VerifyConvoy(ctx, "valid-token"); !errors.Is(err, ierr.ErrTokenUsed) {
+		t.Errorf("Expected ErrTokenUsed, got %v", err)
+	}
+
+	convoy, err := storage.CreateConvoyWithSMSVerification(ctx, "+15555550100", "Lead", "123456", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	for i := 0; i < MaxCodeAttempts; i++ {
+		if _, err := storage.VerifyConvoyCode(ctx, convoy.ID, "000000"); !errors.Is(err, ierr.ErrInvalidToken) {
+			t.Fatalf("Expected ErrInvalidToken for a wrong code, got %v", err)
+		}
+	}
+	if _, err := storage.VerifyConvoyCode(ctx, convoy.ID, "123456"); !errors.Is(err, ierr.ErrTooManyAttempts) {
+		t.Errorf("Expected ErrTooManyAttempts, got %v", err)
+	}
+}
+
+func TestVerifyConvoyCode(t *testing.T) {
+	storage := NewMemoryStorage()
+	ctx := context.Background()
+
+	convoy, err := storage.CreateConvoyWithSMSVerification(ctx, "+15555550100", "Lead", "123456", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+
+	// Wrong codes are counted but don't lock the code below the limit
+	for i := 0; i < MaxCodeAttempts-1; i++ {
+		if _, err := storage.VerifyConvoyCode(ctx, convoy.ID, "000000"); !errors.Is(err, ierr.ErrInvalidToken) {
+			t.Fatalf("Expected ErrInvalidToken for a wrong code, got %v", err)
+		}
+	}
+	for _, v := range storage.verifications {
+		if v.ConvoyID == convoy.ID && v.Attempts != MaxCodeAttempts-1 {
+			t.Errorf("Expected %d attempts to be counted, got %d", MaxCodeAttempts-1, v.Attempts)
+		}
+	}
+
+	verified, err := storage.VerifyConvoyCode(ctx, convoy.ID, "123456")
+	if err != nil {
+		t.Fatalf("Expected the right code to verify the convoy, got %v", err)
+	}
+	if !verified.IsVerified {
+		t.Errorf("Expected the convoy to be verified")
+	}
+	if _, err := storage.VerifyConvoyCode(ctx, convoy.ID, "123456"); !errors.Is(err, ierr.ErrTokenUsed) {
+		t.Errorf("Expected ErrTokenUsed for a reused code, got %v", err)
+	}
+
+	expired, err := storage.CreateConvoyWithSMSVerification(ctx, "+15555550101", "Lead", "654321", time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	if _, err := storage.VerifyConvoyCode(ctx, expired.ID, "654321"); !errors.Is(err, ierr.ErrExpired) {
+		t.Errorf("Expected ErrExpired for an expired code, got %v", err)
+	}
+
+	if _, err := storage.VerifyConvoyCode(ctx, "missing", "123456"); !errors.Is(err, ierr.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound without a pending code, got %v", err)
+	}
+}
+
+func TestVerificationAttemptsAudited(t *testing.T) {
+	storage := NewMemoryStorage()
+	storage.SetVerificationAudit(true)
+	ctx := context.Background()
+
+	convoy, err := storage.CreateConvoyWithVerification(ctx, "lead@example.com", "Lead", "abcdef123456", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	storage.VerifyConvoy(ctx, "guessed-token")
+	storage.VerifyConvoy(ctx, "abcdef123456")
+	storage.VerifyConvoy(ctx, "abcdef123456")
+
+	smsConvoy, err := storage.CreateConvoyWithSMSVerification(ctx, "+15555550100", "Lead", "123456", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	storage.VerifyConvoyCode(ctx, smsConvoy.ID, "000000")
+
+	attempts, err := storage.GetVerificationAttempts(ctx, 0)
+	if err != nil {
+		t.Fatalf("Failed to get attempts: %v", err)
+	}
+	expected := []domain.VerificationAttempt{ // newest first
+		{Method: "sms", ConvoyID: smsConvoy.ID, Outcome: domain.VerificationInvalid},
+		{Method: "email", ConvoyID: convoy.ID, TokenPrefix: "abcdef", Outcome: domain.VerificationAlreadyUsed},
+		{Method: "email", ConvoyID: convoy.ID, TokenPrefix: "abcdef", Outcome: domain.VerificationValid},
+		{Method: "email", TokenPrefix: "guesse", Outcome: domain.VerificationInvalid},
+	}
+	if len(attempts) != len(expected) {
+		t.Fatalf("Expected %d attempts, got %d: %+v", len(expected), len(attempts), attempts)
+	}
+	for i, want := range expected {
+		got := attempts[i]
+		if got.Timestamp.IsZero() {
+			t.Errorf("Expected attempt %d to have a timestamp", i)
+		}
+		got.Timestamp = time.Time{}
+		if got != want {
+			t.Errorf("Expected attempt %d to be %+v, got %+v", i, want, got)
+		}
+	}
+
+	if limited, _ := storage.GetVerificationAttempts(ctx, 1); len(limited) != 1 || limited[0].Method != "sms" {
+		t.Errorf("Expected only the newest attempt with a limit of 1, got %+v", limited)
+	}
+}
+
+func TestVerificationAttemptsNotAuditedByDefault(t *testing.T) {
+	storage := NewMemoryStorage()
+	ctx := context.Background()
+
+	storage.VerifyConvoy(ctx, "guessed-token")
+	if attempts, _ := storage.GetVerificationAttempts(ctx, 0); len(attempts) != 0 {
+		t.Errorf("Expected no attempts recorded without auditing, got %d", len(attempts))
+	}
+}
+
+func TestLocationUpdateRacingLeaveDoesNotResurrectMember(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStorage()
+	convoy, err := s.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+
+	for id := int64(1); id <= 200; id++ {
+		if err := s.AddMember(ctx, convoy.ID, &domain.Member{ID: id, Name: "Racer", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}}); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+
+		var wg sync.WaitGroup
+		var leaveErr, updateErr error
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			leaveErr = s.LeaveConvoy(ctx, convoy.ID, id)
+		}()
+		go func() {
+			defer wg.Done()
+			updateErr = s.UpdateMemberLocation(ctx, convoy.ID, id, domain.LatLng{Lat: 40.0001, Lng: -74.0})
+		}()
+		wg.Wait()
+
+		if leaveErr != nil {
+			t.Fatalf("Failed to leave: %v", leaveErr)
+		}
+		// An update that lost the race is rejected as coming from a member who left
+		if updateErr != nil && !errors.Is(updateErr, ierr.ErrMemberLeft) {
+			t.Fatalf("Expected nil or ErrMemberLeft for member %d, got %v", id, updateErr)
+		}
+		if _, err := s.GetMember(ctx, convoy.ID, id); !errors.Is(err, ierr.ErrNotFound) {
+			t.Fatalf("Expected member %d to stay removed, got %v", id, err)
+		}
+	}
+}
+
+func TestMemberTombstoneExpires(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStorage()
+	convoy, _ := s.CreateConvoy(ctx)
+	for _, id := range []int64{1, 2} {
+		if err := s.AddMember(ctx, convoy.ID, &domain.Member{ID: id, Name: "Member"}); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+	}
+	if err := s.LeaveConvoy(ctx, convoy.ID, 1); err != nil {
+		t.Fatalf("Failed to leave: %v", err)
+	}
+
+	err := s.RecordHeartbeat(ctx, convoy.ID, 1)
+	if !errors.Is(err, ierr.ErrMemberLeft) || !errors.Is(err, ierr.ErrNotFound) {
+		t.Errorf("Expected a late heartbeat to match ErrMemberLeft and ErrNotFound, got %v", err)
+	}
+	if err := s.UpdateMemberStatus(ctx, convoy.ID, 99, domain.StatusConnected); errors.Is(err, ierr.ErrMemberLeft) {
+		t.Errorf("Expected an unknown member not to match ErrMemberLeft, got %v", err)
+	}
+
+	// Once the window has passed the member is simply unknown, and the next
+	// leave clears the expired tombstone
+	s.tombstones[convoy.ID][1] = time.Now().Add(-MemberTombstoneTTL)
+	if err := s.UpdateMemberLocation(ctx, convoy.ID, 1, domain.LatLng{Lat: 40.0, Lng: -74.0}); !errors.Is(err, ierr.ErrNotFound) || errors.Is(err, ierr.ErrMemberLeft) {
+		t.Errorf("Expected plain ErrNotFound after the tombstone expired, got %v", err)
+	}
+	if err := s.LeaveConvoy(ctx, convoy.ID, 2); err != nil {
+		t.Fatalf("Failed to leave: %v", err)
+	}
+	if _, ok := s.tombstones[convoy.ID][1]; ok {
+		t.Error("Expected the expired tombstone to be cleared")
+	}
+}
+
+func TestActiveConvoySnapshotIsIndependent(t *testing.T) {
+	storage := NewMemoryStorage()
+	ctx := context.Background()
+
+	convoy, err := storage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	empty, err := storage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	accuracy := 12.0
+	track := []domain.TrackPoint{
+		{LatLng: domain.LatLng{Lat: 40.0, Lng: -74.0}},
+		{LatLng: domain.LatLng{Lat: 40.1, Lng: -74.0}},
+		{LatLng: domain.LatLng{Lat: 40.2, Lng: -74.0}},
+	}
+	for _, member := range []*domain.Member{
+		{ID: 1, Name: "First", Accuracy: &accuracy, Track: track},
+		{ID: 2, Name: "Second"},
+	} {
+		if err := storage.AddMember(ctx, convoy.ID, member); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+	}
+
+	snapshots, err := storage.GetAllActiveConvoysSnapshot(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get snapshot: %v", err)
+	}
+	if len(snapshots) != 1 || snapshots[0].ID != convoy.ID {
+		t.Fatalf("Expected only convoy %s in the snapshot, not the empty %s, got %v", convoy.ID, empty.ID, snapshots)
+	}
+	snapshot := snapshots[0]
+
+	// Writes after the snapshot don't reach it
+	if err := storage.LeaveConvoy(ctx, convoy.ID, 1); err != nil {
+		t.Fatalf("Failed to leave convoy: %v", err)
+	}
+	if err := storage.UpdateMemberStatus(ctx, convoy.ID, 2, domain.StatusLagging); err != nil {
+		t.Fatalf("Failed to update status: %v", err)
+	}
+	accuracy = 99
+	convoy.Members[0].Name = "Renamed"
+
+	if len(snapshot.Members) != 2 || snapshot.Members[0].ID != 1 || snapshot.Members[1].Name != "Second" {
+		t.Fatalf("Expected the snapshot to keep both members as they were, got %+v", snapshot.Members)
+	}
+	if snapshot.Members[1].Status == domain.StatusLagging {
+		t.Error("Expected a status change not to reach the snapshot")
+	}
+	if *snapshot.Members[0].Accuracy != 12 {
+		t.Errorf("Expected the snapshot's member fields copied, got %+v", snapshot.Members[0])
+	}
+	if got := snapshot.Members[0].Track; len(got) != SnapshotTrackPoints || got[0] != track[1] || got[1] != track[2] {
+		t.Errorf("Expected the snapshot to keep the last %d track points, got %+v", SnapshotTrackPoints, got)
+	}
+	if len(snapshot.Members[1].Track) != 0 {
+		t.Errorf("Expected an empty track to stay empty, got %+v", snapshot.Members[1].Track)
+	}
+
+	// And writes to the snapshot don't reach storage
+	snapshot.Members[1].Name = "Changed"
+	if live, _ := storage.GetMember(ctx, convoy.ID, 2); live.Name != "Renamed" {
+		t.Errorf("Expected the stored member untouched, got %q", live.Name)
+	}
+}
+
+func TestSequentialIDGenerator(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryStorage()
+	storage.SetIDGenerator(&SequentialIDs{})
+
+	first, err := storage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	second, err := storage.CreateConvoyWithVerification(ctx, "a@example.com", "Leader", "token", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	if first.ID != "00000000000000000000000000000001" || second.ID != "00000000000000000000000000000002" {
+		t.Errorf("Expected sequential IDs, got %s and %s", first.ID, second.ID)
+	}
+
+	// A fresh storage starts over, so every local run sees the same IDs
+	restarted := NewMemoryStorage()
+	restarted.SetIDGenerator(&SequentialIDs{})
+	if again, _ := restarted.CreateConvoy(ctx); again.ID != first.ID {
+		t.Errorf("Expected %s after a restart, got %s", first.ID, again.ID)
+	}
+}
@@ -15,15 +15,66 @@ type WebSocketHub interface {
 type Storage interface {
 	CreateConvoy(ctx context.Context) (*domain.Convoy, error)
 	CreateConvoyWithVerification(ctx context.Context, email, leaderName, token string, expiresAt time.Time) (*domain.Convoy, error)
+	CreateConvoyWithSMSVerification(ctx context.Context, phone, leaderName, code string, expiresAt time.Time) (*domain.Convoy, error)
 	GetConvoy(ctx context.Context, convoyID string) (*domain.Convoy, error)
+	GetConvoysByID(ctx context.Context, convoyIDs []string) (map[string]*domain.Convoy, error)
+	// DeleteConvoy removes a convoy and everything kept for it, including
+	// pending verifications
+	DeleteConvoy(ctx context.Context, convoyID string) error
+	// ReserveIdempotencyKey atomically claims key for a new create request or
+	// returns the convoy an identical earlier request created
+	ReserveIdempotencyKey(ctx context.Context, key, fingerprint string, ttl time.Duration) (*domain.Convoy, error)
+	SaveIdempotencyKey(ctx context.Context, key, convoyID string, ttl time.Duration) error
+	ReleaseIdempotencyKey(ctx context.Context, key string) error
 	VerifyConvoy(ctx context.Context, token string) (*domain.Convoy, error)
+	VerifyConvoyCode(ctx context.Context, convoyID, code string) (*domain.Convoy, error)
 	AddMember(ctx context.Context, convoyID string, member *domain.Member) error
+	// AddMembers adds all of the members or, on any error, none of them
+	AddMembers(ctx context.Context, convoyID string, members []*domain.Member) error
+	ValidateConnectToken(ctx context.Context, convoyID string, memberID int64, token string) error
+	RejoinMember(ctx context.Context, convoyID string, memberID int64, token string) (*domain.Member, error)
+	// GetMember returns a copy of one member of a convoy
+	GetMember(ctx context.Context, convoyID string, memberID int64) (*domain.Member, error)
+	UpdateMember(ctx context.Context, convoyID string, memberID int64, update domain.MemberUpdate) (*domain.Member, error)
+	GetMemberTrack(ctx context.Context, convoyID string, memberID int64) ([]domain.TrackPoint, error)
 	UpdateMemberLocation(ctx context.Context, convoyID string, memberID int64, location domain.LatLng) error
+	// UpdateMemberLocationWithAccuracy also records the reported accuracy in meters, which may be nil
+	UpdateMemberLocationWithAccuracy(ctx context.Context, convoyID string, memberID int64, location domain.LatLng, accuracy *float64) error
+	RecordHeartbeat(ctx context.Context, convoyID string, memberID int64) error
 	UpdateMemberStatus(ctx context.Context, convoyID string, memberID int64, status string) error
 	SetConvoyDestination(ctx context.Context, convoyID string, destination *domain.Destination) error
+	// UpdateConvoyDestination changes part of an existing destination and returns the result
+	UpdateConvoyDestination(ctx context.Context, convoyID string, update domain.DestinationUpdate) (*domain.Destination, error)
+	SetConvoyName(ctx context.Context, convoyID, name string) error
+	// SetConvoyMetadata replaces the convoy's metadata; an empty map clears it
+	SetConvoyMetadata(ctx context.Context, convoyID string, metadata map[string]string) error
+	SetConvoyPaused(ctx context.Context, convoyID string, paused bool) error
+	// SetConvoyStarted records the convoy's departure; a convoy starts only once
+	SetConvoyStarted(ctx context.Context, convoyID string, startedAt time.Time) error
+	SetConvoyLeader(ctx context.Context, convoyID string, memberID int64) error
+	SetConvoyAggregate(ctx context.Context, convoyID string, aggregate *domain.ConvoyAggregate) error
 	LeaveConvoy(ctx context.Context, convoyID string, memberID int64) error
+	CreateInvite(ctx context.Context, invite *domain.ConvoyInvite) error
+	// RedeemInvite adds member on an invite and uses up one of its joins, or
+	// neither if the member can't be added
+	RedeemInvite(ctx context.Context, convoyID, token string, member *domain.Member) (*domain.ConvoyInvite, error)
+	AppendConvoyEvent(ctx context.Context, alert *domain.ConvoyAlert) error
+	GetConvoyEvents(ctx context.Context, convoyID string, since time.Time) ([]domain.ConvoyAlert, error)
 	GetAllActiveConvoys(ctx context.Context) ([]*domain.Convoy, error)
+	// GetAllActiveConvoysSnapshot and GetConvoySnapshot return deep copies
+	// that stay safe to read while other requests write to the convoys.
+	// Member tracks hold only the most recent points.
+	GetAllActiveConvoysSnapshot(ctx context.Context) ([]*domain.Convoy, error)
+	GetConvoySnapshot(ctx context.Context, convoyID string) (*domain.Convoy, error)
+	GetAllConvoys(ctx context.Context) ([]*domain.Convoy, error)
 	GetVerification(ctx context.Context, convoyID string) (*domain.ConvoyVerification, error)
 	UpdateVerificationToken(ctx context.Context, convoyID, token string, expiresAt time.Time) error
 	CleanupExpiredVerifications(ctx context.Context) error
+	GetVerificationAttempts(ctx context.Context, limit int) ([]domain.VerificationAttempt, error)
+}
+
+// Pinger is implemented by backends that depend on an external store and can
+// check that it is reachable. In-memory storage is always reachable.
+type Pinger interface {
+	Ping(ctx context.Context) error
 }
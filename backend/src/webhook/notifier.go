@@ -0,0 +1,151 @@
+package webhook
+
+import (
+	"bytes"
+	"convoy-app/backend/src/domain"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultQueueSize   = 100
+	defaultMaxAttempts = 3
+	defaultRetryDelay  = 2 * time.Second
+	defaultTimeout     = 5 * time.Second
+)
+
+// Config holds webhook notifier configuration
+type Config struct {
+	URL        string
+	EventTypes []string // forwarded event types; empty forwards everything
+	Timeout    time.Duration
+}
+
+// WebhookNotifier POSTs convoy alerts as JSON to an external URL. Delivery
+// happens on a background goroutine so a slow endpoint never blocks the caller.
+type WebhookNotifier struct {
+	url         string
+	eventTypes  map[string]bool
+	client      *http.Client
+	maxAttempts int
+	retryDelay  time.Duration // doubled after each failed attempt
+	queue       chan *domain.ConvoyAlert
+	done        chan struct{}
+
+	mu     sync.Mutex // guards closed and sending on queue, so Notify never sends on a closed queue
+	closed bool
+}
+
+// NewWebhookNotifier creates a notifier and starts its delivery goroutine
+func NewWebhookNotifier(config Config) *WebhookNotifier {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	n := &WebhookNotifier{
+		url:         config.URL,
+		client:      &http.Client{Timeout: timeout},
+		maxAttempts: defaultMaxAttempts,
+		retryDelay:  defaultRetryDelay,
+		queue:       make(chan *domain.ConvoyAlert, defaultQueueSize),
+		done:        make(chan struct{}),
+	}
+	if len(config.EventTypes) > 0 {
+		n.eventTypes = make(map[string]bool, len(config.EventTypes))
+		for _, eventType := range config.EventTypes {
+			n.eventTypes[eventType] = true
+		}
+	}
+
+	go n.run()
+	return n
+}
+
+// Notify queues an alert for delivery. It never blocks: if the queue is full
+// the alert is dropped and logged. Alerts after Close are dropped too.
+func (n *WebhookNotifier) Notify(alert *domain.ConvoyAlert) {
+	if n.eventTypes != nil && !n.eventTypes[alert.EventType] {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		log.Printf("WARNING: Webhook notifier closed, dropping %s alert for convoy %s", alert.EventType, alert.ConvoyID)
+		return
+	}
+	select {
+	case n.queue <- alert:
+	default:
+		log.Printf("WARNING: Webhook queue full, dropping %s alert for convoy %s", alert.EventType, alert.ConvoyID)
+	}
+}
+
+// Close stops accepting alerts and waits for queued ones to be delivered. It
+// is safe to call more than once.
+func (n *WebhookNotifier) Close() {
+	n.mu.Lock()
+	if !n.closed {
+		n.closed = true
+		close(n.queue)
+	}
+	n.mu.Unlock()
+	<-n.done
+}
+
+func (n *WebhookNotifier) run() {
+	defer close(n.done)
+	for alert := range n.queue {
+		n.deliver(alert)
+	}
+}
+
+// deliver POSTs an alert, retrying with exponential backoff
+func (n *WebhookNotifier) deliver(alert *domain.ConvoyAlert) {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		log.Printf("ERROR: Failed to encode webhook alert: %v", err)
+		return
+	}
+
+	delay := n.retryDelay
+	for attempt := 1; attempt <= n.maxAttempts; attempt++ {
+		err = n.post(body)
+		if err == nil {
+			return
+		}
+		log.Printf("WARNING: Webhook delivery of %s for convoy %s failed (attempt %d/%d): %v",
+			alert.EventType, alert.ConvoyID, attempt, n.maxAttempts, err)
+
+		if attempt < n.maxAttempts {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+	log.Printf("ERROR: Giving up on webhook delivery of %s for convoy %s", alert.EventType, alert.ConvoyID)
+}
+
+func (n *WebhookNotifier) post(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Convoy-App-Webhook/1.0")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package webhook
+
+import (
+	"convoy-app/backend/src/domain"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder is a webhook endpoint that fails the first failures requests
+type recorder struct {
+	mu       sync.Mutex
+	failures int
+	attempts int
+	received []domain.ConvoyAlert
+}
+
+func (rec *recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	rec.attempts++
+	if rec.attempts <= rec.failures {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	var alert domain.ConvoyAlert
+	json.NewDecoder(r.Body).Decode(&alert)
+	rec.received = append(rec.received, alert)
+}
+
+func newTestNotifier(url string, eventTypes ...string) *WebhookNotifier {
+	n := NewWebhookNotifier(Config{URL: url, EventTypes: eventTypes, Timeout: time.Second})
+	n.retryDelay = time.Millisecond
+	return n
+}
+
+func TestWebhookNotifierRetriesUntilDelivered(t *testing.T) {
+	rec := &recorder{failures: 2}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+
+	n := newTestNotifier(server.URL)
+	n.Notify(&domain.ConvoyAlert{EventType: domain.EventConvoyScattered, ConvoyID: "convoy-1", ScatteredCount: 3})
+	n.Close()
+
+	if rec.attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", rec.attempts)
+	}
+	if len(rec.received) != 1 || rec.received[0].ConvoyID != "convoy-1" || rec.received[0].ScatteredCount != 3 {
+		t.Errorf("Expected the alert to be delivered once, got %+v", rec.received)
+	}
+}
+
+func TestWebhookNotifierGivesUpAfterMaxAttempts(t *testing.T) {
+	rec := &recorder{failures: 10}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+
+	n := newTestNotifier(server.URL)
+	n.Notify(&domain.ConvoyAlert{EventType: domain.EventMemberDisconnected, ConvoyID: "convoy-1"})
+	n.Close()
+
+	if rec.attempts != defaultMaxAttempts {
+		t.Errorf("Expected %d attempts, got %d", defaultMaxAttempts, rec.attempts)
+	}
+}
+
+func TestWebhookNotifierFiltersEventTypes(t *testing.T) {
+	rec := &recorder{}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+
+	n := newTestNotifier(server.URL, domain.EventConvoyScattered)
+	n.Notify(&domain.ConvoyAlert{EventType: domain.EventMemberLagging, ConvoyID: "convoy-1"})
+	n.Notify(&domain.ConvoyAlert{EventType: domain.EventConvoyScattered, ConvoyID: "convoy-1"})
+	n.Close()
+
+	if len(rec.received) != 1 || rec.received[0].EventType != domain.EventConvoyScattered {
+		t.Errorf("Expected only %s to be forwarded, got %+v", domain.EventConvoyScattered, rec.received)
+	}
+}
+
+func TestWebhookNotifierDoesNotBlockOnSlowEndpoint(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	n := newTestNotifier(server.URL)
+	start := time.Now()
+	for i := 0; i < defaultQueueSize+10; i++ {
+		n.Notify(&domain.ConvoyAlert{EventType: domain.EventConvoyScattered, ConvoyID: "convoy-1"})
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Expected Notify to return immediately, took %v", elapsed)
+	}
+}
+
+func TestWebhookNotifierDropsAlertsAfterClose(t *testing.T) {
+	rec := &recorder{}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+
+	n := newTestNotifier(server.URL)
+	n.Notify(&domain.ConvoyAlert{EventType: domain.EventConvoyScattered, ConvoyID: "convoy-1"})
+	n.Close()
+
+	// A handler finishing after shutdown must not panic on the closed queue
+	n.Notify(&domain.ConvoyAlert{EventType: domain.EventConvoyScattered, ConvoyID: "convoy-2"})
+	n.Close()
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.received) != 1 || rec.received[0].ConvoyID != "convoy-1" {
+		t.Errorf("Expected only the alert queued before Close to be delivered, got %+v", rec.received)
+	}
+}
@@ -0,0 +1,106 @@
+package ws
+
+import (
+	"log"
+	"time"
+)
+
+// MessageTypeAck is sent by clients to confirm they received a critical alert
+const MessageTypeAck = "ACK"
+
+const (
+	DefaultAckMaxRetries = 3
+	DefaultAckRetryDelay = 2 * time.Second
+)
+
+// pendingAck is a critical alert sent to one connection and not yet acknowledged
+type pendingAck struct {
+	data     []byte
+	attempts int // resends so far
+	timer    *time.Timer
+}
+
+// SetAckRetry sets how many times an unacknowledged critical alert is resent
+// to a connection, and the delay before the first resend. The delay doubles
+// after each attempt.
+func (h *Hub) SetAckRetry(maxRetries int, delay time.Duration) {
+	h.ackMu.Lock()
+	defer h.ackMu.Unlock()
+	h.ackMaxRetries = maxRetries
+	h.ackRetryDelay = delay
+}
+
+// trackAck starts waiting for conn to acknowledge alertID, resending data if it doesn't
+func (h *Hub) trackAck(conn *Connection, alertID string, data []byte) {
+	h.ackMu.Lock()
+	defer h.ackMu.Unlock()
+
+	if h.ackMaxRetries <= 0 {
+		return
+	}
+	if h.pendingAcks[conn] == nil {
+		h.pendingAcks[conn] = make(map[string]*pendingAck)
+	}
+	pending := &pendingAck{data: data}
+	h.pendingAcks[conn][alertID] = pending
+	pending.timer = time.AfterFunc(h.ackRetryDelay, func() {
+		h.resendUnacked(conn, alertID, h.ackRetryDelay)
+	})
+}
+
+// resendUnacked resends an alert that is still unacknowledged and schedules
+// the next attempt, giving up after ackMaxRetries resends
+func (h *Hub) resendUnacked(conn *Connection, alertID string, delay time.Duration) {
+	h.ackMu.Lock()
+	pending, ok := h.pendingAcks[conn][alertID]
+	if !ok {
+		h.ackMu.Unlock()
+		return
+	}
+	pending.attempts++
+	attempt := pending.attempts
+	if attempt >= h.ackMaxRetries {
+		delete(h.pendingAcks[conn], alertID)
+	} else {
+		delay *= 2
+		pending.timer = time.AfterFunc(delay, func() {
+			h.resendUnacked(conn, alertID, delay)
+		})
+	}
+	h.ackMu.Unlock()
+
+	log.Printf("Resending unacknowledged alert %s (attempt %d/%d)", alertID, attempt, h.ackMaxRetries)
+	if err := conn.enqueue(pending.data); err != nil {
+		log.Printf("Failed to resend alert %s: %v", alertID, err)
+		h.forgetAcks(conn)
+	}
+}
+
+// acknowledge stops resending alertID to conn
+func (h *Hub) acknowledge(conn *Connection, alertID string) {
+	h.ackMu.Lock()
+	defer h.ackMu.Unlock()
+
+	if pending, ok := h.pendingAcks[conn][alertID]; ok {
+		pending.timer.Stop()
+		delete(h.pendingAcks[conn], alertID)
+	}
+}
+
+// forgetAcks drops all pending alerts for a connection that has gone away
+func (h *Hub) forgetAcks(conn *Connection) {
+	h.ackMu.Lock()
+	defer h.ackMu.Unlock()
+
+	for _, pending := range h.pendingAcks[conn] {
+		pending.timer.Stop()
+	}
+	delete(h.pendingAcks, conn)
+}
+
+// pendingAckCount returns how many alerts conn has yet to acknowledge
+func (h *Hub) pendingAckCount(conn *Connection) int {
+	h.ackMu.Lock()
+	defer h.ackMu.Unlock()
+	return len(h.pendingAcks[conn])
+}
@@ -0,0 +1,100 @@
+package ws
+
+import (
+	"convoy-app/backend/src/domain"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// readAlert reads the next frame and decodes it as an alert
+func readAlert(t *testing.T, conn *websocket.Conn, timeout time.Duration) (domain.ConvoyAlert, error) {
+	t.Helper()
+	var alert domain.ConvoyAlert
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		return alert, err
+	}
+	if err := json.Unmarshal(data, &alert); err != nil {
+		t.Fatalf("Failed to decode alert: %v", err)
+	}
+	return alert, nil
+}
+
+func TestUnacknowledgedAlertIsResent(t *testing.T) {
+	hub := NewHub()
+	hub.SetAckRetry(2, 20*time.Millisecond)
+	server := newTestServer(t, hub)
+
+	client := dial(t, server, "/ws/convoys/"+testConvoy1+"?memberId=1")
+	serverConn := waitForMemberConnection(t, hub, testConvoy1, 1, nil)
+
+	hub.Broadcast(testConvoy1, &domain.ConvoyAlert{AlertID: "alert-1", EventType: domain.EventMemberDisconnected, ConvoyID: testConvoy1})
+
+	// The original send plus two resends
+	for i := 0; i < 3; i++ {
+		alert, err := readAlert(t, client, 2*time.Second)
+		if err != nil {
+			t.Fatalf("Expected delivery %d, got error: %v", i+1, err)
+		}
+		if alert.AlertID != "alert-1" {
+			t.Errorf("Expected alert-1, got %q", alert.AlertID)
+		}
+	}
+
+	if _, err := readAlert(t, client, 200*time.Millisecond); err == nil {
+		t.Error("Expected no resends after the retry limit")
+	}
+	if count := hub.pendingAckCount(serverConn); count != 0 {
+		t.Errorf("Expected no pending acks after giving up, got %d", count)
+	}
+}
+
+func TestAckStopsResend(t *testing.T) {
+	hub := NewHub()
+	hub.SetAckRetry(3, 100*time.Millisecond)
+	server := newTestServer(t, hub)
+
+	client := dial(t, server, "/ws/convoys/"+testConvoy1+"?memberId=1")
+	serverConn := waitForMemberConnection(t, hub, testConvoy1, 1, nil)
+
+	hub.Broadcast(testConvoy1, &domain.ConvoyAlert{AlertID: "alert-1", EventType: domain.EventMemberDisconnected, ConvoyID: testConvoy1})
+
+	if _, err := readAlert(t, client, 2*time.Second); err != nil {
+		t.Fatalf("Expected alert, got error: %v", err)
+	}
+	if err := client.WriteMessage(websocket.TextMessage, []byte(`{"type":"ACK","alertId":"alert-1"}`)); err != nil {
+		t.Fatalf("Failed to send ack: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for hub.pendingAckCount(serverConn) != 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if count := hub.pendingAckCount(serverConn); count != 0 {
+		t.Fatalf("Expected ack to clear the pending alert, got %d pending", count)
+	}
+
+	if alert, err := readAlert(t, client, 300*time.Millisecond); err == nil {
+		t.Errorf("Expected no resend after ack, got %+v", alert)
+	}
+}
+
+func TestAlertsWithoutIDAreNotTracked(t *testing.T) {
+	hub := NewHub()
+	server := newTestServer(t, hub)
+
+	client := dial(t, server, "/ws/convoys/"+testConvoy1+"?memberId=1")
+	serverConn := waitForMemberConnection(t, hub, testConvoy1, 1, nil)
+
+	hub.Broadcast(testConvoy1, &domain.ConvoyAlert{EventType: domain.EventMemberLagging, ConvoyID: testConvoy1})
+	if _, err := readAlert(t, client, 2*time.Second); err != nil {
+		t.Fatalf("Expected alert, got error: %v", err)
+	}
+	if count := hub.pendingAckCount(serverConn); count != 0 {
+		t.Errorf("Expected non-critical alert not to await an ack, got %d pending", count)
+	}
+}
@@ -1,88 +1,141 @@
 package ws
 
 import (
-    "log"
-    "time"
-    "github.com/gorilla/websocket"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// SendBufferSize is how many outbound messages may queue for one connection.
+// A connection that falls this far behind is dropped.
+const SendBufferSize = 256
+
+const (
+	writeWait  = 10 * time.Second
+	pongWait   = 60 * time.Second
+	pingPeriod = (pongWait * 9) / 10
 )
 
+// Connection is a registered WebSocket connection. Its write pump is the only
+// writer of data frames, so a slow client only delays its own messages.
+// Control frames (close) may still be written directly.
 type Connection struct {
-    conn     *websocket.Conn
-    convoyID string
-    hub      *Hub
-    send     chan []byte
-    done     chan struct{}
+	conn      *websocket.Conn
+	convoyID  string
+	hub       *Hub
+	send      chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+
+	lastActivity atomic.Int64 // unix nanoseconds of the last pong or client message
+
+	memberID      int64        // set before registration; 0 for spectators and anonymous connections
+	authenticated bool         // memberID was proven with the member's connect token
+	filter        atomic.Value // event filter chosen with SUBSCRIBE; unset means EventFilterAll
+
+	retryMu sync.Mutex
+	stalled []stalledSend // broadcasts held back while the send buffer is full; non-nil while a retry is scheduled
 }
 
 func NewConnection(conn *websocket.Conn, convoyID string, hub *Hub) *Connection {
-    return &Connection{
-        conn:     conn,
-        convoyID: convoyID,
-        hub:      hub,
-        send:     make(chan []byte, 256),
-        done:     make(chan struct{}),
-    }
+	c := &Connection{
+		conn:     conn,
+		convoyID: convoyID,
+		hub:      hub,
+		send:     make(chan []byte, SendBufferSize),
+		done:     make(chan struct{}),
+	}
+	c.touch()
+	return c
+}
+
+// touch records that the client is still there
+func (c *Connection) touch() {
+	c.lastActivity.Store(time.Now().UnixNano())
+}
+
+// LastActivity returns when the client last answered a ping or sent a message
+func (c *Connection) LastActivity() time.Time {
+	return time.Unix(0, c.lastActivity.Load())
 }
 
+// Start runs the write pump, which also keeps the connection alive with pings
 func (c *Connection) Start() {
-    go c.writePump()
-    go c.readPump()
+	go c.writePump()
 }
 
-func (c *Connection) readPump() {
-    defer func() {
-        c.hub.Unregister(c.convoyID, c.conn)
-        c.conn.Close()
-        close(c.done)
-    }()
-
-    c.conn.SetReadLimit(512)
-    c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
-    c.conn.SetPongHandler(func(string) error {
-        c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
-        return nil
-    })
-
-    for {
-        _, _, err := c.conn.ReadMessage()
-        if err != nil {
-            if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-                log.Printf("WebSocket unexpected close for convoy %s: %v", c.convoyID, err)
-            }
-            break
-        }
-    }
+// Close stops the write pump and closes the underlying connection. It is safe
+// to call more than once.
+func (c *Connection) Close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+// Reasons enqueue fails. A full buffer may clear once the client catches up;
+// a closed connection never accepts another message.
+var (
+	errConnectionClosed = errors.New("connection closed")
+	errSendBufferFull   = errors.New("send buffer full")
+)
+
+// enqueue queues data for the write pump without blocking. It fails with
+// errConnectionClosed or errSendBufferFull.
+func (c *Connection) enqueue(data []byte) error {
+	select {
+	case <-c.done:
+		return errConnectionClosed
+	default:
+	}
+
+	select {
+	case c.send <- data:
+		return nil
+	default:
+		return errSendBufferFull
+	}
+}
+
+// closeWithMessage writes a close frame and closes the connection
+func (c *Connection) closeWithMessage(code int, reason string) {
+	closeMsg := websocket.FormatCloseMessage(code, reason)
+	c.conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(time.Second))
+	c.Close()
 }
 
 func (c *Connection) writePump() {
-    ticker := time.NewTicker(54 * time.Second)
-    defer func() {
-        ticker.Stop()
-        c.conn.Close()
-    }()
-
-    for {
-        select {
-        case message, ok := <-c.send:
-            c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-            if !ok {
-                c.conn.WriteMessage(websocket.CloseMessage, []byte{})
-                return
-            }
-
-            if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
-                log.Printf("WebSocket write error for convoy %s: %v", c.convoyID, err)
-                return
-            }
-
-        case <-ticker.C:
-            c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-            if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
-                return
-            }
-
-        case <-c.done:
-            return
-        }
-    }
-}
\ No newline at end of file
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.Close()
+	}()
+
+	for {
+		select {
+		case message := <-c.send:
+			// Not retried: after any failed write, timeouts included, the
+			// socket may hold part of a frame and gorilla refuses further
+			// writes. Momentary stalls are absorbed by the send buffer and
+			// Broadcast's retry instead.
+			if err := c.hub.writeText(c.conn, message); err != nil {
+				log.Printf("WebSocket write error for convoy %s: %v", c.convoyID, err)
+				return
+			}
+
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				log.Printf("Failed to send ping to convoy %s: %v", c.convoyID, err)
+				return
+			}
+
+		case <-c.done:
+			return
+		}
+	}
+}
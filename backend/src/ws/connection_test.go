@@ -0,0 +1,67 @@
+package ws
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestSlowClientDoesNotStallBroadcast(t *testing.T) {
+	hub := NewHub()
+	server := newTestServer(t, hub)
+
+	dial(t, server, "/ws/convoys/"+testConvoy1) // never reads
+	fast := dial(t, server, "/ws/convoys/"+testConvoy1)
+	waitForConnectionCount(t, hub, testConvoy1, 2)
+
+	// Enough data to fill the socket buffers and then the send buffer of the
+	// client that doesn't read
+	const messages = SendBufferSize * 2
+	payload := map[string]string{"type": "test", "padding": strings.Repeat("x", 32*1024)}
+
+	// Each message must reach the reading client promptly even once the
+	// other client's writes are stuck
+	start := time.Now()
+	for i := 0; i < messages; i++ {
+		hub.Broadcast(testConvoy1, payload)
+		fast.SetReadDeadline(time.Now().Add(time.Second))
+		if _, _, err := fast.ReadMessage(); err != nil {
+			t.Fatalf("Expected message %d on the reading client, got error: %v", i+1, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Expected broadcasts not to wait for the slow client, took %v", elapsed)
+	}
+
+	// The slow client fell a full buffer behind and is dropped once its retry fails
+	waitForConnectionCount(t, hub, testConvoy1, 1)
+}
+
+func TestLastActivityTracksClientMessages(t *testing.T) {
+	hub := NewHub()
+	server := newTestServer(t, hub)
+
+	if !hub.LastActivity(testConvoy1, 1).IsZero() {
+		t.Error("Expected no activity for a member without a connection")
+	}
+
+	client := dial(t, server, "/ws/convoys/"+testConvoy1+"?memberId=1")
+	waitForMemberConnection(t, hub, testConvoy1, 1, nil)
+	connected := hub.LastActivity(testConvoy1, 1)
+
+	time.Sleep(20 * time.Millisecond)
+	if err := client.WriteMessage(websocket.TextMessage, []byte(`{"type":"HEARTBEAT"}`)); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if hub.LastActivity(testConvoy1, 1).After(connected) {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Error("Expected a client message to update the connection's last activity")
+}
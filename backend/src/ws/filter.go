@@ -0,0 +1,62 @@
+package ws
+
+import (
+	"convoy-app/backend/src/domain"
+	"log"
+)
+
+// MessageTypeSubscribe is sent by clients to choose which events they receive
+const MessageTypeSubscribe = "SUBSCRIBE"
+
+// Event filters a connection can subscribe to
+const (
+	EventFilterAll  = "all"  // every broadcast; the default
+	EventFilterSelf = "self" // skip other members' status alerts
+)
+
+// memberStatusEvents are the alerts about a single member's status. A
+// connection subscribed to EventFilterSelf only gets them for its own member.
+// Convoy-level events, membership changes and convoy updates always go out.
+var memberStatusEvents = map[string]bool{
+	domain.EventMemberLagging:      true,
+	domain.EventMemberDisconnected: true,
+	domain.EventMemberInactive:     true,
+	domain.EventMemberReactivated:  true,
+	domain.EventMemberStalled:      true,
+	domain.EventMemberMoving:       true,
+	domain.EventMemberReconnected:  true,
+	domain.EventMemberReconnecting: true,
+}
+
+// filteredMemberID returns the member a broadcast is only relevant to, or 0
+// if every connection should get it. Critical alerts must be acknowledged by
+// everyone, so they are never filtered.
+func filteredMemberID(message interface{}) int64 {
+	alert, ok := message.(*domain.ConvoyAlert)
+	if !ok || alert.AlertID != "" || !memberStatusEvents[alert.EventType] {
+		return 0
+	}
+	return alert.MemberID
+}
+
+// subscribe sets the connection's event filter. Unknown filters are ignored
+// so the connection keeps what it had.
+func (c *Connection) subscribe(filter string) {
+	switch filter {
+	case EventFilterAll, EventFilterSelf:
+		c.filter.Store(filter)
+		log.Printf("Connection for convoy %s (member %d) subscribed to %q events", c.convoyID, c.memberID, filter)
+	default:
+		log.Printf("Ignoring unknown event filter %q for convoy %s (member %d)", filter, c.convoyID, c.memberID)
+	}
+}
+
+// wants reports whether the connection should get a broadcast that is only
+// relevant to memberID (0 for broadcasts relevant to everyone)
+func (c *Connection) wants(memberID int64) bool {
+	if memberID == 0 || memberID == c.memberID {
+		return true
+	}
+	filter, _ := c.filter.Load().(string)
+	return filter != EventFilterSelf
+}
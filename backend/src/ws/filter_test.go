@@ -0,0 +1,71 @@
+package ws
+
+import (
+	"convoy-app/backend/src/domain"
+	"testing"
+	"time"
+)
+
+func TestSelfFilterSkipsOtherMembersAlerts(t *testing.T) {
+	hub := NewHub()
+	server := newTestServer(t, hub)
+
+	everything := dial(t, server, "/ws/convoys/"+testConvoy1+"?memberId=1")
+	filtered := dial(t, server, "/ws/convoys/"+testConvoy1+"?memberId=2")
+	waitForMemberConnection(t, hub, testConvoy1, 1, nil)
+	filteredConn := waitForMemberConnection(t, hub, testConvoy1, 2, nil)
+
+	if err := filtered.WriteJSON(map[string]string{"type": MessageTypeSubscribe, "events": EventFilterSelf}); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for filteredConn.wants(1) && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	alerts := []*domain.ConvoyAlert{
+		{EventType: domain.EventMemberLagging, ConvoyID: testConvoy1, MemberID: 1},
+		{EventType: domain.EventMemberDisconnected, ConvoyID: testConvoy1, MemberID: 1, AlertID: "critical-1"},
+		{EventType: domain.EventMemberLagging, ConvoyID: testConvoy1, MemberID: 2},
+		{EventType: domain.EventConvoyScattered, ConvoyID: testConvoy1},
+	}
+	for _, alert := range alerts {
+		hub.Broadcast(testConvoy1, alert)
+	}
+
+	// The unfiltered connection gets every alert in order
+	for _, want := range alerts {
+		got, err := readAlert(t, everything, 2*time.Second)
+		if err != nil {
+			t.Fatalf("Failed to read alert: %v", err)
+		}
+		if got.EventType != want.EventType || got.MemberID != want.MemberID {
+			t.Errorf("Expected %s for member %d, got %s for member %d", want.EventType, want.MemberID, got.EventType, got.MemberID)
+		}
+	}
+
+	// The filtered one skips member 1's routine alert but not the critical one
+	for _, want := range alerts[1:] {
+		got, err := readAlert(t, filtered, 2*time.Second)
+		if err != nil {
+			t.Fatalf("Failed to read alert: %v", err)
+		}
+		if got.EventType != want.EventType || got.MemberID != want.MemberID {
+			t.Errorf("Expected %s for member %d, got %s for member %d", want.EventType, want.MemberID, got.EventType, got.MemberID)
+		}
+	}
+}
+
+func TestUnknownEventFilterKeepsAllEvents(t *testing.T) {
+	conn := &Connection{convoyID: testConvoy1, memberID: 2}
+	conn.subscribe("nearby")
+	if !conn.wants(1) {
+		t.Error("Expected an unknown filter to leave the connection receiving all events")
+	}
+
+	conn.subscribe(EventFilterSelf)
+	conn.subscribe(EventFilterAll)
+	if !conn.wants(1) {
+		t.Error("Expected subscribing to all events to clear the filter")
+	}
+}
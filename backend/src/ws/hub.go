@@ -1,6 +1,9 @@
 package ws
 
 import (
+	"context"
+	"convoy-app/backend/src/cors"
+	"convoy-app/backend/src/domain"
 	"encoding/json"
 	"log"
 	"sync"
@@ -12,72 +15,323 @@ import (
 const (
 	MaxConnectionsPerConvoy = 50   // Reasonable limit for convoy size
 	MaxTotalConnections     = 1000 // Global connection limit
+	MaxSpectatorsPerConvoy  = 200  // Watchers are cheap to serve and may outnumber members
 )
 
+// ConvoyProvider defines the storage the WebSocket handler needs: convoy state
+// to send on connect, a place to record client heartbeats and connect token checks
+type ConvoyProvider interface {
+	GetConvoy(ctx context.Context, convoyID string) (*domain.Convoy, error)
+	RecordHeartbeat(ctx context.Context, convoyID string, memberID int64) error
+	ValidateConnectToken(ctx context.Context, convoyID string, memberID int64, token string) error
+}
+
+// MemberListener is told when a member loses its connection, unless a newer
+// connection of the same member replaced it. It is called without hub locks
+// held, from the goroutine that noticed the connection was gone.
+type MemberListener interface {
+	MemberDisconnected(convoyID string, memberID int64)
+}
+
 // Hub manages WebSocket connections.
 type Hub struct {
 	mu                sync.RWMutex
-	connections       map[string]map[*websocket.Conn]bool  // Multiple connections per convoy
-	memberConnections map[string]map[int64]*websocket.Conn // Track member-specific connections: convoyID -> memberID -> connection
+	connections       map[string]map[*Connection]bool  // Multiple connections per convoy
+	memberConnections map[string]map[int64]*Connection // Track member-specific connections: convoyID -> memberID -> connection
+	convoyProvider    ConvoyProvider                   // Source of the initial snapshot sent to new connections
+	originPolicy      *cors.Policy                     // Browser origins allowed to open connections
+	memberListener    MemberListener                   // Optional; told when a member's connection goes away
+
+	compressionEnabled   bool // negotiate permessage-deflate with clients that support it
+	compressionThreshold int  // frames smaller than this many bytes are sent uncompressed
+
+	maxPerConvoy int // connections allowed per convoy
+	maxTotal     int // connections allowed across all convoys
+
+	// Read-only watchers receive broadcasts but are not members: they don't
+	// count towards member limits or show up in HasActiveConnection
+	spectators    map[string]map[*Connection]bool
+	maxSpectators int // spectators and SSE streams allowed per convoy
+
+	// Server-Sent Events streams, a fallback for clients that can't use
+	// WebSockets. They are read-only and count towards the spectator limit.
+	sseSubscribers map[string]map[*sseSubscriber]bool
+
+	requireConnectToken bool // reject connections without a valid member connect token
+	singleSession       bool // refuse a member's second connection instead of replacing the first
+
+	ackMu         sync.Mutex
+	pendingAcks   map[*Connection]map[string]*pendingAck // connection -> alertID -> unacknowledged critical alert
+	ackMaxRetries int
+	ackRetryDelay time.Duration
+
+	replayMu        sync.Mutex
+	lastBroadcasts  map[string]cachedBroadcast // convoyID -> latest broadcast, replayed to new connections
+	replayRetention time.Duration
+
+	statsMu sync.Mutex
+	stats   map[string]*ConvoyStats // convoyID -> broadcast counters
+
+	shedMu        sync.Mutex
+	shedHighWater float64 // ratio of maxTotal at which load shedding engages (0 disables it)
+	shedLowWater  float64 // ratio of maxTotal below which it disengages
+	shedding      bool
+
+	broadcastRetryBackoff time.Duration // pause before retrying connections whose send buffer was full; 0 drops them at once
+
+	seqMu           sync.Mutex
+	sequenceNumbers bool                   // stamp outbound messages with a per-convoy "seq"
+	sequences       map[string]uint64      // convoyID -> last sequence number sent
+	broadcastLocks  map[string]*sync.Mutex // convoyID -> held while a broadcast is numbered and queued
 }
 
 // NewHub creates a new Hub.
 func NewHub() *Hub {
 	return &Hub{
-		connections:       make(map[string]map[*websocket.Conn]bool),
-		memberConnections: make(map[string]map[int64]*websocket.Conn),
+		connections:       make(map[string]map[*Connection]bool),
+		memberConnections: make(map[string]map[int64]*Connection),
+		originPolicy:      cors.DefaultPolicy(),
+		maxPerConvoy:      MaxConnectionsPerConvoy,
+		maxTotal:          MaxTotalConnections,
+		spectators:        make(map[string]map[*Connection]bool),
+		sseSubscribers:    make(map[string]map[*sseSubscriber]bool),
+		maxSpectators:     MaxSpectatorsPerConvoy,
+		pendingAcks:       make(map[*Connection]map[string]*pendingAck),
+		ackMaxRetries:     DefaultAckMaxRetries,
+		ackRetryDelay:     DefaultAckRetryDelay,
+		lastBroadcasts:    make(map[string]cachedBroadcast),
+		replayRetention:   DefaultReplayRetention,
+		stats:             make(map[string]*ConvoyStats),
+		sequences:         make(map[string]uint64),
+		broadcastLocks:    make(map[string]*sync.Mutex),
+
+		broadcastRetryBackoff: DefaultBroadcastRetryBackoff,
+	}
+}
+
+// SetConvoyProvider sets the source used to send a convoy snapshot to new connections
+func (h *Hub) SetConvoyProvider(provider ConvoyProvider) {
+	h.convoyProvider = provider
+}
+
+// SetMemberListener registers the listener told when a member's connection goes away
+func (h *Hub) SetMemberListener(listener MemberListener) {
+	h.memberListener = listener
+}
+
+// notifyMemberDisconnected tells the member listener, if any, that memberID
+// lost its connection. memberID 0 means the connection had no member.
+func (h *Hub) notifyMemberDisconnected(convoyID string, memberID int64) {
+	if memberID == 0 || h.memberListener == nil {
+		return
 	}
+	h.memberListener.MemberDisconnected(convoyID, memberID)
+}
+
+// SetOriginPolicy sets the origins allowed to open connections; it should be
+// the policy the CORS middleware uses
+func (h *Hub) SetOriginPolicy(policy *cors.Policy) {
+	h.originPolicy = policy
+}
+
+// SetConnectionLimits overrides the per-convoy and global connection limits
+func (h *Hub) SetConnectionLimits(perConvoy, total int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.maxPerConvoy = perConvoy
+	h.maxTotal = total
 }
 
-// Register adds a new connection with limits
-func (h *Hub) Register(convoyID string, conn *websocket.Conn) {
+// SetSpectatorLimit overrides how many spectators may watch each convoy
+func (h *Hub) SetSpectatorLimit(perConvoy int) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
+	h.maxSpectators = perConvoy
+}
+
+// SetRequireConnectToken makes the handler require ?memberId=&token= on every
+// connection. It is off by default while clients migrate.
+func (h *Hub) SetRequireConnectToken(required bool) {
+	h.requireConnectToken = required
+}
+
+// SetSingleSession refuses a member's new connection while an older one is
+// live. By default the newer connection replaces the older one.
+func (h *Hub) SetSingleSession(enabled bool) {
+	h.singleSession = enabled
+}
+
+// SetCompression enables permessage-deflate for frames of at least threshold bytes
+func (h *Hub) SetCompression(enabled bool, threshold int) {
+	h.compressionEnabled = enabled
+	h.compressionThreshold = threshold
+}
+
+// writeText writes a text frame, compressing it only when it is large enough to benefit.
+// Compression is a no-op for clients that didn't negotiate permessage-deflate.
+func (h *Hub) writeText(conn *websocket.Conn, data []byte) error {
+	conn.EnableWriteCompression(h.compressionEnabled && len(data) >= h.compressionThreshold)
+	conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return conn.WriteMessage(websocket.TextMessage, data)
+}
 
-	// Check global connection limit
+// capacityExceeded returns why a new connection to the convoy would exceed a
+// connection limit, or "" if there is room. Callers must hold h.mu.
+func (h *Hub) capacityExceeded(convoyID string) string {
 	totalConns := 0
 	for _, convoyConns := range h.connections {
 		totalConns += len(convoyConns)
 	}
-	if totalConns >= MaxTotalConnections {
-		log.Printf("Global connection limit reached, rejecting connection for convoy %s", convoyID)
-		conn.Close()
-		return
+	if totalConns >= h.maxTotal {
+		return "server is at its connection limit"
+	}
+	if len(h.connections[convoyID]) >= h.maxPerConvoy {
+		return "convoy is at its connection limit"
 	}
+	return ""
+}
 
-	if h.connections[convoyID] == nil {
-		h.connections[convoyID] = make(map[*websocket.Conn]bool)
+// HasCapacity reports whether the convoy can take another connection. The
+// answer may be stale by the time Register runs, which checks again.
+func (h *Hub) HasCapacity(convoyID string) (bool, string) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	reason := h.capacityExceeded(convoyID)
+	return reason == "", reason
+}
+
+// HasSpectatorCapacity reports whether the convoy can take another spectator
+func (h *Hub) HasSpectatorCapacity(convoyID string) (bool, string) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.watcherCount(convoyID) >= h.maxSpectators {
+		return false, "convoy is at its spectator limit"
 	}
+	return true, ""
+}
 
-	// Check per-convoy connection limit
-	if len(h.connections[convoyID]) >= MaxConnectionsPerConvoy {
-		log.Printf("Convoy connection limit reached for %s, rejecting connection", convoyID)
+// rejectOverLimit closes a connection with CloseTryAgainLater so clients can
+// tell a full server from a crash and retry later, after the reconnect hint
+func (h *Hub) rejectOverLimit(conn *websocket.Conn, reason string) {
+	closeMsg := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, h.withReconnectHint(reason))
+	conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(time.Second))
+	conn.Close()
+}
+
+// Register adds a new connection with limits. A connection over a limit is
+// closed with CloseTryAgainLater and false is returned.
+func (h *Hub) Register(convoyID string, conn *Connection) bool {
+	h.mu.Lock()
+	if reason := h.capacityExceeded(convoyID); reason != "" {
+		h.mu.Unlock()
+		log.Printf("Rejecting connection for convoy %s: %s", convoyID, reason)
+		h.rejectOverLimit(conn.conn, reason)
 		conn.Close()
-		return
+		return false
 	}
 
+	if h.connections[convoyID] == nil {
+		h.connections[convoyID] = make(map[*Connection]bool)
+	}
 	h.connections[convoyID][conn] = true
+	count := len(h.connections[convoyID])
+	h.mu.Unlock()
+
 	log.Printf("WebSocket connection registered for convoy %s (total connections for convoy: %d)",
-		convoyID, len(h.connections[convoyID]))
+		convoyID, count)
+	return true
 }
 
-// RegisterMember associates a member ID with a WebSocket connection
-func (h *Hub) RegisterMember(convoyID string, memberID int64, conn *websocket.Conn) {
+// RegisterSpectator adds a read-only connection that receives the convoy's
+// broadcasts. A spectator over the limit is closed with CloseTryAgainLater and
+// false is returned.
+func (h *Hub) RegisterSpectator(convoyID string, conn *Connection) bool {
+	h.mu.Lock()
+	if h.watcherCount(convoyID) >= h.maxSpectators {
+		h.mu.Unlock()
+		log.Printf("Rejecting spectator for convoy %s: spectator limit reached", convoyID)
+		h.rejectOverLimit(conn.conn, "convoy is at its spectator limit")
+		conn.Close()
+		return false
+	}
+
+	if h.spectators[convoyID] == nil {
+		h.spectators[convoyID] = make(map[*Connection]bool)
+	}
+	h.spectators[convoyID][conn] = true
+	count := len(h.spectators[convoyID])
+	h.mu.Unlock()
+
+	log.Printf("Spectator registered for convoy %s (total spectators for convoy: %d)", convoyID, count)
+	return true
+}
+
+// RegisterMember associates a member ID with a WebSocket connection. A member
+// has at most one live connection: an older one is closed with
+// CloseSessionReplaced, or, when single sessions are enforced, the new one is
+// refused and false is returned so the caller can close it.
+//
+// Only a connection that proved the member ID with its connect token may
+// replace or lock out another. Any other connection is associated only while
+// the member has none, so claiming someone's ID can't close their socket or
+// take their slot.
+func (h *Hub) RegisterMember(convoyID string, memberID int64, conn *Connection) bool {
 	h.mu.Lock()
-	defer h.mu.Unlock()
 
 	if h.memberConnections[convoyID] == nil {
-		h.memberConnections[convoyID] = make(map[int64]*websocket.Conn)
+		h.memberConnections[convoyID] = make(map[int64]*Connection)
+	}
+
+	previous := h.memberConnections[convoyID][memberID]
+	if previous == conn {
+		previous = nil
+	}
+	if previous != nil && !conn.authenticated {
+		h.mu.Unlock()
+		log.Printf("Member %d already connected to convoy %s; keeping that connection over one without a connect token", memberID, convoyID)
+		return true
+	}
+	if previous != nil && previous.authenticated && h.singleSession {
+		h.mu.Unlock()
+		log.Printf("Member %d already connected to convoy %s, rejecting second connection", memberID, convoyID)
+		return false
 	}
 
 	h.memberConnections[convoyID][memberID] = conn
+	h.mu.Unlock()
 	log.Printf("Member %d registered for convoy %s", memberID, convoyID)
+
+	// Close the replaced connection outside the lock; its handler unregisters it
+	if previous != nil {
+		log.Printf("Member %d reconnected to convoy %s, closing previous connection", memberID, convoyID)
+		previous.closeWithMessage(CloseSessionReplaced, "replaced by a newer connection")
+	}
+	return true
 }
 
 // Unregister removes a connection from the hub.
-func (h *Hub) Unregister(convoyID string, conn *websocket.Conn) {
+func (h *Hub) Unregister(convoyID string, conn *Connection) {
+	h.forgetAcks(conn)
+
 	h.mu.Lock()
-	defer h.mu.Unlock()
+	memberID := h.unregister(convoyID, conn)
+	h.mu.Unlock()
+
+	h.notifyMemberDisconnected(convoyID, memberID)
+}
+
+// unregister removes a connection and returns the member it belonged to, or
+// 0 if it had none or was already replaced. Must be called with h.mu held.
+func (h *Hub) unregister(convoyID string, conn *Connection) int64 {
+	var memberID int64
+	if watchers := h.spectators[convoyID]; watchers[conn] {
+		delete(watchers, conn)
+		if len(watchers) == 0 {
+			delete(h.spectators, convoyID)
+		}
+		log.Printf("Spectator unregistered for convoy %s", convoyID)
+		return 0
+	}
 
 	if convoyConns, exists := h.connections[convoyID]; exists {
 		if _, connExists := convoyConns[conn]; connExists {
@@ -86,7 +340,7 @@ func (h *Hub) Unregister(convoyID string, conn *websocket.Conn) {
 				convoyID, len(convoyConns))
 
 			// Also remove from member connections
-			h.unregisterMemberConnection(convoyID, conn)
+			memberID = h.unregisterMemberConnection(convoyID, conn)
 
 			// Clean up empty convoy entries
 			if len(convoyConns) == 0 {
@@ -100,6 +354,7 @@ func (h *Hub) Unregister(convoyID string, conn *websocket.Conn) {
 	} else {
 		log.Printf("Attempted to unregister connection for non-existent convoy %s", convoyID)
 	}
+	return memberID
 }
 
 // UnregisterMember removes a member's connection association
@@ -118,70 +373,212 @@ func (h *Hub) UnregisterMember(convoyID string, memberID int64) {
 	}
 }
 
-// unregisterMemberConnection removes member connection by connection object (internal helper)
-func (h *Hub) unregisterMemberConnection(convoyID string, conn *websocket.Conn) {
+// unregisterMemberConnection removes member connection by connection object
+// (internal helper) and returns the member's ID, or 0 if no member had it
+func (h *Hub) unregisterMemberConnection(convoyID string, conn *Connection) int64 {
 	if memberConns, exists := h.memberConnections[convoyID]; exists {
 		for memberID, memberConn := range memberConns {
 			if memberConn == conn {
 				delete(memberConns, memberID)
 				log.Printf("Member %d connection unregistered from convoy %s", memberID, convoyID)
-				break
+				return memberID
 			}
 		}
 	}
+	return 0
 }
 
 // Broadcast sends a message to all connections for a specific convoy.
 func (h *Hub) Broadcast(convoyID string, message interface{}) {
+	public, hasNotes := withoutNotes(message)
+	data, err := json.Marshal(public)
+	if err != nil {
+		log.Printf("Error marshalling WebSocket message for convoy %s: %v", convoyID, err)
+		return
+	}
+
+	// Member notes go only to the leader, in a copy of the message that
+	// carries the same sequence number
+	var leaderID int64
+	var leaderData []byte
+	if hasNotes {
+		if leaderID = h.leaderOf(convoyID, message); leaderID != 0 {
+			if leaderData, err = json.Marshal(message); err != nil {
+				log.Printf("Error marshalling WebSocket message for the leader of convoy %s: %v", convoyID, err)
+				leaderData = nil
+			}
+		}
+	}
+	// Numbering and queueing happen under one lock, so concurrent broadcasts
+	// reach every recipient in sequence order
+	lock := h.broadcastLock(convoyID)
+	lock.Lock()
+	if seq, ok := h.nextSequence(convoyID); ok {
+		data = withSequence(data, seq)
+		if leaderData != nil {
+			leaderData = withSequence(leaderData, seq)
+		}
+	}
+
+	// Kept even when nobody is connected, so the next connection sees it
+	h.rememberBroadcast(convoyID, data, leaderID, leaderData)
+
 	h.mu.RLock()
-	convoyConns, exists := h.connections[convoyID]
-	if !exists || len(convoyConns) == 0 {
+	convoyConns := h.connections[convoyID]
+	watchers := h.spectators[convoyID]
+	streams := h.sseSubscribers[convoyID]
+	if len(convoyConns) == 0 && len(watchers) == 0 && len(streams) == 0 {
 		h.mu.RUnlock()
+		lock.Unlock()
+		h.recordBroadcast(convoyID, len(data), 0)
 		log.Printf("No WebSocket connections found for convoy %s", convoyID)
 		return
 	}
 
 	// Create a copy of connections to avoid holding the lock during broadcast
-	connections := make([]*websocket.Conn, 0, len(convoyConns))
+	connections := make([]*Connection, 0, len(convoyConns)+len(watchers))
 	for conn := range convoyConns {
 		connections = append(connections, conn)
 	}
+	for conn := range watchers {
+		connections = append(connections, conn)
+	}
+	subscribers := make([]*sseSubscriber, 0, len(streams))
+	for sub := range streams {
+		subscribers = append(subscribers, sub)
+	}
 	h.mu.RUnlock()
 
-	data, err := json.Marshal(message)
-	if err != nil {
-		log.Printf("Error marshalling WebSocket message for convoy %s: %v", convoyID, err)
-		return
+	// Critical alerts carry an ID and are resent until each client acknowledges them
+	var alertID string
+	if alert, ok := message.(*domain.ConvoyAlert); ok {
+		alertID = alert.AlertID
 	}
-
-	// Broadcast to all connections
-	failedConnections := make([]*websocket.Conn, 0)
+	onlyFor := filteredMemberID(message)
+
+	// Queue the message on every connection. Each one is written by its own
+	// pump, so a slow client can't hold up the rest of the convoy. A full
+	// buffer may only be a momentary stall under load, so such a connection
+	// gets one more try after a short pause, off this goroutine, and is
+	// dropped only if that fails too.
+	failedConnections := make([]*Connection, 0)
 	successCount := 0
 
 	for _, conn := range connections {
-		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-		if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
-			log.Printf("Error writing to WebSocket connection for convoy %s: %v", convoyID, err)
+		if !conn.wants(onlyFor) {
+			continue
+		}
+		payload := data
+		if leaderData != nil && conn.authenticated && conn.memberID == leaderID {
+			payload = leaderData
+		}
+		queued, err := h.sendOrDefer(conn, payload, alertID)
+		if err != nil {
 			failedConnections = append(failedConnections, conn)
-		} else {
+			continue
+		}
+		if queued {
 			successCount++
+			if alertID != "" {
+				h.trackAck(conn, alertID, payload)
+			}
 		}
 	}
 
-	// Remove failed connections
+	// SSE streams are written by their own handlers; one that has fallen a
+	// full buffer behind is dropped rather than allowed to block the others
+	for _, sub := range subscribers {
+		select {
+		case sub.messages <- data:
+			successCount++
+		default:
+			log.Printf("SSE subscriber for convoy %s is too slow, dropping it", convoyID)
+			h.unsubscribeSSE(convoyID, sub, true)
+		}
+	}
+	lock.Unlock()
+
+	// Only queued messages count; dropped connections are removed below
+	h.recordBroadcast(convoyID, len(data), successCount)
+
 	if len(failedConnections) > 0 {
-		h.mu.Lock()
-		if convoyConns, exists := h.connections[convoyID]; exists {
-			for _, failedConn := range failedConnections {
-				delete(convoyConns, failedConn)
-				failedConn.Close()
+		h.dropConnections(convoyID, failedConnections)
+	}
+
+	log.Printf("Successfully broadcasted message to %d connections for convoy %s", successCount, convoyID)
+}
+
+// dropConnections removes connections that are closed or too far behind to
+// catch up. Their handlers' cleanup then finds them already gone.
+func (h *Hub) dropConnections(convoyID string, conns []*Connection) {
+	var memberIDs []int64
+	h.mu.Lock()
+	for _, conn := range conns {
+		if h.connections[convoyID][conn] {
+			delete(h.connections[convoyID], conn)
+			if memberID := h.unregisterMemberConnection(convoyID, conn); memberID != 0 {
+				memberIDs = append(memberIDs, memberID)
 			}
 		}
-		h.mu.Unlock()
-		log.Printf("Removed %d failed connections for convoy %s", len(failedConnections), convoyID)
+		delete(h.spectators[convoyID], conn)
 	}
+	h.mu.Unlock()
 
-	log.Printf("Successfully broadcasted message to %d connections for convoy %s", successCount, convoyID)
+	for _, memberID := range memberIDs {
+		h.notifyMemberDisconnected(convoyID, memberID)
+	}
+
+	for _, conn := range conns {
+		h.forgetAcks(conn)
+		// The close frame waits for the stuck write to time out, so it must
+		// not hold up the broadcast
+		go conn.closeWithMessage(websocket.CloseTryAgainLater, h.withReconnectHint("client is too slow"))
+	}
+	log.Printf("Dropped %d closed or slow connections for convoy %s", len(conns), convoyID)
+}
+
+// CloseAll sends a close frame with the given code to every connection and then
+// closes it. Each close frame write gets a short deadline so a stuck client
+// can't hold up shutdown. SSE streams are dropped too: http.Server.Shutdown
+// waits for their handlers but never cancels them.
+func (h *Hub) CloseAll(code int, reason string) {
+	h.mu.Lock()
+	streams := 0
+	for convoyID, subscribers := range h.sseSubscribers {
+		for sub := range subscribers {
+			close(sub.dropped)
+			streams++
+		}
+		delete(h.sseSubscribers, convoyID)
+	}
+	connections := make([]*Connection, 0)
+	for _, convoyConns := range h.connections {
+		for conn := range convoyConns {
+			connections = append(connections, conn)
+		}
+	}
+	for _, watchers := range h.spectators {
+		for conn := range watchers {
+			connections = append(connections, conn)
+		}
+	}
+	h.mu.Unlock()
+
+	closeMsg := websocket.FormatCloseMessage(code, reason)
+	var wg sync.WaitGroup
+	for _, conn := range connections {
+		wg.Add(1)
+		go func(conn *Connection) {
+			defer wg.Done()
+			if err := conn.conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(time.Second)); err != nil {
+				log.Printf("Failed to send close frame: %v", err)
+			}
+			conn.Close()
+		}(conn)
+	}
+	wg.Wait()
+
+	log.Printf("Closed %d WebSocket connections with code %d (%s) and %d SSE streams", len(connections), code, reason, streams)
 }
 
 // HasActiveConnection checks if a specific member has an active WebSocket connection
@@ -204,7 +601,7 @@ func (h *Hub) HasActiveConnection(convoyID string, memberID int64) bool {
 }
 
 // GetMemberConnection returns the WebSocket connection for a specific member
-func (h *Hub) GetMemberConnection(convoyID string, memberID int64) *websocket.Conn {
+func (h *Hub) GetMemberConnection(convoyID string, memberID int64) *Connection {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
@@ -214,6 +611,17 @@ func (h *Hub) GetMemberConnection(convoyID string, memberID int64) *websocket.Co
 	return nil
 }
 
+// LastActivity returns when a member's connection last answered a ping or
+// sent a message, or the zero time if the member has no connection. It tells
+// a member who is watching with tracking paused from a dead socket.
+func (h *Hub) LastActivity(convoyID string, memberID int64) time.Time {
+	conn := h.GetMemberConnection(convoyID, memberID)
+	if conn == nil {
+		return time.Time{}
+	}
+	return conn.LastActivity()
+}
+
 // GetConnectionCount returns the number of active connections for a convoy
 func (h *Hub) GetConnectionCount(convoyID string) int {
 	h.mu.RLock()
@@ -225,6 +633,13 @@ func (h *Hub) GetConnectionCount(convoyID string) int {
 	return 0
 }
 
+// GetSpectatorCount returns the number of spectators watching a convoy
+func (h *Hub) GetSpectatorCount(convoyID string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.spectators[convoyID])
+}
+
 // GetActiveConvoyCount returns the number of active convoys
 func (h *Hub) GetActiveConvoyCount() int {
 	h.mu.RLock()
@@ -241,12 +656,18 @@ func (h *Hub) GetActiveConvoyCount() int {
 
 // GetTotalConnections returns the total number of active connections across all convoys
 func (h *Hub) GetTotalConnections() int {
+	total, _ := h.connectionLoad()
+	return total
+}
+
+// connectionLoad returns the total number of connections together with the
+// global connection limit, read under the same lock
+func (h *Hub) connectionLoad() (total, limit int) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
-	total := 0
 	for _, convoyConns := range h.connections {
 		total += len(convoyConns)
 	}
-	return total
+	return total, h.maxTotal
 }
@@ -0,0 +1,47 @@
+package ws
+
+import (
+	"context"
+	"convoy-app/backend/src/domain"
+)
+
+// Member notes are the leader's own reminders ("has the coolers"), so only
+// the leader's member connections receive them. Everyone else, including
+// spectators, SSE streams and the replay cache, gets messages with the notes
+// removed.
+
+// withoutNotes returns the message with member notes removed, and whether
+// there were any to remove
+func withoutNotes(message interface{}) (interface{}, bool) {
+	switch m := message.(type) {
+	case *domain.Convoy:
+		public := m.WithoutNotes()
+		return public, public != m
+	case *domain.MemberMoved:
+		if m.Member == nil || m.Member.Notes == "" {
+			return message, false
+		}
+		member := *m.Member
+		member.Notes = ""
+		public := *m
+		public.Member = &member
+		return &public, true
+	}
+	return message, false
+}
+
+// leaderOf returns the ID of the convoy's leader, taken from the message when
+// it is the convoy itself. It returns 0 if the leader can't be found.
+func (h *Hub) leaderOf(convoyID string, message interface{}) int64 {
+	if convoy, ok := message.(*domain.Convoy); ok {
+		return convoy.LeaderID
+	}
+	if h.convoyProvider == nil {
+		return 0
+	}
+	convoy, err := h.convoyProvider.GetConvoy(context.Background(), convoyID)
+	if err != nil {
+		return 0
+	}
+	return convoy.LeaderID
+}
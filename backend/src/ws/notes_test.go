@@ -0,0 +1,104 @@
+package ws
+
+import (
+	"context"
+	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/storage"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// readConvoy reads the next message from conn as a convoy
+func readConvoy(t *testing.T, conn *websocket.Conn) domain.Convoy {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+	var convoy domain.Convoy
+	if err := json.Unmarshal(data, &convoy); err != nil {
+		t.Fatalf("Failed to decode convoy: %v", err)
+	}
+	return convoy
+}
+
+func TestMemberNotesOnlySentToLeader(t *testing.T) {
+	memStorage := storage.NewMemoryStorage()
+	hub := NewHub()
+	hub.SetConvoyProvider(memStorage)
+	hub.SetReplayRetention(0)
+
+	ctx := context.Background()
+	convoy, err := memStorage.CreateConvoy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	for _, member := range []*domain.Member{{ID: 1, Name: "Leader"}, {ID: 2, Name: "Follower", Notes: "has the coolers"}} {
+		member.SetConnectToken("token-" + strconv.FormatInt(member.ID, 10))
+		if err := memStorage.AddMember(ctx, convoy.ID, member); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+	}
+
+	server := newTestServer(t, hub)
+	leader := dial(t, server, "/ws/convoys/"+convoy.ID+"?memberId=1&token=token-1")
+	follower := dial(t, server, "/ws/convoys/"+convoy.ID+"?memberId=2&token=token-2")
+	spectator := dial(t, server, "/ws/convoys/"+convoy.ID+"?role=spectator&memberId=1&token=token-1")
+	// The leader's ID is public, so claiming it without the token gets nothing
+	impostor := dial(t, server, "/ws/convoys/"+convoy.ID+"?memberId=1")
+
+	notesOf := func(convoy domain.Convoy) string {
+		for _, member := range convoy.Members {
+			if member.ID == 2 {
+				return member.Notes
+			}
+		}
+		return ""
+	}
+
+	if got := notesOf(readConvoy(t, leader)); got != "has the coolers" {
+		t.Errorf("Expected the leader's snapshot to carry notes, got %q", got)
+	}
+	if got := notesOf(readConvoy(t, follower)); got != "" {
+		t.Errorf("Expected the follower's snapshot without notes, got %q", got)
+	}
+	if got := notesOf(readConvoy(t, spectator)); got != "" {
+		t.Errorf("Expected the spectator's snapshot without notes, got %q", got)
+	}
+	if got := notesOf(readConvoy(t, impostor)); got != "" {
+		t.Errorf("Expected a connection without the leader's token to get no notes, got %q", got)
+	}
+
+	waitForConnectionCount(t, hub, convoy.ID, 3)
+	deadline := time.Now().Add(2 * time.Second)
+	for hub.GetSpectatorCount(convoy.ID) != 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	live, err := memStorage.GetConvoy(ctx, convoy.ID)
+	if err != nil {
+		t.Fatalf("Failed to get convoy: %v", err)
+	}
+	hub.Broadcast(convoy.ID, live)
+
+	if got := notesOf(readConvoy(t, leader)); got != "has the coolers" {
+		t.Errorf("Expected the leader's broadcast to carry notes, got %q", got)
+	}
+	if got := notesOf(readConvoy(t, follower)); got != "" {
+		t.Errorf("Expected the follower's broadcast without notes, got %q", got)
+	}
+	if got := notesOf(readConvoy(t, spectator)); got != "" {
+		t.Errorf("Expected the spectator's broadcast without notes, got %q", got)
+	}
+	if got := notesOf(readConvoy(t, impostor)); got != "" {
+		t.Errorf("Expected a connection without the leader's token to get no notes, got %q", got)
+	}
+	if notesOf(*live) != "has the coolers" {
+		t.Error("Expected broadcasting not to modify the stored convoy")
+	}
+}
@@ -0,0 +1,33 @@
+package ws
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// MinReconnectAfter is the reconnect hint given to clients of an idle server
+	MinReconnectAfter = time.Second
+	// MaxReconnectAfter is the reconnect hint given once the server is at its
+	// connection limit
+	MaxReconnectAfter = 30 * time.Second
+)
+
+// ReconnectAfter returns how long clients should wait before reconnecting.
+// It grows linearly from MinReconnectAfter to MaxReconnectAfter as the server
+// fills up, so a burst of disconnects doesn't come back as a reconnect storm.
+// Clients are expected to add their own jitter on top.
+func (h *Hub) ReconnectAfter() time.Duration {
+	total, limit := h.connectionLoad()
+	if limit <= 0 || total <= 0 {
+		return MinReconnectAfter
+	}
+	load := min(float64(total)/float64(limit), 1)
+	return MinReconnectAfter + time.Duration(load*float64(MaxReconnectAfter-MinReconnectAfter)).Round(time.Millisecond)
+}
+
+// withReconnectHint appends the reconnect hint to a close frame reason as
+// "; reconnectAfterMs=N", which clients parse from the end of the text
+func (h *Hub) withReconnectHint(reason string) string {
+	return fmt.Sprintf("%s; reconnectAfterMs=%d", reason, h.ReconnectAfter().Milliseconds())
+}
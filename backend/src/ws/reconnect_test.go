@@ -0,0 +1,65 @@
+package ws
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestReconnectAfterGrowsWithLoad(t *testing.T) {
+	hub := NewHub()
+	hub.SetConnectionLimits(100, 10)
+
+	if got := hub.ReconnectAfter(); got != MinReconnectAfter {
+		t.Errorf("Expected %v on an idle server, got %v", MinReconnectAfter, got)
+	}
+
+	// Stand-in connections; only their count matters
+	hub.connections[testConvoy1] = make(map[*Connection]bool)
+	previous := hub.ReconnectAfter()
+	for i := 1; i <= 10; i++ {
+		hub.connections[testConvoy1][&Connection{}] = true
+		got := hub.ReconnectAfter()
+		if got <= previous {
+			t.Errorf("Expected the hint to grow at %d connections, got %v after %v", i, got, previous)
+		}
+		previous = got
+	}
+	if previous != MaxReconnectAfter {
+		t.Errorf("Expected %v at the connection limit, got %v", MaxReconnectAfter, previous)
+	}
+
+	hub.connections[testConvoy1][&Connection{}] = true
+	if got := hub.ReconnectAfter(); got != MaxReconnectAfter {
+		t.Errorf("Expected the hint to be capped at %v, got %v", MaxReconnectAfter, got)
+	}
+}
+
+func TestOverLimitCloseCarriesReconnectHint(t *testing.T) {
+	hub := NewHub()
+	hub.SetConnectionLimits(1, 100)
+	server := newTestServer(t, hub)
+
+	dial(t, server, "/ws/convoys/"+testConvoy1)
+	waitForConnectionCount(t, hub, testConvoy1, 1)
+
+	overflow := dial(t, server, "/ws/convoys/"+testConvoy1)
+	overflow.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		_, _, err := overflow.ReadMessage()
+		if err == nil {
+			continue
+		}
+		var closeErr *websocket.CloseError
+		if !errors.As(err, &closeErr) {
+			t.Fatalf("Expected close error, got %v", err)
+		}
+		if !strings.HasSuffix(closeErr.Text, "; reconnectAfterMs=1290") {
+			t.Errorf("Expected the close reason to end with the reconnect hint, got %q", closeErr.Text)
+		}
+		return
+	}
+}
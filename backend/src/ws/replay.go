@@ -0,0 +1,86 @@
+package ws
+
+import (
+	"log"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// DefaultReplayRetention is how long the last broadcast of a convoy is kept
+// for replay to new connections
+const DefaultReplayRetention = 10 * time.Minute
+
+// cachedBroadcast is the most recent message broadcast to a convoy
+type cachedBroadcast struct {
+	data       []byte
+	leaderID   int64  // set when the leader got its own version with member notes
+	leaderData []byte // that version
+	sentAt     time.Time
+}
+
+// SetReplayRetention sets how long the last broadcast of each convoy is
+// replayed to new connections. 0 disables replay and drops cached messages.
+func (h *Hub) SetReplayRetention(retention time.Duration) {
+	h.replayMu.Lock()
+	defer h.replayMu.Unlock()
+	h.replayRetention = retention
+	if retention <= 0 {
+		h.lastBroadcasts = make(map[string]cachedBroadcast)
+	}
+}
+
+// rememberBroadcast keeps data as the convoy's latest broadcast, replacing the
+// previous one, and drops entries of other convoys that are past retention.
+// leaderData, if not nil, is what the leader was sent instead.
+func (h *Hub) rememberBroadcast(convoyID string, data []byte, leaderID int64, leaderData []byte) {
+	h.replayMu.Lock()
+	defer h.replayMu.Unlock()
+
+	if h.replayRetention <= 0 {
+		return
+	}
+	now := time.Now()
+	for id, cached := range h.lastBroadcasts {
+		if now.Sub(cached.sentAt) > h.replayRetention {
+			delete(h.lastBroadcasts, id)
+		}
+	}
+	h.lastBroadcasts[convoyID] = cachedBroadcast{data: data, leaderID: leaderID, leaderData: leaderData, sentAt: now}
+}
+
+// lastBroadcast returns the convoy's latest broadcast if it is within retention
+func (h *Hub) lastBroadcast(convoyID string) ([]byte, bool) {
+	return h.lastBroadcastFor(convoyID, 0)
+}
+
+// lastBroadcastFor returns the convoy's latest broadcast as memberID was sent
+// it, if it is within retention
+func (h *Hub) lastBroadcastFor(convoyID string, memberID int64) ([]byte, bool) {
+	h.replayMu.Lock()
+	defer h.replayMu.Unlock()
+
+	cached, ok := h.lastBroadcasts[convoyID]
+	if !ok || time.Since(cached.sentAt) > h.replayRetention {
+		return nil, false
+	}
+	if cached.leaderData != nil && memberID != 0 && memberID == cached.leaderID {
+		return cached.leaderData, true
+	}
+	return cached.data, true
+}
+
+// replayLastBroadcast sends the convoy's latest broadcast to a new connection
+// of memberID (0 for spectators). It returns false if the connection should
+// be dropped.
+func (h *Hub) replayLastBroadcast(convoyID string, memberID int64, conn *websocket.Conn) bool {
+	data, ok := h.lastBroadcastFor(convoyID, memberID)
+	if !ok {
+		return true
+	}
+	if err := h.writeText(conn, data); err != nil {
+		log.Printf("Failed to replay last broadcast to convoy %s: %v", convoyID, err)
+		return false
+	}
+	return true
+}
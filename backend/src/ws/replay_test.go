@@ -0,0 +1,50 @@
+package ws
+
+import (
+	"convoy-app/backend/src/domain"
+	"testing"
+	"time"
+)
+
+func TestLateConnectionReceivesLastBroadcast(t *testing.T) {
+	// No convoy provider: anything the client receives comes from the hub's cache
+	hub := NewHub()
+	server := newTestServer(t, hub)
+
+	hub.Broadcast(testConvoy1, &domain.ConvoyAlert{EventType: domain.EventMemberLagging, ConvoyID: testConvoy1})
+	hub.Broadcast(testConvoy1, &domain.ConvoyAlert{EventType: domain.EventConvoyScattered, ConvoyID: testConvoy1})
+
+	client := dial(t, server, "/ws/convoys/"+testConvoy1)
+	alert, err := readAlert(t, client, 2*time.Second)
+	if err != nil {
+		t.Fatalf("Expected the cached broadcast, got %v", err)
+	}
+	if alert.EventType != domain.EventConvoyScattered {
+		t.Errorf("Expected only the latest broadcast to be replayed, got %s", alert.EventType)
+	}
+
+	other := dial(t, server, "/ws/convoys/"+testConvoy2)
+	if _, err := readAlert(t, other, 100*time.Millisecond); err == nil {
+		t.Error("Expected no replay for a convoy that was never broadcast to")
+	}
+}
+
+func TestReplayDisabledOrExpired(t *testing.T) {
+	hub := NewHub()
+	hub.SetReplayRetention(0)
+	server := newTestServer(t, hub)
+
+	hub.Broadcast(testConvoy1, &domain.ConvoyAlert{EventType: domain.EventMemberLagging, ConvoyID: testConvoy1})
+	client := dial(t, server, "/ws/convoys/"+testConvoy1)
+	if _, err := readAlert(t, client, 100*time.Millisecond); err == nil {
+		t.Error("Expected no replay when retention is disabled")
+	}
+
+	hub.SetReplayRetention(20 * time.Millisecond)
+	hub.Broadcast(testConvoy2, &domain.ConvoyAlert{EventType: domain.EventMemberLagging, ConvoyID: testConvoy2})
+	time.Sleep(50 * time.Millisecond)
+	client = dial(t, server, "/ws/convoys/"+testConvoy2)
+	if _, err := readAlert(t, client, 100*time.Millisecond); err == nil {
+		t.Error("Expected no replay of a broadcast past retention")
+	}
+}
@@ -0,0 +1,73 @@
+package ws
+
+import (
+	"errors"
+	"log"
+	"time"
+)
+
+// DefaultBroadcastRetryBackoff is how long a connection whose send buffer was
+// full gets to catch up before its broadcasts are retried
+const DefaultBroadcastRetryBackoff = 50 * time.Millisecond
+
+// stalledSend is a broadcast waiting for a connection's send buffer to drain
+type stalledSend struct {
+	payload []byte
+	alertID string // set for critical alerts, which are tracked for acks once queued
+}
+
+// SetBroadcastRetryBackoff sets how long connections with a full send buffer
+// get before their broadcasts are retried once. Connections that are closed
+// are dropped without a retry. 0 drops full connections at once.
+func (h *Hub) SetBroadcastRetryBackoff(backoff time.Duration) {
+	h.broadcastRetryBackoff = backoff
+}
+
+// sendOrDefer queues payload on conn. If the buffer is full, or conn already
+// waits for a retry, payload is held back behind the earlier broadcasts so the
+// client still gets them in order, and the retry runs on a timer rather than
+// holding up the caller. It reports whether payload was queued now; an error
+// means conn must be dropped.
+func (h *Hub) sendOrDefer(conn *Connection, payload []byte, alertID string) (bool, error) {
+	conn.retryMu.Lock()
+	defer conn.retryMu.Unlock()
+
+	if conn.stalled != nil {
+		if len(conn.stalled) >= SendBufferSize {
+			return false, errSendBufferFull
+		}
+		conn.stalled = append(conn.stalled, stalledSend{payload: payload, alertID: alertID})
+		return false, nil
+	}
+
+	err := conn.enqueue(payload)
+	if errors.Is(err, errSendBufferFull) && h.broadcastRetryBackoff > 0 {
+		conn.stalled = []stalledSend{{payload: payload, alertID: alertID}}
+		time.AfterFunc(h.broadcastRetryBackoff, func() { h.retryStalled(conn) })
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// retryStalled queues the broadcasts held back for conn, in order, and drops
+// the connection if its buffer is still full
+func (h *Hub) retryStalled(conn *Connection) {
+	conn.retryMu.Lock()
+	var err error
+	for _, s := range conn.stalled {
+		if err = conn.enqueue(s.payload); err != nil {
+			break
+		}
+		if s.alertID != "" {
+			h.trackAck(conn, s.alertID, s.payload)
+		}
+		h.recordDeferredDelivery(conn.convoyID, len(s.payload))
+	}
+	conn.stalled = nil
+	conn.retryMu.Unlock()
+
+	if err != nil {
+		log.Printf("Connection for convoy %s (member %d) still can't take messages after %v: %v", conn.convoyID, conn.memberID, h.broadcastRetryBackoff, err)
+		h.dropConnections(conn.convoyID, []*Connection{conn})
+	}
+}
@@ -0,0 +1,118 @@
+package ws
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newStalledConnection registers a connection to testConvoy1 whose write pump
+// isn't running, with a send buffer of one message that is already taken.
+// Broadcasts can only get through once the test drains the buffer.
+func newStalledConnection(t *testing.T, hub *Hub) *Connection {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	t.Cleanup(func() { ws.Close() })
+
+	conn := &Connection{conn: ws, convoyID: testConvoy1, hub: hub, send: make(chan []byte, 1), done: make(chan struct{})}
+	conn.send <- []byte(`{"type":"earlier"}`)
+	if !hub.Register(testConvoy1, conn) {
+		t.Fatal("Failed to register connection")
+	}
+	return conn
+}
+
+// connectionCountReaches waits up to within for the convoy to hold n
+// connections and reports whether it did
+func connectionCountReaches(hub *Hub, convoyID string, n int, within time.Duration) bool {
+	deadline := time.Now().Add(within)
+	for hub.GetConnectionCount(convoyID) != n {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return true
+}
+
+func TestBroadcastRetriesConnectionWithFullBuffer(t *testing.T) {
+	hub := NewHub()
+	hub.SetBroadcastRetryBackoff(200 * time.Millisecond)
+	conn := newStalledConnection(t, hub)
+
+	// Broadcast doesn't wait for the retry
+	start := time.Now()
+	hub.Broadcast(testConvoy1, map[string]string{"type": "first"})
+	hub.Broadcast(testConvoy1, map[string]string{"type": "second"})
+	if elapsed := time.Since(start); elapsed >= 200*time.Millisecond {
+		t.Errorf("Expected Broadcast to return before the backoff, took %v", elapsed)
+	}
+
+	// The client catches up during the backoff
+	if message := <-conn.send; string(message) != `{"type":"earlier"}` {
+		t.Fatalf("Expected the earlier message first, got %s", message)
+	}
+	for _, expected := range []string{`{"type":"first"}`, `{"type":"second"}`} {
+		select {
+		case message := <-conn.send:
+			if string(message) != expected {
+				t.Errorf("Expected %s in order, got %s", expected, message)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected %s to be queued on the retry", expected)
+		}
+	}
+	if count := hub.GetConnectionCount(testConvoy1); count != 1 {
+		t.Errorf("Expected the connection to be kept, got %d connections", count)
+	}
+}
+
+func TestBroadcastDropsConnectionStillFullAfterRetry(t *testing.T) {
+	hub := NewHub()
+	hub.SetBroadcastRetryBackoff(50 * time.Millisecond)
+	newStalledConnection(t, hub)
+
+	start := time.Now()
+	hub.Broadcast(testConvoy1, map[string]string{"type": "test"})
+	if count := hub.GetConnectionCount(testConvoy1); count != 1 {
+		t.Fatalf("Expected the connection to be kept until the retry, got %d connections", count)
+	}
+	if !connectionCountReaches(hub, testConvoy1, 0, time.Second) {
+		t.Fatalf("Expected the connection to be dropped, got %d connections", hub.GetConnectionCount(testConvoy1))
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected a retry after the backoff before dropping, dropped after %v", elapsed)
+	}
+}
+
+func TestBroadcastDropsClosedConnectionWithoutRetry(t *testing.T) {
+	hub := NewHub()
+	hub.SetBroadcastRetryBackoff(time.Second)
+	conn := newStalledConnection(t, hub)
+	conn.Close()
+
+	hub.Broadcast(testConvoy1, map[string]string{"type": "test"})
+	if count := hub.GetConnectionCount(testConvoy1); count != 0 {
+		t.Errorf("Expected the connection to be dropped at once, got %d connections", count)
+	}
+}
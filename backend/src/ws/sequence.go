@@ -0,0 +1,68 @@
+package ws
+
+import (
+	"bytes"
+	"strconv"
+	"sync"
+)
+
+// SetSequenceNumbers enables a per-convoy "seq" field on every outbound
+// message. Throttled snapshots and unthrottled alerts can overtake each
+// other, so clients use seq to discard frames older than one already applied.
+func (h *Hub) SetSequenceNumbers(enabled bool) {
+	h.seqMu.Lock()
+	defer h.seqMu.Unlock()
+	h.sequenceNumbers = enabled
+}
+
+// stampSequence adds the convoy's next sequence number to a marshalled JSON
+// object. Data is returned unchanged when sequencing is off or it is not an object.
+func (h *Hub) stampSequence(convoyID string, data []byte) []byte {
+	if len(data) < 2 || data[0] != '{' {
+		return data
+	}
+	seq, ok := h.nextSequence(convoyID)
+	if !ok {
+		return data
+	}
+	return withSequence(data, seq)
+}
+
+// nextSequence advances the convoy's sequence number. It returns false when
+// sequencing is off.
+func (h *Hub) nextSequence(convoyID string) (uint64, bool) {
+	h.seqMu.Lock()
+	defer h.seqMu.Unlock()
+	if !h.sequenceNumbers {
+		return 0, false
+	}
+	h.sequences[convoyID]++
+	return h.sequences[convoyID], true
+}
+
+// broadcastLock returns the lock that orders the convoy's broadcasts
+func (h *Hub) broadcastLock(convoyID string) *sync.Mutex {
+	h.seqMu.Lock()
+	defer h.seqMu.Unlock()
+	lock, ok := h.broadcastLocks[convoyID]
+	if !ok {
+		lock = &sync.Mutex{}
+		h.broadcastLocks[convoyID] = lock
+	}
+	return lock
+}
+
+// withSequence adds seq to a marshalled JSON object, or returns data
+// unchanged if it is not an object
+func withSequence(data []byte, seq uint64) []byte {
+	if len(data) < 2 || data[0] != '{' {
+		return data
+	}
+	stamped := make([]byte, 0, len(data)+24)
+	stamped = append(stamped, `{"seq":`...)
+	stamped = strconv.AppendUint(stamped, seq, 10)
+	if rest := bytes.TrimSpace(data[1:]); len(rest) > 0 && rest[0] != '}' {
+		stamped = append(stamped, ',')
+	}
+	return append(stamped, data[1:]...)
+}
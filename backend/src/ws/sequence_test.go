@@ -0,0 +1,119 @@
+package ws
+
+import (
+	"context"
+	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/storage"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSequenceNumbersIncreaseAcrossSnapshotsAndAlerts(t *testing.T) {
+	memStorage := storage.NewMemoryStorage()
+	hub := NewHub()
+	hub.SetConvoyProvider(memStorage)
+	hub.SetReplayRetention(0)
+	hub.SetSequenceNumbers(true)
+
+	convoy, err := memStorage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	server := newTestServer(t, hub)
+	conn := dial(t, server, "/ws/convoys/"+convoy.ID)
+	waitForConnectionCount(t, hub, convoy.ID, 1)
+
+	hub.Broadcast(convoy.ID, convoy)
+	hub.Broadcast(convoy.ID, &domain.ConvoyAlert{EventType: domain.EventMemberLagging, ConvoyID: convoy.ID})
+	hub.Broadcast(convoy.ID, convoy)
+	hub.Broadcast(convoy.ID, &domain.ConvoyAlert{EventType: domain.EventConvoyScattered, ConvoyID: convoy.ID})
+
+	// Connect snapshot plus four broadcasts
+	var last uint64
+	for i := 0; i < 5; i++ {
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("Expected frame %d, got %v", i, err)
+		}
+		var frame struct {
+			Seq uint64 `json:"seq"`
+		}
+		if err := json.Unmarshal(data, &frame); err != nil {
+			t.Fatalf("Frame %d is not valid JSON after stamping: %v (%s)", i, err, data)
+		}
+		if frame.Seq <= last {
+			t.Errorf("Expected frame %d to have seq above %d, got %d", i, last, frame.Seq)
+		}
+		last = frame.Seq
+	}
+}
+
+func TestConcurrentBroadcastsArriveInSequence(t *testing.T) {
+	memStorage := storage.NewMemoryStorage()
+	hub := NewHub()
+	hub.SetConvoyProvider(memStorage)
+	hub.SetReplayRetention(0)
+	hub.SetSequenceNumbers(true)
+
+	convoy, err := memStorage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	server := newTestServer(t, hub)
+	conn := dial(t, server, "/ws/convoys/"+convoy.ID)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, _, err := conn.ReadMessage(); err != nil {
+		t.Fatalf("Expected initial snapshot, got error: %v", err)
+	}
+	waitForConnectionCount(t, hub, convoy.ID, 1)
+
+	const broadcasts = 32
+	var wg sync.WaitGroup
+	for i := 0; i < broadcasts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hub.Broadcast(convoy.ID, &domain.ConvoyAlert{EventType: domain.EventMemberLagging, ConvoyID: convoy.ID})
+		}()
+	}
+	wg.Wait()
+
+	var last uint64
+	for i := 0; i < broadcasts; i++ {
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		var frame struct {
+			Seq uint64 `json:"seq"`
+		}
+		if err := conn.ReadJSON(&frame); err != nil {
+			t.Fatalf("Expected frame %d, got %v", i, err)
+		}
+		if frame.Seq <= last {
+			t.Fatalf("Expected frame %d to have seq above %d, got %d", i, last, frame.Seq)
+		}
+		last = frame.Seq
+	}
+}
+
+func TestStampSequence(t *testing.T) {
+	hub := NewHub()
+	if got := string(hub.stampSequence(testConvoy1, []byte(`{"a":1}`))); got != `{"a":1}` {
+		t.Errorf("Expected messages unchanged while disabled, got %s", got)
+	}
+
+	hub.SetSequenceNumbers(true)
+	if got := string(hub.stampSequence(testConvoy1, []byte(`{"a":1}`))); got != `{"seq":1,"a":1}` {
+		t.Errorf("Unexpected stamped message %s", got)
+	}
+	if got := string(hub.stampSequence(testConvoy1, []byte(`{}`))); got != `{"seq":2}` {
+		t.Errorf("Unexpected stamped empty object %s", got)
+	}
+	if got := string(hub.stampSequence(testConvoy2, []byte(`{}`))); got != `{"seq":1}` {
+		t.Errorf("Expected an independent counter per convoy, got %s", got)
+	}
+	if got := string(hub.stampSequence(testConvoy1, []byte(`[1]`))); got != `[1]` {
+		t.Errorf("Expected non-objects unchanged, got %s", got)
+	}
+}
@@ -0,0 +1,40 @@
+package ws
+
+import "log"
+
+// SetLoadShedding makes the hub shed load once total connections reach
+// highWater of the global connection limit, and stop once they fall below
+// lowWater. Both are ratios between 0 and 1; a highWater of 0 disables it.
+func (h *Hub) SetLoadShedding(highWater, lowWater float64) {
+	h.shedMu.Lock()
+	defer h.shedMu.Unlock()
+	h.shedHighWater = highWater
+	h.shedLowWater = lowWater
+	if highWater <= 0 {
+		h.shedding = false
+	}
+}
+
+// Shedding reports whether the server is refusing new convoys and
+// connections to protect the ones it already serves. The gap between the
+// water marks keeps it from flapping around a single threshold.
+func (h *Hub) Shedding() bool {
+	total, limit := h.connectionLoad()
+
+	h.shedMu.Lock()
+	defer h.shedMu.Unlock()
+
+	if h.shedHighWater <= 0 || limit <= 0 {
+		return false
+	}
+	load := float64(total) / float64(limit)
+	switch {
+	case !h.shedding && load >= h.shedHighWater:
+		h.shedding = true
+		log.Printf("WARNING: Load shedding engaged at %d/%d connections", total, limit)
+	case h.shedding && load < h.shedLowWater:
+		h.shedding = false
+		log.Printf("Load shedding disengaged at %d/%d connections", total, limit)
+	}
+	return h.shedding
+}
@@ -0,0 +1,73 @@
+package ws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestSheddingEngagesAtHighWaterAndReleasesBelowLowWater(t *testing.T) {
+	hub := NewHub()
+	hub.SetConnectionLimits(100, 10)
+	hub.SetLoadShedding(0.8, 0.5)
+
+	// Stand-in connections; only their count matters
+	hub.connections[testConvoy1] = make(map[*Connection]bool)
+	conns := make([]*Connection, 0, 8)
+	for i := 0; i < 8; i++ {
+		conn := &Connection{}
+		conns = append(conns, conn)
+		if hub.Shedding() {
+			t.Fatalf("Expected no shedding at %d connections", i)
+		}
+		hub.connections[testConvoy1][conn] = true
+	}
+	if !hub.Shedding() {
+		t.Fatal("Expected shedding at the high-water mark")
+	}
+
+	// Between the marks it stays engaged
+	delete(hub.connections[testConvoy1], conns[7])
+	delete(hub.connections[testConvoy1], conns[6])
+	if !hub.Shedding() {
+		t.Error("Expected shedding to hold above the low-water mark")
+	}
+
+	for _, conn := range conns[2:6] {
+		delete(hub.connections[testConvoy1], conn)
+	}
+	if hub.Shedding() {
+		t.Error("Expected shedding to release below the low-water mark")
+	}
+}
+
+func TestSheddingDisabledByDefault(t *testing.T) {
+	hub := NewHub()
+	hub.SetConnectionLimits(100, 1)
+	hub.connections[testConvoy1] = map[*Connection]bool{{}: true}
+	if hub.Shedding() {
+		t.Error("Expected a hub without water marks never to shed load")
+	}
+}
+
+func TestHandlerRefusesConnectionsWhileShedding(t *testing.T) {
+	hub := NewHub()
+	hub.SetConnectionLimits(100, 4)
+	hub.SetLoadShedding(0.5, 0.25)
+	server := newTestServer(t, hub)
+
+	first := dial(t, server, "/ws/convoys/"+testConvoy1)
+	dial(t, server, "/ws/convoys/"+testConvoy2)
+	waitForConnectionCount(t, hub, testConvoy2, 1)
+
+	overflow := dial(t, server, "/ws/convoys/"+testConvoy1)
+	expectClose(t, overflow, websocket.CloseTryAgainLater)
+
+	// Existing connections are still served
+	hub.Broadcast(testConvoy1, map[string]string{"type": "test"})
+	first.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, _, err := first.ReadMessage(); err != nil {
+		t.Errorf("Expected the existing connection to keep receiving broadcasts, got %v", err)
+	}
+}
@@ -0,0 +1,176 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// sseBufferSize is how many messages an SSE subscriber may fall behind
+	// before the hub drops it; the client's EventSource reconnects on its own
+	sseBufferSize = 32
+
+	// sseKeepAliveInterval keeps proxies from closing an idle stream
+	sseKeepAliveInterval = 25 * time.Second
+)
+
+// sseSubscriber receives a convoy's broadcasts for a Server-Sent Events
+// stream. Unlike WebSocket connections, the hub never writes to the client
+// itself: messages are queued for the stream's handler to write.
+type sseSubscriber struct {
+	messages chan []byte
+	dropped  chan struct{} // closed when the hub drops the subscriber: it fell behind or the server is shutting down
+}
+
+// watcherCount returns the read-only subscribers of a convoy, WebSocket
+// spectators and SSE streams alike. Callers must hold h.mu.
+func (h *Hub) watcherCount(convoyID string) int {
+	return len(h.spectators[convoyID]) + len(h.sseSubscribers[convoyID])
+}
+
+// subscribeSSE adds an SSE subscriber to the convoy. It returns nil if the
+// convoy is at its spectator limit, which SSE streams share with spectators.
+func (h *Hub) subscribeSSE(convoyID string) *sseSubscriber {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.watcherCount(convoyID) >= h.maxSpectators {
+		return nil
+	}
+	sub := &sseSubscriber{
+		messages: make(chan []byte, sseBufferSize),
+		dropped:  make(chan struct{}),
+	}
+	if h.sseSubscribers[convoyID] == nil {
+		h.sseSubscribers[convoyID] = make(map[*sseSubscriber]bool)
+	}
+	h.sseSubscribers[convoyID][sub] = true
+	log.Printf("SSE subscriber registered for convoy %s (total SSE subscribers for convoy: %d)",
+		convoyID, len(h.sseSubscribers[convoyID]))
+	return sub
+}
+
+// unsubscribeSSE removes an SSE subscriber. If drop is true the subscriber's
+// dropped channel is closed; this happens at most once, by whoever removes it.
+func (h *Hub) unsubscribeSSE(convoyID string, sub *sseSubscriber, drop bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	subscribers := h.sseSubscribers[convoyID]
+	if !subscribers[sub] {
+		return
+	}
+	delete(subscribers, sub)
+	if len(subscribers) == 0 {
+		delete(h.sseSubscribers, convoyID)
+	}
+	if drop {
+		close(sub.dropped)
+	}
+}
+
+// GetSSESubscriberCount returns the number of SSE streams open for a convoy
+func (h *Hub) GetSSESubscriberCount(convoyID string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.sseSubscribers[convoyID])
+}
+
+// SSEHandler streams a convoy's broadcasts as Server-Sent Events, for clients
+// behind proxies that block WebSockets. Each snapshot, update and alert is
+// sent as one data frame carrying the same JSON as the WebSocket message.
+// Like spectators, streams are read-only, and they likewise need a member's
+// ?memberId=&token= when connect tokens are required.
+func (h *Hub) SSEHandler(w http.ResponseWriter, r *http.Request) {
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	if h.requireConnectToken && h.authenticatedMember(r, convoyID) == 0 {
+		log.Printf("SSE: rejected stream for convoy %s without a valid connect token", convoyID)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var snapshot []byte
+	if h.convoyProvider != nil {
+		convoy, err := h.convoyProvider.GetConvoy(r.Context(), convoyID)
+		if err != nil {
+			http.Error(w, "Convoy not found", http.StatusNotFound)
+			return
+		}
+		snapshot, err = json.Marshal(snapshotMessage{Convoy: convoy.WithoutNotes(), ProtocolVersion: ProtocolVersion})
+		if err != nil {
+			log.Printf("Error marshalling snapshot for convoy %s: %v", convoyID, err)
+			snapshot = nil
+		}
+	}
+
+	// Subscribe before writing the snapshot so no broadcast falls in between;
+	// only this handler writes to the stream, so the two can't interleave
+	sub := h.subscribeSSE(convoyID)
+	if sub == nil {
+		log.Printf("Rejecting SSE subscriber for convoy %s: spectator limit reached", convoyID)
+		w.Header().Set("Retry-After", "30")
+		http.Error(w, "Convoy is at its spectator limit", http.StatusServiceUnavailable)
+		return
+	}
+	defer h.unsubscribeSSE(convoyID, sub, false)
+
+	// The stream outlives the server's write timeout
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // disable nginx response buffering
+	header.Set(ProtocolVersionHeader, strconv.Itoa(ProtocolVersion))
+	w.WriteHeader(http.StatusOK)
+
+	// The snapshot was read just now, so the last broadcast can only be as
+	// new or older; replaying it would roll the client back
+	if snapshot != nil {
+		writeSSEData(w, h.stampSequence(convoyID, snapshot))
+	} else if data, ok := h.lastBroadcast(convoyID); ok {
+		writeSSEData(w, data)
+	}
+	if err := rc.Flush(); err != nil {
+		log.Printf("SSE stream for convoy %s can't be flushed: %v", convoyID, err)
+		return
+	}
+	log.Printf("SSE stream established for convoy %s", convoyID)
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			log.Printf("SSE stream for convoy %s closed by client", convoyID)
+			return
+		case <-sub.dropped:
+			log.Printf("SSE stream for convoy %s dropped by the server", convoyID)
+			return
+		case data := <-sub.messages:
+			writeSSEData(w, data)
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeSSEData writes one event. Marshalled JSON never contains newlines, so
+// a single data line carries the whole message.
+func writeSSEData(w http.ResponseWriter, data []byte) {
+	fmt.Fprintf(w, "data: %s\n\n", data)
+}
@@ -0,0 +1,230 @@
+package ws
+
+import (
+	"bufio"
+	"context"
+	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/storage"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// readSSEData reads lines until the next data frame and returns its payload
+func readSSEData(t *testing.T, reader *bufio.Reader) []byte {
+	t.Helper()
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read SSE stream: %v", err)
+		}
+		if data, ok := strings.CutPrefix(strings.TrimRight(line, "\n"), "data: "); ok {
+			return []byte(data)
+		}
+	}
+}
+
+func TestSSEHandlerStreamsSnapshotAndBroadcasts(t *testing.T) {
+	memStorage := storage.NewMemoryStorage()
+	hub := NewHub()
+	hub.SetConvoyProvider(memStorage)
+
+	convoy, err := memStorage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/convoys/{convoyId}/events/stream", hub.SSEHandler)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/convoys/"+convoy.ID+"/events/stream", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Expected an event stream, got %q", ct)
+	}
+	reader := bufio.NewReader(resp.Body)
+
+	var snapshot domain.Convoy
+	if err := json.Unmarshal(readSSEData(t, reader), &snapshot); err != nil || snapshot.ID != convoy.ID {
+		t.Fatalf("Expected the convoy snapshot first, got %+v (%v)", snapshot, err)
+	}
+
+	hub.Broadcast(convoy.ID, &domain.ConvoyAlert{EventType: domain.EventMemberLagging, ConvoyID: convoy.ID})
+	var alert domain.ConvoyAlert
+	if err := json.Unmarshal(readSSEData(t, reader), &alert); err != nil || alert.EventType != domain.EventMemberLagging {
+		t.Fatalf("Expected the broadcast alert, got %+v (%v)", alert, err)
+	}
+
+	// Disconnecting unsubscribes the stream
+	cancel()
+	deadline := time.Now().Add(2 * time.Second)
+	for hub.GetSSESubscriberCount(convoy.ID) != 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := hub.GetSSESubscriberCount(convoy.ID); n != 0 {
+		t.Errorf("Expected the subscriber to be removed on disconnect, got %d", n)
+	}
+}
+
+func TestSSEHandlerSkipsReplayAfterSnapshot(t *testing.T) {
+	memStorage := storage.NewMemoryStorage()
+	hub := NewHub()
+	hub.SetConvoyProvider(memStorage)
+
+	convoy, err := memStorage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	hub.Broadcast(convoy.ID, &domain.ConvoyAlert{EventType: domain.EventMemberLagging, ConvoyID: convoy.ID})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/convoys/{convoyId}/events/stream", hub.SSEHandler)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/convoys/" + convoy.ID + "/events/stream")
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+	readSSEData(t, reader) // the snapshot
+
+	hub.Broadcast(convoy.ID, &domain.ConvoyAlert{EventType: domain.EventMemberDisconnected, ConvoyID: convoy.ID})
+	var alert domain.ConvoyAlert
+	if err := json.Unmarshal(readSSEData(t, reader), &alert); err != nil || alert.EventType != domain.EventMemberDisconnected {
+		t.Errorf("Expected the new broadcast right after the snapshot, not the older one, got %+v (%v)", alert, err)
+	}
+}
+
+func TestCloseAllEndsSSEStreams(t *testing.T) {
+	memStorage := storage.NewMemoryStorage()
+	hub := NewHub()
+	hub.SetConvoyProvider(memStorage)
+
+	convoy, err := memStorage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/convoys/{convoyId}/events/stream", hub.SSEHandler)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/convoys/" + convoy.ID + "/events/stream")
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	defer resp.Body.Close()
+	readSSEData(t, bufio.NewReader(resp.Body)) // the snapshot
+
+	hub.CloseAll(websocket.CloseServiceRestart, "server restarting")
+	if count := hub.GetSSESubscriberCount(convoy.ID); count != 0 {
+		t.Errorf("Expected no SSE subscribers after CloseAll, got %d", count)
+	}
+
+	// With the handler gone, shutdown doesn't wait for the stream
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := server.Config.Shutdown(ctx); err != nil {
+		t.Errorf("Expected shutdown to finish once streams are closed, got %v", err)
+	}
+}
+
+func TestSSEHandlerRequiresConnectToken(t *testing.T) {
+	memStorage := storage.NewMemoryStorage()
+	hub := NewHub()
+	hub.SetConvoyProvider(memStorage)
+	hub.SetRequireConnectToken(true)
+
+	convoy, err := memStorage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	member := &domain.Member{ID: 1, Name: "TestMember1"}
+	member.SetConnectToken("valid-token")
+	if err := memStorage.AddMember(context.Background(), convoy.ID, member); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+
+	for query, expected := range map[string]int{
+		"":                              http.StatusUnauthorized,
+		"?memberId=1":                   http.StatusUnauthorized,
+		"?memberId=1&token=wrong-token": http.StatusUnauthorized,
+		"?memberId=1&token=valid-token": http.StatusOK,
+	} {
+		ctx, cancel := context.WithCancel(context.Background())
+		req := httptest.NewRequestWithContext(ctx, http.MethodGet, "/api/convoys/"+convoy.ID+"/events/stream"+query, nil)
+		req.SetPathValue("convoyId", convoy.ID)
+		rec := httptest.NewRecorder()
+		if expected == http.StatusOK {
+			cancel() // the stream ends right after the snapshot
+		}
+		hub.SSEHandler(rec, req)
+		cancel()
+		if rec.Code != expected {
+			t.Errorf("Expected %d for %q, got %d", expected, query, rec.Code)
+		}
+	}
+}
+
+func TestSSEHandlerUnknownConvoy(t *testing.T) {
+	hub := NewHub()
+	hub.SetConvoyProvider(storage.NewMemoryStorage())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/convoys/"+missingConvoyID+"/events/stream", nil)
+	req.SetPathValue("convoyId", missingConvoyID)
+	rec := httptest.NewRecorder()
+	hub.SSEHandler(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404, got %d", rec.Code)
+	}
+}
+
+func TestSSEHandlerRejectsMalformedConvoyID(t *testing.T) {
+	hub := NewHub()
+	hub.SetConvoyProvider(storage.NewMemoryStorage())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/convoys/convoy-1/events/stream", nil)
+	req.SetPathValue("convoyId", "convoy-1")
+	rec := httptest.NewRecorder()
+	hub.SSEHandler(rec, req)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "INVALID_CONVOY_ID") {
+		t.Errorf("Expected 400 INVALID_CONVOY_ID, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestSlowSSESubscriberIsDropped(t *testing.T) {
+	hub := NewHub()
+	sub := hub.subscribeSSE(testConvoy1)
+	for i := 0; i <= sseBufferSize; i++ {
+		hub.Broadcast(testConvoy1, map[string]int{"n": i})
+	}
+
+	select {
+	case <-sub.dropped:
+	default:
+		t.Fatal("Expected a subscriber with a full buffer to be dropped")
+	}
+	if n := hub.GetSSESubscriberCount(testConvoy1); n != 0 {
+		t.Errorf("Expected the dropped subscriber to be removed, got %d", n)
+	}
+	if stats := hub.GetConvoyStats(testConvoy1); stats.Messages != sseBufferSize+1 {
+		t.Errorf("Expected every broadcast to be counted, got %d", stats.Messages)
+	}
+}
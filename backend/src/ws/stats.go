@@ -0,0 +1,90 @@
+package ws
+
+import (
+	"time"
+)
+
+// ConvoyStats counts the traffic the hub has broadcast to one convoy
+type ConvoyStats struct {
+	Messages int64     `json:"messages"` // broadcasts made, whether or not anyone was connected
+	Bytes    int64     `json:"bytes"`    // bytes written, summed over every connection that received them
+	Since    time.Time `json:"since"`    // first broadcast counted
+}
+
+// recordBroadcast counts one broadcast of size bytes delivered to recipients
+// connections. Failed writes must not be counted as recipients.
+func (h *Hub) recordBroadcast(convoyID string, size, recipients int) {
+	h.statsMu.Lock()
+	defer h.statsMu.Unlock()
+
+	stats, ok := h.stats[convoyID]
+	if !ok {
+		stats = &ConvoyStats{Since: time.Now()}
+		h.stats[convoyID] = stats
+	}
+	stats.Messages++
+	stats.Bytes += int64(size) * int64(recipients)
+}
+
+// recordDeferredDelivery counts the bytes of a broadcast that a connection
+// took on a retry; the message itself was counted when it was broadcast
+func (h *Hub) recordDeferredDelivery(convoyID string, size int) {
+	h.statsMu.Lock()
+	defer h.statsMu.Unlock()
+
+	if stats, ok := h.stats[convoyID]; ok {
+		stats.Bytes += int64(size)
+	}
+}
+
+// GetConvoyStats returns the broadcast counters of a convoy, zero if it has
+// never been broadcast to
+func (h *Hub) GetConvoyStats(convoyID string) ConvoyStats {
+	h.statsMu.Lock()
+	defer h.statsMu.Unlock()
+
+	if stats, ok := h.stats[convoyID]; ok {
+		return *stats
+	}
+	return ConvoyStats{}
+}
+
+// GetAllConvoyStats returns a copy of the broadcast counters of every convoy
+func (h *Hub) GetAllConvoyStats() map[string]ConvoyStats {
+	h.statsMu.Lock()
+	defer h.statsMu.Unlock()
+
+	all := make(map[string]ConvoyStats, len(h.stats))
+	for convoyID, stats := range h.stats {
+		all[convoyID] = *stats
+	}
+	return all
+}
+
+// PruneConvoys forgets the counters, sequence numbers and replay cache of
+// convoys for which exists returns false, so removed convoys start from zero
+// if an ID is reused
+func (h *Hub) PruneConvoys(exists func(convoyID string) bool) {
+	h.statsMu.Lock()
+	var removed []string
+	for convoyID := range h.stats {
+		if !exists(convoyID) {
+			removed = append(removed, convoyID)
+			delete(h.stats, convoyID)
+		}
+	}
+	h.statsMu.Unlock()
+
+	h.replayMu.Lock()
+	for _, convoyID := range removed {
+		delete(h.lastBroadcasts, convoyID)
+	}
+	h.replayMu.Unlock()
+
+	h.seqMu.Lock()
+	for _, convoyID := range removed {
+		delete(h.sequences, convoyID)
+		delete(h.broadcastLocks, convoyID)
+	}
+	h.seqMu.Unlock()
+}
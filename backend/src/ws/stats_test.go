@@ -0,0 +1,77 @@
+package ws
+
+import (
+	"convoy-app/backend/src/domain"
+	"encoding/json"
+	"testing"
+)
+
+func TestBroadcastUpdatesConvoyStats(t *testing.T) {
+	hub := NewHub()
+	hub.SetReplayRetention(0)
+	server := newTestServer(t, hub)
+
+	dial(t, server, "/ws/convoys/"+testConvoy1)
+	dial(t, server, "/ws/convoys/"+testConvoy1)
+	waitForConnectionCount(t, hub, testConvoy1, 2)
+
+	alert := &domain.ConvoyAlert{EventType: domain.EventMemberLagging, ConvoyID: testConvoy1}
+	data, _ := json.Marshal(alert)
+	const n = 5
+	for i := 0; i < n; i++ {
+		hub.Broadcast(testConvoy1, alert)
+	}
+
+	stats := hub.GetConvoyStats(testConvoy1)
+	if stats.Messages != n {
+		t.Errorf("Expected %d messages, got %d", n, stats.Messages)
+	}
+	if want := int64(n * 2 * len(data)); stats.Bytes != want {
+		t.Errorf("Expected %d bytes for two connections, got %d", want, stats.Bytes)
+	}
+	if stats.Since.IsZero() {
+		t.Error("Expected the counters to record when they started")
+	}
+
+	// Close one server-side connection: the message it can't queue must not be counted
+	hub.mu.RLock()
+	var broken *Connection
+	for conn := range hub.connections[testConvoy1] {
+		broken = conn
+		break
+	}
+	hub.mu.RUnlock()
+	broken.Close()
+
+	hub.Broadcast(testConvoy1, alert)
+	stats = hub.GetConvoyStats(testConvoy1)
+	if stats.Messages != n+1 {
+		t.Errorf("Expected %d messages, got %d", n+1, stats.Messages)
+	}
+	if want := int64((n*2 + 1) * len(data)); stats.Bytes != want {
+		t.Errorf("Expected only the healthy connection to add bytes (%d), got %d", want, stats.Bytes)
+	}
+
+	if other := hub.GetConvoyStats(testConvoy2); other.Messages != 0 || other.Bytes != 0 {
+		t.Errorf("Expected no stats for an unused convoy, got %+v", other)
+	}
+}
+
+func TestPruneConvoysResetsStats(t *testing.T) {
+	hub := NewHub()
+	hub.Broadcast(testConvoy1, map[string]string{"type": "test"})
+	hub.Broadcast(testConvoy2, map[string]string{"type": "test"})
+
+	hub.PruneConvoys(func(convoyID string) bool { return convoyID == testConvoy2 })
+
+	all := hub.GetAllConvoyStats()
+	if _, ok := all[testConvoy1]; ok {
+		t.Error("Expected the removed convoy's stats to be dropped")
+	}
+	if all[testConvoy2].Messages != 1 {
+		t.Errorf("Expected the remaining convoy to keep its stats, got %+v", all[testConvoy2])
+	}
+	if _, ok := hub.lastBroadcast(testConvoy1); ok {
+		t.Error("Expected the removed convoy's replay cache to be dropped")
+	}
+}
@@ -1,164 +1,289 @@
 package ws
 
 import (
+	"context"
+	"convoy-app/backend/src/domain"
+	"encoding/json"
 	"log"
 	"net/http"
-	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/websocket"
 )
 
-var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		origin := r.Header.Get("Origin")
+// CloseConvoyNotFound is the application close code sent when the requested convoy does not exist
+const CloseConvoyNotFound = 4404
 
-		// Allow empty origin (for testing tools)
-		if origin == "" {
-			return true
-		}
+// CloseSessionReplaced is sent to a member's connection when the member opens a newer one
+const CloseSessionReplaced = 4410
 
-		// Allow localhost development (both HTTP and HTTPS)
-		if origin == "http://localhost:3000" || origin == "http://127.0.0.1:3000" ||
-			origin == "https://localhost:3000" || origin == "https://127.0.0.1:3000" ||
-			origin == "http://localhost:8000" || origin == "http://127.0.0.1:8000" ||
-			origin == "https://localhost:8000" || origin == "https://127.0.0.1:8000" {
-			return true
-		}
+// CloseMemberAlreadyConnected is sent to a member's second connection when single sessions are enforced
+const CloseMemberAlreadyConnected = 4409
 
-		// Allow Caddy HTTPS proxy (192.168.1.18:443)
-		if origin == "https://192.168.1.18" || origin == "https://192.168.1.18:443" {
-			return true
-		}
+// MessageTypeHeartbeat is sent by clients while the app is open, even when GPS is paused
+const MessageTypeHeartbeat = "HEARTBEAT"
 
-		// Allow ngrok domains (for internet access)
-		// Check for ngrok-free.app, ngrok-free.dev, ngrok.app, or ngrok.io domains
-		if len(origin) > 8 && origin[:8] == "https://" {
-			hostname := origin[8:]
-
-			// Remove port if present
-			if colonIdx := len(hostname) - 1; colonIdx > 0 {
-				for i := len(hostname) - 1; i >= 0; i-- {
-					if hostname[i] == ':' {
-						hostname = hostname[:i]
-						break
-					}
-					if hostname[i] == '/' {
-						break
-					}
-				}
-			}
+// ProtocolV1 is the WebSocket subprotocol for the current message schema.
+// Clients that send no Sec-WebSocket-Protocol header are served it too.
+const ProtocolV1 = "convoy.v1"
 
-			// Allow *.ngrok-free.dev (current free tier domain) - 15 characters
-			if len(hostname) > 15 && hostname[len(hostname)-15:] == ".ngrok-free.dev" {
-				log.Printf("WebSocket: Allowing ngrok free domain (.ngrok-free.dev): %s", origin)
-				return true
-			}
-			// Allow *.ngrok-free.app (older free tier domain) - 15 characters
-			if len(hostname) > 15 && hostname[len(hostname)-15:] == ".ngrok-free.app" {
-				log.Printf("WebSocket: Allowing ngrok free domain (.ngrok-free.app): %s", origin)
-				return true
-			}
-			// Allow *.ngrok.app (paid tier domain) - 10 characters
-			if len(hostname) > 10 && hostname[len(hostname)-10:] == ".ngrok.app" {
-				log.Printf("WebSocket: Allowing ngrok paid domain (.ngrok.app): %s", origin)
-				return true
-			}
-			// Allow *.ngrok.io (legacy domain) - 9 characters
-			if len(hostname) > 9 && hostname[len(hostname)-9:] == ".ngrok.io" {
-				log.Printf("WebSocket: Allowing ngrok legacy domain (.ngrok.io): %s", origin)
-				return true
-			}
-		}
+// ProtocolVersion is the schema version announced in the initial snapshot
+const ProtocolVersion = 1
 
-		// Allow custom origin from environment variable (for production or custom setups)
-		if customOrigin := os.Getenv("ALLOWED_ORIGIN"); customOrigin != "" {
-			if origin == customOrigin {
-				log.Printf("WebSocket: Allowing custom origin from env: %s", origin)
-				return true
-			}
-		}
+// ProtocolVersionHeader carries ProtocolVersion on the upgrade response
+const ProtocolVersionHeader = "X-Convoy-Protocol-Version"
 
-		// Allow local network IPs on port 3000 (for mobile testing)
-		if len(origin) > 7 && origin[:7] == "http://" {
-			// Extract the part after "http://"
-			hostPort := origin[7:]
+// supportedProtocols lists the subprotocols this server speaks, newest first
+var supportedProtocols = []string{ProtocolV1}
 
-			// Check if it ends with ":3000"
-			if len(hostPort) > 5 && hostPort[len(hostPort)-5:] == ":3000" {
-				// Extract the IP part
-				ip := hostPort[:len(hostPort)-5]
+// RoleSpectator, passed as ?role=spectator, connects a read-only watcher that
+// is not a convoy member. When connect tokens are required it must pass a
+// member's memberId and token like any other connection.
+const RoleSpectator = "spectator"
 
-				// Allow private IP ranges commonly used in local networks
-				return isPrivateIPForWebSocket(ip)
-			}
-		}
+// clientMessage is the envelope for frames sent by clients
+type clientMessage struct {
+	Type    string `json:"type"`
+	AlertID string `json:"alertId,omitempty"` // set on ACK messages
+	Events  string `json:"events,omitempty"`  // set on SUBSCRIBE messages: EventFilterAll or EventFilterSelf
+}
 
-		return false
-	},
+// upgrader is copied per connection, which sets CheckOrigin to the hub's policy
+var upgrader = websocket.Upgrader{
+	Subprotocols:    supportedProtocols,
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
 }
 
-// isPrivateIPForWebSocket checks if an IP address is in a private range for WebSocket connections
-func isPrivateIPForWebSocket(ip string) bool {
-	// Common private IP ranges:
-	// 192.168.x.x (most common home networks)
-	// 10.x.x.x (corporate networks)
-	// 172.16.x.x - 172.31.x.x (less common)
-	// 127.x.x.x (localhost)
-
-	if len(ip) >= 7 {
-		// Check 192.168.x.x
-		if len(ip) >= 8 && ip[:8] == "192.168." {
-			return true
-		}
+// snapshotMessage is the first frame sent on a connection
+type snapshotMessage struct {
+	*domain.Convoy
+	ProtocolVersion int `json:"protocolVersion"`
+}
 
-		// Check 10.x.x.x
-		if len(ip) >= 3 && ip[:3] == "10." {
-			return true
+// supportsRequestedProtocol reports whether the client either asked for no
+// subprotocol or asked for at least one this server supports
+func supportsRequestedProtocol(r *http.Request) bool {
+	requested := websocket.Subprotocols(r)
+	if len(requested) == 0 {
+		return true
+	}
+	for _, protocol := range requested {
+		for _, supported := range supportedProtocols {
+			if protocol == supported {
+				return true
+			}
 		}
+	}
+	return false
+}
 
-		// Check 127.x.x.x (localhost)
-		if len(ip) >= 4 && ip[:4] == "127." {
-			return true
-		}
+// checkOrigin applies the shared CORS origin policy to WebSocket upgrades
+func (h *Hub) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
 
-		// Check 172.16.x.x - 172.31.x.x
-		if len(ip) >= 7 && ip[:4] == "172." {
-			// This is a simplified check - in production you'd want more precise validation
-			return true
-		}
+	// Allow empty origin (for testing tools)
+	if origin == "" {
+		return true
 	}
 
-	return false
+	if !h.originPolicy.Allows(origin) {
+		log.Printf("WebSocket: Rejected origin %s", origin)
+		return false
+	}
+	return true
+}
+
+// sendSnapshot writes the current convoy state to a new connection of
+// memberID (0 for spectators and connections without a valid connect token)
+// as its first message. Member notes are left out unless memberID is the
+// leader. It reports whether a snapshot was sent, and false for ok if the
+// connection should be dropped.
+func (h *Hub) sendSnapshot(ctx context.Context, convoyID string, memberID int64, conn *websocket.Conn) (sent, ok bool) {
+	if h.convoyProvider == nil {
+		return false, true
+	}
+
+	convoy, err := h.convoyProvider.GetConvoy(ctx, convoyID)
+	if err != nil {
+		log.Printf("WebSocket: convoy %s not found, closing connection: %v", convoyID, err)
+		closeMsg := websocket.FormatCloseMessage(CloseConvoyNotFound, "convoy not found")
+		conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(time.Second))
+		return false, false
+	}
+
+	if memberID == 0 || memberID != convoy.LeaderID {
+		convoy = convoy.WithoutNotes()
+	}
+
+	data, err := json.Marshal(snapshotMessage{Convoy: convoy, ProtocolVersion: ProtocolVersion})
+	if err != nil {
+		log.Printf("Error marshalling snapshot for convoy %s: %v", convoyID, err)
+		return false, true
+	}
+	data = h.stampSequence(convoyID, data)
+
+	if err := h.writeText(conn, data); err != nil {
+		log.Printf("Failed to send snapshot to convoy %s: %v", convoyID, err)
+		return false, false
+	}
+	return true, true
+}
+
+// authenticatedMember returns the member ID in the query string if the
+// connect token next to it is valid, or 0. It applies whether or not tokens
+// are required: anything only one member may see or do is keyed on it, since
+// member IDs alone are public.
+func (h *Hub) authenticatedMember(r *http.Request, convoyID string) int64 {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		return 0
+	}
+	if h.convoyProvider == nil {
+		log.Printf("WebSocket: cannot check connect tokens without a convoy provider")
+		return 0
+	}
+
+	memberID, err := strconv.ParseInt(r.URL.Query().Get("memberId"), 10, 64)
+	if err != nil {
+		log.Printf("WebSocket: connect token for convoy %s without a valid member ID", convoyID)
+		return 0
+	}
+	if err := h.convoyProvider.ValidateConnectToken(r.Context(), convoyID, memberID, token); err != nil {
+		log.Printf("WebSocket: invalid connect token for member %d in convoy %s: %v", memberID, convoyID, err)
+		return 0
+	}
+	return memberID
+}
+
+// convoyIDFromPath returns the convoyId path value, or writes a 400
+// INVALID_CONVOY_ID and returns false if it can't be a convoy ID, the same
+// way the REST API does
+func convoyIDFromPath(w http.ResponseWriter, r *http.Request) (string, bool) {
+	convoyID := r.PathValue("convoyId")
+	if !domain.IsValidConvoyID(convoyID) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid convoy ID", "code": "INVALID_CONVOY_ID"})
+		return "", false
+	}
+	return convoyID, true
 }
 
 // Handler handles WebSocket connections.
 func (h *Hub) Handler(w http.ResponseWriter, r *http.Request) {
-	convoyID := r.PathValue("convoyId")
-	if convoyID == "" {
-		http.Error(w, "Convoy ID is required", http.StatusBadRequest)
+	convoyID, ok := convoyIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	// WebSocket upgrades only exist in HTTP/1.1; over HTTP/2 the upgrader
+	// fails with a vague handshake error. Proxies in front of this endpoint
+	// must talk HTTP/1.1 to it.
+	if r.ProtoMajor != 1 {
+		log.Printf("WebSocket: rejected %s connection to convoy %s; upgrades require HTTP/1.1", r.Proto, convoyID)
+		http.Error(w, "WebSocket connections require HTTP/1.1, got "+r.Proto, http.StatusUpgradeRequired)
+		return
+	}
+
+	// Spectators never act as a member. When tokens are required they still
+	// need a member's connect token, so live locations are only shown to
+	// someone a member let watch.
+	spectator := r.URL.Query().Get("role") == RoleSpectator
+
+	verifiedID := h.authenticatedMember(r, convoyID)
+	if h.requireConnectToken && verifiedID == 0 {
+		log.Printf("WebSocket: rejected connection to convoy %s without a valid connect token", convoyID)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Old clients must not silently receive messages they can't parse
+	if !supportsRequestedProtocol(r) {
+		log.Printf("WebSocket: rejected connection to convoy %s requesting unsupported subprotocols %v", convoyID, websocket.Subprotocols(r))
+		http.Error(w, "Unsupported WebSocket subprotocol; supported: "+strings.Join(supportedProtocols, ", "), http.StatusBadRequest)
 		return
 	}
 
-	conn, err := upgrader.Upgrade(w, r, nil)
+	connUpgrader := upgrader
+	connUpgrader.CheckOrigin = h.checkOrigin
+	connUpgrader.EnableCompression = h.compressionEnabled
+
+	responseHeader := http.Header{}
+	responseHeader.Set(ProtocolVersionHeader, strconv.Itoa(ProtocolVersion))
+	conn, err := connUpgrader.Upgrade(w, r, responseHeader)
 	if err != nil {
 		log.Printf("Error upgrading to WebSocket: %v", err)
 		return
 	}
 
-	// Register this specific connection
-	h.Register(convoyID, conn)
+	// Turn away connections over a limit before doing any work for them. The
+	// close frame, rather than an HTTP error, is what browsers can observe.
+	hasCapacity := h.HasCapacity
+	if spectator {
+		hasCapacity = h.HasSpectatorCapacity
+	}
+	if h.Shedding() {
+		log.Printf("Rejecting connection for convoy %s: shedding load", convoyID)
+		h.rejectOverLimit(conn, "server is shedding load")
+		return
+	}
+	if ok, reason := hasCapacity(convoyID); !ok {
+		log.Printf("Rejecting connection for convoy %s: %s", convoyID, reason)
+		h.rejectOverLimit(conn, reason)
+		return
+	}
+
+	// Only the leader's connections see member notes, so the first messages
+	// depend on which member the connection proved to be. Spectators never
+	// act as a member, whatever token they hold.
+	var noteReaderID int64
+	if !spectator {
+		noteReaderID = verifiedID
+	}
+
+	// Send the current convoy state before registering, so the snapshot write
+	// can't interleave with a concurrent Broadcast to this connection
+	sent, ok := h.sendSnapshot(r.Context(), convoyID, noteReaderID, conn)
+	if !ok {
+		conn.Close()
+		return
+	}
 
-	// Check if member ID is provided in query parameters
+	// Without a snapshot, fall back to the last broadcast. A snapshot was read
+	// just now, so replaying the last broadcast after it would roll the client
+	// back, as on the SSE stream.
+	if !sent && !h.replayLastBroadcast(convoyID, noteReaderID, conn) {
+		conn.Close()
+		return
+	}
+
+	// From here on the write pump is the connection's only data writer
+	client := NewConnection(conn, convoyID, h)
+
+	// Register the connection, as a spectator or as a member if a member ID
+	// is provided in query parameters. Spectators keep memberID 0.
 	memberIDStr := r.URL.Query().Get("memberId")
 	var memberID int64
-	if memberIDStr != "" {
+	if spectator {
+		if !h.RegisterSpectator(convoyID, client) {
+			return
+		}
+		log.Printf("WebSocket spectator connection established for convoy %s", convoyID)
+	} else if !h.Register(convoyID, client) {
+		return
+	} else if memberIDStr != "" {
 		if parsedID, err := strconv.ParseInt(memberIDStr, 10, 64); err == nil {
+			client.memberID = parsedID
+			client.authenticated = verifiedID != 0 && parsedID == verifiedID
+			if !h.RegisterMember(convoyID, parsedID, client) {
+				h.Unregister(convoyID, client)
+				client.closeWithMessage(CloseMemberAlreadyConnected, "member already connected")
+				return
+			}
 			memberID = parsedID
-			h.RegisterMember(convoyID, memberID, conn)
 			log.Printf("WebSocket connection established for convoy %s with member %d", convoyID, memberID)
 		} else {
 			log.Printf("WebSocket connection established for convoy %s (invalid member ID: %s)", convoyID, memberIDStr)
@@ -168,55 +293,25 @@ func (h *Hub) Handler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	defer func() {
-		h.Unregister(convoyID, conn)
-		if memberID != 0 {
-			h.UnregisterMember(convoyID, memberID)
-			log.Printf("WebSocket cleanup: Member %d unregistered from convoy %s", memberID, convoyID)
-		}
-		conn.Close()
+		// Unregister also drops the member association, but only if it still
+		// points at this connection and not at a newer one that replaced it
+		h.Unregister(convoyID, client)
+		client.Close()
 		log.Printf("WebSocket handler cleanup completed for convoy %s", convoyID)
 	}()
 
-	// Configure ping/pong handling
-	const (
-		writeWait  = 10 * time.Second
-		pongWait   = 60 * time.Second
-		pingPeriod = (pongWait * 9) / 10
-	)
-
+	// Configure ping/pong handling; the write pump sends the pings
 	conn.SetReadDeadline(time.Now().Add(pongWait))
 	conn.SetPongHandler(func(string) error {
 		conn.SetReadDeadline(time.Now().Add(pongWait))
+		client.touch()
 		return nil
 	})
-
-	// Start ping ticker
-	ticker := time.NewTicker(pingPeriod)
-	defer ticker.Stop()
-
-	// Channel to signal when to stop
-	done := make(chan struct{})
-
-	// Goroutine to handle ping messages
-	go func() {
-		defer close(done)
-		for {
-			select {
-			case <-ticker.C:
-				conn.SetWriteDeadline(time.Now().Add(writeWait))
-				if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
-					log.Printf("Failed to send ping to convoy %s (member %d): %v", convoyID, memberID, err)
-					return
-				}
-			case <-done:
-				return
-			}
-		}
-	}()
+	client.Start()
 
 	// Main message reading loop
 	for {
-		messageType, _, err := conn.ReadMessage()
+		messageType, data, err := conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure, websocket.CloseNormalClosure) {
 				log.Printf("WebSocket unexpected close for convoy %s (member %d): %v", convoyID, memberID, err)
@@ -228,14 +323,35 @@ func (h *Hub) Handler(w http.ResponseWriter, r *http.Request) {
 
 		// Reset read deadline on any message
 		conn.SetReadDeadline(time.Now().Add(pongWait))
+		client.touch()
 
-		// Handle ping messages
-		if messageType == websocket.PingMessage {
-			conn.SetWriteDeadline(time.Now().Add(writeWait))
-			if err := conn.WriteMessage(websocket.PongMessage, nil); err != nil {
-				log.Printf("Failed to send pong for convoy %s: %v", convoyID, err)
-				break
-			}
+		if messageType == websocket.TextMessage {
+			h.handleClientMessage(r.Context(), client, convoyID, memberID, data)
+		}
+	}
+}
+
+// handleClientMessage processes a text frame sent by a client
+func (h *Hub) handleClientMessage(ctx context.Context, conn *Connection, convoyID string, memberID int64, data []byte) {
+	var msg clientMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		log.Printf("Ignoring malformed WebSocket message for convoy %s (member %d): %v", convoyID, memberID, err)
+		return
+	}
+
+	switch msg.Type {
+	case MessageTypeHeartbeat:
+		if memberID == 0 || h.convoyProvider == nil {
+			return
+		}
+		if err := h.convoyProvider.RecordHeartbeat(ctx, convoyID, memberID); err != nil {
+			log.Printf("Failed to record heartbeat for member %d in convoy %s: %v", memberID, convoyID, err)
+		}
+	case MessageTypeAck:
+		if msg.AlertID != "" {
+			h.acknowledge(conn, msg.AlertID)
 		}
+	case MessageTypeSubscribe:
+		conn.subscribe(msg.Events)
 	}
 }
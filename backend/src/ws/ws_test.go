@@ -0,0 +1,760 @@
+package ws
+
+import (
+	"context"
+	"convoy-app/backend/src/domain"
+	"convoy-app/backend/src/storage"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Convoy IDs in the format storage generates, which the handlers require
+const (
+	testConvoy1     = "00000000000000000000000000000001"
+	testConvoy2     = "00000000000000000000000000000002"
+	testConvoy3     = "00000000000000000000000000000003"
+	missingConvoyID = "ffffffffffffffffffffffffffffffff"
+)
+
+// newTestServer starts an HTTP server routing the WebSocket endpoint to the hub
+func newTestServer(t *testing.T, hub *Hub) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /ws/convoys/{convoyId}", hub.Handler)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// tokenProvider serves an empty convoy for any ID and accepts only the
+// connect token "valid", for tests that need authenticated members but no
+// stored convoy
+type tokenProvider struct{}
+
+func (tokenProvider) GetConvoy(ctx context.Context, convoyID string) (*domain.Convoy, error) {
+	return &domain.Convoy{ID: convoyID}, nil
+}
+
+func (tokenProvider) RecordHeartbeat(ctx context.Context, convoyID string, memberID int64) error {
+	return nil
+}
+
+func (tokenProvider) ValidateConnectToken(ctx context.Context, convoyID string, memberID int64, token string) error {
+	if token != "valid" {
+		return errors.New("invalid token")
+	}
+	return nil
+}
+
+// dial opens a WebSocket connection to the given path on the test server
+func dial(t *testing.T, server *httptest.Server, path string) *websocket.Conn {
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + path
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Failed to dial %s: %v", path, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestHandlerSendsSnapshotOnConnect(t *testing.T) {
+	memStorage := storage.NewMemoryStorage()
+	hub := NewHub()
+	hub.SetConvoyProvider(memStorage)
+
+	convoy, err := memStorage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	member := &domain.Member{ID: 1, Name: "TestMember1", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}}
+	if err := memStorage.AddMember(context.Background(), convoy.ID, member); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+
+	server := newTestServer(t, hub)
+	conn := dial(t, server, "/ws/convoys/"+convoy.ID+"?memberId=1")
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("Expected initial snapshot, got error: %v", err)
+	}
+
+	var snapshot domain.Convoy
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		t.Fatalf("Failed to decode snapshot: %v", err)
+	}
+	if snapshot.ID != convoy.ID {
+		t.Errorf("Expected snapshot for convoy %s, got %s", convoy.ID, snapshot.ID)
+	}
+	if len(snapshot.Members) != 1 || snapshot.Members[0].Name != "TestMember1" {
+		t.Errorf("Expected snapshot with TestMember1, got %+v", snapshot.Members)
+	}
+
+	var envelope struct {
+		ProtocolVersion int `json:"protocolVersion"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil || envelope.ProtocolVersion != ProtocolVersion {
+		t.Errorf("Expected protocolVersion %d in snapshot, got %d (%v)", ProtocolVersion, envelope.ProtocolVersion, err)
+	}
+}
+
+func TestHandlerSkipsReplayAfterSnapshot(t *testing.T) {
+	memStorage := storage.NewMemoryStorage()
+	hub := NewHub()
+	hub.SetConvoyProvider(memStorage)
+
+	convoy, err := memStorage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	hub.Broadcast(convoy.ID, &domain.ConvoyAlert{EventType: domain.EventMemberLagging, ConvoyID: convoy.ID})
+
+	server := newTestServer(t, hub)
+	conn := dial(t, server, "/ws/convoys/"+convoy.ID)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, _, err := conn.ReadMessage(); err != nil {
+		t.Fatalf("Expected initial snapshot, got error: %v", err)
+	}
+	waitForConnectionCount(t, hub, convoy.ID, 1)
+
+	hub.Broadcast(convoy.ID, &domain.ConvoyAlert{EventType: domain.EventMemberDisconnected, ConvoyID: convoy.ID})
+	var alert domain.ConvoyAlert
+	if err := conn.ReadJSON(&alert); err != nil || alert.EventType != domain.EventMemberDisconnected {
+		t.Errorf("Expected the new broadcast right after the snapshot, not the older one, got %+v (%v)", alert, err)
+	}
+}
+
+func TestHandlerNegotiatesSubprotocol(t *testing.T) {
+	hub := NewHub()
+	server := newTestServer(t, hub)
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/convoys/" + testConvoy1
+
+	dialer := websocket.Dialer{Subprotocols: []string{"convoy.v2", ProtocolV1}}
+	conn, resp, err := dialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Expected a supported subprotocol to connect, got %v", err)
+	}
+	defer conn.Close()
+	if conn.Subprotocol() != ProtocolV1 {
+		t.Errorf("Expected subprotocol %s, got %q", ProtocolV1, conn.Subprotocol())
+	}
+	if got := resp.Header.Get(ProtocolVersionHeader); got != "1" {
+		t.Errorf("Expected %s: 1, got %q", ProtocolVersionHeader, got)
+	}
+
+	// Clients that predate subprotocols are still served
+	legacy, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Expected a client without subprotocols to connect, got %v", err)
+	}
+	defer legacy.Close()
+}
+
+func TestHandlerRejectsUnsupportedSubprotocol(t *testing.T) {
+	hub := NewHub()
+	server := newTestServer(t, hub)
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/convoys/" + testConvoy1
+
+	dialer := websocket.Dialer{Subprotocols: []string{"convoy.v9"}}
+	conn, resp, err := dialer.Dial(url, nil)
+	if err == nil {
+		conn.Close()
+		t.Fatal("Expected an unsupported subprotocol to be rejected")
+	}
+	if resp == nil || resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for an unsupported subprotocol, got %v", resp)
+	}
+	if hub.GetConnectionCount(testConvoy1) != 0 {
+		t.Error("Expected no connection to be registered")
+	}
+}
+
+func TestHandlerRejectsHTTP2(t *testing.T) {
+	hub := NewHub()
+	req := httptest.NewRequest(http.MethodGet, "/ws/convoys/"+testConvoy1, nil)
+	req.Proto, req.ProtoMajor, req.ProtoMinor = "HTTP/2.0", 2, 0
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	req.SetPathValue("convoyId", testConvoy1)
+	rec := httptest.NewRecorder()
+
+	hub.Handler(rec, req)
+
+	if rec.Code != http.StatusUpgradeRequired {
+		t.Fatalf("Expected 426 for an HTTP/2 request, got %d", rec.Code)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "HTTP/1.1") {
+		t.Errorf("Expected the error to say HTTP/1.1 is required, got %q", body)
+	}
+	if hub.GetConnectionCount(testConvoy1) != 0 {
+		t.Error("Expected no connection to be registered")
+	}
+}
+
+func TestHandlerRejectsMalformedConvoyID(t *testing.T) {
+	hub := NewHub()
+	server := newTestServer(t, hub)
+
+	for _, convoyID := range []string{"convoy-1", "0123abcd", "0123456789ABCDEF0123456789ABCDEF"} {
+		url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/convoys/" + convoyID
+		_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+		if err == nil || resp == nil || resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected 400 for convoy ID %q, got %v", convoyID, resp)
+			continue
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if !strings.Contains(string(body), "INVALID_CONVOY_ID") {
+			t.Errorf("Expected INVALID_CONVOY_ID for %q, got %s", convoyID, body)
+		}
+	}
+}
+
+func TestHandlerClosesUnknownConvoy(t *testing.T) {
+	hub := NewHub()
+	hub.SetConvoyProvider(storage.NewMemoryStorage())
+
+	server := newTestServer(t, hub)
+	conn := dial(t, server, "/ws/convoys/"+missingConvoyID)
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err := conn.ReadMessage()
+
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) {
+		t.Fatalf("Expected close error, got %v", err)
+	}
+	if closeErr.Code != CloseConvoyNotFound {
+		t.Errorf("Expected close code %d, got %d", CloseConvoyNotFound, closeErr.Code)
+	}
+	if hub.GetTotalConnections() != 0 {
+		t.Errorf("Expected no registered connections, got %d", hub.GetTotalConnections())
+	}
+}
+
+// frameRecorder is a client-side net.Conn that keeps the raw bytes it reads,
+// so tests can look at frame headers the websocket library hides
+type frameRecorder struct {
+	net.Conn
+	mu   sync.Mutex
+	read []byte
+}
+
+func (c *frameRecorder) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.mu.Lock()
+	c.read = append(c.read, p[:n]...)
+	c.mu.Unlock()
+	return n, err
+}
+
+// takeFirstByte returns the first byte read since the last call and forgets the rest
+func (c *frameRecorder) takeFirstByte() byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.read) == 0 {
+		return 0
+	}
+	first := c.read[0]
+	c.read = nil
+	return first
+}
+
+func TestBroadcastWithCompression(t *testing.T) {
+	hub := NewHub()
+	hub.SetCompression(true, 256)
+	server := newTestServer(t, hub)
+
+	var recorder *frameRecorder
+	dialer := websocket.Dialer{
+		EnableCompression: true,
+		NetDial: func(network, addr string) (net.Conn, error) {
+			conn, err := net.Dial(network, addr)
+			if err != nil {
+				return nil, err
+			}
+			recorder = &frameRecorder{Conn: conn}
+			return recorder, nil
+		},
+	}
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/convoys/" + testConvoy1
+	conn, resp, err := dialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	if ext := resp.Header.Get("Sec-Websocket-Extensions"); !strings.Contains(ext, "permessage-deflate") {
+		t.Fatalf("Expected permessage-deflate to be negotiated, got %q", ext)
+	}
+
+	// Wait for the handler to register the connection
+	deadline := time.Now().Add(2 * time.Second)
+	for hub.GetConnectionCount(testConvoy1) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	recorder.takeFirstByte() // drop the handshake
+
+	small := map[string]string{"eventType": "MEMBER_LAGGING"}
+	large := map[string]string{"payload": strings.Repeat("member-location;", 200)}
+
+	const rsv1 = 0x40 // set on frames compressed with permessage-deflate
+	for _, message := range []map[string]string{small, large} {
+		hub.Broadcast(testConvoy1, message)
+
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("Failed to read broadcast: %v", err)
+		}
+
+		var received map[string]string
+		if err := json.Unmarshal(data, &received); err != nil {
+			t.Fatalf("Failed to decode broadcast: %v", err)
+		}
+		for key, value := range message {
+			if received[key] != value {
+				t.Errorf("Expected %s=%q to round-trip, got %q", key, value, received[key])
+			}
+		}
+
+		compressed := recorder.takeFirstByte()&rsv1 != 0
+		if wantCompressed := len(data) >= 256; compressed != wantCompressed {
+			t.Errorf("Expected a %d-byte frame to be compressed=%v, got %v", len(data), wantCompressed, compressed)
+		}
+	}
+}
+
+func TestHandlerRecordsHeartbeat(t *testing.T) {
+	memStorage := storage.NewMemoryStorage()
+	hub := NewHub()
+	hub.SetConvoyProvider(memStorage)
+
+	convoy, err := memStorage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	member := &domain.Member{ID: 1, Name: "TestMember1", Location: domain.LatLng{Lat: 40.0, Lng: -74.0}}
+	if err := memStorage.AddMember(context.Background(), convoy.ID, member); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+	lastUpdate := member.LastUpdate
+
+	server := newTestServer(t, hub)
+	conn := dial(t, server, "/ws/convoys/"+convoy.ID+"?memberId=1")
+
+	// Drain the snapshot
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, _, err := conn.ReadMessage(); err != nil {
+		t.Fatalf("Expected initial snapshot, got error: %v", err)
+	}
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"HEARTBEAT"}`)); err != nil {
+		t.Fatalf("Failed to send heartbeat: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		// GetMember copies under the storage lock, so this doesn't race the heartbeat
+		updated, err := memStorage.GetMember(context.Background(), convoy.ID, member.ID)
+		if err != nil {
+			t.Fatalf("Failed to get member: %v", err)
+		}
+		if !updated.LastSeen.IsZero() {
+			if !updated.LastUpdate.Equal(lastUpdate) {
+				t.Errorf("Expected heartbeat to leave lastUpdate unchanged")
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Expected heartbeat to set lastSeen")
+}
+
+func TestCloseAllSendsCloseCode(t *testing.T) {
+	hub := NewHub()
+	server := newTestServer(t, hub)
+	conns := []*websocket.Conn{
+		dial(t, server, "/ws/convoys/"+testConvoy1),
+		dial(t, server, "/ws/convoys/"+testConvoy2),
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for hub.GetTotalConnections() < len(conns) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	hub.CloseAll(websocket.CloseServiceRestart, "server restarting")
+
+	for _, conn := range conns {
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, _, err := conn.ReadMessage()
+
+		var closeErr *websocket.CloseError
+		if !errors.As(err, &closeErr) {
+			t.Fatalf("Expected close error, got %v", err)
+		}
+		if closeErr.Code != websocket.CloseServiceRestart || closeErr.Text != "server restarting" {
+			t.Errorf("Expected close %d %q, got %d %q", websocket.CloseServiceRestart, "server restarting", closeErr.Code, closeErr.Text)
+		}
+	}
+}
+
+func TestHandlerRequiresConnectToken(t *testing.T) {
+	memStorage := storage.NewMemoryStorage()
+	hub := NewHub()
+	hub.SetConvoyProvider(memStorage)
+	hub.SetRequireConnectToken(true)
+
+	convoy, err := memStorage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	member := &domain.Member{ID: 1, Name: "TestMember1"}
+	member.SetConnectToken("valid-token")
+	if err := memStorage.AddMember(context.Background(), convoy.ID, member); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+
+	server := newTestServer(t, hub)
+	base := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/convoys/" + convoy.ID
+
+	rejected := []string{
+		"",
+		"?memberId=1",
+		"?memberId=1&token=wrong-token",
+		"?memberId=2&token=valid-token",
+		"?token=valid-token",
+	}
+	for _, query := range rejected {
+		_, resp, err := websocket.DefaultDialer.Dial(base+query, nil)
+		if err == nil {
+			t.Errorf("Expected connection with %q to be rejected", query)
+			continue
+		}
+		if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected 401 for %q, got %v", query, resp)
+		}
+	}
+
+	conn := dial(t, server, "/ws/convoys/"+convoy.ID+"?memberId=1&token=valid-token")
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, _, err := conn.ReadMessage(); err != nil {
+		t.Fatalf("Expected snapshot for authorized connection, got %v", err)
+	}
+
+	// With the flag off, tokenless connections still work during migration
+	hub.SetRequireConnectToken(false)
+	conn = dial(t, server, "/ws/convoys/"+convoy.ID)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, _, err := conn.ReadMessage(); err != nil {
+		t.Fatalf("Expected snapshot without a token when not required, got %v", err)
+	}
+}
+
+// expectClose reads from conn until it is closed and checks the close code
+func expectClose(t *testing.T, conn *websocket.Conn, code int) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue // snapshot or broadcast sent before the close
+		}
+		var closeErr *websocket.CloseError
+		if !errors.As(err, &closeErr) {
+			t.Fatalf("Expected close error, got %v", err)
+		}
+		if closeErr.Code != code {
+			t.Errorf("Expected close code %d, got %d", code, closeErr.Code)
+		}
+		return
+	}
+}
+
+// waitForMemberConnection waits until the hub maps the member to a connection other than previous
+func waitForMemberConnection(t *testing.T, hub *Hub, convoyID string, memberID int64, previous *Connection) *Connection {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if conn := hub.GetMemberConnection(convoyID, memberID); conn != nil && conn != previous {
+			return conn
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Member %d was not registered", memberID)
+	return nil
+}
+
+func TestRegisterMemberClosesPreviousConnection(t *testing.T) {
+	hub := NewHub()
+	hub.SetConvoyProvider(tokenProvider{})
+	server := newTestServer(t, hub)
+
+	first := dial(t, server, "/ws/convoys/"+testConvoy1+"?memberId=1&token=valid")
+	firstServerConn := waitForMemberConnection(t, hub, testConvoy1, 1, nil)
+
+	second := dial(t, server, "/ws/convoys/"+testConvoy1+"?memberId=1&token=valid")
+	secondServerConn := waitForMemberConnection(t, hub, testConvoy1, 1, firstServerConn)
+
+	expectClose(t, first, CloseSessionReplaced)
+
+	// The old handler's cleanup must not drop the newer connection
+	deadline := time.Now().Add(2 * time.Second)
+	for hub.GetConnectionCount(testConvoy1) != 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if hub.GetConnectionCount(testConvoy1) != 1 {
+		t.Errorf("Expected 1 connection after replacement, got %d", hub.GetConnectionCount(testConvoy1))
+	}
+	if hub.GetMemberConnection(testConvoy1, 1) != secondServerConn {
+		t.Error("Expected the member to stay mapped to the newer connection")
+	}
+
+	hub.Broadcast(testConvoy1, map[string]string{"eventType": "MEMBER_LAGGING"})
+	second.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, _, err := second.ReadMessage(); err != nil {
+		t.Errorf("Expected the newer connection to stay open, got %v", err)
+	}
+}
+
+func TestSingleSessionRejectsSecondConnection(t *testing.T) {
+	hub := NewHub()
+	hub.SetConvoyProvider(tokenProvider{})
+	hub.SetSingleSession(true)
+	server := newTestServer(t, hub)
+
+	dial(t, server, "/ws/convoys/"+testConvoy1+"?memberId=1&token=valid")
+	firstServerConn := waitForMemberConnection(t, hub, testConvoy1, 1, nil)
+
+	second := dial(t, server, "/ws/convoys/"+testConvoy1+"?memberId=1&token=valid")
+	expectClose(t, second, CloseMemberAlreadyConnected)
+
+	if hub.GetMemberConnection(testConvoy1, 1) != firstServerConn {
+		t.Error("Expected the member to stay mapped to the first connection")
+	}
+}
+
+func TestConnectionWithoutTokenCannotTakeOverSession(t *testing.T) {
+	for _, singleSession := range []bool{false, true} {
+		hub := NewHub()
+		hub.SetConvoyProvider(tokenProvider{})
+		hub.SetSingleSession(singleSession)
+		server := newTestServer(t, hub)
+
+		member := dial(t, server, "/ws/convoys/"+testConvoy1+"?memberId=1&token=valid")
+		memberServerConn := waitForMemberConnection(t, hub, testConvoy1, 1, nil)
+
+		// Claiming the member's ID neither closes the member's socket nor is refused
+		impostor := dial(t, server, "/ws/convoys/"+testConvoy1+"?memberId=1")
+		waitForConnectionCount(t, hub, testConvoy1, 2)
+		if hub.GetMemberConnection(testConvoy1, 1) != memberServerConn {
+			t.Errorf("singleSession=%v: expected the member to stay mapped to its own connection", singleSession)
+		}
+
+		hub.Broadcast(testConvoy1, map[string]string{"eventType": "MEMBER_LAGGING"})
+		for _, conn := range []*websocket.Conn{member, impostor} {
+			conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+			if _, _, err := conn.ReadMessage(); err != nil { // the snapshot
+				t.Fatalf("singleSession=%v: expected a snapshot, got %v", singleSession, err)
+			}
+			if _, _, err := conn.ReadMessage(); err != nil {
+				t.Errorf("singleSession=%v: expected both connections to stay open, got %v", singleSession, err)
+			}
+		}
+
+		// A squatter that got there first is replaced by the real member
+		squatter := dial(t, server, "/ws/convoys/"+testConvoy1+"?memberId=2")
+		squatterServerConn := waitForMemberConnection(t, hub, testConvoy1, 2, nil)
+		dial(t, server, "/ws/convoys/"+testConvoy1+"?memberId=2&token=valid")
+		waitForMemberConnection(t, hub, testConvoy1, 2, squatterServerConn)
+		expectClose(t, squatter, CloseSessionReplaced)
+	}
+}
+
+// waitForConnectionCount waits until the hub holds n connections for the convoy
+func waitForConnectionCount(t *testing.T, hub *Hub, convoyID string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for hub.GetConnectionCount(convoyID) != n && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := hub.GetConnectionCount(convoyID); got != n {
+		t.Fatalf("Expected %d connections for %s, got %d", n, convoyID, got)
+	}
+}
+
+func TestHandlerRejectsConnectionsOverConvoyLimit(t *testing.T) {
+	hub := NewHub()
+	hub.SetConnectionLimits(2, 100)
+	server := newTestServer(t, hub)
+
+	dial(t, server, "/ws/convoys/"+testConvoy1)
+	dial(t, server, "/ws/convoys/"+testConvoy1)
+	waitForConnectionCount(t, hub, testConvoy1, 2)
+
+	overflow := dial(t, server, "/ws/convoys/"+testConvoy1)
+	expectClose(t, overflow, websocket.CloseTryAgainLater)
+	if got := hub.GetConnectionCount(testConvoy1); got != 2 {
+		t.Errorf("Expected the convoy to stay at 2 connections, got %d", got)
+	}
+
+	// Other convoys still have room
+	dial(t, server, "/ws/convoys/"+testConvoy2)
+	waitForConnectionCount(t, hub, testConvoy2, 1)
+}
+
+func TestHandlerRejectsConnectionsOverTotalLimit(t *testing.T) {
+	hub := NewHub()
+	hub.SetConnectionLimits(10, 2)
+	server := newTestServer(t, hub)
+
+	dial(t, server, "/ws/convoys/"+testConvoy1)
+	dial(t, server, "/ws/convoys/"+testConvoy2)
+	waitForConnectionCount(t, hub, testConvoy1, 1)
+	waitForConnectionCount(t, hub, testConvoy2, 1)
+
+	overflow := dial(t, server, "/ws/convoys/"+testConvoy3)
+	expectClose(t, overflow, websocket.CloseTryAgainLater)
+}
+
+func TestSpectatorReceivesBroadcastsWithoutCountingAsMember(t *testing.T) {
+	memStorage := storage.NewMemoryStorage()
+	hub := NewHub()
+	hub.SetConvoyProvider(memStorage)
+	hub.SetRequireConnectToken(true)
+
+	convoy, err := memStorage.CreateConvoy(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create convoy: %v", err)
+	}
+	member := &domain.Member{ID: 1, Name: "TestMember1"}
+	member.SetConnectToken("valid-token")
+	if err := memStorage.AddMember(context.Background(), convoy.ID, member); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+
+	server := newTestServer(t, hub)
+	base := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/convoys/" + convoy.ID
+	// With tokens required, a spectator needs a member's connect token too
+	for _, query := range []string{"?role=spectator", "?role=spectator&memberId=1", "?role=spectator&memberId=1&token=wrong-token"} {
+		_, resp, err := websocket.DefaultDialer.Dial(base+query, nil)
+		if err == nil {
+			t.Errorf("Expected spectator connection with %q to be rejected", query)
+			continue
+		}
+		if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected 401 for %q, got %v", query, resp)
+		}
+	}
+
+	// The member ID that came with the token isn't taken on
+	spectator := dial(t, server, "/ws/convoys/"+convoy.ID+"?role=spectator&memberId=1&token=valid-token")
+
+	spectator.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, _, err := spectator.ReadMessage(); err != nil {
+		t.Fatalf("Expected the spectator to get a snapshot, got %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for hub.GetSpectatorCount(convoy.ID) != 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if hub.GetSpectatorCount(convoy.ID) != 1 {
+		t.Fatalf("Expected 1 spectator, got %d", hub.GetSpectatorCount(convoy.ID))
+	}
+
+	if hub.GetConnectionCount(convoy.ID) != 0 {
+		t.Errorf("Expected spectators not to count as member connections, got %d", hub.GetConnectionCount(convoy.ID))
+	}
+	if hub.HasActiveConnection(convoy.ID, 1) {
+		t.Error("Expected a spectator not to make member 1 look connected")
+	}
+
+	hub.Broadcast(convoy.ID, &domain.ConvoyAlert{EventType: domain.EventMemberLagging, ConvoyID: convoy.ID})
+	alert, err := readAlert(t, spectator, 2*time.Second)
+	if err != nil || alert.EventType != domain.EventMemberLagging {
+		t.Errorf("Expected the spectator to receive the alert, got %+v (%v)", alert, err)
+	}
+
+	spectator.Close()
+	deadline = time.Now().Add(2 * time.Second)
+	for hub.GetSpectatorCount(convoy.ID) != 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if hub.GetSpectatorCount(convoy.ID) != 0 {
+		t.Error("Expected the spectator to be unregistered on close")
+	}
+}
+
+func TestSpectatorLimitIsSeparateFromMembers(t *testing.T) {
+	hub := NewHub()
+	hub.SetConnectionLimits(1, 100)
+	hub.SetSpectatorLimit(2)
+	server := newTestServer(t, hub)
+
+	dial(t, server, "/ws/convoys/"+testConvoy1)
+	waitForConnectionCount(t, hub, testConvoy1, 1)
+
+	// The member limit is reached, but spectators have their own
+	dial(t, server, "/ws/convoys/"+testConvoy1+"?role=spectator")
+	dial(t, server, "/ws/convoys/"+testConvoy1+"?role=spectator")
+	deadline := time.Now().Add(2 * time.Second)
+	for hub.GetSpectatorCount(testConvoy1) != 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if hub.GetSpectatorCount(testConvoy1) != 2 {
+		t.Fatalf("Expected 2 spectators, got %d", hub.GetSpectatorCount(testConvoy1))
+	}
+
+	overflow := dial(t, server, "/ws/convoys/"+testConvoy1+"?role=spectator")
+	expectClose(t, overflow, websocket.CloseTryAgainLater)
+}
+
+// recordingListener collects the members the hub reports as disconnected
+type recordingListener chan int64
+
+func (l recordingListener) MemberDisconnected(convoyID string, memberID int64) {
+	l <- memberID
+}
+
+func TestMemberListenerToldWhenConnectionCloses(t *testing.T) {
+	hub := NewHub()
+	hub.SetConvoyProvider(tokenProvider{})
+	listener := make(recordingListener, 4)
+	hub.SetMemberListener(listener)
+	server := newTestServer(t, hub)
+
+	first := dial(t, server, "/ws/convoys/"+testConvoy1+"?memberId=1&token=valid")
+	firstServerConn := waitForMemberConnection(t, hub, testConvoy1, 1, nil)
+
+	// A replaced session is not a disconnect
+	second := dial(t, server, "/ws/convoys/"+testConvoy1+"?memberId=1&token=valid")
+	waitForMemberConnection(t, hub, testConvoy1, 1, firstServerConn)
+	expectClose(t, first, CloseSessionReplaced)
+	waitForConnectionCount(t, hub, testConvoy1, 1)
+	select {
+	case memberID := <-listener:
+		t.Fatalf("Expected no disconnect for a replaced session, got member %d", memberID)
+	default:
+	}
+
+	second.Close()
+	select {
+	case memberID := <-listener:
+		if memberID != 1 {
+			t.Errorf("Expected member 1 to be reported, got %d", memberID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the listener to be told the member disconnected")
+	}
+}
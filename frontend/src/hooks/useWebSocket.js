@@ -120,7 +120,21 @@ const useWebSocket = (convoyId) => {
             }
             return;
           }
-          
+
+          // In delta broadcast mode, a location update carries only the member who moved
+          if (data.eventType === 'MEMBER_MOVED') {
+            const moved = {
+              ...data.member,
+              location: [data.member.location.lat, data.member.location.lng],
+              status: data.member.status || 'connected'
+            };
+            setConvoyData(prev => prev && {
+              ...prev,
+              members: prev.members.map(member => (member.id === moved.id ? moved : member))
+            });
+            return;
+          }
+
           // Handle regular convoy data updates
           const transformedMembers = (data.members || []).map(member => ({
             ...member,